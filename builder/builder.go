@@ -1,16 +1,33 @@
 package builder
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/sicko7947/gorkflow"
 )
 
 // WorkflowBuilder provides a fluent API for building workflows
 type WorkflowBuilder struct {
-	workflow     *gorkflow.Workflow
-	lastStepIDs  []string
-	currentChain []string
+	workflow       *gorkflow.Workflow
+	lastStepIDs    []string
+	currentChain   []string
+	skipTypeChecks bool
+
+	// Size limits checked by Build, 0 means no limit
+	maxNodes int
+	maxDepth int
+
+	// Whether SetEntryPoint chose the entry point rather than the first step added
+	entryPointSet bool
+
+	// Problems found while adding steps, reported by Build
+	errs []error
+
+	// Steps the current run of ThenStepWhen branches are attached to
+	branchFrom []string
 }
 
 // NewWorkflow creates a new workflow builder
@@ -54,18 +71,14 @@ func (b *WorkflowBuilder) WithContext(ctx any) *WorkflowBuilder {
 
 // ThenStep chains the given step after the last added step
 func (b *WorkflowBuilder) ThenStep(step gorkflow.StepExecutor) *WorkflowBuilder {
+	b.branchFrom = nil
 	stepID := step.GetID()
-
-	// Register step if not already registered
-	if _, err := b.workflow.GetStep(stepID); err != nil {
-		b.workflow.AddStep(step)
-		b.workflow.Graph().AddNode(stepID, gorkflow.NodeTypeSequential)
-	}
+	b.registerStep(step, gorkflow.NodeTypeSequential)
 
 	// Chain from last steps
 	for _, lastID := range b.lastStepIDs {
 		if err := b.workflow.Graph().AddEdge(lastID, stepID); err != nil {
-			panic(fmt.Sprintf("failed to add edge: %v", err))
+			b.errs = append(b.errs, fmt.Errorf("failed to add edge: %w", err))
 		}
 	}
 
@@ -76,21 +89,27 @@ func (b *WorkflowBuilder) ThenStep(step gorkflow.StepExecutor) *WorkflowBuilder
 }
 
 // Parallel adds multiple steps that execute in parallel after the last step(s)
+// A step added next joins the block and receives its outputs keyed by step ID
 func (b *WorkflowBuilder) Parallel(steps ...gorkflow.StepExecutor) *WorkflowBuilder {
+	return b.ParallelWithLimit(0, steps...)
+}
+
+// ParallelWithLimit adds a parallel block running at most maxParallel steps at a time
+// A maxParallel of 0 runs every step of the block at once
+func (b *WorkflowBuilder) ParallelWithLimit(maxParallel int, steps ...gorkflow.StepExecutor) *WorkflowBuilder {
+	b.branchFrom = nil
 	var newLastIDs []string
 	for _, step := range steps {
 		stepID := step.GetID()
 
-		// Register step if not already registered
-		if _, err := b.workflow.GetStep(stepID); err != nil {
-			b.workflow.AddStep(step)
-			b.workflow.Graph().AddNode(stepID, gorkflow.NodeTypeParallel)
+		if b.registerStep(step, gorkflow.NodeTypeParallel) {
+			b.workflow.Graph().Nodes[stepID].MaxParallel = maxParallel
 		}
 
 		// Chain from last steps
 		for _, lastID := range b.lastStepIDs {
 			if err := b.workflow.Graph().AddEdge(lastID, stepID); err != nil {
-				panic(fmt.Sprintf("failed to add edge: %v", err))
+				b.errs = append(b.errs, fmt.Errorf("failed to add edge: %w", err))
 			}
 		}
 
@@ -102,6 +121,34 @@ func (b *WorkflowBuilder) Parallel(steps ...gorkflow.StepExecutor) *WorkflowBuil
 	return b
 }
 
+// registerStep adds a step and its graph node, reporting whether it was new. Adding the
+// same step again only wires it up again, but a different step reusing a registered ID
+// is recorded as a build error rather than silently dropped.
+func (b *WorkflowBuilder) registerStep(step gorkflow.StepExecutor, nodeType gorkflow.NodeType) bool {
+	stepID := step.GetID()
+
+	existing, err := b.workflow.GetStep(stepID)
+	if err != nil {
+		b.workflow.AddStep(step)
+		b.workflow.Graph().AddNode(stepID, nodeType)
+		return true
+	}
+
+	if !sameStep(existing, step) {
+		b.errs = append(b.errs, fmt.Errorf("duplicate step ID %s: %q and %q are different steps", stepID, existing.GetName(), step.GetName()))
+	}
+	return false
+}
+
+// sameStep reports whether a and b are the same step instance
+func sameStep(a, b gorkflow.StepExecutor) bool {
+	// Comparing interfaces holding uncomparable values would panic
+	if t := reflect.TypeOf(a); t != reflect.TypeOf(b) || !t.Comparable() {
+		return false
+	}
+	return a == b
+}
+
 // Sequence adds multiple steps and chains them together in order
 func (b *WorkflowBuilder) Sequence(steps ...gorkflow.StepExecutor) *WorkflowBuilder {
 	for _, step := range steps {
@@ -112,7 +159,8 @@ func (b *WorkflowBuilder) Sequence(steps ...gorkflow.StepExecutor) *WorkflowBuil
 
 // ThenStepIf chains a step with a condition after the last added step
 // The step executes only if condition evaluates to true at runtime
-// If false, defaultValue is used as output (pass nil for zero value)
+// If false, defaultValue is used as output (pass nil for zero value); Build rejects
+// a default that does not decode as the step's output type
 //
 // Example:
 //
@@ -128,16 +176,85 @@ func (b *WorkflowBuilder) ThenStepIf(step gorkflow.StepExecutor, condition gorkf
 	return b.ThenStep(wrappedStep)
 }
 
+// ThenStepWhen adds a branch reached only when condition returns true after the last step(s).
+// Consecutive ThenStepWhen calls branch from the same step(s), so a step can route to
+// different successors; branches whose condition is false are skipped along with the steps
+// after them. A step added next joins the branches that ran and receives their outputs
+// keyed by step ID, with skipped branches set to null.
+//
+// Example:
+//
+//	builder.ThenStep(classify).
+//	    ThenStepWhen(fastPath, isSmall).
+//	    ThenStepWhen(slowPath, isLarge).
+//	    ThenStep(report)
+func (b *WorkflowBuilder) ThenStepWhen(step gorkflow.StepExecutor, condition gorkflow.Condition) *WorkflowBuilder {
+	if b.branchFrom == nil {
+		b.branchFrom = b.lastStepIDs
+		b.lastStepIDs = nil
+	}
+	stepID := step.GetID()
+	b.registerStep(step, gorkflow.NodeTypeSequential)
+
+	// Branch from the steps before the first ThenStepWhen
+	for _, fromID := range b.branchFrom {
+		if err := b.workflow.Graph().AddConditionalEdge(fromID, stepID, condition); err != nil {
+			b.errs = append(b.errs, fmt.Errorf("failed to add conditional edge: %w", err))
+		}
+	}
+
+	b.lastStepIDs = append(b.lastStepIDs, stepID)
+	b.currentChain = append(b.currentChain, stepID)
+
+	return b
+}
+
+// WithoutTypeChecks disables the Build-time check that each step's output
+// type can be decoded into the input type of the steps that follow it
+func (b *WorkflowBuilder) WithoutTypeChecks() *WorkflowBuilder {
+	b.skipTypeChecks = true
+	return b
+}
+
+// WithMaxNodes makes Build reject graphs with more than n steps (0, the default, means no limit)
+func (b *WorkflowBuilder) WithMaxNodes(n int) *WorkflowBuilder {
+	b.maxNodes = n
+	return b
+}
+
+// WithMaxDepth makes Build reject graphs whose longest path from the entry point
+// has more than n steps (0, the default, means no limit)
+func (b *WorkflowBuilder) WithMaxDepth(n int) *WorkflowBuilder {
+	b.maxDepth = n
+	return b
+}
+
 // SetEntryPoint sets the workflow entry point explicitly
 func (b *WorkflowBuilder) SetEntryPoint(stepID string) *WorkflowBuilder {
 	if err := b.workflow.Graph().SetEntryPoint(stepID); err != nil {
 		panic(fmt.Sprintf("failed to set entry point: %v", err))
 	}
+	b.entryPointSet = true
 	return b
 }
 
 // Build finalizes and validates the workflow
 func (b *WorkflowBuilder) Build() (*gorkflow.Workflow, error) {
+	if len(b.errs) > 0 {
+		return nil, fmt.Errorf("invalid workflow: %w", errors.Join(b.errs...))
+	}
+
+	if nodes := len(b.workflow.Graph().Nodes); b.maxNodes > 0 && nodes > b.maxNodes {
+		return nil, fmt.Errorf("invalid workflow graph: %d steps exceeds the limit of %d", nodes, b.maxNodes)
+	}
+
+	// Several roots make the implicit entry point a guess that leaves the others unreachable
+	if !b.entryPointSet {
+		if roots := b.workflow.Graph().FindRoots(); len(roots) > 1 {
+			return nil, fmt.Errorf("invalid workflow graph: multiple entry candidates %s, use SetEntryPoint to choose one", strings.Join(roots, ", "))
+		}
+	}
+
 	// Validate graph
 	if err := b.workflow.Graph().Validate(); err != nil {
 		return nil, fmt.Errorf("invalid workflow graph: %w", err)
@@ -150,6 +267,28 @@ func (b *WorkflowBuilder) Build() (*gorkflow.Workflow, error) {
 		}
 	}
 
+	// Validate declared step inputs
+	if err := ValidateInputSources(b.workflow); err != nil {
+		return nil, fmt.Errorf("invalid workflow: %w", err)
+	}
+
+	// Validate that chained steps exchange compatible types
+	if !b.skipTypeChecks {
+		if err := ValidateStepTypes(b.workflow); err != nil {
+			return nil, fmt.Errorf("invalid workflow: %w", err)
+		}
+	}
+
+	// The graph is final, so runs can share one execution order
+	order, err := b.workflow.ExecutionOrder()
+	if err != nil {
+		return nil, fmt.Errorf("invalid workflow graph: %w", err)
+	}
+
+	if depth := graphDepth(b.workflow.Graph(), order); b.maxDepth > 0 && depth > b.maxDepth {
+		return nil, fmt.Errorf("invalid workflow graph: depth %d exceeds the limit of %d", depth, b.maxDepth)
+	}
+
 	return b.workflow, nil
 }
 
@@ -161,3 +300,18 @@ func (b *WorkflowBuilder) MustBuild() *gorkflow.Workflow {
 	}
 	return wf
 }
+
+// graphDepth returns the number of steps on the longest path from the entry point,
+// given the graph's topological order
+func graphDepth(graph *gorkflow.ExecutionGraph, order []string) int {
+	depths := make(map[string]int, len(order))
+	maxDepth := 0
+	for _, nodeID := range order {
+		depth := max(depths[nodeID], 1)
+		maxDepth = max(maxDepth, depth)
+		for _, nextID := range graph.Nodes[nodeID].Next {
+			depths[nextID] = max(depths[nextID], depth+1)
+		}
+	}
+	return maxDepth
+}
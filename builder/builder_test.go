@@ -1,6 +1,7 @@
 package builder
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/sicko7947/gorkflow"
@@ -134,6 +135,38 @@ func TestWorkflowBuilder_AutoEntryPoint(t *testing.T) {
 	assert.Equal(t, "step1", wf.Graph().EntryPoint)
 }
 
+func TestWorkflowBuilder_Build_MultipleRoots(t *testing.T) {
+	fetchA := gorkflow.NewStep("fetch_a", "Fetch A", testHandler)
+	fetchB := gorkflow.NewStep("fetch_b", "Fetch B", testHandler)
+	merge := gorkflow.NewStep("merge", "Merge", testHandler)
+
+	// Two disconnected roots leave the entry point ambiguous
+	_, err := NewWorkflow("test-workflow", "Test Workflow").
+		Parallel(fetchA, fetchB).
+		ThenStep(merge).
+		WithoutTypeChecks().
+		Build()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "multiple entry candidates fetch_a, fetch_b")
+}
+
+func TestWorkflowBuilder_Build_SingleImplicitRoot(t *testing.T) {
+	start := gorkflow.NewStep("start", "Start", testHandler)
+	left := gorkflow.NewStep("left", "Left", testHandler)
+	right := gorkflow.NewStep("right", "Right", testHandler)
+
+	wf, err := NewWorkflow("test-workflow", "Test Workflow").
+		ThenStep(start).
+		Parallel(left, right).
+		WithoutTypeChecks().
+		Build()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"start"}, wf.Graph().FindRoots())
+	assert.Equal(t, "start", wf.Graph().EntryPoint)
+}
+
 func TestWorkflowBuilder_Build_EmptyWorkflow(t *testing.T) {
 	_, err := NewWorkflow("test-workflow", "Test Workflow").
 		Build()
@@ -169,6 +202,96 @@ func TestWorkflowBuilder_Build_InvalidGraph(t *testing.T) {
 	assert.Contains(t, err.Error(), "cycle")
 }
 
+func TestWorkflowBuilder_Build_SelfEdge(t *testing.T) {
+	step1 := gorkflow.NewStep("step1", "Step 1", testHandler)
+
+	// Chaining a step to itself is a build error rather than a panic
+	_, err := NewWorkflow("test-workflow", "Test Workflow").
+		ThenStep(step1).
+		ThenStep(step1).
+		Build()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "step step1 cannot have an edge to itself")
+}
+
+func TestWorkflowBuilder_Build_DuplicateStepID(t *testing.T) {
+	first := gorkflow.NewStep("process", "Process Orders", testHandler)
+	second := gorkflow.NewStep("process", "Process Refunds", testHandler)
+
+	_, err := NewWorkflow("test-workflow", "Test Workflow").
+		ThenStep(first).
+		ThenStep(second).
+		Build()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `duplicate step ID process: "Process Orders" and "Process Refunds" are different steps`)
+}
+
+func TestWorkflowBuilder_Build_DuplicateStepIDInParallel(t *testing.T) {
+	start := gorkflow.NewStep("start", "Start", testHandler)
+
+	_, err := NewWorkflow("test-workflow", "Test Workflow").
+		ThenStep(start).
+		Parallel(
+			gorkflow.NewStep("fetch", "Fetch A", testHandler),
+			gorkflow.NewStep("fetch", "Fetch B", testHandler),
+		).
+		Build()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate step ID fetch")
+}
+
+func TestWorkflowBuilder_Build_LongChain(t *testing.T) {
+	const length = 10000
+
+	b := NewWorkflow("test-workflow", "Test Workflow")
+	for i := range length {
+		b.ThenStep(gorkflow.NewStep(fmt.Sprintf("step%d", i), "Step", testHandler))
+	}
+
+	// Validation walks the graph iteratively, so a deep chain cannot overflow the stack
+	var wf *gorkflow.Workflow
+	require.NotPanics(t, func() {
+		var err error
+		wf, err = b.Build()
+		require.NoError(t, err)
+	})
+
+	order, err := wf.ExecutionOrder()
+	require.NoError(t, err)
+	require.Len(t, order, length)
+	assert.Equal(t, "step0", order[0])
+	assert.Equal(t, "step9999", order[length-1])
+	assert.NoError(t, ValidateNoCycles(wf.Graph()))
+	assert.NoError(t, ValidateReachability(wf.Graph()))
+}
+
+func TestWorkflowBuilder_Build_Limits(t *testing.T) {
+	chain := func() *WorkflowBuilder {
+		return NewWorkflow("test-workflow", "Test Workflow").
+			ThenStep(gorkflow.NewStep("start", "Start", testHandler)).
+			Parallel(
+				gorkflow.NewStep("a", "A", testHandler),
+				gorkflow.NewStep("b", "B", testHandler),
+			).
+			ThenStep(gorkflow.NewStep("end", "End", testHandler))
+	}
+
+	_, err := chain().WithMaxNodes(3).Build()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "4 steps exceeds the limit of 3")
+
+	// The longest path is start -> a -> end
+	_, err = chain().WithMaxDepth(2).Build()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "depth 3 exceeds the limit of 2")
+
+	_, err = chain().WithMaxNodes(4).WithMaxDepth(3).Build()
+	assert.NoError(t, err)
+}
+
 func TestWorkflowBuilder_MustBuild_Success(t *testing.T) {
 	step1 := gorkflow.NewStep("step1", "Step 1", testHandler)
 
@@ -272,6 +395,33 @@ func TestWorkflowBuilder_ThenStepIf(t *testing.T) {
 	assert.Contains(t, nextSteps, "step2")
 }
 
+func TestWorkflowBuilder_ThenStepWhen(t *testing.T) {
+	condition := func(ctx *gorkflow.StepContext) (bool, error) {
+		return true, nil
+	}
+
+	wf, err := NewWorkflow("test-workflow", "Test Workflow").
+		ThenStep(gorkflow.NewStep("step1", "Step 1", testHandler)).
+		ThenStepWhen(gorkflow.NewStep("step2a", "Step 2A", testHandler), condition).
+		ThenStepWhen(gorkflow.NewStep("step2b", "Step 2B", testHandler), condition).
+		ThenStep(gorkflow.NewStep("step3", "Step 3", testHandler)).
+		Build()
+	require.NoError(t, err)
+
+	graph := wf.Graph()
+
+	// Consecutive branches hang off the same step
+	assert.Equal(t, []string{"step2a", "step2b"}, graph.Nodes["step1"].Next)
+	assert.Equal(t, gorkflow.NodeTypeConditional, graph.Nodes["step1"].Type)
+	assert.NotNil(t, graph.EdgeCondition("step1", "step2a"))
+	assert.NotNil(t, graph.EdgeCondition("step1", "step2b"))
+
+	// The next step joins the branches unconditionally
+	assert.Equal(t, []string{"step3"}, graph.Nodes["step2a"].Next)
+	assert.Equal(t, []string{"step3"}, graph.Nodes["step2b"].Next)
+	assert.Nil(t, graph.EdgeCondition("step2a", "step3"))
+}
+
 func TestWorkflowBuilder_ThenStepIf_WithDefaultValue(t *testing.T) {
 	step1 := gorkflow.NewStep("step1", "Step 1", testHandler)
 	step2 := gorkflow.NewStep("step2", "Step 2", testHandler)
@@ -0,0 +1,152 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// FromJSON builds a workflow from a JSON gorkflow.WorkflowDefinition, binding each
+// step to the executor registered under its handler name (or its ID when no
+// handler is given). The result is validated like any other built workflow.
+//
+// Example:
+//
+//	registry := map[string]gorkflow.StepExecutor{
+//	    "fetch": gorkflow.NewStep("fetch", "Fetch", fetchHandler),
+//	    "save":  gorkflow.NewStep("save", "Save", saveHandler),
+//	}
+//	wf, err := builder.FromJSON(definition, registry)
+func FromJSON(data []byte, registry map[string]gorkflow.StepExecutor) (*gorkflow.Workflow, error) {
+	var def gorkflow.WorkflowDefinition
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&def); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow definition: %w", err)
+	}
+
+	return FromDefinition(def, registry)
+}
+
+// FromDefinition builds a workflow from a parsed definition; see FromJSON
+func FromDefinition(def gorkflow.WorkflowDefinition, registry map[string]gorkflow.StepExecutor) (*gorkflow.Workflow, error) {
+	if def.ID == "" {
+		return nil, fmt.Errorf("workflow definition has no id")
+	}
+	if len(def.Steps) == 0 {
+		return nil, fmt.Errorf("workflow definition %s has no steps", def.ID)
+	}
+
+	b := NewWorkflow(def.ID, def.Name)
+	if def.Description != "" {
+		b.WithDescription(def.Description)
+	}
+	if def.Version != "" {
+		b.WithVersion(def.Version)
+	}
+	if def.Tags != nil {
+		b.WithTags(def.Tags)
+	}
+
+	wf := b.workflow
+	graph := wf.Graph()
+
+	for _, stepDef := range def.Steps {
+		if stepDef.ID == "" {
+			return nil, fmt.Errorf("workflow definition %s has a step without an id", def.ID)
+		}
+		if _, exists := graph.Nodes[stepDef.ID]; exists {
+			return nil, fmt.Errorf("step %s is defined more than once", stepDef.ID)
+		}
+
+		handler := stepDef.Handler
+		if handler == "" {
+			handler = stepDef.ID
+		}
+
+		step, ok := registry[handler]
+		if !ok || step == nil {
+			return nil, fmt.Errorf("step %s references unknown handler %s", stepDef.ID, handler)
+		}
+		if step.GetID() != stepDef.ID {
+			return nil, fmt.Errorf("step %s is bound to handler %s whose step ID is %s", stepDef.ID, handler, step.GetID())
+		}
+
+		nodeType := stepDef.Type
+		if nodeType == "" {
+			nodeType = gorkflow.NodeTypeSequential
+		}
+		switch nodeType {
+		case gorkflow.NodeTypeSequential, gorkflow.NodeTypeParallel, gorkflow.NodeTypeConditional:
+		default:
+			return nil, fmt.Errorf("step %s has unknown node type %s", stepDef.ID, nodeType)
+		}
+
+		applyStepDefinition(step, stepDef)
+
+		wf.AddStep(step)
+		graph.AddNode(stepDef.ID, nodeType)
+		graph.Nodes[stepDef.ID].MaxParallel = stepDef.MaxParallel
+	}
+
+	for _, edge := range def.Edges {
+		if _, exists := graph.Nodes[edge.From]; !exists {
+			return nil, fmt.Errorf("edge %s -> %s references unknown step %s", edge.From, edge.To, edge.From)
+		}
+		if _, exists := graph.Nodes[edge.To]; !exists {
+			return nil, fmt.Errorf("edge %s -> %s references unknown step %s", edge.From, edge.To, edge.To)
+		}
+		if edge.Conditional {
+			return nil, fmt.Errorf("edge %s -> %s is conditional and cannot be loaded from JSON", edge.From, edge.To)
+		}
+		if err := graph.AddEdge(edge.From, edge.To); err != nil {
+			return nil, fmt.Errorf("failed to add edge: %w", err)
+		}
+	}
+
+	if def.EntryPoint != "" {
+		if err := graph.SetEntryPoint(def.EntryPoint); err != nil {
+			return nil, fmt.Errorf("invalid entry point: %w", err)
+		}
+		b.entryPointSet = true
+	}
+
+	return b.Build()
+}
+
+// applyStepDefinition applies the definition's name, inputs and config overrides to the step
+func applyStepDefinition(step gorkflow.StepExecutor, def gorkflow.StepDefinition) {
+	if def.Name != "" {
+		if s, ok := step.(interface{ SetName(string) }); ok {
+			s.SetName(def.Name)
+		}
+	}
+
+	var opts []gorkflow.StepOption
+	if len(def.InputFrom) > 0 {
+		opts = append(opts, gorkflow.WithInputFrom(def.InputFrom...))
+	}
+
+	if cfg := def.Config; cfg != nil {
+		if cfg.MaxRetries != nil {
+			opts = append(opts, gorkflow.WithRetries(*cfg.MaxRetries))
+		}
+		if cfg.RetryDelayMs != nil {
+			opts = append(opts, gorkflow.WithRetryDelay(time.Duration(*cfg.RetryDelayMs)*time.Millisecond))
+		}
+		if cfg.RetryBackoff != "" {
+			opts = append(opts, gorkflow.WithBackoff(cfg.RetryBackoff))
+		}
+		if cfg.TimeoutSeconds != nil {
+			opts = append(opts, gorkflow.WithTimeout(time.Duration(*cfg.TimeoutSeconds)*time.Second))
+		}
+		if cfg.ContinueOnError != nil {
+			opts = append(opts, gorkflow.WithContinueOnError(*cfg.ContinueOnError))
+		}
+	}
+
+	gorkflow.ApplyStepOptions(step, opts...)
+}
@@ -0,0 +1,183 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const diamondJSON = `{
+	"id": "diamond",
+	"name": "Diamond",
+	"version": "2.0",
+	"tags": {"team": "data"},
+	"steps": [
+		{"id": "fetch", "name": "Fetch Data"},
+		{"id": "left", "type": "PARALLEL", "maxParallel": 2, "config": {"maxRetries": 5, "timeoutSeconds": 10}},
+		{"id": "right", "type": "PARALLEL", "maxParallel": 2, "config": {"continueOnError": true, "retryBackoff": "NONE"}},
+		{"id": "merge", "handler": "mergeHandler"}
+	],
+	"edges": [
+		{"from": "fetch", "to": "left"},
+		{"from": "fetch", "to": "right"},
+		{"from": "left", "to": "merge"},
+		{"from": "right", "to": "merge"}
+	]
+}`
+
+func newDiamondRegistry() map[string]gorkflow.StepExecutor {
+	return map[string]gorkflow.StepExecutor{
+		"fetch":        gorkflow.NewStep("fetch", "Fetch", testHandler),
+		"left":         gorkflow.NewStep("left", "Left", testHandler),
+		"right":        gorkflow.NewStep("right", "Right", testHandler),
+		"mergeHandler": gorkflow.NewStep("merge", "Merge", testHandler),
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	wf, err := FromJSON([]byte(diamondJSON), newDiamondRegistry())
+	require.NoError(t, err)
+
+	assert.Equal(t, "diamond", wf.ID())
+	assert.Equal(t, "Diamond", wf.Name())
+	assert.Equal(t, "2.0", wf.Version())
+	assert.Equal(t, map[string]string{"team": "data"}, wf.Tags())
+
+	// Equivalent to the same diamond built in code
+	registry := newDiamondRegistry()
+	expected, err := NewWorkflow("diamond", "Diamond").
+		ThenStep(registry["fetch"]).
+		ParallelWithLimit(2, registry["left"], registry["right"]).
+		ThenStep(registry["mergeHandler"]).
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, expected.Graph().EntryPoint, wf.Graph().EntryPoint)
+	require.Len(t, wf.Graph().Nodes, len(expected.Graph().Nodes))
+	for stepID, node := range expected.Graph().Nodes {
+		actual := wf.Graph().Nodes[stepID]
+		require.NotNil(t, actual, stepID)
+		assert.Equal(t, node.Type, actual.Type, stepID)
+		assert.Equal(t, node.MaxParallel, actual.MaxParallel, stepID)
+		assert.ElementsMatch(t, node.Next, actual.Next, stepID)
+	}
+
+	// Names and config overrides are applied to the bound steps
+	fetch, err := wf.GetStep("fetch")
+	require.NoError(t, err)
+	assert.Equal(t, "Fetch Data", fetch.GetName())
+
+	left, err := wf.GetStep("left")
+	require.NoError(t, err)
+	assert.Equal(t, 5, left.GetConfig().MaxRetries)
+	assert.Equal(t, 10, left.GetConfig().TimeoutSeconds)
+	assert.Equal(t, gorkflow.DefaultExecutionConfig.RetryDelayMs, left.GetConfig().RetryDelayMs)
+
+	right, err := wf.GetStep("right")
+	require.NoError(t, err)
+	assert.True(t, right.GetConfig().ContinueOnError)
+	assert.Equal(t, gorkflow.BackoffNone, right.GetConfig().RetryBackoff)
+}
+
+func TestFromJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{
+			name:    "malformed",
+			json:    `{"id": "wf", "steps": [`,
+			wantErr: "failed to parse workflow definition",
+		},
+		{
+			name:    "unknown field",
+			json:    `{"id": "wf", "steps": [{"id": "fetch", "retries": 3}]}`,
+			wantErr: "unknown field",
+		},
+		{
+			name:    "no steps",
+			json:    `{"id": "wf", "steps": []}`,
+			wantErr: "has no steps",
+		},
+		{
+			name:    "unknown handler",
+			json:    `{"id": "wf", "steps": [{"id": "fetch"}, {"id": "publish"}]}`,
+			wantErr: "step publish references unknown handler publish",
+		},
+		{
+			name:    "handler ID mismatch",
+			json:    `{"id": "wf", "steps": [{"id": "other", "handler": "fetch"}]}`,
+			wantErr: "step other is bound to handler fetch whose step ID is fetch",
+		},
+		{
+			name:    "duplicate step",
+			json:    `{"id": "wf", "steps": [{"id": "fetch"}, {"id": "fetch"}]}`,
+			wantErr: "step fetch is defined more than once",
+		},
+		{
+			name:    "unknown edge target",
+			json:    `{"id": "wf", "steps": [{"id": "fetch"}], "edges": [{"from": "fetch", "to": "missing"}]}`,
+			wantErr: "edge fetch -> missing references unknown step missing",
+		},
+		{
+			name:    "unknown entry point",
+			json:    `{"id": "wf", "entryPoint": "missing", "steps": [{"id": "fetch"}]}`,
+			wantErr: "invalid entry point",
+		},
+		{
+			name:    "unknown node type",
+			json:    `{"id": "wf", "steps": [{"id": "fetch", "type": "LOOP"}]}`,
+			wantErr: "unknown node type LOOP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf, err := FromJSON([]byte(tt.json), newDiamondRegistry())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Nil(t, wf)
+		})
+	}
+}
+
+func TestFromJSON_MarshalDefinitionRoundTrip(t *testing.T) {
+	registry := newDiamondRegistry()
+	wf, err := NewWorkflow("diamond", "Diamond").
+		WithVersion("2.0").
+		ThenStep(registry["fetch"]).
+		ParallelWithLimit(2, registry["left"], registry["right"]).
+		ThenStep(gorkflow.NewStep("merge", "Merge", testHandler,
+			gorkflow.WithRetries(7),
+			gorkflow.WithInputFrom("left", "right"),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	data, err := wf.MarshalDefinition()
+	require.NoError(t, err)
+
+	// Stable across calls
+	again, err := wf.MarshalDefinition()
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	// The definition rebuilds an equivalent workflow onto fresh handlers
+	rebuilt, err := FromJSON(data, map[string]gorkflow.StepExecutor{
+		"fetch": gorkflow.NewStep("fetch", "Fetch", testHandler),
+		"left":  gorkflow.NewStep("left", "Left", testHandler),
+		"right": gorkflow.NewStep("right", "Right", testHandler),
+		"merge": gorkflow.NewStep("merge", "Merge", testHandler),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, wf.Definition(), rebuilt.Definition())
+
+	merge, err := rebuilt.GetStep("merge")
+	require.NoError(t, err)
+	assert.Equal(t, 7, merge.GetConfig().MaxRetries)
+	assert.Equal(t, "PARALLEL", string(rebuilt.Graph().Nodes["left"].Type))
+	assert.Equal(t, 2, rebuilt.Graph().Nodes["right"].MaxParallel)
+}
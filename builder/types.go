@@ -0,0 +1,319 @@
+package builder
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// jsonShape is the JSON value kind a Go type encodes to or decodes from
+type jsonShape int
+
+const (
+	shapeAny jsonShape = iota // interface{}, json.RawMessage, custom (un)marshalers
+	shapeNull
+	shapeBool
+	shapeNumber
+	shapeString
+	shapeArray
+	shapeObject
+)
+
+var (
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	rawMessageType      = reflect.TypeOf(json.RawMessage{})
+)
+
+// ValidateStepTypes checks that every step's output can be decoded as the input of the steps it feeds.
+// Steps declaring WithInputFrom are checked against their declared sources instead of their predecessors.
+// Steps joining several predecessors receive an object keyed by step ID and are checked field by field.
+// Steps with WithInputMapper are not checked against their upstream steps.
+func ValidateStepTypes(w *gorkflow.Workflow) error {
+	if errs := stepTypeErrors(w); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// stepTypeErrors returns every incompatible step pairing in the workflow
+func stepTypeErrors(w *gorkflow.Workflow) []error {
+	var errs []error
+	graph := w.Graph()
+
+	// Count predecessors once rather than calling Predecessors per edge
+	incoming := make(map[string]int, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		for i, nextID := range node.Next {
+			if !slices.Contains(node.Next[:i], nextID) {
+				incoming[nextID]++
+			}
+		}
+	}
+
+	for fromID, node := range graph.Nodes {
+		from, err := w.GetStep(fromID)
+		if err != nil {
+			continue
+		}
+
+		for _, toID := range node.Next {
+			to, err := w.GetStep(toID)
+			if err != nil || len(inputSources(to)) > 0 || hasInputMapper(to) {
+				continue
+			}
+
+			in := to.InputType()
+			if incoming[toID] > 1 {
+				if in = keyedInputType(in, fromID); in == nil {
+					continue
+				}
+			}
+
+			if err := checkStepTypes(from, to, from.OutputType(), in); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, to := range w.GetAllSteps() {
+		if hasInputMapper(to) {
+			continue
+		}
+		sources := inputSources(to)
+		for _, sourceID := range sources {
+			from, err := w.GetStep(sourceID)
+			if err != nil {
+				continue
+			}
+
+			// Several sources arrive as an object keyed by step ID
+			in := to.InputType()
+			if len(sources) > 1 {
+				if in = keyedInputType(in, sourceID); in == nil {
+					continue
+				}
+			}
+
+			if err := checkStepTypes(from, to, from.OutputType(), in); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, step := range w.GetAllSteps() {
+		if err := checkDefaultValue(step); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// checkDefaultValue checks that the default output of a ThenStepIf step decodes as the step's output type
+func checkDefaultValue(step gorkflow.StepExecutor) error {
+	provider, ok := step.(interface{ GetDefaultValue() any })
+	if !ok || provider.GetDefaultValue() == nil || step.OutputType() == nil {
+		return nil
+	}
+	value, out := provider.GetDefaultValue(), step.OutputType()
+
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, reflect.New(out).Interface())
+	}
+	if err != nil || !jsonCompatible(reflect.TypeOf(value), out, map[[2]reflect.Type]bool{}) {
+		return fmt.Errorf(
+			"step %s default value of type %T is not compatible with its output type %s",
+			step.GetID(), value, out,
+		)
+	}
+	return nil
+}
+
+func checkStepTypes(from, to gorkflow.StepExecutor, out, in reflect.Type) error {
+	if !jsonCompatible(out, in, map[[2]reflect.Type]bool{}) {
+		return fmt.Errorf(
+			"step %s output type %s is not compatible with step %s input type %s",
+			from.GetID(), out, to.GetID(), in,
+		)
+	}
+	return nil
+}
+
+// inputSources returns the upstream steps a step declared with WithInputFrom
+func inputSources(step gorkflow.StepExecutor) []string {
+	if provider, ok := step.(interface{ GetInputFrom() []string }); ok {
+		return provider.GetInputFrom()
+	}
+	return nil
+}
+
+// hasInputMapper reports whether a step reshapes its input with WithInputMapper, which leaves its
+// upstream types unchecked
+func hasInputMapper(step gorkflow.StepExecutor) bool {
+	provider, ok := step.(interface{ GetInputMapper() gorkflow.InputMapper })
+	return ok && provider.GetInputMapper() != nil
+}
+
+// keyedInputType returns the type that receives the given step's output within a keyed input object
+func keyedInputType(in reflect.Type, stepID string) reflect.Type {
+	in = derefType(in)
+	switch in.Kind() {
+	case reflect.Map:
+		return in.Elem()
+	case reflect.Struct:
+		return jsonFields(in)[strings.ToLower(stepID)]
+	default:
+		return nil
+	}
+}
+
+// jsonCompatible reports whether a JSON encoding of out can be decoded into in.
+// Structs and maps are compared leniently: missing or extra fields are allowed,
+// but fields present on both sides must have compatible shapes.
+func jsonCompatible(out, in reflect.Type, seen map[[2]reflect.Type]bool) bool {
+	if out == nil || in == nil || out.AssignableTo(in) {
+		return true
+	}
+
+	// Recursive types: assume compatible while the pair is being compared
+	pair := [2]reflect.Type{out, in}
+	if seen[pair] {
+		return true
+	}
+	seen[pair] = true
+
+	outShape, inShape := encodeShape(out), decodeShape(in)
+	if outShape == shapeAny || inShape == shapeAny || outShape == shapeNull {
+		return true
+	}
+	if outShape != inShape {
+		return false
+	}
+
+	out, in = derefType(out), derefType(in)
+
+	switch outShape {
+	case shapeArray:
+		return jsonCompatible(out.Elem(), in.Elem(), seen)
+
+	case shapeObject:
+		if out.Kind() == reflect.Map && in.Kind() == reflect.Map {
+			return jsonCompatible(out.Elem(), in.Elem(), seen)
+		}
+		if out.Kind() != reflect.Struct || in.Kind() != reflect.Struct {
+			return true
+		}
+
+		outFields := jsonFields(out)
+		for name, inField := range jsonFields(in) {
+			if outField, ok := outFields[name]; ok && !jsonCompatible(outField, inField, seen) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return true
+}
+
+// encodeShape returns the JSON shape produced when marshaling t
+func encodeShape(t reflect.Type) jsonShape {
+	if t == rawMessageType || t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return shapeAny
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return shapeString
+	}
+	return kindShape(t)
+}
+
+// decodeShape returns the JSON shape accepted when unmarshaling into t
+func decodeShape(t reflect.Type) jsonShape {
+	if t == rawMessageType || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return shapeAny
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return shapeString
+	}
+	return kindShape(t)
+}
+
+func kindShape(t reflect.Type) jsonShape {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return kindShape(t.Elem())
+	case reflect.Interface:
+		return shapeAny
+	case reflect.Bool:
+		return shapeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return shapeNumber
+	case reflect.String:
+		return shapeString
+	case reflect.Slice:
+		// []byte is encoded as a base64 string
+		if t.Elem().Kind() == reflect.Uint8 {
+			return shapeString
+		}
+		return shapeArray
+	case reflect.Array:
+		return shapeArray
+	case reflect.Map, reflect.Struct:
+		return shapeObject
+	default:
+		// Channels, funcs and complex numbers cannot be encoded at all
+		return shapeNull
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonFields maps the JSON names of a struct's exported fields to their types, following embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && derefType(field.Type).Kind() == reflect.Struct {
+			for embeddedName, embeddedType := range jsonFields(derefType(field.Type)) {
+				if _, exists := fields[embeddedName]; !exists {
+					fields[embeddedName] = embeddedType
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		// encoding/json matches names case-insensitively
+		fields[strings.ToLower(name)] = field.Type
+	}
+
+	return fields
+}
@@ -0,0 +1,160 @@
+package builder
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderInput struct {
+	OrderID string `json:"orderId"`
+}
+
+type orderOutput struct {
+	OrderID string  `json:"orderId"`
+	Total   float64 `json:"total"`
+}
+
+type invoiceInput struct {
+	OrderID string `json:"orderId"`
+	Total   int    `json:"total"`
+	Notes   string `json:"notes"`
+}
+
+type mismatchedInput struct {
+	OrderID int `json:"orderId"`
+}
+
+func passthrough[TIn, TOut any](ctx *gorkflow.StepContext, input TIn) (TOut, error) {
+	var out TOut
+	return out, nil
+}
+
+func TestWorkflowBuilder_Build_CompatibleTypes(t *testing.T) {
+	wf, err := NewWorkflow("typed", "Typed").
+		ThenStep(gorkflow.NewStep("create", "Create", passthrough[orderInput, orderOutput])).
+		ThenStep(gorkflow.NewStep("invoice", "Invoice", passthrough[invoiceInput, string])).
+		Build()
+
+	require.NoError(t, err)
+	assert.NotNil(t, wf)
+}
+
+func TestWorkflowBuilder_Build_IncompatibleTypes(t *testing.T) {
+	_, err := NewWorkflow("typed", "Typed").
+		ThenStep(gorkflow.NewStep("create", "Create", passthrough[orderInput, orderOutput])).
+		ThenStep(gorkflow.NewStep("ship", "Ship", passthrough[mismatchedInput, string])).
+		Build()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "create")
+	assert.Contains(t, err.Error(), "ship")
+}
+
+func TestWorkflowBuilder_WithoutTypeChecks(t *testing.T) {
+	wf, err := NewWorkflow("typed", "Typed").
+		WithoutTypeChecks().
+		ThenStep(gorkflow.NewStep("create", "Create", passthrough[orderInput, orderOutput])).
+		ThenStep(gorkflow.NewStep("ship", "Ship", passthrough[mismatchedInput, string])).
+		Build()
+
+	require.NoError(t, err)
+	assert.NotNil(t, wf)
+}
+
+func TestWorkflowBuilder_Build_ConditionalDefaultTypes(t *testing.T) {
+	skip := func(ctx *gorkflow.StepContext) (bool, error) { return false, nil }
+
+	_, err := NewWorkflow("typed", "Typed").
+		ThenStep(gorkflow.NewStep("receive", "Receive", passthrough[orderInput, orderInput])).
+		ThenStepIf(gorkflow.NewStep("price", "Price", passthrough[orderInput, orderOutput]), skip, "free").
+		Build()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "step price default value of type string is not compatible with its output type builder.orderOutput")
+
+	_, err = NewWorkflow("typed", "Typed").
+		ThenStep(gorkflow.NewStep("receive", "Receive", passthrough[orderInput, orderInput])).
+		ThenStepIf(gorkflow.NewStep("price", "Price", passthrough[orderInput, orderOutput]), skip, map[string]any{"total": "free"}).
+		Build()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "step price default value")
+
+	wf, err := NewWorkflow("typed", "Typed").
+		ThenStep(gorkflow.NewStep("receive", "Receive", passthrough[orderInput, orderInput])).
+		ThenStepIf(gorkflow.NewStep("price", "Price", passthrough[orderInput, orderOutput]), skip, orderOutput{Total: 0}).
+		Build()
+	require.NoError(t, err)
+	assert.NotNil(t, wf)
+}
+
+func TestWorkflowBuilder_Build_InputMapperSkipsTypeChecks(t *testing.T) {
+	toShip := func(prev []byte) ([]byte, error) { return []byte(`{"orderId": 1}`), nil }
+
+	wf, err := NewWorkflow("typed", "Typed").
+		ThenStep(gorkflow.NewStep("create", "Create", passthrough[orderInput, orderOutput])).
+		ThenStep(gorkflow.NewStep("ship", "Ship", passthrough[mismatchedInput, string], gorkflow.WithInputMapper(toShip))).
+		Build()
+
+	require.NoError(t, err)
+	assert.NotNil(t, wf)
+}
+
+func TestJSONCompatible(t *testing.T) {
+	type recursive struct {
+		Children []recursive `json:"children"`
+	}
+
+	tests := []struct {
+		name string
+		out  any
+		in   any
+		want bool
+	}{
+		{"same type", orderOutput{}, orderOutput{}, true},
+		{"disjoint structs", orderInput{}, struct{ Other bool }{}, true},
+		{"number into number", orderOutput{}, invoiceInput{}, true},
+		{"string into number field", orderOutput{}, mismatchedInput{}, false},
+		{"struct into map", orderOutput{}, map[string]any{}, true},
+		{"struct into string", orderOutput{}, "", false},
+		{"slice into slice", []orderOutput{}, []invoiceInput{}, true},
+		{"slice into struct", []string{}, orderInput{}, false},
+		{"pointer into value", &orderOutput{}, invoiceInput{}, true},
+		{"bytes into string", []byte{}, "", true},
+		{"time into string", time.Time{}, "", true},
+		{"anything into raw message", 42, json.RawMessage{}, true},
+		{"recursive types", recursive{}, recursive{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := jsonCompatible(reflect.TypeOf(tt.out), reflect.TypeOf(tt.in), map[[2]reflect.Type]bool{})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWorkflowBuilder_Build_ParallelJoinTypes(t *testing.T) {
+	type joinInput struct {
+		Create orderOutput     `json:"create"`
+		Ship   mismatchedInput `json:"ship"`
+	}
+
+	_, err := NewWorkflow("join", "Join").
+		ThenStep(gorkflow.NewStep("start", "Start", passthrough[orderInput, orderInput])).
+		Parallel(
+			gorkflow.NewStep("create", "Create", passthrough[orderInput, orderOutput]),
+			gorkflow.NewStep("ship", "Ship", passthrough[orderInput, orderOutput]),
+		).
+		ThenStep(gorkflow.NewStep("join", "Join", passthrough[joinInput, string])).
+		Build()
+
+	// The join's "ship" field cannot hold the ship step's output
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ship")
+	assert.Contains(t, err.Error(), "join")
+}
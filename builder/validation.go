@@ -2,6 +2,7 @@ package builder
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/sicko7947/gorkflow"
 )
@@ -34,35 +35,8 @@ func ValidateNoCycles(graph *gorkflow.ExecutionGraph) error {
 		return nil
 	}
 
-	visited := make(map[string]bool)
-	recStack := make(map[string]bool)
-
-	var hasCycle func(string) bool
-	hasCycle = func(nodeID string) bool {
-		visited[nodeID] = true
-		recStack[nodeID] = true
-
-		node := graph.Nodes[nodeID]
-		for _, nextID := range node.Next {
-			if !visited[nextID] {
-				if hasCycle(nextID) {
-					return true
-				}
-			} else if recStack[nextID] {
-				return true
-			}
-		}
-
-		recStack[nodeID] = false
-		return false
-	}
-
-	for nodeID := range graph.Nodes {
-		if !visited[nodeID] {
-			if hasCycle(nodeID) {
-				return fmt.Errorf("cycle detected in execution graph")
-			}
-		}
+	if graph.HasCycle() {
+		return fmt.Errorf("cycle detected in execution graph")
 	}
 
 	return nil
@@ -74,21 +48,7 @@ func ValidateReachability(graph *gorkflow.ExecutionGraph) error {
 		return fmt.Errorf("no entry point set")
 	}
 
-	reachable := make(map[string]bool)
-	var visit func(string)
-	visit = func(nodeID string) {
-		if reachable[nodeID] {
-			return
-		}
-		reachable[nodeID] = true
-
-		node := graph.Nodes[nodeID]
-		for _, nextID := range node.Next {
-			visit(nextID)
-		}
-	}
-
-	visit(graph.EntryPoint)
+	reachable := graph.Reachable(graph.EntryPoint)
 
 	// Check if all nodes are reachable
 	for nodeID := range graph.Nodes {
@@ -109,3 +69,86 @@ func ValidateStepReferences(w *gorkflow.Workflow) error {
 	}
 	return nil
 }
+
+// ValidateInputSources ensures steps declared with WithInputFrom exist and run before the consuming step
+func ValidateInputSources(w *gorkflow.Workflow) error {
+	if errs := inputSourceErrors(w); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// inputSourceErrors returns every invalid WithInputFrom declaration in the workflow
+func inputSourceErrors(w *gorkflow.Workflow) []error {
+	var errs []error
+	graph := w.Graph()
+
+	for _, step := range w.GetAllSteps() {
+		for _, sourceID := range inputSources(step) {
+			if _, exists := graph.Nodes[sourceID]; !exists {
+				errs = append(errs, fmt.Errorf("step %s takes input from unknown step %s", step.GetID(), sourceID))
+				continue
+			}
+			if !isUpstream(graph, sourceID, step.GetID()) {
+				errs = append(errs, fmt.Errorf("step %s takes input from step %s, which does not run before it", step.GetID(), sourceID))
+			}
+		}
+	}
+
+	return errs
+}
+
+// ValidateAll runs every workflow check without stopping at the first problem: graph structure,
+// step registration, declared input sources and step type compatibility. It returns all problems
+// found, sorted by message, or nil for a sound workflow.
+func ValidateAll(w *gorkflow.Workflow) []error {
+	graph := w.Graph()
+	var errs []error
+
+	// Graph structure
+	if graph.EntryPoint == "" {
+		errs = append(errs, fmt.Errorf("execution graph has no entry point"))
+	} else if _, exists := graph.Nodes[graph.EntryPoint]; !exists {
+		errs = append(errs, fmt.Errorf("entry point %s not found in graph", graph.EntryPoint))
+	}
+	for nodeID, node := range graph.Nodes {
+		for _, nextID := range node.Next {
+			if _, exists := graph.Nodes[nextID]; !exists {
+				errs = append(errs, fmt.Errorf("edge %s -> %s references unknown step %s", nodeID, nextID, nextID))
+			}
+		}
+	}
+	if err := ValidateNoCycles(graph); err != nil {
+		errs = append(errs, err)
+	}
+	if _, exists := graph.Nodes[graph.EntryPoint]; exists {
+		reachable := graph.Reachable(graph.EntryPoint)
+		for nodeID := range graph.Nodes {
+			if !reachable[nodeID] {
+				errs = append(errs, fmt.Errorf("node %s is not reachable from entry point", nodeID))
+			}
+		}
+	}
+
+	// Step references
+	for stepID := range graph.Nodes {
+		if _, err := w.GetStep(stepID); err != nil {
+			errs = append(errs, fmt.Errorf("step %s referenced in graph but not registered", stepID))
+		}
+	}
+
+	errs = append(errs, inputSourceErrors(w)...)
+	errs = append(errs, stepTypeErrors(w)...)
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
+}
+
+// isUpstream reports whether target can be reached from source by following edges
+func isUpstream(graph *gorkflow.ExecutionGraph, source, target string) bool {
+	node, exists := graph.Nodes[source]
+	if !exists {
+		return false
+	}
+	return graph.Reachable(node.Next...)[target]
+}
@@ -0,0 +1,33 @@
+package gorkflow
+
+import "encoding/json"
+
+// Codec marshals step inputs, outputs and state. Payloads are still treated as JSON
+// (schemas, redaction, merged inputs), so a codec must read and write standard JSON;
+// it exists to swap in a faster implementation such as goccy/go-json or json-iterator.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// jsonCodec is the encoding/json Codec
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// DefaultCodec is used wherever no codec is configured
+var DefaultCodec Codec = jsonCodec{}
+
+// codecOrDefault returns codec, or DefaultCodec when it is nil
+func codecOrDefault(codec Codec) Codec {
+	if codec == nil {
+		return DefaultCodec
+	}
+	return codec
+}
@@ -1,6 +1,11 @@
 package gorkflow
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
 
 // ExecutionConfig holds step-level execution parameters
 type ExecutionConfig struct {
@@ -9,7 +14,7 @@ type ExecutionConfig struct {
 	RetryDelayMs int
 	RetryBackoff BackoffStrategy
 
-	// Timeout
+	// Timeout; zero or negative means the step runs without one
 	TimeoutSeconds int
 
 	// Concurrency (for parallel execution in future)
@@ -62,6 +67,13 @@ func (f stepOptionFunc) applyStep(step interface{}) {
 	f(step)
 }
 
+// ApplyStepOptions applies options to an already constructed step
+func ApplyStepOptions(step StepExecutor, opts ...StepOption) {
+	for _, opt := range opts {
+		opt.applyStep(step)
+	}
+}
+
 // WithRetries sets the maximum retry attempts
 func WithRetries(max int) StepOption {
 	return stepOptionFunc(func(s interface{}) {
@@ -71,11 +83,176 @@ func WithRetries(max int) StepOption {
 	})
 }
 
-// WithTimeout sets the step timeout
+// ErrDoNotRetry marks a step error as permanent. Attempts failing with an error
+// wrapping it are never retried, whatever the step's retry predicate says.
+var ErrDoNotRetry = errors.New("do not retry")
+
+// RetryPredicate reports whether a failed step attempt should be retried
+type RetryPredicate func(err error) bool
+
+// ShouldRetry applies a step's retry predicate to a failed attempt. ErrDoNotRetry
+// always stops retries; otherwise a nil predicate retries every error.
+func ShouldRetry(predicate RetryPredicate, err error) bool {
+	if errors.Is(err, ErrDoNotRetry) {
+		return false
+	}
+	return predicate == nil || predicate(err)
+}
+
+// RetryAfter is implemented by errors that say when a step may be tried again, such as a
+// downstream rate limit. The next attempt waits that long instead of the configured backoff.
+type RetryAfter interface {
+	RetryAfter() time.Duration
+}
+
+// RetryAfterError wraps a step error with the delay to wait before the next attempt
+type RetryAfterError struct {
+	Err      error
+	Duration time.Duration
+}
+
+// NewRetryAfterError asks for the next attempt to run after d
+func NewRetryAfterError(err error, d time.Duration) *RetryAfterError {
+	return &RetryAfterError{Err: err, Duration: d}
+}
+
+func (e *RetryAfterError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("retry after %s", e.Duration)
+	}
+	return fmt.Sprintf("%v (retry after %s)", e.Err, e.Duration)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the requested delay
+func (e *RetryAfterError) RetryAfter() time.Duration {
+	return e.Duration
+}
+
+// RetryAfterDelay returns the delay requested by err or any error it wraps
+func RetryAfterDelay(err error) (time.Duration, bool) {
+	var retryAfter RetryAfter
+	if errors.As(err, &retryAfter) {
+		return retryAfter.RetryAfter(), true
+	}
+	return 0, false
+}
+
+// WithRetryIf retries a failed attempt only when predicate returns true,
+// so permanent failures fail the step without exhausting MaxRetries
+func WithRetryIf(predicate RetryPredicate) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetRetryIf(RetryPredicate) }); ok {
+			step.SetRetryIf(predicate)
+		}
+	})
+}
+
+// CircuitBreakerConfig trips a step's circuit after FailThreshold consecutive failed
+// executions, across runs. While open, executions fail fast until Cooldown passes.
+type CircuitBreakerConfig struct {
+	FailThreshold int
+	Cooldown      time.Duration
+}
+
+// WithCircuitBreaker fast-fails the step for cooldown once it has failed
+// failThreshold times in a row, protecting a failing dependency
+func WithCircuitBreaker(failThreshold int, cooldown time.Duration) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface {
+			SetCircuitBreaker(*CircuitBreakerConfig)
+		}); ok {
+			step.SetCircuitBreaker(&CircuitBreakerConfig{FailThreshold: failThreshold, Cooldown: cooldown})
+		}
+	})
+}
+
+// RateLimitConfig limits how often a step is executed, across all runs in an engine
+type RateLimitConfig struct {
+	RPS   float64
+	Burst int
+}
+
+// WithRateLimit allows at most rps executions of the step per second, with bursts of up to
+// burst, shared by every run in the engine. Retries count as executions.
+func WithRateLimit(rps float64, burst int) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetRateLimit(*RateLimitConfig) }); ok {
+			step.SetRateLimit(&RateLimitConfig{RPS: rps, Burst: burst})
+		}
+	})
+}
+
+// WithWeight sets the step's share of the run's progress relative to other steps (default 1.0),
+// so a long-running step can count for more than a quick one. Non-positive weights count as 1.0.
+func WithWeight(w float64) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetWeight(float64) }); ok {
+			step.SetWeight(w)
+		}
+	})
+}
+
+// WithRawIO passes the step's input and output as raw bytes instead of JSON. The step
+// must take and return []byte; see NewRawStep.
+func WithRawIO() StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetRawIO(bool) }); ok {
+			step.SetRawIO(true)
+		}
+	})
+}
+
+// WithStepTags attaches metadata such as owner, SLA or criticality to the step. Tags are
+// copied to its StepExecution records and added to its log fields; repeated calls merge.
+func WithStepTags(tags map[string]string) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetTags(map[string]string) }); ok {
+			step.SetTags(tags)
+		}
+	})
+}
+
+// WithOutputVersion stamps the step's stored outputs with version, so outputs written by an
+// older deploy can be upgraded on load by the migrations registered with WithOutputMigration
+func WithOutputVersion(version int) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetOutputVersion(int) }); ok {
+			step.SetOutputVersion(version)
+		}
+	})
+}
+
+// WithOutputMigration registers how to upgrade an output stored at version from to version
+// from+1. Outputs stored before the step declared a version are version 0.
+func WithOutputMigration(from int, migrate OutputMigration) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface {
+			AddOutputMigration(int, OutputMigration)
+		}); ok {
+			step.AddOutputMigration(from, migrate)
+		}
+	})
+}
+
+// WithMaxOutputBytes fails the step when its serialized output is larger than n bytes,
+// instead of letting an oversized payload reach the store (0 = no limit)
+func WithMaxOutputBytes(n int) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetMaxOutputBytes(int) }); ok {
+			step.SetMaxOutputBytes(n)
+		}
+	})
+}
+
+// WithTimeout sets the step timeout, rounded up to whole seconds; d <= 0 removes the timeout
 func WithTimeout(d time.Duration) StepOption {
 	return stepOptionFunc(func(s interface{}) {
 		if step, ok := s.(interface{ SetTimeout(int) }); ok {
-			step.SetTimeout(int(d.Seconds()))
+			step.SetTimeout(int(math.Ceil(d.Seconds())))
 		}
 	})
 }
@@ -107,6 +284,52 @@ func WithContinueOnError(continueOnError bool) StepOption {
 	})
 }
 
+// WithStepContext gives a step its own custom context, used instead of the workflow context
+func WithStepContext(ctx any) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetCustomContext(any) }); ok {
+			step.SetCustomContext(ctx)
+		}
+	})
+}
+
+// WithInputFrom makes the outputs of the given upstream steps the step's input,
+// instead of the output of the step before it. With several steps the input is
+// a JSON object keyed by step ID.
+func WithInputFrom(stepIDs ...string) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetInputFrom([]string) }); ok {
+			step.SetInputFrom(stepIDs)
+		}
+	})
+}
+
+// InputMapper reshapes the input the engine resolved for a step (the upstream output, or the
+// workflow input for the entry step) before the step receives it
+type InputMapper func(prev []byte) ([]byte, error)
+
+// WithInputMapper applies mapper to the step's input before the step runs, so an upstream
+// output can be reshaped without a glue step in between. Type checks between the upstream
+// step and this one are skipped, since the mapper decides the shape.
+func WithInputMapper(mapper InputMapper) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetInputMapper(InputMapper) }); ok {
+			step.SetInputMapper(mapper)
+		}
+	})
+}
+
+// WithInputDefault gives the step value as its input when the resolved input is empty or JSON
+// null, e.g. because the upstream step was skipped or failed with ContinueOnError. The default
+// is applied before any WithInputMapper.
+func WithInputDefault(value any) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetInputDefault(any) }); ok {
+			step.SetInputDefault(value)
+		}
+	})
+}
+
 // StartOption allows functional configuration of workflow execution
 type StartOption func(*StartOptions)
 
@@ -119,6 +342,8 @@ type StartOptions struct {
 	TriggerType      string
 	TriggerSource    string
 	Synchronous      bool
+	Timeout          time.Duration
+	MaxTotalRetries  int
 }
 
 // WithResourceID sets the resource ID for concurrency control
@@ -155,3 +380,19 @@ func WithSynchronousExecution() StartOption {
 		opts.Synchronous = true
 	}
 }
+
+// WithWorkflowTimeout sets the overall execution deadline for this run,
+// overriding the engine's DefaultTimeout
+func WithWorkflowTimeout(d time.Duration) StartOption {
+	return func(opts *StartOptions) {
+		opts.Timeout = d
+	}
+}
+
+// WithMaxTotalRetries caps the retries taken across all steps of this run,
+// overriding the engine's MaxTotalRetries
+func WithMaxTotalRetries(n int) StartOption {
+	return func(opts *StartOptions) {
+		opts.MaxTotalRetries = n
+	}
+}
@@ -1,6 +1,8 @@
 package gorkflow
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -37,6 +39,16 @@ func TestWithTimeout(t *testing.T) {
 	assert.Equal(t, 60, step.Config.TimeoutSeconds)
 }
 
+func TestWithTimeout_RoundsUpToWholeSeconds(t *testing.T) {
+	step := NewStep("test", "Test", testHandler)
+
+	WithTimeout(500 * time.Millisecond).applyStep(step)
+	assert.Equal(t, 1, step.Config.TimeoutSeconds)
+
+	WithTimeout(0).applyStep(step)
+	assert.Equal(t, 0, step.Config.TimeoutSeconds)
+}
+
 func TestWithBackoff(t *testing.T) {
 	step := NewStep("test", "Test", testHandler)
 
@@ -175,3 +187,15 @@ func TestDefaultEngineConfig(t *testing.T) {
 	assert.Equal(t, 10, config.MaxConcurrentWorkflows)
 	assert.Equal(t, 5*time.Minute, config.DefaultTimeout)
 }
+
+func TestRetryAfterDelay(t *testing.T) {
+	err := fmt.Errorf("call failed: %w", NewRetryAfterError(errors.New("429"), 2*time.Second))
+
+	delay, ok := RetryAfterDelay(err)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, delay)
+	assert.Equal(t, "429", errors.Unwrap(errors.Unwrap(err)).Error())
+
+	_, ok = RetryAfterDelay(errors.New("boom"))
+	assert.False(t, ok)
+}
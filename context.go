@@ -2,8 +2,10 @@ package gorkflow
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"maps"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 )
@@ -28,6 +30,14 @@ type StepContext struct {
 
 	// Custom context (user-defined)
 	CustomContext any
+
+	// Codec for the step's input and output (nil = DefaultCodec)
+	Codec Codec
+}
+
+// codec returns the codec used for the step's payloads
+func (ctx *StepContext) codec() Codec {
+	return codecOrDefault(ctx.Codec)
 }
 
 // GetContext retrieves the custom context from the step context
@@ -60,6 +70,20 @@ func GetTypedOutput[T any](accessor StepOutputAccessor, stepID string) (T, error
 	return result, err
 }
 
+// GetTypedOutputs collects the outputs of several steps, such as the branches joined by a
+// fan-in step, in the order of stepIDs
+func GetTypedOutputs[T any](accessor StepOutputAccessor, stepIDs []string) ([]T, error) {
+	results := make([]T, 0, len(stepIDs))
+	for _, stepID := range stepIDs {
+		result, err := GetTypedOutput[T](accessor, stepID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get output of step %s: %w", stepID, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
 // StateAccessor provides type-safe access to workflow state
 type StateAccessor interface {
 	// Set stores a value in the workflow state
@@ -76,6 +100,13 @@ type StateAccessor interface {
 
 	// GetAll retrieves all state data
 	GetAll() (map[string][]byte, error)
+
+	// List retrieves the state entries whose keys start with prefix
+	List(prefix string) (map[string][]byte, error)
+
+	// Increment atomically adds delta to an integer value and returns the new total
+	// A missing key starts from zero
+	Increment(key string, delta int64) (int64, error)
 }
 
 // SetTyped is a generic function for type-safe state setting
@@ -90,44 +121,90 @@ func GetTyped[T any](accessor StateAccessor, key string) (T, error) {
 	return result, err
 }
 
-// stepOutputAccessor implements StepOutputAccessor
+// stepOutputAccessor implements StepOutputAccessor. Its cache is shared by steps running
+// in parallel, so every access goes through mu.
 type stepOutputAccessor struct {
 	runID string
 	store WorkflowStore
-	cache map[string][]byte
+	mu    sync.RWMutex
+	cache map[string]outputCacheEntry
+	ttl   time.Duration
+	codec Codec
+
+	// Upgrades outputs stored at an older step output version, nil leaves them as stored
+	workflow *Workflow
+}
+
+// outputCacheEntry is a loaded step output, or a remembered miss when found is false
+type outputCacheEntry struct {
+	data     []byte
+	found    bool
+	loadedAt time.Time
+}
+
+// StepOutputAccessorOption configures a step output accessor
+type StepOutputAccessorOption func(*stepOutputAccessor)
+
+// WithOutputCacheTTL reloads cached outputs, and re-checks missing ones, once they are
+// older than ttl, so long-running runs see outputs rewritten in the store (0 = never expire)
+func WithOutputCacheTTL(ttl time.Duration) StepOutputAccessorOption {
+	return func(a *stepOutputAccessor) {
+		a.ttl = ttl
+	}
+}
+
+// WithOutputCodec unmarshals outputs with codec instead of DefaultCodec
+func WithOutputCodec(codec Codec) StepOutputAccessorOption {
+	return func(a *stepOutputAccessor) {
+		a.codec = codecOrDefault(codec)
+	}
+}
+
+// WithOutputMigrations upgrades outputs of wf's steps stored at an older WithOutputVersion
+// through the step's migrations before they are cached and unmarshaled
+func WithOutputMigrations(wf *Workflow) StepOutputAccessorOption {
+	return func(a *stepOutputAccessor) {
+		a.workflow = wf
+	}
 }
 
 // newStepOutputAccessor creates a new output accessor
-func newStepOutputAccessor(runID string, wfStore WorkflowStore) StepOutputAccessor {
-	return &stepOutputAccessor{
+func newStepOutputAccessor(runID string, wfStore WorkflowStore, opts ...StepOutputAccessorOption) StepOutputAccessor {
+	a := &stepOutputAccessor{
 		runID: runID,
 		store: wfStore,
-		cache: make(map[string][]byte),
+		cache: make(map[string]outputCacheEntry),
+		codec: DefaultCodec,
+	}
+	for _, opt := range opts {
+		opt(a)
 	}
+	return a
 }
 
 // NewStepOutputAccessor creates a new output accessor (exported)
-func NewStepOutputAccessor(runID string, wfStore WorkflowStore) StepOutputAccessor {
-	return newStepOutputAccessor(runID, wfStore)
+// Outputs are read through a cache shared by every step given the accessor
+func NewStepOutputAccessor(runID string, wfStore WorkflowStore, opts ...StepOutputAccessorOption) StepOutputAccessor {
+	return newStepOutputAccessor(runID, wfStore, opts...)
 }
 
 func (a *stepOutputAccessor) GetOutput(stepID string, target interface{}) error {
 	// Check cache first
-	if data, ok := a.cache[stepID]; ok {
-		return json.Unmarshal(data, target)
+	if entry, ok := a.cached(stepID); ok && entry.found {
+		return a.codec.Unmarshal(entry.data, target)
 	}
 
 	// Load from store
-	data, err := a.store.LoadStepOutput(context.Background(), a.runID, stepID)
+	data, err := a.load(stepID)
 	if err != nil {
 		return fmt.Errorf("failed to load output for step %s: %w", stepID, err)
 	}
 
 	// Cache it
-	a.cache[stepID] = data
+	a.remember(stepID, outputCacheEntry{data: data, found: true, loadedAt: time.Now()})
 
 	// Unmarshal
-	if err := json.Unmarshal(data, target); err != nil {
+	if err := a.codec.Unmarshal(data, target); err != nil {
 		return fmt.Errorf("failed to unmarshal output for step %s: %w", stepID, err)
 	}
 
@@ -135,46 +212,136 @@ func (a *stepOutputAccessor) GetOutput(stepID string, target interface{}) error
 }
 
 func (a *stepOutputAccessor) HasOutput(stepID string) bool {
-	// Check cache
-	if _, ok := a.cache[stepID]; ok {
-		return true
+	// Check cache, including steps already found to have no output
+	if entry, ok := a.cached(stepID); ok {
+		return entry.found
 	}
 
 	// Check store
-	_, err := a.store.LoadStepOutput(context.Background(), a.runID, stepID)
+	data, err := a.load(stepID)
+	a.remember(stepID, outputCacheEntry{data: data, found: err == nil, loadedAt: time.Now()})
 	return err == nil
 }
 
-// stateAccessor implements StateAccessor
+// load reads a step's output from the store, upgraded to the step's current output version
+func (a *stepOutputAccessor) load(stepID string) ([]byte, error) {
+	ctx := context.Background()
+	data, err := a.store.LoadStepOutput(ctx, a.runID, stepID)
+	if err != nil || a.workflow == nil {
+		return data, err
+	}
+	return UpgradeStepOutput(ctx, a.store, a.workflow, a.runID, stepID, data)
+}
+
+// WarmCache loads the outputs of the given steps, with one batch read when the store supports it,
+// so steps reading them later are served from the cache. Steps without an output are cached as missing.
+func (a *stepOutputAccessor) WarmCache(stepIDs []string) error {
+	if len(stepIDs) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	outputs, err := LoadStepOutputs(ctx, a.store, a.runID, stepIDs)
+	if err != nil {
+		return fmt.Errorf("failed to warm output cache: %w", err)
+	}
+
+	loadedAt := time.Now()
+	for _, stepID := range stepIDs {
+		data, found := outputs[stepID]
+		if found && a.workflow != nil {
+			if data, err = UpgradeStepOutput(ctx, a.store, a.workflow, a.runID, stepID, data); err != nil {
+				return fmt.Errorf("failed to warm output cache: %w", err)
+			}
+		}
+		a.remember(stepID, outputCacheEntry{data: data, found: found, loadedAt: loadedAt})
+	}
+	return nil
+}
+
+// Invalidate drops the cached output of a step, e.g. once the step has produced a new one
+func (a *stepOutputAccessor) Invalidate(stepID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.cache, stepID)
+}
+
+// cached returns the cache entry for a step unless it has expired
+func (a *stepOutputAccessor) cached(stepID string) (outputCacheEntry, bool) {
+	a.mu.RLock()
+	entry, ok := a.cache[stepID]
+	a.mu.RUnlock()
+	if !ok {
+		return outputCacheEntry{}, false
+	}
+
+	if a.ttl > 0 && time.Since(entry.loadedAt) >= a.ttl {
+		a.mu.Lock()
+		// Another step may have reloaded it meanwhile
+		if current, ok := a.cache[stepID]; ok && current.loadedAt.Equal(entry.loadedAt) {
+			delete(a.cache, stepID)
+		}
+		a.mu.Unlock()
+		return outputCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// remember caches the entry for a step
+func (a *stepOutputAccessor) remember(stepID string, entry outputCacheEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cache[stepID] = entry
+}
+
+// stateAccessor implements StateAccessor. Like the output accessor, it may be used by
+// parallel steps at once, so its cache is guarded by mu.
 type stateAccessor struct {
 	runID string
 	store WorkflowStore
+	mu    sync.RWMutex
 	cache map[string][]byte
+	codec Codec
+}
+
+// StateAccessorOption configures a state accessor
+type StateAccessorOption func(*stateAccessor)
+
+// WithStateCodec marshals state values with codec instead of DefaultCodec
+func WithStateCodec(codec Codec) StateAccessorOption {
+	return func(a *stateAccessor) {
+		a.codec = codecOrDefault(codec)
+	}
 }
 
 // newStateAccessor creates a new state accessor
-func newStateAccessor(runID string, wfStore WorkflowStore) StateAccessor {
-	return &stateAccessor{
+func newStateAccessor(runID string, wfStore WorkflowStore, opts ...StateAccessorOption) StateAccessor {
+	a := &stateAccessor{
 		runID: runID,
 		store: wfStore,
 		cache: make(map[string][]byte),
+		codec: DefaultCodec,
 	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
 // NewStateAccessor creates a new state accessor (exported)
-func NewStateAccessor(runID string, wfStore WorkflowStore) StateAccessor {
-	return newStateAccessor(runID, wfStore)
+func NewStateAccessor(runID string, wfStore WorkflowStore, opts ...StateAccessorOption) StateAccessor {
+	return newStateAccessor(runID, wfStore, opts...)
 }
 
 func (a *stateAccessor) Set(key string, value interface{}) error {
 	// Marshal value
-	data, err := json.Marshal(value)
+	data, err := a.codec.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal state value for key %s: %w", key, err)
 	}
 
 	// Update cache
-	a.cache[key] = data
+	a.remember(map[string][]byte{key: data})
 
 	// Persist to store
 	if err := a.store.SaveState(context.Background(), a.runID, key, data); err != nil {
@@ -186,8 +353,8 @@ func (a *stateAccessor) Set(key string, value interface{}) error {
 
 func (a *stateAccessor) Get(key string, target interface{}) error {
 	// Check cache first
-	if data, ok := a.cache[key]; ok {
-		return json.Unmarshal(data, target)
+	if data, ok := a.cached(key); ok {
+		return a.codec.Unmarshal(data, target)
 	}
 
 	// Load from store
@@ -197,10 +364,10 @@ func (a *stateAccessor) Get(key string, target interface{}) error {
 	}
 
 	// Cache it
-	a.cache[key] = data
+	a.remember(map[string][]byte{key: data})
 
 	// Unmarshal
-	if err := json.Unmarshal(data, target); err != nil {
+	if err := a.codec.Unmarshal(data, target); err != nil {
 		return fmt.Errorf("failed to unmarshal state for key %s: %w", key, err)
 	}
 
@@ -209,7 +376,7 @@ func (a *stateAccessor) Get(key string, target interface{}) error {
 
 func (a *stateAccessor) Delete(key string) error {
 	// Remove from cache
-	delete(a.cache, key)
+	a.forget(key)
 
 	// Delete from store
 	if err := a.store.DeleteState(context.Background(), a.runID, key); err != nil {
@@ -221,7 +388,7 @@ func (a *stateAccessor) Delete(key string) error {
 
 func (a *stateAccessor) Has(key string) bool {
 	// Check cache
-	if _, ok := a.cache[key]; ok {
+	if _, ok := a.cached(key); ok {
 		return true
 	}
 
@@ -238,9 +405,53 @@ func (a *stateAccessor) GetAll() (map[string][]byte, error) {
 	}
 
 	// Update cache
-	for k, v := range data {
-		a.cache[k] = v
+	a.remember(data)
+
+	return data, nil
+}
+
+func (a *stateAccessor) List(prefix string) (map[string][]byte, error) {
+	data, err := a.store.ListState(context.Background(), a.runID, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list state with prefix %s: %w", prefix, err)
 	}
 
+	// Update cache
+	a.remember(data)
+
 	return data, nil
 }
+
+func (a *stateAccessor) Increment(key string, delta int64) (int64, error) {
+	total, err := a.store.IncrementState(context.Background(), a.runID, key, delta)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment state for key %s: %w", key, err)
+	}
+
+	// Concurrent increments return their totals out of order, so the next Get reads the store
+	a.forget(key)
+
+	return total, nil
+}
+
+// cached returns the cached value of a key
+func (a *stateAccessor) cached(key string) ([]byte, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	data, ok := a.cache[key]
+	return data, ok
+}
+
+// forget drops a key from the cache
+func (a *stateAccessor) forget(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.cache, key)
+}
+
+// remember caches the given values
+func (a *stateAccessor) remember(values map[string][]byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	maps.Copy(a.cache, values)
+}
@@ -0,0 +1,211 @@
+package gorkflow_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadCountingStore counts the step output loads reaching the wrapped store
+type loadCountingStore struct {
+	gorkflow.WorkflowStore
+	loads      int
+	batchLoads int
+}
+
+func (s *loadCountingStore) LoadStepOutput(ctx context.Context, runID, stepID string) ([]byte, error) {
+	s.loads++
+	return s.WorkflowStore.LoadStepOutput(ctx, runID, stepID)
+}
+
+func (s *loadCountingStore) BatchLoadStepOutputs(ctx context.Context, runID string, stepIDs []string) (map[string][]byte, error) {
+	s.batchLoads++
+	return s.WorkflowStore.(gorkflow.StepOutputBatchLoader).BatchLoadStepOutputs(ctx, runID, stepIDs)
+}
+
+func newLoadCountingStore(t *testing.T) *loadCountingStore {
+	wfStore := &loadCountingStore{WorkflowStore: store.NewMemoryStore()}
+	require.NoError(t, wfStore.SaveStepOutput(context.Background(), "run-1", "fetch", []byte(`{"count":3}`)))
+	return wfStore
+}
+
+func TestLoadStepOutputs_WithoutBatchLoader(t *testing.T) {
+	wfStore := newLoadCountingStore(t)
+
+	// The wrapper hides BatchLoadStepOutputs, leaving one load per step
+	outputs, err := gorkflow.LoadStepOutputs(context.Background(), struct{ gorkflow.WorkflowStore }{wfStore}, "run-1", []string{"fetch", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"fetch": []byte(`{"count":3}`)}, outputs)
+	assert.Equal(t, 2, wfStore.loads)
+	assert.Zero(t, wfStore.batchLoads)
+}
+
+func TestStepOutputAccessor_GetOutputCached(t *testing.T) {
+	wfStore := newLoadCountingStore(t)
+	outputs := gorkflow.NewStepOutputAccessor("run-1", wfStore)
+
+	for i := 0; i < 2; i++ {
+		var output struct {
+			Count int `json:"count"`
+		}
+		require.NoError(t, outputs.GetOutput("fetch", &output))
+		assert.Equal(t, 3, output.Count)
+	}
+	assert.True(t, outputs.HasOutput("fetch"))
+
+	assert.Equal(t, 1, wfStore.loads)
+}
+
+func TestStepOutputAccessor_HasOutputNegativeCache(t *testing.T) {
+	wfStore := newLoadCountingStore(t)
+	outputs := gorkflow.NewStepOutputAccessor("run-1", wfStore)
+
+	assert.False(t, outputs.HasOutput("missing"))
+	assert.False(t, outputs.HasOutput("missing"))
+	assert.Equal(t, 1, wfStore.loads)
+
+	// A step that saves its output afterwards is found once its entry is invalidated
+	require.NoError(t, wfStore.SaveStepOutput(context.Background(), "run-1", "missing", []byte(`{}`)))
+	outputs.(interface{ Invalidate(string) }).Invalidate("missing")
+	assert.True(t, outputs.HasOutput("missing"))
+	assert.Equal(t, 2, wfStore.loads)
+}
+
+func TestStepOutputAccessor_WarmCache(t *testing.T) {
+	wfStore := newLoadCountingStore(t)
+	require.NoError(t, wfStore.SaveStepOutput(context.Background(), "run-1", "parse", []byte(`{"count":5}`)))
+	outputs := gorkflow.NewStepOutputAccessor("run-1", wfStore)
+
+	warmer := outputs.(interface{ WarmCache(stepIDs []string) error })
+	require.NoError(t, warmer.WarmCache([]string{"fetch", "parse", "skipped"}))
+	assert.Equal(t, 1, wfStore.batchLoads)
+
+	// Warmed outputs and misses are served without further loads
+	var output struct {
+		Count int `json:"count"`
+	}
+	require.NoError(t, outputs.GetOutput("fetch", &output))
+	assert.Equal(t, 3, output.Count)
+	require.NoError(t, outputs.GetOutput("parse", &output))
+	assert.Equal(t, 5, output.Count)
+	assert.False(t, outputs.HasOutput("skipped"))
+	assert.Zero(t, wfStore.loads)
+}
+
+func TestStepOutputAccessor_CacheTTL(t *testing.T) {
+	wfStore := newLoadCountingStore(t)
+	outputs := gorkflow.NewStepOutputAccessor("run-1", wfStore, gorkflow.WithOutputCacheTTL(20*time.Millisecond))
+
+	var output map[string]int
+	require.NoError(t, outputs.GetOutput("fetch", &output))
+	require.NoError(t, outputs.GetOutput("fetch", &output))
+	assert.Equal(t, 1, wfStore.loads)
+
+	// Expired entries are reloaded and pick up rewritten outputs
+	require.NoError(t, wfStore.SaveStepOutput(context.Background(), "run-1", "fetch", []byte(`{"count":4}`)))
+	time.Sleep(30 * time.Millisecond)
+
+	require.NoError(t, outputs.GetOutput("fetch", &output))
+	assert.Equal(t, 4, output["count"])
+	assert.Equal(t, 2, wfStore.loads)
+}
+
+// lockFreeStore answers without any locking of its own, so the race detector sees
+// unsynchronized accessor caches instead of the ordering a real store's mutex imposes
+type lockFreeStore struct {
+	gorkflow.WorkflowStore
+}
+
+func (lockFreeStore) LoadStepOutput(ctx context.Context, runID, stepID string) ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"step":%q}`, stepID)), nil
+}
+
+func (lockFreeStore) SaveState(ctx context.Context, runID, key string, value []byte) error {
+	return nil
+}
+
+func (lockFreeStore) LoadState(ctx context.Context, runID, key string) ([]byte, error) {
+	return nil, fmt.Errorf("state %s not found", key)
+}
+
+func TestAccessors_ConcurrentUse(t *testing.T) {
+	outputs := gorkflow.NewStepOutputAccessor("run-1", lockFreeStore{}, gorkflow.WithOutputCacheTTL(time.Millisecond))
+	state := gorkflow.NewStateAccessor("run-1", lockFreeStore{})
+
+	// Parallel steps share one accessor of each kind; run with -race
+	var wg sync.WaitGroup
+	for worker := range 8 {
+		wg.Go(func() {
+			for round := range 50 {
+				key := fmt.Sprintf("worker%d/%d", worker, round)
+				assert.NoError(t, state.Set(key, round))
+				value, err := gorkflow.GetTyped[int](state, key)
+				assert.NoError(t, err)
+				assert.Equal(t, round, value)
+				assert.True(t, state.Has(key))
+
+				var output map[string]string
+				other := fmt.Sprintf("step%d", (worker+round)%8)
+				assert.NoError(t, outputs.GetOutput(other, &output))
+				assert.Equal(t, other, output["step"])
+				assert.True(t, outputs.HasOutput(other))
+				outputs.(interface{ Invalidate(string) }).Invalidate(other)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestStateAccessor_IncrementShared(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	require.NoError(t, wfStore.CreateRun(context.Background(), &gorkflow.WorkflowRun{RunID: "run-1"}))
+
+	// Parallel steps share the run's accessor, so their totals come back out of order
+	state := gorkflow.NewStateAccessor("run-1", wfStore)
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(delta int64) {
+			defer wg.Done()
+			_, err := state.Increment("processed", delta)
+			assert.NoError(t, err)
+		}(int64(i))
+	}
+	wg.Wait()
+
+	total, err := gorkflow.GetTyped[int64](state, "processed")
+	require.NoError(t, err)
+	assert.Equal(t, int64(210), total)
+}
+
+func TestGetTypedOutputs(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	ctx := context.Background()
+	for i, stepID := range []string{"branch_a", "branch_b", "branch_c"} {
+		data := []byte(fmt.Sprintf(`{"count":%d}`, i+1))
+		require.NoError(t, wfStore.SaveStepOutput(ctx, "run-1", stepID, data))
+	}
+	require.NoError(t, wfStore.SaveStepOutput(ctx, "run-1", "broken", []byte(`"not an object"`)))
+
+	type branchOutput struct {
+		Count int `json:"count"`
+	}
+	outputs := gorkflow.NewStepOutputAccessor("run-1", wfStore)
+
+	results, err := gorkflow.GetTypedOutputs[branchOutput](outputs, []string{"branch_c", "branch_a", "branch_b"})
+	require.NoError(t, err)
+	assert.Equal(t, []branchOutput{{Count: 3}, {Count: 1}, {Count: 2}}, results)
+
+	_, err = gorkflow.GetTypedOutputs[branchOutput](outputs, []string{"branch_a", "missing"})
+	assert.ErrorContains(t, err, "step missing")
+
+	_, err = gorkflow.GetTypedOutputs[branchOutput](outputs, []string{"broken"})
+	assert.ErrorContains(t, err, "step broken")
+}
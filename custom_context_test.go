@@ -67,3 +67,59 @@ func TestWorkflowWithCustomContext(t *testing.T) {
 	require.Len(t, executions, 1)
 	assert.Equal(t, "\"Processed for user-123 in production\"", string(executions[0].Output))
 }
+
+type DBContext struct {
+	DSN string
+}
+
+func TestWorkflowWithStepContext(t *testing.T) {
+	appStep := gorkflow.NewStep("app", "App Step",
+		func(ctx *gorkflow.StepContext, input string) (string, error) {
+			appCtx, err := gorkflow.GetContext[*AppContext](ctx)
+			if err != nil {
+				return "", err
+			}
+			return appCtx.UserID, nil
+		},
+		gorkflow.WithStepContext(&AppContext{UserID: "user-456"}),
+	)
+
+	dbStep := gorkflow.NewStep("db", "DB Step",
+		func(ctx *gorkflow.StepContext, input string) (string, error) {
+			dbCtx, err := gorkflow.GetContext[*DBContext](ctx)
+			if err != nil {
+				return "", err
+			}
+			return input + "@" + dbCtx.DSN, nil
+		},
+		gorkflow.WithStepContext(&DBContext{DSN: "postgres://db"}),
+	)
+
+	// Steps without their own context still see the workflow context
+	workflowStep := gorkflow.NewStep("workflow", "Workflow Step",
+		func(ctx *gorkflow.StepContext, input string) (string, error) {
+			appCtx, err := gorkflow.GetContext[*AppContext](ctx)
+			if err != nil {
+				return "", err
+			}
+			return input + "/" + appCtx.UserID, nil
+		},
+	)
+
+	wf := gorkflow.NewWorkflowInstance("test-wf", "Test Workflow",
+		gorkflow.WithContext(&AppContext{UserID: "workflow-user"}),
+	)
+	for _, step := range []gorkflow.StepExecutor{appStep, dbStep, workflowStep} {
+		wf.AddStep(step)
+		wf.Graph().AddNode(step.GetID(), gorkflow.NodeTypeSequential)
+	}
+	require.NoError(t, wf.Graph().AddEdge("app", "db"))
+	require.NoError(t, wf.Graph().AddEdge("db", "workflow"))
+
+	eng := engine.NewEngine(store.NewMemoryStore())
+
+	run, err := eng.RunWorkflowSync(context.Background(), wf, "start")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.Equal(t, `"user-456@postgres://db/workflow-user"`, string(run.Output))
+}
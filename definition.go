@@ -0,0 +1,118 @@
+package gorkflow
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// WorkflowDefinition describes a workflow's structure without its handlers.
+// It is the JSON schema read by builder.FromJSON.
+type WorkflowDefinition struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	EntryPoint  string            `json:"entryPoint,omitempty"`
+	Steps       []StepDefinition  `json:"steps"`
+	Edges       []EdgeDefinition  `json:"edges,omitempty"`
+}
+
+// StepDefinition describes a single step and the handler it is bound to
+type StepDefinition struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+
+	// Registry key of the step's handler, defaults to the step ID
+	Handler string `json:"handler,omitempty"`
+
+	// Node type, defaults to SEQUENTIAL
+	Type        NodeType `json:"type,omitempty"`
+	MaxParallel int      `json:"maxParallel,omitempty"`
+
+	InputFrom []string              `json:"inputFrom,omitempty"`
+	Config    *StepConfigDefinition `json:"config,omitempty"`
+}
+
+// StepConfigDefinition overrides a step's execution config; unset fields keep the step's own values
+type StepConfigDefinition struct {
+	MaxRetries      *int            `json:"maxRetries,omitempty"`
+	RetryDelayMs    *int            `json:"retryDelayMs,omitempty"`
+	RetryBackoff    BackoffStrategy `json:"retryBackoff,omitempty"`
+	TimeoutSeconds  *int            `json:"timeoutSeconds,omitempty"`
+	ContinueOnError *bool           `json:"continueOnError,omitempty"`
+}
+
+// EdgeDefinition is a directed edge between two steps
+type EdgeDefinition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// Set for edges guarded by a condition, which is not itself serializable
+	Conditional bool `json:"conditional,omitempty"`
+}
+
+// Definition describes the workflow's structure: steps, node types, per-step config,
+// entry point and edges. Steps are sorted by ID so equal workflows produce equal definitions.
+func (w *Workflow) Definition() WorkflowDefinition {
+	def := WorkflowDefinition{
+		ID:          w.id,
+		Name:        w.name,
+		Description: w.description,
+		Version:     w.version,
+		EntryPoint:  w.graph.EntryPoint,
+		Steps:       []StepDefinition{},
+	}
+	if len(w.tags) > 0 {
+		def.Tags = w.tags
+	}
+
+	for _, stepID := range w.graph.sortedNodeIDs() {
+		node := w.graph.Nodes[stepID]
+
+		stepDef := StepDefinition{
+			ID:          stepID,
+			Type:        node.Type,
+			MaxParallel: node.MaxParallel,
+		}
+
+		if step, ok := w.steps[stepID]; ok {
+			stepDef.Name = step.GetName()
+
+			config := step.GetConfig()
+			stepDef.Config = &StepConfigDefinition{
+				MaxRetries:      &config.MaxRetries,
+				RetryDelayMs:    &config.RetryDelayMs,
+				RetryBackoff:    config.RetryBackoff,
+				TimeoutSeconds:  &config.TimeoutSeconds,
+				ContinueOnError: &config.ContinueOnError,
+			}
+
+			if provider, ok := step.(interface{ GetInputFrom() []string }); ok {
+				stepDef.InputFrom = provider.GetInputFrom()
+			}
+		}
+
+		def.Steps = append(def.Steps, stepDef)
+
+		for _, nextID := range node.Next {
+			def.Edges = append(def.Edges, EdgeDefinition{
+				From:        stepID,
+				To:          nextID,
+				Conditional: w.graph.EdgeCondition(stepID, nextID) != nil,
+			})
+		}
+	}
+
+	return def
+}
+
+// MarshalDefinition encodes the workflow's Definition as JSON. Handlers are not included;
+// builder.FromJSON rebuilds the workflow from the result given a handler registry.
+func (w *Workflow) MarshalDefinition() ([]byte, error) {
+	data, err := json.Marshal(w.Definition())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workflow definition: %w", err)
+	}
+	return data, nil
+}
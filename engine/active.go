@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// activeRun is a run executing in this engine
+type activeRun struct {
+	finished chan struct{}
+	cancel   context.CancelFunc
+
+	// The concurrency slot the run holds, released when it finishes
+	slot *runSlot
+
+	// Closed by Resume; nil while the run is not paused
+	resume chan struct{}
+
+	// Closed by SendSignal, keyed by the signal a step is waiting for
+	signals map[string]chan struct{}
+
+	// Added by AddTags while the run executes, merged into the run before each write
+	tags map[string]string
+
+	// Set when another engine took over the run's lease; the run is cancelled and writes nothing more
+	leaseLost bool
+}
+
+// executeSync executes the run inline, signalling WaitForCompletion callers when it ends
+func (e *Engine) executeSync(ctx context.Context, wf *gorkflow.Workflow, run *gorkflow.WorkflowRun, slot *runSlot, settings runSettings) error {
+	ctx, finish := e.trackRun(ctx, run.RunID, slot)
+	defer finish()
+	return e.executeWorkflow(ctx, wf, run, settings)
+}
+
+// executeAsync executes the run in the background. The run is tracked before the goroutine
+// starts, so a WaitForCompletion or Cancel call right after StartWorkflow finds it.
+func (e *Engine) executeAsync(ctx context.Context, wf *gorkflow.Workflow, run *gorkflow.WorkflowRun, slot *runSlot, settings runSettings) {
+	ctx, finish := e.trackRun(ctx, run.RunID, slot)
+	go func() {
+		defer finish()
+		e.executeWorkflow(ctx, wf, run, settings)
+	}()
+}
+
+// trackRun registers a run executing in this engine and returns the context it executes under,
+// which Cancel cancels. The returned func releases the run's slot and signals its completion.
+func (e *Engine) trackRun(ctx context.Context, runID string, slot *runSlot) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	active := &activeRun{finished: make(chan struct{}), cancel: cancel, slot: slot}
+
+	e.runningMu.Lock()
+	e.running[runID] = active
+	e.runningMu.Unlock()
+
+	return ctx, func() {
+		e.runningMu.Lock()
+		if e.running[runID] == active {
+			delete(e.running, runID)
+		}
+		e.runningMu.Unlock()
+
+		cancel()
+		slot.release()
+		e.closeStepWatchers(runID)
+		close(active.finished)
+	}
+}
+
+// completionSignal returns a channel closed when the run finishes executing in this engine.
+// It is nil, and so never ready, for runs this engine is not executing.
+func (e *Engine) completionSignal(runID string) <-chan struct{} {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	if active, ok := e.running[runID]; ok {
+		return active.finished
+	}
+	return nil
+}
+
+// cancelActiveRun cancels the context of a run executing in this engine, if any
+func (e *Engine) cancelActiveRun(runID string) {
+	e.runningMu.Lock()
+	active, ok := e.running[runID]
+	e.runningMu.Unlock()
+
+	if ok {
+		active.cancel()
+	}
+}
+
+// loseLease flags a run executing in this engine as taken over by another engine and cancels it
+func (e *Engine) loseLease(runID string) {
+	e.runningMu.Lock()
+	active, ok := e.running[runID]
+	if ok {
+		active.leaseLost = true
+	}
+	e.runningMu.Unlock()
+
+	if ok {
+		active.cancel()
+	}
+}
+
+// leaseLost reports whether another engine took over the lease of a run executing in this engine
+func (e *Engine) leaseLost(runID string) bool {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	active, ok := e.running[runID]
+	return ok && active.leaseLost
+}
+
+// pauseActiveRun flags a run executing in this engine as paused, so it stops before its next step
+func (e *Engine) pauseActiveRun(runID string) {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	if active, ok := e.running[runID]; ok && active.resume == nil {
+		active.resume = make(chan struct{})
+	}
+}
+
+// resumeActiveRun wakes a paused run executing in this engine, if any
+func (e *Engine) resumeActiveRun(runID string) {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	if active, ok := e.running[runID]; ok && active.resume != nil {
+		close(active.resume)
+		active.resume = nil
+	}
+}
+
+// resumeSignal returns a channel closed when the paused run is resumed in this engine.
+// It is nil when the run was not paused through this engine.
+func (e *Engine) resumeSignal(runID string) <-chan struct{} {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	if active, ok := e.running[runID]; ok {
+		return active.resume
+	}
+	return nil
+}
@@ -10,3 +10,12 @@ import (
 func calculateBackoff(baseDelayMs int, attempt int, strategy string) time.Duration {
 	return gorkflow.CalculateBackoff(baseDelayMs, attempt, strategy)
 }
+
+// retryBackoff is the wait before a retry: the delay requested by the failed attempt's error
+// when it implements gorkflow.RetryAfter, otherwise the step's configured backoff
+func retryBackoff(config gorkflow.ExecutionConfig, attempt int, lastErr error) time.Duration {
+	if delay, ok := gorkflow.RetryAfterDelay(lastErr); ok {
+		return delay
+	}
+	return calculateBackoff(config.RetryDelayMs, attempt, string(config.RetryBackoff))
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// ErrCircuitOpen is returned for a step execution refused because the step's circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// circuitBreaker tracks consecutive failed executions of a step across runs
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// circuitBreakerFor returns the breaker for a step, or nil when the step has none configured
+func (e *Engine) circuitBreakerFor(step gorkflow.StepExecutor) (*circuitBreaker, *gorkflow.CircuitBreakerConfig) {
+	provider, ok := step.(interface {
+		GetCircuitBreaker() *gorkflow.CircuitBreakerConfig
+	})
+	if !ok {
+		return nil, nil
+	}
+	config := provider.GetCircuitBreaker()
+	if config == nil || config.FailThreshold <= 0 {
+		return nil, nil
+	}
+
+	e.breakersMu.Lock()
+	defer e.breakersMu.Unlock()
+
+	breaker, ok := e.breakers[step.GetID()]
+	if !ok {
+		breaker = &circuitBreaker{}
+		e.breakers[step.GetID()] = breaker
+	}
+	return breaker, config
+}
+
+// allow reports whether an execution may proceed. Once the cooldown has passed a single
+// trial execution is let through; the circuit stays open for everyone else until it reports.
+func (b *circuitBreaker) allow(config *gorkflow.CircuitBreakerConfig, now time.Time) bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < config.FailThreshold {
+		return true
+	}
+	if now.Before(b.openUntil) {
+		return false
+	}
+	b.openUntil = now.Add(config.Cooldown)
+	return true
+}
+
+// record reports the outcome of an execution; success closes the circuit and a failure at
+// the threshold (re)opens it for the cooldown
+func (b *circuitBreaker) record(config *gorkflow.CircuitBreakerConfig, failed bool, now time.Time) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+
+	b.failures++
+	if b.failures >= config.FailThreshold {
+		b.openUntil = now.Add(config.Cooldown)
+	}
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_CircuitBreaker(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var calls int32
+	var healthy atomic.Bool
+
+	wf, err := builder.NewWorkflow("circuit", "Circuit").
+		ThenStep(gorkflow.NewStep("call_api", "Call API",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				atomic.AddInt32(&calls, 1)
+				if !healthy.Load() {
+					return input, errors.New("dependency unavailable")
+				}
+				return input, nil
+			},
+			gorkflow.WithRetries(0),
+			gorkflow.WithCircuitBreaker(2, 300*time.Millisecond),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run := func() (*gorkflow.WorkflowRun, error) {
+		return engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	}
+
+	// Two consecutive failures trip the breaker
+	for i := 0; i < 2; i++ {
+		_, err := run()
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// Later runs fail fast without calling the step
+	healthy.Store(true)
+	failed, err := run()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, gorkflow.RunStatusFailed, failed.Status)
+
+	step, err := engine.GetStepExecution(context.Background(), failed.RunID, "call_api")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.StepStatusFailed, step.Status)
+	require.NotNil(t, step.Error)
+	assert.Equal(t, gorkflow.ErrCodeCircuitOpen, step.Error.Code)
+
+	// After the cooldown a trial execution is let through and closes the circuit
+	time.Sleep(350 * time.Millisecond)
+	completed, err := run()
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, completed.Status)
+
+	_, err = run()
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestEngine_CircuitBreaker_FailedTrialReopens(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var calls int32
+	wf, err := builder.NewWorkflow("circuit_trial", "Circuit Trial").
+		ThenStep(gorkflow.NewStep("call_api", "Call API",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				atomic.AddInt32(&calls, 1)
+				return input, errors.New("dependency unavailable")
+			},
+			gorkflow.WithRetries(0),
+			gorkflow.WithCircuitBreaker(1, 200*time.Millisecond),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run := func() error {
+		_, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+		return err
+	}
+
+	require.Error(t, run())
+	assert.ErrorIs(t, run(), ErrCircuitOpen)
+
+	// The trial fails, so the circuit opens again for another cooldown
+	time.Sleep(250 * time.Millisecond)
+	err = run()
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+	assert.ErrorIs(t, run(), ErrCircuitOpen)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
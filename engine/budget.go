@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrRetryBudgetExhausted is wrapped by the step error when a retry is refused because the
+// run has used up its MaxTotalRetries. It fails the run even for ContinueOnError steps.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// retryBudget counts the retries taken by all steps of a run. A nil budget is unlimited.
+type retryBudget struct {
+	mu    sync.Mutex
+	limit int
+	used  int
+}
+
+// newRetryBudget returns a budget of limit retries, or nil when limit is not positive
+func newRetryBudget(limit int) *retryBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &retryBudget{limit: limit}
+}
+
+// take claims one retry and returns the retries left afterwards; ok is false once the budget is spent
+func (b *retryBudget) take() (remaining int, ok bool) {
+	if b == nil {
+		return -1, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.used >= b.limit {
+		return 0, false
+	}
+	b.used++
+	return b.limit - b.used, true
+}
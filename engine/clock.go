@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the engine's source of time: recorded timestamps and retry backoff waits go through it
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the default Clock, the wall clock in UTC
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now().UTC() }
+func (systemClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// ClockFunc is a Clock that reads the time from a function and waits in real time,
+// e.g. ClockFunc(func() time.Time { return fixed }) for deterministic timestamps
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time                       { return f() }
+func (ClockFunc) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (ClockFunc) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock sets the time source for the timestamps the engine records on runs and step
+// executions and for retry backoff waits (default time.Now in UTC)
+func WithClock(clock Clock) EngineOption {
+	return func(e *Engine) {
+		if clock != nil {
+			e.clock = clock
+		}
+	}
+}
+
+// now returns the current time from the engine clock
+func (e *Engine) now() time.Time {
+	return e.clock.Now()
+}
+
+// sleep waits d on the engine clock, returning ctx's error early if ctx ends first
+func (e *Engine) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	select {
+	case <-e.clock.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a Clock whose time only moves when Sleep or After advance it
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func newFakeClock(start time.Time) *fakeClock {
+	return &fakeClock{now: start}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep advances virtual time by d without waiting
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+}
+
+// After advances virtual time by d and fires immediately
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.Sleep(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+// Sleeps returns the waits requested so far
+func (c *fakeClock) Sleeps() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.sleeps)
+}
+
+func TestEngine_WithClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	wfStore := store.NewMemoryStore()
+	engine := NewEngine(wfStore,
+		WithLogger(zerolog.New(os.Stdout)),
+		WithClock(ClockFunc(func() time.Time { return fixed })),
+	)
+
+	wf, err := builder.NewWorkflow("clock_test", "Clock Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+
+	stored, err := engine.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+	assert.Equal(t, fixed, stored.CreatedAt)
+	require.NotNil(t, stored.StartedAt)
+	assert.Equal(t, fixed, *stored.StartedAt)
+	require.NotNil(t, stored.CompletedAt)
+	assert.Equal(t, fixed, *stored.CompletedAt)
+	assert.Equal(t, fixed, stored.UpdatedAt)
+
+	executions, err := engine.GetStepExecutions(context.Background(), run.RunID)
+	require.NoError(t, err)
+	require.Len(t, executions, 2)
+	for _, exec := range executions {
+		require.NotNil(t, exec.StartedAt)
+		assert.Equal(t, fixed, *exec.StartedAt)
+		require.NotNil(t, exec.CompletedAt)
+		assert.Equal(t, fixed, *exec.CompletedAt)
+	}
+}
+
+func TestEngine_WithClock_StepDuration(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	engine := NewEngine(store.NewMemoryStore(), WithLogger(zerolog.New(os.Stdout)), WithClock(clock))
+
+	wf, err := builder.NewWorkflow("clock_duration", "Clock Duration").
+		ThenStep(gorkflow.NewStep("slow", "Slow",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				clock.Sleep(1500 * time.Millisecond)
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech"})
+	require.NoError(t, err)
+
+	exec, err := engine.GetStepExecution(context.Background(), run.RunID, "slow")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), exec.DurationMs)
+	require.Len(t, exec.Attempts, 1)
+	assert.Equal(t, start, exec.Attempts[0].StartedAt)
+	assert.Equal(t, start.Add(1500*time.Millisecond), exec.Attempts[0].CompletedAt)
+	assert.Equal(t, int64(1500), exec.Attempts[0].DurationMs)
+}
+
+func TestEngine_DefaultClockIsUTC(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("clock_utc", "Clock UTC").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, time.UTC, run.CreatedAt.Location())
+	require.NotNil(t, run.CompletedAt)
+	assert.Equal(t, time.UTC, run.CompletedAt.Location())
+}
@@ -0,0 +1,13 @@
+package engine
+
+import "github.com/sicko7947/gorkflow"
+
+// WithCodec marshals workflow inputs, step inputs and outputs, and state with codec
+// instead of gorkflow.DefaultCodec (encoding/json)
+func WithCodec(codec gorkflow.Codec) EngineOption {
+	return func(e *Engine) {
+		if codec != nil {
+			e.codec = codec
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingCodec records the types it marshals and unmarshals
+type recordingCodec struct {
+	mu        sync.Mutex
+	marshaled []string
+	unmarshal []string
+}
+
+func (c *recordingCodec) Marshal(v any) ([]byte, error) {
+	c.mu.Lock()
+	c.marshaled = append(c.marshaled, fmt.Sprintf("%T", v))
+	c.mu.Unlock()
+	return json.Marshal(v)
+}
+
+func (c *recordingCodec) Unmarshal(data []byte, v any) error {
+	c.mu.Lock()
+	c.unmarshal = append(c.unmarshal, fmt.Sprintf("%T", v))
+	c.mu.Unlock()
+	return json.Unmarshal(data, v)
+}
+
+func TestEngine_WithCodec(t *testing.T) {
+	codec := &recordingCodec{}
+	engine := NewEngine(store.NewMemoryStore(), WithLogger(zerolog.New(os.Stdout)), WithCodec(codec))
+
+	wf, err := builder.NewWorkflow("codec_test", "Codec Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.NewStep("count", "Count Companies",
+			func(ctx *gorkflow.StepContext, input DiscoverOutput) (DiscoverOutput, error) {
+				// Accessors use the engine's codec too
+				if err := ctx.State.Set("seen", input.Count); err != nil {
+					return DiscoverOutput{}, err
+				}
+				return gorkflow.GetTypedOutput[DiscoverOutput](ctx.Outputs, "discover")
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	codec.mu.Lock()
+	defer codec.mu.Unlock()
+
+	// Workflow input, step outputs and the state value
+	assert.Contains(t, codec.marshaled, "engine.DiscoverInput")
+	assert.Contains(t, codec.marshaled, "engine.DiscoverOutput")
+	assert.Contains(t, codec.marshaled, "int")
+
+	// Step inputs and the output read through ctx.Outputs
+	assert.Contains(t, codec.unmarshal, "*engine.DiscoverInput")
+	assert.Contains(t, codec.unmarshal, "*engine.DiscoverOutput")
+}
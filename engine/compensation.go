@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// compensationStepID names the step execution that records a step's compensation
+func compensationStepID(stepID string) string {
+	return stepID + ".compensation"
+}
+
+// compensate runs the compensators of completed steps in reverse execution order.
+// Compensation is best effort: a failing compensator is recorded and logged, and
+// the remaining compensators still run.
+func (e *Engine) compensate(ctx context.Context, wf *gorkflow.Workflow, run *gorkflow.WorkflowRun, completed []string) {
+	for i := len(completed) - 1; i >= 0; i-- {
+		step, err := wf.GetStep(completed[i])
+		if err != nil {
+			continue
+		}
+
+		provider, ok := step.(interface {
+			GetCompensation() gorkflow.CompensationHandler
+		})
+		if !ok || provider.GetCompensation() == nil {
+			continue
+		}
+
+		e.runCompensation(ctx, wf, run, step, provider.GetCompensation())
+	}
+}
+
+// runCompensation invokes one step's compensator and records it as a step execution
+func (e *Engine) runCompensation(
+	ctx context.Context,
+	wf *gorkflow.Workflow,
+	run *gorkflow.WorkflowRun,
+	step gorkflow.StepExecutor,
+	handler gorkflow.CompensationHandler,
+) {
+	stepID := step.GetID()
+	stepLogger := e.stepLogger(run.RunID, step)
+
+	output, err := e.store.LoadStepOutput(ctx, run.RunID, stepID)
+	if err != nil {
+		stepLogger.Error().Err(err).Msg("Failed to load step output for compensation")
+		return
+	}
+
+	startedAt := e.now()
+	exec := &gorkflow.StepExecution{
+		RunID:     run.RunID,
+		StepID:    compensationStepID(stepID),
+		Status:    gorkflow.StepStatusRunning,
+		Input:     stepPayload(step, output),
+		Tags:      stepTags(step),
+		StartedAt: &startedAt,
+		CreatedAt: startedAt,
+		UpdatedAt: startedAt,
+	}
+
+	if err := e.store.CreateStepExecution(ctx, exec); err != nil {
+		gorkflow.LogPersistenceError(e.logger, run.RunID, "create_compensation_execution", err)
+	}
+	e.publishStepExecution(exec)
+
+	stepCtx := &gorkflow.StepContext{
+		Context:       ctx,
+		RunID:         run.RunID,
+		StepID:        stepID,
+		Logger:        stepLogger,
+		Outputs:       e.newOutputAccessor(wf, run.RunID),
+		State:         e.newStateAccessor(run.RunID),
+		CustomContext: stepCustomContext(step, wf.GetContext()),
+		Codec:         e.codec,
+	}
+
+	// Run compensator (with panic recovery)
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("compensation panicked: %v", r)
+			}
+		}()
+		err = handler(stepCtx, output)
+	}()
+
+	completedAt := e.now()
+	exec.CompletedAt = &completedAt
+	exec.UpdatedAt = completedAt
+	exec.DurationMs = completedAt.Sub(startedAt).Milliseconds()
+
+	if err != nil {
+		exec.Status = gorkflow.StepStatusFailed
+		exec.Error = gorkflow.NewStepError(gorkflow.ErrCodeExecutionFailed, err.Error(), 0).WithCause(err)
+		stepLogger.Error().Err(err).Msg("Step compensation failed")
+	} else {
+		exec.Status = gorkflow.StepStatusCompleted
+		stepLogger.Info().Msg("Step compensated")
+	}
+
+	if err := e.store.UpdateStepExecution(ctx, exec); err != nil {
+		gorkflow.LogPersistenceError(e.logger, run.RunID, "update_compensation_execution", err)
+	}
+	e.publishStepExecution(exec)
+}
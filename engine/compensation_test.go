@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Compensation(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var mu sync.Mutex
+	var compensated []string
+	var compensatedCounts []int
+
+	compensator := func(stepID string) gorkflow.StepOption {
+		return gorkflow.WithCompensation(func(ctx *gorkflow.StepContext, output DiscoverOutput) error {
+			mu.Lock()
+			defer mu.Unlock()
+			compensated = append(compensated, stepID)
+			compensatedCounts = append(compensatedCounts, output.Count)
+			return nil
+		})
+	}
+
+	step1 := gorkflow.NewStep("step1", "Step 1",
+		func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+			return DiscoverOutput{Companies: []string{"A"}, Count: 1}, nil
+		},
+		compensator("step1"),
+	)
+	step2 := gorkflow.NewStep("step2", "Step 2",
+		func(ctx *gorkflow.StepContext, input DiscoverOutput) (DiscoverOutput, error) {
+			return DiscoverOutput{Companies: append(input.Companies, "B"), Count: input.Count + 1}, nil
+		},
+		compensator("step2"),
+	)
+	step3 := gorkflow.NewStep("step3", "Step 3",
+		func(ctx *gorkflow.StepContext, input DiscoverOutput) (DiscoverOutput, error) {
+			return DiscoverOutput{}, errors.New("payment declined")
+		},
+		gorkflow.WithRetries(0),
+		compensator("step3"),
+	)
+
+	wf, err := builder.NewWorkflow("saga_test", "Saga Test").
+		Sequence(step1, step2, step3).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+
+	run := waitForCompletion(t, engine, runID, 10*time.Second)
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+
+	// Completed steps are compensated in reverse order with their stored outputs;
+	// the failed step is not compensated
+	mu.Lock()
+	assert.Equal(t, []string{"step2", "step1"}, compensated)
+	assert.Equal(t, []int{2, 1}, compensatedCounts)
+	mu.Unlock()
+
+	steps, err := engine.GetStepExecutions(context.Background(), runID)
+	require.NoError(t, err)
+	statuses := make(map[string]gorkflow.StepStatus)
+	for _, step := range steps {
+		statuses[step.StepID] = step.Status
+	}
+	assert.Equal(t, gorkflow.StepStatusCompleted, statuses["step1.compensation"])
+	assert.Equal(t, gorkflow.StepStatusCompleted, statuses["step2.compensation"])
+	assert.NotContains(t, statuses, "step3.compensation")
+}
+
+func TestEngine_Compensation_FailureIsRecorded(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var step1Compensated bool
+	step1 := gorkflow.NewStep("step1", "Step 1", discoverCompanies,
+		gorkflow.WithCompensation(func(ctx *gorkflow.StepContext, output DiscoverOutput) error {
+			step1Compensated = true
+			return nil
+		}),
+	)
+	step2 := gorkflow.NewStep("step2", "Step 2",
+		func(ctx *gorkflow.StepContext, input DiscoverOutput) (DiscoverOutput, error) {
+			return input, nil
+		},
+		gorkflow.WithCompensation(func(ctx *gorkflow.StepContext, output DiscoverOutput) error {
+			return errors.New("refund failed")
+		}),
+	)
+	step3 := gorkflow.NewStep("step3", "Step 3",
+		func(ctx *gorkflow.StepContext, input DiscoverOutput) (DiscoverOutput, error) {
+			return DiscoverOutput{}, errors.New("boom")
+		},
+		gorkflow.WithRetries(0),
+	)
+
+	wf, err := builder.NewWorkflow("saga_failure_test", "Saga Failure Test").
+		Sequence(step1, step2, step3).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.Error(t, err)
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+
+	// A failing compensator does not stop earlier steps from being compensated
+	assert.True(t, step1Compensated)
+
+	exec, err := engine.store.GetStepExecution(context.Background(), run.RunID, "step2.compensation")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.StepStatusFailed, exec.Status)
+	require.NotNil(t, exec.Error)
+	assert.Contains(t, exec.Error.Message, "refund failed")
+}
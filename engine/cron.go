@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sicko7947/gorkflow"
+)
+
+// ErrEngineShutdown is returned when runs are started or background work is registered after Shutdown
+var ErrEngineShutdown = errors.New("engine is shut down")
+
+// cronParser accepts standard 5-field specs, an optional leading seconds field and descriptors like @hourly
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// RegisterCron starts a new run of the workflow on every tick of the cron spec.
+// inputFactory is called once per tick to build that run's input and may be nil.
+// Ticks missed while the engine was down or busy are skipped, not backfilled.
+// The returned cancel func stops the job; Shutdown stops all jobs.
+func (e *Engine) RegisterCron(
+	spec string,
+	wf *gorkflow.Workflow,
+	inputFactory func() interface{},
+) (cancel func(), err error) {
+	schedule, err := parseCronSpec(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+	}
+
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	cancel = func() {
+		stopOnce.Do(func() { close(stop) })
+	}
+
+	if !e.startBackground(func() { e.runCron(spec, schedule, wf, inputFactory, stop) }) {
+		return nil, ErrEngineShutdown
+	}
+
+	return cancel, nil
+}
+
+// parseCronSpec parses a cron spec. "@every" intervals are used exactly, including sub-second ones,
+// rather than being rounded up to whole seconds by the cron parser.
+func parseCronSpec(spec string) (cron.Schedule, error) {
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("interval must be positive, got %s", d)
+		}
+		return everySchedule(d), nil
+	}
+
+	return cronParser.Parse(spec)
+}
+
+// everySchedule fires at a fixed interval after the previous tick
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// runCron starts a run on each tick until the job is cancelled or the engine shuts down
+func (e *Engine) runCron(
+	spec string,
+	schedule cron.Schedule,
+	wf *gorkflow.Workflow,
+	inputFactory func() interface{},
+	stop <-chan struct{},
+) {
+	trigger := func(opts *gorkflow.StartOptions) {
+		opts.TriggerType = "schedule"
+		opts.TriggerSource = "cron:" + spec
+	}
+
+	for {
+		// Always schedule from now so missed ticks are skipped
+		timer := time.NewTimer(time.Until(schedule.Next(time.Now())))
+
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-e.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		var input interface{}
+		if inputFactory != nil {
+			input = inputFactory()
+		}
+
+		if _, err := e.StartWorkflow(context.Background(), wf, input, trigger); err != nil {
+			e.logger.Error().
+				Err(err).
+				Str("workflow_id", wf.ID()).
+				Str("cron_spec", spec).
+				Msg("Failed to start cron workflow run")
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCronWorkflow(t *testing.T, starts *int32) *gorkflow.Workflow {
+	wf, err := builder.NewWorkflow("cron_test", "Cron Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				atomic.AddInt32(starts, 1)
+				return discoverCompanies(ctx, input)
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+	return wf
+}
+
+func TestEngine_RegisterCron(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	engine := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)))
+
+	var starts int32
+	wf := newCronWorkflow(t, &starts)
+
+	cancel, err := engine.RegisterCron("@every 200ms", wf, func() interface{} {
+		return DiscoverInput{Query: "cron", Limit: 5}
+	})
+	require.NoError(t, err)
+	defer cancel()
+
+	// Three intervals plus some slack for the last run to execute
+	time.Sleep(700 * time.Millisecond)
+	require.NoError(t, engine.Shutdown(context.Background()))
+
+	assert.InDelta(t, 3, atomic.LoadInt32(&starts), 1)
+
+	runs, err := engine.ListRuns(context.Background(), gorkflow.RunFilter{WorkflowID: "cron_test"})
+	require.NoError(t, err)
+	require.NotEmpty(t, runs)
+	assert.Equal(t, "schedule", runs[0].Trigger.Type)
+	assert.Equal(t, "cron:@every 200ms", runs[0].Trigger.Source)
+
+	// No further runs once the engine is shut down
+	stoppedAt := atomic.LoadInt32(&starts)
+	time.Sleep(400 * time.Millisecond)
+	assert.Equal(t, stoppedAt, atomic.LoadInt32(&starts))
+}
+
+func TestEngine_RegisterCron_Cancel(t *testing.T) {
+	engine := NewEngine(store.NewMemoryStore(), WithLogger(zerolog.New(os.Stdout)))
+
+	var starts int32
+	cancel, err := engine.RegisterCron("@every 50ms", newCronWorkflow(t, &starts), nil)
+	require.NoError(t, err)
+
+	cancel()
+	cancel() // idempotent
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Zero(t, atomic.LoadInt32(&starts))
+	require.NoError(t, engine.Shutdown(context.Background()))
+}
+
+func TestEngine_RegisterCron_InvalidSpec(t *testing.T) {
+	engine := NewEngine(store.NewMemoryStore(), WithLogger(zerolog.New(os.Stdout)))
+	var starts int32
+	wf := newCronWorkflow(t, &starts)
+
+	for _, spec := range []string{"not a spec", "@every nope", "@every -1s"} {
+		_, err := engine.RegisterCron(spec, wf, nil)
+		assert.Error(t, err, spec)
+	}
+
+	// Standard specs, with or without seconds, are accepted
+	for _, spec := range []string{"*/5 * * * *", "0 */5 * * * *", "@hourly"} {
+		cancel, err := engine.RegisterCron(spec, wf, nil)
+		require.NoError(t, err, spec)
+		cancel()
+	}
+}
+
+func TestEngine_RegisterCron_AfterShutdown(t *testing.T) {
+	engine := NewEngine(store.NewMemoryStore(), WithLogger(zerolog.New(os.Stdout)))
+	require.NoError(t, engine.Shutdown(context.Background()))
+
+	var starts int32
+	_, err := engine.RegisterCron("@every 1s", newCronWorkflow(t, &starts), nil)
+	assert.ErrorIs(t, err, ErrEngineShutdown)
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// activateSuccessors marks the steps reached through the outgoing edges of a step that ran.
+// Conditional edges are followed only when their condition returns true.
+func (e *Engine) activateSuccessors(
+	ctx context.Context,
+	run *gorkflow.WorkflowRun,
+	graph *gorkflow.ExecutionGraph,
+	step gorkflow.StepExecutor,
+	outputs gorkflow.StepOutputAccessor,
+	state gorkflow.StateAccessor,
+	customContext any,
+	active map[string]bool,
+) error {
+	stepID := step.GetID()
+
+	nextSteps, err := graph.GetNextSteps(stepID)
+	if err != nil {
+		return err
+	}
+
+	for _, nextID := range nextSteps {
+		condition := graph.EdgeCondition(stepID, nextID)
+		if condition == nil {
+			active[nextID] = true
+			continue
+		}
+
+		// Conditions see the run as of the step that just completed
+		stepCtx := &gorkflow.StepContext{
+			Context:       ctx,
+			RunID:         run.RunID,
+			StepID:        stepID,
+			Logger:        e.stepLogger(run.RunID, step),
+			Outputs:       outputs,
+			State:         state,
+			CustomContext: stepCustomContext(step, customContext),
+			Codec:         e.codec,
+		}
+
+		follow, err := condition(stepCtx)
+		if err != nil {
+			return fmt.Errorf("condition on edge %s -> %s failed: %w", stepID, nextID, err)
+		}
+		if follow {
+			active[nextID] = true
+		}
+	}
+
+	return nil
+}
+
+// skipStep records a step that no active edge reached
+func (e *Engine) skipStep(ctx context.Context, run *gorkflow.WorkflowRun, stepID string) {
+	now := e.now()
+	stepExec := &gorkflow.StepExecution{
+		RunID:       run.RunID,
+		StepID:      stepID,
+		Status:      gorkflow.StepStatusSkipped,
+		CompletedAt: &now,
+		UpdatedAt:   now,
+	}
+
+	if err := e.store.CreateStepExecution(ctx, stepExec); err != nil {
+		gorkflow.LogPersistenceError(e.logger, run.RunID, "create_skipped_step_execution", err)
+	}
+	e.publishStepExecution(stepExec)
+
+	gorkflow.LogStepSkipped(e.logger, run.RunID, stepID, "no_active_edge")
+}
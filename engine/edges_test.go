@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type routeOutput struct {
+	Path string `json:"path"`
+}
+
+type routeJoinInput struct {
+	Small *routeOutput `json:"small"`
+	Large *routeOutput `json:"large"`
+}
+
+func routeIs(path string) gorkflow.Condition {
+	return func(ctx *gorkflow.StepContext) (bool, error) {
+		var route string
+		if err := ctx.State.Get("route", &route); err != nil {
+			return false, err
+		}
+		return route == path, nil
+	}
+}
+
+func newRoutingWorkflow(t *testing.T) *gorkflow.Workflow {
+	newPath := func(id string) gorkflow.StepExecutor {
+		return gorkflow.NewStep(id, id,
+			func(ctx *gorkflow.StepContext, input DiscoverOutput) (routeOutput, error) {
+				return routeOutput{Path: id}, nil
+			},
+		)
+	}
+
+	wf, err := builder.NewWorkflow("routing", "Routing").
+		ThenStep(gorkflow.NewStep("classify", "Classify",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				route := "small"
+				if input.Limit > 5 {
+					route = "large"
+				}
+				if err := ctx.State.Set("route", route); err != nil {
+					return DiscoverOutput{}, err
+				}
+				return DiscoverOutput{Count: input.Limit}, nil
+			},
+		)).
+		ThenStepWhen(newPath("small"), routeIs("small")).
+		ThenStepWhen(newPath("large"), routeIs("large")).
+		ThenStep(gorkflow.NewStep("report", "Report",
+			func(ctx *gorkflow.StepContext, input routeJoinInput) (FilterOutput, error) {
+				var taken []string
+				for _, branch := range []*routeOutput{input.Small, input.Large} {
+					if branch != nil {
+						taken = append(taken, branch.Path)
+					}
+				}
+				return FilterOutput{Filtered: taken}, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+	return wf
+}
+
+func TestEngine_ConditionalEdges_RouteByState(t *testing.T) {
+	engine, _ := createTestEngine(t)
+	wf := newRoutingWorkflow(t)
+
+	tests := []struct {
+		limit   int
+		taken   string
+		skipped string
+	}{
+		{limit: 3, taken: "small", skipped: "large"},
+		{limit: 10, taken: "large", skipped: "small"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.taken, func(t *testing.T) {
+			run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: tt.limit})
+			require.NoError(t, err)
+			require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+			assert.Equal(t, 1.0, run.Progress)
+
+			// Only the taken branch reaches the join
+			var output FilterOutput
+			require.NoError(t, json.Unmarshal(run.Output, &output))
+			assert.Equal(t, []string{tt.taken}, output.Filtered)
+
+			taken, err := engine.store.GetStepExecution(context.Background(), run.RunID, tt.taken)
+			require.NoError(t, err)
+			assert.Equal(t, gorkflow.StepStatusCompleted, taken.Status)
+
+			skipped, err := engine.store.GetStepExecution(context.Background(), run.RunID, tt.skipped)
+			require.NoError(t, err)
+			assert.Equal(t, gorkflow.StepStatusSkipped, skipped.Status)
+
+			_, err = engine.store.LoadStepOutput(context.Background(), run.RunID, tt.skipped)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEngine_ConditionalEdges_NoBranchTaken(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	never := func(ctx *gorkflow.StepContext) (bool, error) { return false, nil }
+
+	wf, err := builder.NewWorkflow("no_branch", "No Branch").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStepWhen(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies), never).
+		ThenStep(gorkflow.NewStep("filter", "Filter Companies", filterCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 5})
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	// Steps after an untaken branch are skipped too
+	steps, err := engine.GetStepExecutions(context.Background(), run.RunID)
+	require.NoError(t, err)
+	statuses := make(map[string]gorkflow.StepStatus, len(steps))
+	for _, step := range steps {
+		statuses[step.StepID] = step.Status
+	}
+	assert.Equal(t, map[string]gorkflow.StepStatus{
+		"discover": gorkflow.StepStatusCompleted,
+		"enrich":   gorkflow.StepStatusSkipped,
+		"filter":   gorkflow.StepStatusSkipped,
+	}, statuses)
+	assert.Empty(t, run.Output)
+}
+
+func TestEngine_ConditionalEdges_ConditionError(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	failing := func(ctx *gorkflow.StepContext) (bool, error) {
+		return false, errors.New("route lookup failed")
+	}
+
+	wf, err := builder.NewWorkflow("edge_error", "Edge Error").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStepWhen(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies), failing).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 5})
+	require.Error(t, err)
+	require.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	require.NotNil(t, run.Error)
+	assert.Contains(t, run.Error.Message, "condition on edge discover -> enrich failed: route lookup failed")
+}
@@ -3,32 +3,107 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/sicko7947/gorkflow"
+	"golang.org/x/time/rate"
 )
 
+// ErrInputTooLarge is wrapped by the error of a workflow start whose input exceeds EngineConfig.MaxInputBytes
+var ErrInputTooLarge = errors.New("workflow input too large")
+
 // Engine orchestrates workflow execution
 type Engine struct {
-	store  gorkflow.WorkflowStore
-	logger zerolog.Logger
-	config EngineConfig
+	store      gorkflow.WorkflowStore
+	logger     zerolog.Logger
+	config     EngineConfig
+	middleware []StepMiddleware
+
+	// How often step executions are written to the store
+	stepWriteMode StepWriteMode
+
+	// Log step inputs and outputs at debug level
+	logPayloads bool
+
+	// Shortest gap between progress-only run writes (0 writes after every step)
+	progressInterval time.Duration
+
+	// Leave outputs off step execution records, keeping only the canonical step output
+	omitInlineOutput bool
+
+	// Marshals inputs, outputs and state
+	codec gorkflow.Codec
+
+	// Time source for recorded timestamps and backoff waits
+	clock Clock
+
+	// Owner name for run leases, and how long a lease lasts without renewal (0 disables leasing)
+	engineID string
+	leaseTTL time.Duration
+
+	// How RecoverOrphans finds and treats runs left RUNNING by a stopped engine
+	orphanStaleness time.Duration
+	orphanAction    OrphanAction
+
+	// Workflows the scheduler can start, keyed by workflow ID
+	workflows     map[string]*gorkflow.Workflow
+	workflowsMu   sync.RWMutex
+	schedulerOnce sync.Once
+
+	// Runs executing in this engine, keyed by run ID
+	running   map[string]*activeRun
+	runningMu sync.Mutex
+
+	// One slot per executing run, bounding them to MaxConcurrentWorkflows; nil when unlimited.
+	// Runs take a slot before they are created or claimed and release it when they finish.
+	slots chan struct{}
+
+	// Circuit breaker state shared by all runs, keyed by step ID
+	breakers   map[string]*circuitBreaker
+	breakersMu sync.Mutex
+
+	// Rate limiters shared by all runs, keyed by step ID
+	limiters   map[string]*rate.Limiter
+	limitersMu sync.Mutex
+
+	// Step execution watchers, keyed by run ID
+	watchers   map[string][]*stepWatcher
+	watchersMu sync.Mutex
+
+	// Background loops (scheduler, cron jobs) stopped by Shutdown
+	done       chan struct{}
+	background sync.WaitGroup
+	stopped    bool
+	stopMu     sync.Mutex
 }
 
 // EngineConfig holds engine configuration
 type EngineConfig struct {
 	MaxConcurrentWorkflows int
 	DefaultTimeout         time.Duration
+	SchedulePollInterval   time.Duration // How often the store is checked for due scheduled runs
+	CompletionPollInterval time.Duration // How often WaitForCompletion re-reads runs executing elsewhere
+	MaxTotalRetries        int           // Retries allowed across all steps of a run (0 = no limit)
+	OutputCacheTTL         time.Duration // How long a run keeps step outputs it has read cached (0 = whole run)
+	StoreTimeout           time.Duration // Bound on each result or status write, which ignores step and run deadlines (default 10s)
+	MaxInputBytes          int           // Largest serialized workflow input accepted when starting a run (0 = no limit)
+	MaxLoggedPayloadBytes  int           // Longest step payload logged with WithPayloadLogging before it is truncated (default 1024)
 }
 
 // DefaultEngineConfig provides sensible defaults
 var DefaultEngineConfig = EngineConfig{
 	MaxConcurrentWorkflows: 10,
 	DefaultTimeout:         5 * time.Minute,
+	SchedulePollInterval:   time.Second,
+	CompletionPollInterval: 500 * time.Millisecond,
+	StoreTimeout:           DefaultStoreTimeout,
 }
 
 // NewEngine creates a new workflow engine
@@ -61,9 +136,18 @@ func NewEngine(store gorkflow.WorkflowStore, opts ...EngineOption) *Engine {
 		Level(zerolog.InfoLevel)
 
 	eng := &Engine{
-		store:  store,
-		logger: defaultLogger,
-		config: DefaultEngineConfig,
+		store:     store,
+		logger:    defaultLogger,
+		config:    DefaultEngineConfig,
+		codec:     gorkflow.DefaultCodec,
+		clock:     systemClock{},
+		engineID:  uuid.New().String(),
+		workflows: make(map[string]*gorkflow.Workflow),
+		running:   make(map[string]*activeRun),
+		breakers:  make(map[string]*circuitBreaker),
+		limiters:  make(map[string]*rate.Limiter),
+		watchers:  make(map[string][]*stepWatcher),
+		done:      make(chan struct{}),
 	}
 
 	// Apply options
@@ -71,52 +155,246 @@ func NewEngine(store gorkflow.WorkflowStore, opts ...EngineOption) *Engine {
 		opt(eng)
 	}
 
+	if limit := eng.config.MaxConcurrentWorkflows; limit > 0 {
+		eng.slots = make(chan struct{}, limit)
+	}
+
 	return eng
 }
 
-// StartWorkflow initiates a workflow execution
+// Shutdown stops the scheduler and all cron jobs and waits for them to exit. New runs are
+// rejected with ErrEngineShutdown; runs already executing are not interrupted.
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.stopMu.Lock()
+	if !e.stopped {
+		e.stopped = true
+		close(e.done)
+	}
+	e.stopMu.Unlock()
+
+	exited := make(chan struct{})
+	go func() {
+		e.background.Wait()
+		close(exited)
+	}()
+
+	select {
+	case <-exited:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop background jobs: %w", ctx.Err())
+	}
+}
+
+// accepting reports whether the engine still starts new runs, i.e. Shutdown was not called
+func (e *Engine) accepting() bool {
+	e.stopMu.Lock()
+	defer e.stopMu.Unlock()
+	return !e.stopped
+}
+
+// startBackground runs fn in a goroutine tracked by Shutdown; it reports false once the engine is shut down
+func (e *Engine) startBackground(fn func()) bool {
+	e.stopMu.Lock()
+	defer e.stopMu.Unlock()
+
+	if e.stopped {
+		return false
+	}
+
+	e.background.Add(1)
+	go func() {
+		defer e.background.Done()
+		fn()
+	}()
+	return true
+}
+
+// StartWorkflow initiates a workflow execution. An asynchronous run keeps ctx's values but not
+// its cancellation or deadline, since it outlives the call; bound it with WithWorkflowTimeout.
+// With WithSynchronousExecution, a ctx deadline that passes fails the run with TIMEOUT.
+// While MaxConcurrentWorkflows runs are executing, no run is created and a CONCURRENCY_LIMIT
+// error is returned.
 func (e *Engine) StartWorkflow(
 	ctx context.Context,
 	wf *gorkflow.Workflow,
 	input interface{},
 	opts ...gorkflow.StartOption,
 ) (string, error) {
-	// Apply options
+	if !e.accepting() {
+		return "", ErrEngineShutdown
+	}
+	options := applyStartOptions(opts)
+
+	slot, err := e.takeSlot()
+	if err != nil {
+		return "", err
+	}
+
+	run, err := e.createRun(ctx, wf, input, options)
+	if err != nil {
+		slot.release()
+		return "", err
+	}
+
+	// Launch execution in background, keeping the caller's context values but not its cancellation
+	if !options.Synchronous {
+		if _, hasDeadline := ctx.Deadline(); hasDeadline && options.Timeout <= 0 {
+			e.logger.Warn().
+				Str("run_id", run.RunID).
+				Msg("Context deadline does not apply to asynchronous runs, use WithWorkflowTimeout to bound the run")
+		}
+		e.executeAsync(context.WithoutCancel(ctx), wf, run, slot, e.runSettings(options))
+	} else {
+		return run.RunID, e.executeSync(ctx, wf, run, slot, e.runSettings(options))
+	}
+
+	return run.RunID, nil
+}
+
+// RunWorkflowSync executes a workflow inline and returns the finished run, including its Output
+// The run is returned alongside the error when execution fails. A ctx deadline that passes
+// fails the run with TIMEOUT; cancelling ctx cancels it. Like StartWorkflow, it fails with
+// CONCURRENCY_LIMIT while MaxConcurrentWorkflows runs are executing.
+func (e *Engine) RunWorkflowSync(
+	ctx context.Context,
+	wf *gorkflow.Workflow,
+	input interface{},
+	opts ...gorkflow.StartOption,
+) (*gorkflow.WorkflowRun, error) {
+	if !e.accepting() {
+		return nil, ErrEngineShutdown
+	}
+	options := applyStartOptions(opts)
+	options.Synchronous = true
+
+	slot, err := e.takeSlot()
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := e.createRun(ctx, wf, input, options)
+	if err != nil {
+		slot.release()
+		return nil, err
+	}
+
+	return run, e.executeSync(ctx, wf, run, slot, e.runSettings(options))
+}
+
+// applyStartOptions collects start options into StartOptions
+func applyStartOptions(opts []gorkflow.StartOption) *gorkflow.StartOptions {
 	options := &gorkflow.StartOptions{}
 	for _, opt := range opts {
 		opt(options)
 	}
+	return options
+}
 
+// runSettings holds the limits a single run executes under
+type runSettings struct {
+	timeout         time.Duration
+	maxTotalRetries int
+}
+
+// runSettings returns the limits for a run; per-run options override the engine defaults
+func (e *Engine) runSettings(options *gorkflow.StartOptions) runSettings {
+	settings := runSettings{
+		timeout:         e.config.DefaultTimeout,
+		maxTotalRetries: e.config.MaxTotalRetries,
+	}
+	if options.Timeout > 0 {
+		settings.timeout = options.Timeout
+	}
+	if options.MaxTotalRetries > 0 {
+		settings.maxTotalRetries = options.MaxTotalRetries
+	}
+	return settings
+}
+
+// createRun persists a new pending run for the workflow
+func (e *Engine) createRun(
+	ctx context.Context,
+	wf *gorkflow.Workflow,
+	input interface{},
+	options *gorkflow.StartOptions,
+) (*gorkflow.WorkflowRun, error) {
+	run, err := e.newRun(wf, input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.insertRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// insertRun persists a run built by newRun
+func (e *Engine) insertRun(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	if err := e.store.CreateRun(ctx, run); err != nil {
+		return fmt.Errorf("failed to create workflow run: %w", err)
+	}
+
+	gorkflow.LogWorkflowCreated(e.logger, run.RunID, run.WorkflowID, run.ResourceID)
+
+	return nil
+}
+
+// newRun builds a pending run for the workflow without persisting it
+func (e *Engine) newRun(
+	wf *gorkflow.Workflow,
+	input interface{},
+	options *gorkflow.StartOptions,
+) (*gorkflow.WorkflowRun, error) {
 	// Generate run ID
 	runID := uuid.New().String()
 
-	// Serialize input
-	inputBytes, err := json.Marshal(input)
-	if err != nil {
-		return "", fmt.Errorf("failed to serialize workflow input: %w", err)
+	// Serialize input; raw entry steps take bytes as they are
+	var err error
+	inputBytes, isRaw := input.([]byte)
+	if !isRaw || !entryIsRaw(wf) {
+		if inputBytes, err = e.codec.Marshal(input); err != nil {
+			return nil, fmt.Errorf("failed to serialize workflow input: %w", err)
+		}
+	}
+
+	// Reject oversized or malformed input before a run is created
+	if limit := e.config.MaxInputBytes; limit > 0 && len(inputBytes) > limit {
+		err := fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrInputTooLarge, len(inputBytes), limit)
+		return nil, gorkflow.NewWorkflowError(gorkflow.ErrCodeValidation, err.Error()).WithCause(err)
+	}
+	if err := validateWorkflowInput(wf, inputBytes); err != nil {
+		return nil, err
 	}
 
 	// Serialize context if present
 	var contextBytes json.RawMessage
 	if wf.GetContext() != nil {
-		contextBytes, err = json.Marshal(wf.GetContext())
+		contextBytes, err = e.codec.Marshal(wf.GetContext())
 		if err != nil {
-			return "", fmt.Errorf("failed to serialize workflow context: %w", err)
+			return nil, fmt.Errorf("failed to serialize workflow context: %w", err)
 		}
 	}
 
+	// Record the graph this run executes
+	definition, err := wf.MarshalDefinition()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create workflow run
-	now := time.Now()
+	now := e.now()
 	run := &gorkflow.WorkflowRun{
 		RunID:           runID,
 		WorkflowID:      wf.ID(),
 		WorkflowVersion: wf.Version(),
-		Status:          gorkflow.RunStatusPending,
 		Progress:        0.0,
 		CreatedAt:       now,
 		UpdatedAt:       now,
 		Input:           inputBytes,
 		Context:         contextBytes,
+		Definition:      definition,
 		ResourceID:      options.ResourceID,
 		Trigger: &gorkflow.TriggerInfo{
 			Type:      options.TriggerType,
@@ -125,54 +403,82 @@ func (e *Engine) StartWorkflow(
 		},
 		Tags: options.Tags,
 	}
+	run.SetStatus(gorkflow.RunStatusPending, now)
 
 	// Set TTL if specified
 	if options.TTL > 0 {
-		run.TTL = time.Now().Add(options.TTL).Unix()
+		run.TTL = e.now().Add(options.TTL).Unix()
 	}
 
-	// Persist run
-	if err := e.store.CreateRun(ctx, run); err != nil {
-		return "", fmt.Errorf("failed to create workflow run: %w", err)
-	}
+	return run, nil
+}
 
-	gorkflow.LogWorkflowCreated(e.logger, runID, wf.ID(), options.ResourceID)
+// validateWorkflowInput checks the workflow input against the entry step's schema and validation rules
+func validateWorkflowInput(wf *gorkflow.Workflow, input []byte) error {
+	entryStep, err := wf.GetStep(wf.Graph().EntryPoint)
+	if err != nil {
+		return nil
+	}
 
-	// Launch execution in background
-	if !options.Synchronous {
-		go e.executeWorkflow(context.Background(), wf, run)
-	} else {
-		return runID, e.executeWorkflow(ctx, wf, run)
+	if err := entryStep.ValidateInput(input); err != nil {
+		return gorkflow.NewWorkflowErrorWithStep(gorkflow.ErrCodeValidation, err.Error(), entryStep.GetID()).WithCause(err)
 	}
+	return nil
+}
 
-	return runID, nil
+// entryIsRaw reports whether the workflow's entry step takes raw bytes
+func entryIsRaw(wf *gorkflow.Workflow) bool {
+	entryStep, err := wf.GetStep(wf.Graph().EntryPoint)
+	return err == nil && isRawStep(entryStep)
 }
 
 // executeWorkflow runs the workflow (called asynchronously)
-func (e *Engine) executeWorkflow(ctx context.Context, wf *gorkflow.Workflow, run *gorkflow.WorkflowRun) error {
+// A positive timeout bounds the whole execution; steps see it through their context
+func (e *Engine) executeWorkflow(ctx context.Context, wf *gorkflow.Workflow, run *gorkflow.WorkflowRun, settings runSettings) error {
 	workflowLogger := gorkflow.WorkflowLogger(e.logger, run.RunID, run.WorkflowID, run.ResourceID)
 
+	// Only the engine holding the run's lease executes it
+	release, err := e.holdLease(ctx, run.RunID)
+	if err != nil {
+		workflowLogger.Warn().Err(err).Msg("Not executing workflow run")
+		return err
+	}
+	defer release()
+
 	gorkflow.LogWorkflowStarted(e.logger, run.RunID, run.WorkflowID, run.ResourceID)
 
 	// Update status to running
-	startTime := time.Now()
-	run.Status = gorkflow.RunStatusRunning
+	startTime := e.now()
+	run.SetStatus(gorkflow.RunStatusRunning, startTime)
 	run.StartedAt = &startTime
 	run.UpdatedAt = startTime
 
-	if err := e.store.UpdateRun(ctx, run); err != nil {
+	persistCtx, cancel := e.persistCtx(ctx)
+	err = e.store.UpdateRun(persistCtx, run)
+	cancel()
+	if err != nil {
 		gorkflow.LogPersistenceError(e.logger, run.RunID, "update_run_status", err)
 		return err
 	}
 
+	// Steps run under the workflow deadline; results and statuses are written with
+	// persistCtx so the final status can still be recorded after the deadline passes
+	timeout := settings.timeout
+	runCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	// Build execution context - create accessors for state and outputs
-	outputs := gorkflow.NewStepOutputAccessor(run.RunID, e.store)
-	state := gorkflow.NewStateAccessor(run.RunID, e.store)
+	outputs := e.newOutputAccessor(wf, run.RunID)
+	state := e.newStateAccessor(run.RunID)
 
-	// Get execution order from graph
+	// Get execution order, cached on the workflow
 	graph := wf.Graph()
 	traverser := NewGraphTraverser(graph)
-	executionOrder, err := traverser.GetExecutionOrder()
+	executionOrder, err := wf.ExecutionOrder()
 	if err != nil {
 		workflowLogger.Error().Err(err).Msg("Failed to get execution order")
 		return e.failWorkflow(ctx, run, err)
@@ -185,99 +491,236 @@ func (e *Engine) executeWorkflow(ctx context.Context, wf *gorkflow.Workflow, run
 	totalSteps := len(executionOrder)
 	completedSteps := 0
 
-	// Execute steps in order
+	// Progress is the completed share of the steps' total weight
+	var totalWeight, completedWeight float64
 	for _, stepID := range executionOrder {
-		// Check for cancellation
+		totalWeight += stepWeight(wf, stepID)
+	}
+
+	// Steps that completed successfully, compensated in reverse if the workflow fails
+	var succeeded []string
+
+	// Retries shared by every step of the run
+	budget := newRetryBudget(settings.maxTotalRetries)
+
+	// Steps reached through an active edge; conditional edges may leave some unreached
+	active := map[string]bool{graph.EntryPoint: true}
+
+	// Pauses made through another engine are polled from the store at most once per
+	// CompletionPollInterval; the progress writes in between never overwrite them
+	pollInterval := e.config.CompletionPollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultEngineConfig.CompletionPollInterval
+	}
+	pausePolls := &progressThrottle{interval: pollInterval, last: startTime}
+	paused := e.pauseRequested(runCtx, run, pausePolls)
+
+	// The start already wrote the run, so the first progress write waits a full interval
+	progressWrites := &progressThrottle{interval: e.progressInterval, last: startTime}
+
+	// Execute steps in order; the steps of a parallel block run concurrently
+	for _, group := range traverser.GetStepGroups(executionOrder) {
+		// A paused run waits here, between steps, until it is resumed. Its concurrency slot is
+		// freed meanwhile; a run that cannot get one back before runCtx ends is cancelled or
+		// timed out below
+		if paused {
+			e.parkRun(run.RunID)
+			e.waitForResume(runCtx, run)
+			e.unparkRun(runCtx, run.RunID)
+			if runCtx.Err() == nil {
+				e.warmOutputCache(outputs, run.RunID, succeeded)
+			}
+		}
+
+		// Check for cancellation or workflow timeout
 		select {
-		case <-ctx.Done():
+		case <-runCtx.Done():
+			if e.leaseLost(run.RunID) {
+				return e.abandonRun(run)
+			}
+			if ctx.Err() == nil {
+				e.compensate(ctx, wf, run, succeeded)
+				return e.timeoutWorkflow(ctx, run, timeout)
+			}
+			if ctx.Err() == context.DeadlineExceeded {
+				return e.callerDeadlineWorkflow(ctx, wf, run, succeeded)
+			}
 			gorkflow.LogWorkflowCancelled(e.logger, run.RunID)
-			return e.cancelWorkflow(ctx, run)
+			return e.cancelWorkflow(context.WithoutCancel(ctx), run)
 		default:
 		}
 
-		// Get step
-		step, err := wf.GetStep(stepID)
-		if err != nil {
-			workflowLogger.Error().Err(err).Str("step_id", stepID).Msg("Step not found")
-			return e.failWorkflow(ctx, run, err)
+		// Steps no active edge reached are skipped, as are their successors
+		runnable := make([]string, 0, len(group))
+		for _, stepID := range group {
+			if !active[stepID] {
+				e.skipStep(ctx, run, stepID)
+				completedSteps++
+				completedWeight += stepWeight(wf, stepID)
+				continue
+			}
+			runnable = append(runnable, stepID)
 		}
 
-		gorkflow.LogStepStarted(e.logger, run.RunID, stepID, step.GetName(), completedSteps+1, totalSteps)
-
-		// Prepare input for this step
-		var stepInput []byte
-		if completedSteps == 0 {
-			// First step gets workflow input
-			stepInput = run.Input
-		} else {
-			// Subsequent steps: get output from previous step
-			// This assumes a linear chain for now. For complex graphs, we need to resolve dependencies.
-			prevStepID := executionOrder[completedSteps-1]
-			var err error
-			stepInput, err = e.store.LoadStepOutput(ctx, run.RunID, prevStepID)
+		steps := make([]gorkflow.StepExecutor, len(runnable))
+		inputs := make([][]byte, len(runnable))
+		for i, stepID := range runnable {
+			// Get step
+			step, err := wf.GetStep(stepID)
 			if err != nil {
-				// Check if previous step had ContinueOnError set
-				prevStep, stepErr := wf.GetStep(prevStepID)
-				if stepErr == nil && prevStep.GetConfig().ContinueOnError {
-					workflowLogger.Warn().
-						Str("prev_step_id", prevStepID).
-						Msg("Previous step output not found, but ContinueOnError is true. Passing empty input.")
-					// Pass JSON null so unmarshaling works (results in zero value)
-					stepInput = []byte("null")
-				} else {
-					workflowLogger.Error().
-						Err(err).
-						Str("prev_step_id", prevStepID).
-						Msg("Failed to load output from previous step")
-					return e.failWorkflow(ctx, run, err)
-				}
+				workflowLogger.Error().Err(err).Str("step_id", stepID).Msg("Step not found")
+				e.compensate(ctx, wf, run, succeeded)
+				return e.failUnknownStep(ctx, run, stepID, err)
+			}
+
+			gorkflow.LogStepStarted(e.logger, run.RunID, stepID, step.GetName(), completedSteps+i+1, totalSteps)
+
+			// Prepare input for this step
+			stepInput, err := e.resolveStepInput(ctx, wf, run, step)
+			if err != nil {
+				workflowLogger.Error().
+					Err(err).
+					Str("step_id", stepID).
+					Msg("Failed to resolve step input")
+				e.compensate(ctx, wf, run, succeeded)
+				return e.failWorkflowAtStep(ctx, run, stepID, nil, err)
+			}
+
+			steps[i] = step
+			inputs[i] = stepInput
+		}
+
+		// Record the steps about to run for progress UIs
+		if len(runnable) > 0 {
+			run.CurrentStep = strings.Join(runnable, ",")
+			if progressWrites.allow(e.now()) {
+				run.UpdatedAt = e.now()
+				e.persistProgress(ctx, run, "update_run_current_step")
 			}
 		}
 
-		// Execute step
-		_, err = e.executeStep(ctx, run, step, stepInput, outputs, state, wf.GetContext())
-		if err != nil {
+		// Execute steps
+		results, stepErrs := e.executeSteps(runCtx, run, steps, inputs, outputs, state, wf.GetContext(), traverser.GetMaxParallel(group[0]), budget)
+		for _, result := range results {
+			if result != nil {
+				run.TotalAttempts += result.AttemptsMade
+			}
+		}
+
+		// A cancelled run stops here; steps that saw the cancellation are not failures
+		if e.leaseLost(run.RunID) {
+			return e.abandonRun(run)
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return e.callerDeadlineWorkflow(ctx, wf, run, succeeded)
+		}
+		if ctx.Err() != nil {
+			return e.cancelWorkflow(context.WithoutCancel(ctx), run)
+		}
+
+		// A workflow timeout fails the run regardless of ContinueOnError
+		timedOut := runCtx.Err() == context.DeadlineExceeded
+
+		var stepErr error
+		failedAt := -1
+		for i, step := range steps {
+			err := stepErrs[i]
+			if err == nil {
+				forgetCachedOutput(outputs, step.GetID())
+				succeeded = append(succeeded, step.GetID())
+				completedSteps++
+				completedWeight += stepWeight(wf, step.GetID())
+				continue
+			}
+
 			// Check if we should continue on error
-			if step.GetConfig().ContinueOnError {
+			if step.GetConfig().ContinueOnError && !timedOut && !errors.Is(err, ErrRetryBudgetExhausted) {
 				workflowLogger.Warn().
 					Err(err).
-					Str("step_id", stepID).
+					Str("step_id", step.GetID()).
 					Msg("Step failed but continuing due to ContinueOnError")
-			} else {
+				completedSteps++
+				completedWeight += stepWeight(wf, step.GetID())
+				continue
+			}
+
+			if stepErr == nil {
+				stepErr = err
+				failedAt = i
 				workflowLogger.Error().
 					Err(err).
-					Str("step_id", stepID).
+					Str("step_id", step.GetID()).
 					Msg("Step failed, stopping workflow")
-				return e.failWorkflow(ctx, run, err)
 			}
 		}
 
-		completedSteps++
+		if stepErr != nil {
+			e.compensate(ctx, wf, run, succeeded)
+			if timedOut {
+				return e.timeoutWorkflow(ctx, run, timeout)
+			}
+			return e.failWorkflowAtStep(ctx, run, steps[failedAt].GetID(), results[failedAt], stepErr)
+		}
+
+		// Follow the outgoing edges of the steps that ran
+		for _, step := range steps {
+			if err := e.activateSuccessors(runCtx, run, graph, step, outputs, state, wf.GetContext(), active); err != nil {
+				workflowLogger.Error().
+					Err(err).
+					Str("step_id", step.GetID()).
+					Msg("Failed to evaluate edge condition")
+				e.compensate(ctx, wf, run, succeeded)
+				return e.failWorkflowAtStep(ctx, run, step.GetID(), nil, err)
+			}
+		}
 
 		// Update progress
-		progress := float64(completedSteps) / float64(totalSteps)
+		progress := completedWeight / totalWeight
 		run.Progress = progress
-		run.UpdatedAt = time.Now()
-
-		if err := e.store.UpdateRun(ctx, run); err != nil {
-			gorkflow.LogPersistenceError(e.logger, run.RunID, "update_run_progress", err)
+		run.CurrentStep = ""
+		run.UpdatedAt = e.now()
+
+		// Pausing is a status change and is written regardless of the progress interval
+		if paused = e.pauseRequested(runCtx, run, pausePolls); paused {
+			run.SetStatus(gorkflow.RunStatusPaused, run.UpdatedAt)
+			e.persistRun(ctx, run, "update_run_progress")
+		} else if progressWrites.allow(run.UpdatedAt) {
+			e.persistProgress(ctx, run, "update_run_progress")
 		}
 
 		gorkflow.LogWorkflowProgress(e.logger, run.RunID, progress)
 	}
 
 	// All steps completed successfully
-	return e.completeWorkflow(ctx, run)
+	output, err := e.collectRunOutput(ctx, run.RunID, wf.Graph().TerminalNodes())
+	if err != nil {
+		workflowLogger.Error().Err(err).Msg("Failed to collect workflow output")
+		e.compensate(ctx, wf, run, succeeded)
+		return e.failWorkflow(ctx, run, err)
+	}
+
+	return e.completeWorkflow(ctx, run, output)
+}
+
+// abandonRun stops executing a run whose lease another engine took over, without writing it
+func (e *Engine) abandonRun(run *gorkflow.WorkflowRun) error {
+	e.logger.Warn().Str("run_id", run.RunID).Msg("Abandoning workflow run after losing its lease")
+	return ErrLeaseLost
 }
 
-// completeWorkflow marks workflow as completed
-func (e *Engine) completeWorkflow(ctx context.Context, run *gorkflow.WorkflowRun) error {
-	completedAt := time.Now()
-	run.Status = gorkflow.RunStatusCompleted
+// completeWorkflow marks workflow as completed and records its final output
+func (e *Engine) completeWorkflow(ctx context.Context, run *gorkflow.WorkflowRun, output []byte) error {
+	completedAt := e.now()
+	run.SetStatus(gorkflow.RunStatusCompleted, completedAt)
+	run.Output = output
 	run.Progress = 1.0
+	run.CurrentStep = ""
 	run.CompletedAt = &completedAt
 	run.UpdatedAt = completedAt
 
+	ctx, cancel := e.persistCtx(ctx)
+	defer cancel()
+	e.applyActiveTags(run)
 	if err := e.store.UpdateRun(ctx, run); err != nil {
 		return fmt.Errorf("failed to update run on completion: %w", err)
 	}
@@ -288,17 +731,102 @@ func (e *Engine) completeWorkflow(ctx context.Context, run *gorkflow.WorkflowRun
 	return nil
 }
 
+// collectRunOutput builds the run output from the terminal steps' outputs.
+// A single terminal step's output is used as-is; several are combined into a
+// JSON object keyed by step ID. Terminal steps without output are left out.
+func (e *Engine) collectRunOutput(ctx context.Context, runID string, terminalSteps []string) (json.RawMessage, error) {
+	outputs := make(map[string]json.RawMessage, len(terminalSteps))
+	for _, stepID := range terminalSteps {
+		output, err := e.store.LoadStepOutput(ctx, runID, stepID)
+		if err != nil {
+			// Failed steps with ContinueOnError have no output
+			continue
+		}
+		outputs[stepID] = output
+	}
+
+	if len(terminalSteps) == 1 {
+		return outputs[terminalSteps[0]], nil
+	}
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+
+	output, err := e.codec.Marshal(outputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize workflow output: %w", err)
+	}
+	return output, nil
+}
+
 // failWorkflow marks workflow as failed
 func (e *Engine) failWorkflow(ctx context.Context, run *gorkflow.WorkflowRun, err error) error {
-	completedAt := time.Now()
-	run.Status = gorkflow.RunStatusFailed
+	return e.failWorkflowWithCode(ctx, run, gorkflow.ErrCodeExecutionFailed, err)
+}
+
+// timeoutWorkflow marks workflow as failed because its execution deadline passed
+func (e *Engine) timeoutWorkflow(ctx context.Context, run *gorkflow.WorkflowRun, timeout time.Duration) error {
+	return e.failWorkflowWithCode(ctx, run, gorkflow.ErrCodeTimeout,
+		fmt.Errorf("workflow timed out after %s", timeout))
+}
+
+// callerDeadlineWorkflow fails a run, like a workflow timeout, because the deadline of the context
+// it executes under passed. Only synchronous runs execute under the caller's context.
+func (e *Engine) callerDeadlineWorkflow(ctx context.Context, wf *gorkflow.Workflow, run *gorkflow.WorkflowRun, succeeded []string) error {
+	deadlineErr := ctx.Err()
+
+	// Compensation and the failure record run after the deadline
+	ctx = context.WithoutCancel(ctx)
+	e.compensate(ctx, wf, run, succeeded)
+	return e.failWorkflowWithCode(ctx, run, gorkflow.ErrCodeTimeout,
+		fmt.Errorf("workflow exceeded the caller's deadline: %w", deadlineErr))
+}
+
+// failWorkflowAtStep marks workflow as failed by a step, recording the step and its attempts and duration
+func (e *Engine) failWorkflowAtStep(
+	ctx context.Context,
+	run *gorkflow.WorkflowRun,
+	stepID string,
+	result *StepExecutionResult,
+	err error,
+) error {
+	wfErr := gorkflow.NewWorkflowErrorWithStep(gorkflow.ErrCodeExecutionFailed, err.Error(), stepID).WithCause(err)
+	if result != nil {
+		wfErr.WithDetails(map[string]interface{}{
+			"attempts":    result.AttemptsMade,
+			"duration_ms": result.DurationMs,
+		})
+	}
+	return e.recordFailure(ctx, run, wfErr, err)
+}
+
+// failUnknownStep marks workflow as failed because the graph names a step the workflow does not
+// have, a graph or registration bug rather than a handler failure
+func (e *Engine) failUnknownStep(ctx context.Context, run *gorkflow.WorkflowRun, stepID string, err error) error {
+	wfErr := gorkflow.NewWorkflowErrorWithStep(gorkflow.ErrCodeInternalError,
+		fmt.Sprintf("step %s is in the execution graph but not registered with the workflow", stepID), stepID).WithCause(err)
+	return e.recordFailure(ctx, run, wfErr, wfErr)
+}
+
+// failWorkflowWithCode marks workflow as failed with the given error code
+func (e *Engine) failWorkflowWithCode(ctx context.Context, run *gorkflow.WorkflowRun, code string, err error) error {
+	return e.recordFailure(ctx, run, gorkflow.NewWorkflowError(code, err.Error()).WithCause(err), err)
+}
+
+// recordFailure marks workflow as failed with the given error
+func (e *Engine) recordFailure(ctx context.Context, run *gorkflow.WorkflowRun, wfErr *gorkflow.WorkflowError, err error) error {
+	completedAt := e.now()
+	wfErr.Timestamp = completedAt
+	run.SetStatus(gorkflow.RunStatusFailed, completedAt)
 	run.CompletedAt = &completedAt
 	run.UpdatedAt = completedAt
-	run.Error = &gorkflow.WorkflowError{
-		Message:   err.Error(),
-		Code:      gorkflow.ErrCodeExecutionFailed,
-		Timestamp: completedAt,
-	}
+	run.CurrentStep = ""
+	run.Error = wfErr
+
+	ctx, cancel := e.persistCtx(ctx)
+	defer cancel()
+	run.PartialOutputs = e.collectPartialOutputs(ctx, run.RunID)
+	e.applyActiveTags(run)
 
 	if updateErr := e.store.UpdateRun(ctx, run); updateErr != nil {
 		gorkflow.LogPersistenceError(e.logger, run.RunID, "update_run_failure", updateErr)
@@ -309,12 +837,50 @@ func (e *Engine) failWorkflow(ctx context.Context, run *gorkflow.WorkflowRun, er
 	return err
 }
 
+// collectPartialOutputs gathers the outputs of a failed run's completed steps into a
+// JSON object keyed by step ID, or nil when none completed
+func (e *Engine) collectPartialOutputs(ctx context.Context, runID string) json.RawMessage {
+	executions, err := e.store.ListStepExecutions(ctx, runID)
+	if err != nil {
+		gorkflow.LogPersistenceError(e.logger, runID, "list_step_executions", err)
+		return nil
+	}
+
+	outputs := make(map[string]json.RawMessage)
+	for _, exec := range executions {
+		if exec.Status != gorkflow.StepStatusCompleted {
+			continue
+		}
+		output, err := e.store.LoadStepOutput(ctx, runID, exec.StepID)
+		if err != nil || !json.Valid(output) {
+			// Raw step outputs are not JSON and cannot be embedded
+			continue
+		}
+		outputs[exec.StepID] = output
+	}
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	partial, err := e.codec.Marshal(outputs)
+	if err != nil {
+		e.logger.Warn().Err(err).Str("run_id", runID).Msg("Failed to serialize partial outputs")
+		return nil
+	}
+	return partial
+}
+
 // cancelWorkflow marks workflow as cancelled
 func (e *Engine) cancelWorkflow(ctx context.Context, run *gorkflow.WorkflowRun) error {
-	completedAt := time.Now()
-	run.Status = gorkflow.RunStatusCancelled
+	completedAt := e.now()
+	run.SetStatus(gorkflow.RunStatusCancelled, completedAt)
 	run.CompletedAt = &completedAt
 	run.UpdatedAt = completedAt
+	run.CurrentStep = ""
+
+	ctx, cancel := e.persistCtx(ctx)
+	defer cancel()
+	e.applyActiveTags(run)
 
 	if err := e.store.UpdateRun(ctx, run); err != nil {
 		return fmt.Errorf("failed to update run on cancellation: %w", err)
@@ -335,7 +901,13 @@ func (e *Engine) GetStepExecutions(ctx context.Context, runID string) ([]*gorkfl
 	return e.store.ListStepExecutions(ctx, runID)
 }
 
-// Cancel cancels a running workflow
+// GetStepExecution retrieves the execution of a single step, e.g. to poll a long-running step
+func (e *Engine) GetStepExecution(ctx context.Context, runID, stepID string) (*gorkflow.StepExecution, error) {
+	return e.store.GetStepExecution(ctx, runID, stepID)
+}
+
+// Cancel cancels a running workflow. A run executing in this engine has its context
+// cancelled, so the current step sees ctx.Done() and no further steps start.
 func (e *Engine) Cancel(ctx context.Context, runID string) error {
 	run, err := e.store.GetRun(ctx, runID)
 	if err != nil {
@@ -346,10 +918,94 @@ func (e *Engine) Cancel(ctx context.Context, runID string) error {
 		return fmt.Errorf("cannot cancel workflow in %s state", run.Status)
 	}
 
-	return e.cancelWorkflow(ctx, run)
+	if err := e.cancelWorkflow(ctx, run); err != nil {
+		return err
+	}
+
+	e.cancelActiveRun(runID)
+	return nil
+}
+
+// CancelByResource cancels every pending, running or paused run for a resource, e.g. during an
+// incident, and returns how many it cancelled. Runs that finish before they are reached are left
+// as they are; other failures are returned together once every run has been tried.
+func (e *Engine) CancelByResource(ctx context.Context, resourceID string) (int, error) {
+	cancelled := 0
+	var errs []error
+	for _, status := range []gorkflow.RunStatus{gorkflow.RunStatusPending, gorkflow.RunStatusRunning, gorkflow.RunStatusPaused} {
+		runs, err := e.store.ListRuns(ctx, gorkflow.RunFilter{ResourceID: resourceID, Status: &status})
+		if err != nil {
+			return cancelled, fmt.Errorf("failed to list %s runs: %w", status, err)
+		}
+
+		for _, run := range runs {
+			if err := e.Cancel(ctx, run.RunID); err != nil {
+				if current, getErr := e.store.GetRun(ctx, run.RunID); getErr == nil && current.Status.IsTerminal() {
+					continue
+				}
+				errs = append(errs, fmt.Errorf("failed to cancel run %s: %w", run.RunID, err))
+				continue
+			}
+			cancelled++
+		}
+	}
+	return cancelled, errors.Join(errs...)
+}
+
+// DeleteRun removes a finished workflow run along with its step executions, outputs and state
+func (e *Engine) DeleteRun(ctx context.Context, runID string) error {
+	run, err := e.store.GetRun(ctx, runID)
+	if err != nil {
+		return fmt.Errorf("failed to get run: %w", err)
+	}
+
+	if !run.Status.IsTerminal() {
+		return fmt.Errorf("cannot delete workflow in %s state", run.Status)
+	}
+
+	return e.store.DeleteRun(ctx, runID)
 }
 
 // ListRuns lists workflow runs with filtering
 func (e *Engine) ListRuns(ctx context.Context, filter gorkflow.RunFilter) ([]*gorkflow.WorkflowRun, error) {
 	return e.store.ListRuns(ctx, filter)
 }
+
+// CountRuns counts the workflow runs matching a filter
+func (e *Engine) CountRuns(ctx context.Context, filter gorkflow.RunFilter) (int, error) {
+	return e.store.CountRuns(ctx, filter)
+}
+
+// newOutputAccessor creates the step output accessor shared by all steps of a run
+func (e *Engine) newOutputAccessor(wf *gorkflow.Workflow, runID string) gorkflow.StepOutputAccessor {
+	return gorkflow.NewStepOutputAccessor(runID, e.store,
+		gorkflow.WithOutputCacheTTL(e.config.OutputCacheTTL),
+		gorkflow.WithOutputCodec(e.codec),
+		gorkflow.WithOutputMigrations(wf),
+	)
+}
+
+// newStateAccessor creates the state accessor shared by all steps of a run
+func (e *Engine) newStateAccessor(runID string) gorkflow.StateAccessor {
+	return gorkflow.NewStateAccessor(runID, e.store, gorkflow.WithStateCodec(e.codec))
+}
+
+// warmOutputCache batch-loads the outputs of the steps that completed into the accessor's cache,
+// e.g. after a pause, so later steps do not read them back one at a time
+func (e *Engine) warmOutputCache(outputs gorkflow.StepOutputAccessor, runID string, completed []string) {
+	cache, ok := outputs.(interface{ WarmCache(stepIDs []string) error })
+	if !ok {
+		return
+	}
+	if err := cache.WarmCache(completed); err != nil {
+		e.logger.Warn().Err(err).Str("run_id", runID).Msg("Failed to warm step output cache")
+	}
+}
+
+// forgetCachedOutput drops what the accessor cached about a step that has just saved its output,
+// such as a HasOutput miss from before it ran
+func forgetCachedOutput(outputs gorkflow.StepOutputAccessor, stepID string) {
+	if cache, ok := outputs.(interface{ Invalidate(stepID string) }); ok {
+		cache.Invalidate(stepID)
+	}
+}
@@ -1,10 +1,14 @@
 package engine
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -113,22 +117,9 @@ func waitForCompletion(t *testing.T, engine *Engine, runID string, timeout time.
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			t.Fatal("Timeout waiting for workflow completion")
-		case <-ticker.C:
-			run, err := engine.GetRun(context.Background(), runID)
-			require.NoError(t, err)
-
-			if run.Status.IsTerminal() {
-				return run
-			}
-		}
-	}
+	run, err := engine.WaitForCompletion(ctx, runID)
+	require.NoError(t, err, "waiting for workflow completion")
+	return run
 }
 
 func TestEngine_SimpleSequentialWorkflow(t *testing.T) {
@@ -176,6 +167,233 @@ func TestEngine_SimpleSequentialWorkflow(t *testing.T) {
 	}
 }
 
+func TestEngine_RunWorkflowSync(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("sync_test", "Sync Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies)).
+		ThenStep(gorkflow.NewStep("filter", "Filter Companies", filterCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+	require.NotNil(t, run)
+
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	var output FilterOutput
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Equal(t, []string{"CompanyA", "CompanyB"}, output.Filtered)
+}
+
+func TestEngine_RunDefinition(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("definition_test", "Definition Test").
+		WithVersion("3.1").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies, gorkflow.WithRetries(5))).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+	waitForCompletion(t, engine, runID, 10*time.Second)
+
+	run, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	require.NotEmpty(t, run.Definition)
+
+	expected, err := wf.MarshalDefinition()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(run.Definition))
+
+	// The stored definition describes the executed graph
+	var def gorkflow.WorkflowDefinition
+	require.NoError(t, json.Unmarshal(run.Definition, &def))
+	assert.Equal(t, "3.1", def.Version)
+	assert.Equal(t, "discover", def.EntryPoint)
+	assert.Equal(t, []gorkflow.EdgeDefinition{{From: "discover", To: "enrich"}}, def.Edges)
+	require.Len(t, def.Steps, 2)
+	assert.Equal(t, 5, *def.Steps[0].Config.MaxRetries)
+}
+
+func TestEngine_RunOutputAsync(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("async_output_test", "Async Output Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+
+	waitForCompletion(t, engine, runID, 10*time.Second)
+
+	// Output is persisted with the final run update
+	run, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	var output DiscoverOutput
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Equal(t, 3, output.Count)
+}
+
+type traceIDKey struct{}
+
+func TestEngine_AsyncContextValues(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("context_values", "Context Values").
+		ThenStep(gorkflow.NewStep("trace", "Trace",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				traceID, _ := ctx.Value(traceIDKey{}).(string)
+				return DiscoverOutput{Companies: []string{traceID}}, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	startCtx, cancel := context.WithCancel(context.WithValue(context.Background(), traceIDKey{}, "trace-123"))
+	runID, err := engine.StartWorkflow(startCtx, wf, DiscoverInput{Query: "tech"})
+	require.NoError(t, err)
+
+	// The run outlives the request that started it
+	cancel()
+
+	run := waitForCompletion(t, engine, runID, 10*time.Second)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	var output DiscoverOutput
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Equal(t, []string{"trace-123"}, output.Companies)
+}
+
+func TestEngine_RunOutputSingleTerminal(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("terminal_test", "Terminal Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	// A single terminal step's output is the run output as it is, not keyed by step ID
+	var output EnrichOutput
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Len(t, output.Enriched, 3)
+
+	var keyed map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(run.Output, &keyed))
+	assert.NotContains(t, keyed, "enrich")
+}
+
+func TestEngine_RunOutputMultipleTerminals(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	newBranch := func(id, company string) gorkflow.StepExecutor {
+		return gorkflow.NewStep(id, id,
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (FilterOutput, error) {
+				return FilterOutput{Filtered: []string{company}}, nil
+			},
+		)
+	}
+
+	wf, err := builder.NewWorkflow("terminals_test", "Terminals Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Parallel(newBranch("branch_a", "CompanyA"), newBranch("branch_b", "CompanyB")).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+
+	waitForCompletion(t, engine, runID, 10*time.Second)
+
+	run, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	// Outputs of both terminal steps, keyed by step ID
+	var output map[string]FilterOutput
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Len(t, output, 2)
+	assert.Equal(t, []string{"CompanyA"}, output["branch_a"].Filtered)
+	assert.Equal(t, []string{"CompanyB"}, output["branch_b"].Filtered)
+}
+
+func TestEngine_StartWorkflow_InputSchema(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	schema := []byte(`{"type": "object", "properties": {"query": {"type": "string", "minLength": 1}}, "required": ["query"]}`)
+	step := gorkflow.NewStep("discover", "Discover Companies", discoverCompanies, gorkflow.WithInputSchema(schema))
+
+	wf, err := builder.NewWorkflow("schema_test", "Schema Test").
+		ThenStep(step).
+		Build()
+	require.NoError(t, err)
+
+	// Missing required field is rejected before a run is created
+	runID, err := engine.StartWorkflow(context.Background(), wf, map[string]interface{}{"limit": 10})
+	require.Error(t, err)
+	assert.Empty(t, runID)
+
+	var wfErr *gorkflow.WorkflowError
+	require.True(t, errors.As(err, &wfErr))
+	assert.Equal(t, gorkflow.ErrCodeValidation, wfErr.Code)
+	assert.Equal(t, "discover", wfErr.Step)
+
+	runs, err := engine.ListRuns(context.Background(), gorkflow.RunFilter{WorkflowID: "schema_test"})
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	// Valid input runs normally
+	runID, err = engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+
+	run := waitForCompletion(t, engine, runID, 10*time.Second)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+}
+
+func TestEngine_StartWorkflow_MaxInputBytes(t *testing.T) {
+	engine, _ := createTestEngine(t)
+	engine.config.MaxInputBytes = 64
+
+	wf, err := builder.NewWorkflow("input_limit", "Input Limit").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	// Oversized input is rejected before a run is created
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: strings.Repeat("x", 100), Limit: 10})
+	require.Error(t, err)
+	assert.Empty(t, runID)
+	assert.ErrorIs(t, err, ErrInputTooLarge)
+
+	var wfErr *gorkflow.WorkflowError
+	require.True(t, errors.As(err, &wfErr))
+	assert.Equal(t, gorkflow.ErrCodeValidation, wfErr.Code)
+
+	runs, err := engine.ListRuns(context.Background(), gorkflow.RunFilter{WorkflowID: "input_limit"})
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	// Input within the limit runs normally
+	runID, err = engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+
+	run := waitForCompletion(t, engine, runID, 10*time.Second)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+}
+
 func TestEngine_WorkflowWithFailure(t *testing.T) {
 	engine, _ := createTestEngine(t)
 
@@ -205,6 +423,297 @@ func TestEngine_WorkflowWithFailure(t *testing.T) {
 	assert.Contains(t, run.Error.Message, "intentional failure")
 }
 
+func TestEngine_WorkflowFailure_StepDetails(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("failing_details", "Failing Details").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies",
+			func(ctx *gorkflow.StepContext, input EnrichInput) (EnrichOutput, error) {
+				return EnrichOutput{}, errors.New("enrichment unavailable")
+			},
+			gorkflow.WithRetries(1),
+			gorkflow.WithBackoff(gorkflow.BackoffNone),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.Error(t, err)
+	require.Equal(t, gorkflow.RunStatusFailed, run.Status)
+
+	// The stored error names the failing step and how it failed
+	stored, err := engine.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Error)
+	assert.Equal(t, "enrich", stored.Error.Step)
+	assert.Equal(t, gorkflow.ErrCodeExecutionFailed, stored.Error.Code)
+	assert.Contains(t, stored.Error.Message, "enrichment unavailable")
+	require.Contains(t, stored.Error.Details, "attempts")
+	assert.EqualValues(t, 2, stored.Error.Details["attempts"])
+	assert.Contains(t, stored.Error.Details, "duration_ms")
+}
+
+func TestEngine_StatusHistory(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("status_history", "Status History").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+
+	stored, err := engine.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+
+	var statuses []gorkflow.RunStatus
+	for i, transition := range stored.StatusHistory {
+		statuses = append(statuses, transition.Status)
+		if i > 0 {
+			assert.False(t, transition.At.Before(stored.StatusHistory[i-1].At), "transition %d goes back in time", i)
+		}
+	}
+	assert.Equal(t, []gorkflow.RunStatus{
+		gorkflow.RunStatusPending,
+		gorkflow.RunStatusRunning,
+		gorkflow.RunStatusCompleted,
+	}, statuses)
+	assert.Equal(t, stored.CreatedAt, stored.StatusHistory[0].At)
+	assert.Equal(t, *stored.CompletedAt, stored.StatusHistory[2].At)
+}
+
+func TestEngine_StepTags(t *testing.T) {
+	var logs bytes.Buffer
+	engine := NewEngine(store.NewMemoryStore(), WithLogger(zerolog.New(&logs)))
+
+	wf, err := builder.NewWorkflow("step_tags", "Step Tags").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies,
+			gorkflow.WithStepTags(map[string]string{"team": "growth"}),
+			gorkflow.WithStepTags(map[string]string{"criticality": "high"}),
+		)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+
+	discover, err := engine.GetStepExecution(context.Background(), run.RunID, "discover")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "growth", "criticality": "high"}, discover.Tags)
+
+	enrich, err := engine.GetStepExecution(context.Background(), run.RunID, "enrich")
+	require.NoError(t, err)
+	assert.Empty(t, enrich.Tags)
+
+	// The step's logger carries its tags
+	assert.Contains(t, logs.String(), `"step_tags":{`)
+	assert.Contains(t, logs.String(), `"team":"growth"`)
+}
+
+func TestEngine_OutputVersionStamped(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("output_version", "Output Version").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies,
+			gorkflow.WithOutputVersion(2),
+			gorkflow.WithOutputMigration(1, func(data []byte) ([]byte, error) {
+				return nil, errors.New("current outputs must not be migrated")
+			}),
+		)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	discover, err := engine.GetStepExecution(context.Background(), run.RunID, "discover")
+	require.NoError(t, err)
+	assert.Equal(t, 2, discover.OutputVersion)
+
+	enrich, err := engine.GetStepExecution(context.Background(), run.RunID, "enrich")
+	require.NoError(t, err)
+	assert.Zero(t, enrich.OutputVersion)
+}
+
+func TestEngine_WorkflowFailure_PartialOutputs(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("partial_outputs", "Partial Outputs").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies",
+			func(ctx *gorkflow.StepContext, input EnrichInput) (EnrichOutput, error) {
+				return EnrichOutput{}, errors.New("enrichment unavailable")
+			},
+			gorkflow.WithRetries(0),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.Error(t, err)
+	require.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	assert.Empty(t, run.Output)
+
+	// The completed step's output survives on the failed run, keyed by step ID
+	stored, err := engine.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+	require.NotEmpty(t, stored.PartialOutputs)
+
+	var partial map[string]DiscoverOutput
+	require.NoError(t, json.Unmarshal(stored.PartialOutputs, &partial))
+	require.Len(t, partial, 1)
+	assert.Equal(t, 3, partial["discover"].Count)
+}
+
+func TestEngine_WorkflowFailure_UnwrapsCause(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	errUnavailable := errors.New("enrichment unavailable")
+	wf, err := builder.NewWorkflow("unwrap_cause", "Unwrap Cause").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				return DiscoverOutput{}, fmt.Errorf("calling provider: %w", errUnavailable)
+			},
+			gorkflow.WithRetries(0),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.Error(t, err)
+	require.NotNil(t, run.Error)
+	assert.ErrorIs(t, run.Error, errUnavailable)
+
+	// The cause stays out of the serialized error
+	data, err := json.Marshal(run.Error)
+	require.NoError(t, err)
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.NotContains(t, fields, "cause")
+}
+
+func TestEngine_MaxOutputBytes(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var calls atomic.Int32
+	wf, err := builder.NewWorkflow("output_limit", "Output Limit").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				calls.Add(1)
+				companies := make([]string, 100)
+				for i := range companies {
+					companies[i] = "Company With A Long Name"
+				}
+				return DiscoverOutput{Companies: companies, Count: len(companies)}, nil
+			},
+			gorkflow.WithMaxOutputBytes(256),
+			gorkflow.WithRetries(2),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.ErrorIs(t, err, ErrOutputTooLarge)
+	require.Equal(t, gorkflow.RunStatusFailed, run.Status)
+
+	// An oversized output is not retried and never reaches the output store
+	assert.Equal(t, int32(1), calls.Load())
+	_, err = engine.store.LoadStepOutput(context.Background(), run.RunID, "discover")
+	assert.Error(t, err)
+
+	exec, err := engine.GetStepExecution(context.Background(), run.RunID, "discover")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.StepStatusFailed, exec.Status)
+	require.NotNil(t, exec.Error)
+	assert.Equal(t, gorkflow.ErrCodeValidation, exec.Error.Code)
+	assert.Regexp(t, `step output too large: \d+ bytes exceeds the limit of 256 bytes`, exec.Error.Message)
+}
+
+type loginInput struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type loginOutput struct {
+	Username string `json:"username"`
+	Token    string `json:"token"`
+}
+
+func TestEngine_Redaction(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("redaction", "Redaction").
+		ThenStep(gorkflow.NewStep("login", "Login",
+			func(ctx *gorkflow.StepContext, input loginInput) (loginOutput, error) {
+				if input.Password != "hunter2" {
+					return loginOutput{}, errors.New("handler did not receive the password")
+				}
+				return loginOutput{Username: input.Username, Token: "secret-token"}, nil
+			},
+			gorkflow.WithRedaction([]string{"password", "token"}),
+		)).
+		ThenStep(gorkflow.NewStep("use", "Use Token",
+			func(ctx *gorkflow.StepContext, input loginOutput) (loginOutput, error) {
+				if input.Token != "secret-token" {
+					return loginOutput{}, errors.New("downstream step did not receive the token")
+				}
+				return loginOutput{Username: input.Username}, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, loginInput{Username: "ada", Password: "hunter2"})
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	// The stored execution keeps the shape of the payloads without the secrets
+	exec, err := engine.GetStepExecution(context.Background(), run.RunID, "login")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"username":"ada","password":"[REDACTED]"}`, string(exec.Input))
+	assert.JSONEq(t, `{"username":"ada","token":"[REDACTED]"}`, string(exec.Output))
+}
+
+func TestEngine_OutputCacheSeesLaterSteps(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	hasEnrich := func(id string) gorkflow.StepExecutor {
+		return gorkflow.NewStep(id, id,
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				if ctx.Outputs.HasOutput("enrich") {
+					input.Query += "+" + id
+				}
+				return input, nil
+			},
+		)
+	}
+
+	wf, err := builder.NewWorkflow("output_cache", "Output Cache").
+		ThenStep(hasEnrich("before")).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				return input, nil
+			},
+		)).
+		ThenStep(hasEnrich("after")).
+		Build()
+	require.NoError(t, err)
+
+	// The miss cached before enrich ran does not hide its output afterwards
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "q"})
+	require.NoError(t, err)
+
+	var output DiscoverInput
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Equal(t, "q+after", output.Query)
+}
+
 func TestEngine_WorkflowProgress(t *testing.T) {
 	engine, _ := createTestEngine(t)
 
@@ -244,6 +753,132 @@ func TestEngine_WorkflowProgress(t *testing.T) {
 	assert.Equal(t, 1.0, run.Progress)
 }
 
+func TestEngine_WeightedProgress(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	// Each step records the run's progress as it starts
+	var observed []float64
+	observe := func(id string, opts ...gorkflow.StepOption) gorkflow.StepExecutor {
+		return gorkflow.NewStep(id, id,
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				run, err := engine.GetRun(ctx.Context, ctx.RunID)
+				if err != nil {
+					return input, err
+				}
+				observed = append(observed, run.Progress)
+				return input, nil
+			},
+			opts...,
+		)
+	}
+
+	wf, err := builder.NewWorkflow("weighted_progress", "Weighted Progress").
+		ThenStep(observe("prepare")).
+		ThenStep(observe("crunch", gorkflow.WithWeight(8))).
+		ThenStep(observe("publish")).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.NoError(t, err)
+
+	// The heavy middle step accounts for 8 of the 10 units of progress
+	require.Len(t, observed, 3)
+	assert.InDelta(t, 0.0, observed[0], 1e-9)
+	assert.InDelta(t, 0.1, observed[1], 1e-9)
+	assert.InDelta(t, 0.9, observed[2], 1e-9)
+	assert.Equal(t, 1.0, run.Progress)
+}
+
+func TestEngine_TotalAttempts(t *testing.T) {
+	engine, store := createTestEngine(t)
+
+	// Fails twice, then succeeds on its third attempt
+	var attempts int32
+	flaky := gorkflow.NewStep("discover", "Discover Companies",
+		func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+			if atomic.AddInt32(&attempts, 1) <= 2 {
+				return DiscoverOutput{}, errors.New("try again")
+			}
+			return discoverCompanies(ctx, input)
+		},
+		gorkflow.WithRetries(2),
+		gorkflow.WithBackoff(gorkflow.BackoffNone),
+		gorkflow.WithRetryDelay(time.Millisecond),
+	)
+
+	wf, err := builder.NewWorkflow("total_attempts", "Total Attempts").
+		ThenStep(flaky).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.Equal(t, 3, run.TotalAttempts)
+
+	stored, err := store.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.TotalAttempts)
+}
+
+func TestEngine_UnregisteredStep(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	// The builder never produces this, so wire the graph by hand: "ghost" has a node but no step
+	wf := gorkflow.NewWorkflowInstance("unregistered", "Unregistered")
+	wf.AddStep(gorkflow.PassthroughStep[DiscoverInput]("first", "First"))
+	wf.Graph().AddNode("first", gorkflow.NodeTypeSequential)
+	wf.Graph().AddNode("ghost", gorkflow.NodeTypeSequential)
+	require.NoError(t, wf.Graph().AddEdge("first", "ghost"))
+	require.NoError(t, wf.Graph().SetEntryPoint("first"))
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.Error(t, err)
+
+	var wfErr *gorkflow.WorkflowError
+	require.ErrorAs(t, err, &wfErr)
+	assert.Equal(t, gorkflow.ErrCodeInternalError, wfErr.Code)
+	assert.Equal(t, "ghost", wfErr.Step)
+
+	require.NotNil(t, run)
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	require.NotNil(t, run.Error)
+	assert.Equal(t, gorkflow.ErrCodeInternalError, run.Error.Code)
+	assert.Equal(t, "ghost", run.Error.Step)
+	assert.Contains(t, run.Error.Message, "ghost")
+}
+
+func TestEngine_CurrentStep(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	wf, err := builder.NewWorkflow("current_step", "Current Step").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("first", "First")).
+		ThenStep(gorkflow.NewStep("slow", "Slow",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				close(started)
+				<-release
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.NoError(t, err)
+
+	<-started
+	run, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	assert.Equal(t, "slow", run.CurrentStep)
+
+	close(release)
+	run = waitForCompletion(t, engine, runID, 5*time.Second)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.Empty(t, run.CurrentStep)
+}
+
 func TestEngine_StepOutputPassing(t *testing.T) {
 	engine, wfStore := createTestEngine(t)
 
@@ -315,6 +950,35 @@ func TestEngine_GetStepExecutions(t *testing.T) {
 	}
 }
 
+func TestEngine_GetStepExecution(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("test", "Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover", discoverCompanies)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich", enrichCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+
+	waitForCompletion(t, engine, runID, 10*time.Second)
+
+	step, err := engine.GetStepExecution(context.Background(), runID, "enrich")
+	require.NoError(t, err)
+	assert.Equal(t, runID, step.RunID)
+	assert.Equal(t, "enrich", step.StepID)
+	assert.Equal(t, gorkflow.StepStatusCompleted, step.Status)
+	assert.NotNil(t, step.CompletedAt)
+
+	var output EnrichOutput
+	require.NoError(t, json.Unmarshal(step.Output, &output))
+	assert.Len(t, output.Enriched, 3)
+
+	_, err = engine.GetStepExecution(context.Background(), runID, "missing")
+	assert.Error(t, err)
+}
+
 func TestEngine_ListRuns(t *testing.T) {
 	engine, _ := createTestEngine(t)
 
@@ -382,6 +1046,188 @@ func TestEngine_Cancel(t *testing.T) {
 	assert.Equal(t, gorkflow.RunStatusCancelled, run.Status)
 }
 
+func TestEngine_Cancel_MidStep(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	started := make(chan struct{})
+	var nextRan atomic.Bool
+
+	wf, err := builder.NewWorkflow("cancel_mid_step", "Cancel Mid Step").
+		ThenStep(gorkflow.NewStep("long", "Long Step",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				close(started)
+				<-ctx.Done()
+				return DiscoverOutput{}, ctx.Err()
+			},
+			gorkflow.WithRetries(3),
+		)).
+		ThenStep(gorkflow.NewStep("next", "Next Step",
+			func(ctx *gorkflow.StepContext, input DiscoverOutput) (DiscoverOutput, error) {
+				nextRan.Store(true)
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+
+	<-started
+	require.NoError(t, engine.Cancel(context.Background(), runID))
+
+	// The step's ctx.Err() is recognized as a cancellation, not a failure
+	run := waitForCompletion(t, engine, runID, 5*time.Second)
+	assert.Equal(t, gorkflow.RunStatusCancelled, run.Status)
+	assert.Nil(t, run.Error)
+	assert.False(t, nextRan.Load())
+
+	step, err := engine.GetStepExecution(context.Background(), runID, "long")
+	require.NoError(t, err)
+	require.NotNil(t, step.Error)
+	assert.Equal(t, gorkflow.ErrCodeCancelled, step.Error.Code)
+	assert.Equal(t, 0, step.Attempt, "cancelled steps are not retried")
+}
+
+func TestEngine_DelayStep(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	delay := 200 * time.Millisecond
+	wf, err := builder.NewWorkflow("delay_test", "Delay Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.DelayStep("wait", "Wait", delay)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	started := time.Now()
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.GreaterOrEqual(t, time.Since(started), delay)
+
+	// The delay hands the discover output on unchanged
+	wait, err := engine.GetStepExecution(context.Background(), run.RunID, "wait")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(wait.Input), string(wait.Output))
+	assert.GreaterOrEqual(t, wait.DurationMs, delay.Milliseconds())
+}
+
+func TestEngine_DelayStep_Cancel(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("delay_cancel", "Delay Cancel").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.DelayStep("wait", "Wait", time.Hour)).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		exec, err := engine.GetStepExecution(context.Background(), runID, "wait")
+		return err == nil && exec.Status == gorkflow.StepStatusRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancelled := time.Now()
+	require.NoError(t, engine.Cancel(context.Background(), runID))
+
+	run := waitForCompletion(t, engine, runID, 5*time.Second)
+	assert.Equal(t, gorkflow.RunStatusCancelled, run.Status)
+	assert.Less(t, time.Since(cancelled), time.Second)
+}
+
+func TestEngine_CancelByResource(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("cancel_by_resource", "Cancel By Resource").
+		ThenStep(gorkflow.DelayStep("wait", "Wait", time.Hour)).
+		Build()
+	require.NoError(t, err)
+
+	var runIDs []string
+	for range 3 {
+		runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test"}, gorkflow.WithResourceID("tenant-1"))
+		require.NoError(t, err)
+		runIDs = append(runIDs, runID)
+	}
+	other, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test"}, gorkflow.WithResourceID("tenant-2"))
+	require.NoError(t, err)
+
+	cancelled, err := engine.CancelByResource(context.Background(), "tenant-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, cancelled)
+
+	for _, runID := range runIDs {
+		run := waitForCompletion(t, engine, runID, 5*time.Second)
+		assert.Equal(t, gorkflow.RunStatusCancelled, run.Status)
+	}
+
+	// Runs for other resources keep going
+	run, err := engine.GetRun(context.Background(), other)
+	require.NoError(t, err)
+	assert.False(t, run.Status.IsTerminal())
+	require.NoError(t, engine.Cancel(context.Background(), other))
+
+	// Nothing is left to cancel
+	cancelled, err = engine.CancelByResource(context.Background(), "tenant-1")
+	require.NoError(t, err)
+	assert.Zero(t, cancelled)
+}
+
+func TestEngine_DeleteRun(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+
+	step := gorkflow.NewStep("discover", "Discover", discoverCompanies)
+
+	wf, err := builder.NewWorkflow("delete_test", "Delete Test").
+		ThenStep(step).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+	otherRunID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "other", Limit: 10})
+	require.NoError(t, err)
+
+	waitForCompletion(t, engine, runID, 10*time.Second)
+	waitForCompletion(t, engine, otherRunID, 10*time.Second)
+
+	require.NoError(t, engine.DeleteRun(context.Background(), runID))
+
+	_, err = engine.GetRun(context.Background(), runID)
+	assert.Error(t, err)
+	_, err = wfStore.LoadStepOutput(context.Background(), runID, "discover")
+	assert.Error(t, err)
+
+	// Other runs are untouched
+	_, err = engine.GetRun(context.Background(), otherRunID)
+	assert.NoError(t, err)
+	_, err = wfStore.LoadStepOutput(context.Background(), otherRunID, "discover")
+	assert.NoError(t, err)
+}
+
+func TestEngine_DeleteRun_NonTerminal(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+
+	run := &gorkflow.WorkflowRun{
+		RunID:      "running-run",
+		WorkflowID: "delete_test",
+		Status:     gorkflow.RunStatusRunning,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	require.NoError(t, wfStore.CreateRun(context.Background(), run))
+
+	err := engine.DeleteRun(context.Background(), run.RunID)
+	assert.Error(t, err)
+
+	// Run must still exist
+	_, err = engine.GetRun(context.Background(), run.RunID)
+	assert.NoError(t, err)
+}
+
 func TestEngine_WorkflowState(t *testing.T) {
 	engine, wfStore := createTestEngine(t)
 
@@ -2,12 +2,22 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"runtime/debug"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/sicko7947/gorkflow"
 )
 
+// ErrOutputTooLarge is wrapped by the error of a step whose output exceeds its WithMaxOutputBytes limit
+var ErrOutputTooLarge = errors.New("step output too large")
+
 // StepExecutionResult holds the result of a step execution
 type StepExecutionResult struct {
 	StepID       string
@@ -26,27 +36,35 @@ func (e *Engine) executeStep(
 	outputs gorkflow.StepOutputAccessor,
 	state gorkflow.StateAccessor,
 	customContext any,
+	budget *retryBudget,
+	batch *outputBatch,
 ) (*StepExecutionResult, error) {
 	config := step.GetConfig()
 
+	// Record step progress even after ctx is cancelled or its deadline passes
+	storeCtx := context.WithoutCancel(ctx)
+
 	// Create step execution record
 	stepExec := &gorkflow.StepExecution{
 		RunID:          run.RunID,
 		StepID:         step.GetID(),
 		ExecutionIndex: 0,
 		Status:         gorkflow.StepStatusPending,
-		Input:          inputBytes,
+		Input:          stepPayload(step, inputBytes),
+		Tags:           stepTags(step),
 		StartedAt:      nil,
 		CompletedAt:    nil,
-		UpdatedAt:      time.Now(),
+		UpdatedAt:      e.now(),
 	}
 
-	if err := e.store.CreateStepExecution(ctx, stepExec); err != nil {
+	writer := e.newStepWriter(stepExec)
+	if err := writer.pending(storeCtx); err != nil {
 		return nil, fmt.Errorf("failed to create step execution: %w", err)
 	}
 
 	// Build step context
-	stepLogger := gorkflow.StepLogger(e.logger, step.GetID(), step.GetName(), 0).With().Str("run_id", run.RunID).Logger()
+	stepLogger := e.stepLogger(run.RunID, step)
+	e.logPayload(stepLogger, step, "input", inputBytes)
 
 	stepCtx := &gorkflow.StepContext{
 		Context:       ctx,
@@ -55,91 +73,153 @@ func (e *Engine) executeStep(
 		Logger:        stepLogger,
 		Outputs:       outputs,
 		State:         state,
-		CustomContext: customContext,
+		CustomContext: stepCustomContext(step, customContext),
+		Codec:         e.codec,
+	}
+
+	// Signal steps wait for their signal instead of running a handler
+	if signalStep, ok := step.(interface{ GetSignal() string }); ok {
+		return e.awaitSignal(ctx, run, step, signalStep.GetSignal(), writer)
+	}
+
+	// An open circuit fails the step without running it
+	breaker, breakerConfig := e.circuitBreakerFor(step)
+	if !breaker.allow(breakerConfig, e.now()) {
+		return e.rejectOpenCircuit(storeCtx, run, step, writer, breakerConfig)
+	}
+
+	limiter := e.rateLimiterFor(step)
+
+	var maxOutputBytes int
+	if provider, ok := step.(interface{ GetMaxOutputBytes() int }); ok {
+		maxOutputBytes = provider.GetMaxOutputBytes()
 	}
 
 	var outputBytes []byte
 	var lastErr error
 	var attemptsMade int
 
+	handler := e.wrapStep(step.Execute)
+
+	var retryIf gorkflow.RetryPredicate
+	if provider, ok := step.(interface {
+		GetRetryIf() gorkflow.RetryPredicate
+	}); ok {
+		retryIf = provider.GetRetryIf()
+	}
+
 	// Retry loop
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
 		attemptsMade = attempt + 1
 		stepCtx.Attempt = attempt
 
 		if attempt > 0 {
-			// Apply backoff
-			delay := calculateBackoff(config.RetryDelayMs, attempt, string(config.RetryBackoff))
+			// Apply backoff, or the delay the failed attempt asked for
+			delay := retryBackoff(config, attempt, lastErr)
 
 			gorkflow.LogStepRetrying(e.logger, run.RunID, step.GetID(), attempt, delay)
 
 			stepExec.Status = gorkflow.StepStatusRetrying
 			stepExec.Attempt = attempt
-			stepExec.UpdatedAt = time.Now()
+			stepExec.UpdatedAt = e.now()
+
+			writer.progress(storeCtx, "update_step_execution_retry")
 
-			if err := e.store.UpdateStepExecution(ctx, stepExec); err != nil {
-				gorkflow.LogPersistenceError(e.logger, run.RunID, "update_step_execution_retry", err)
+			// Cancellation or the workflow deadline ends the wait, and the step with it
+			if err := e.sleep(ctx, delay); err != nil {
+				lastErr = fmt.Errorf("retry backoff interrupted: %w", err)
+				gorkflow.LogStepFailed(e.logger, run.RunID, step.GetID(), lastErr, attempt, 0)
+				break
 			}
+		}
 
-			if delay > 0 {
-				time.Sleep(delay)
+		// Wait for the step's rate limit; cancellation stops the wait
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				lastErr = fmt.Errorf("rate limit wait failed: %w", err)
+				gorkflow.LogStepFailed(e.logger, run.RunID, step.GetID(), lastErr, attempt, 0)
+				break
 			}
 		}
 
 		// Update to running
 		stepExec.Status = gorkflow.StepStatusRunning
-		now := time.Now()
+		now := e.now()
 		stepExec.StartedAt = &now
 		stepExec.Attempt = attempt
 		stepExec.UpdatedAt = now
 
-		if err := e.store.UpdateStepExecution(ctx, stepExec); err != nil {
-			gorkflow.LogPersistenceError(e.logger, run.RunID, "update_step_execution_running", err)
-		}
+		writer.progress(storeCtx, "update_step_execution_running")
 
 		// Execute with timeout
-		execCtx, cancel := context.WithTimeout(
-			ctx,
-			time.Duration(config.TimeoutSeconds)*time.Second,
-		)
+		execCtx, cancel := withStepTimeout(ctx, config.TimeoutSeconds)
 
 		stepCtx.Context = execCtx
-		startTime := time.Now()
+		startTime := e.now()
 
 		// Execute step (with panic recovery)
 		func() {
 			defer func() {
 				if r := recover(); r != nil {
-					lastErr = fmt.Errorf("step panicked: %v", r)
-					stepLogger.Error().Interface("panic", r).Msg("Step panicked")
+					stack := debug.Stack()
+					lastErr = &panicError{value: r, stack: stack}
+					stepLogger.Error().Interface("panic", r).Bytes("stack", stack).Msg("Step panicked")
 				}
 			}()
 
-			outputBytes, lastErr = step.Execute(stepCtx, inputBytes)
+			outputBytes, lastErr = handler(stepCtx, inputBytes)
 		}()
 
 		cancel() // Clean up timeout context
-		duration := time.Since(startTime)
+
+		// Oversized outputs fail the step here rather than at the store
+		if lastErr == nil && maxOutputBytes > 0 && len(outputBytes) > maxOutputBytes {
+			lastErr = fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrOutputTooLarge, len(outputBytes), maxOutputBytes)
+		}
+		finishedAt := e.now()
+		duration := finishedAt.Sub(startTime)
 		stepExec.DurationMs = duration.Milliseconds()
 
+		// Check if error is timeout
+		if lastErr != nil && ctx.Err() == nil && execCtx.Err() == context.DeadlineExceeded {
+			lastErr = fmt.Errorf("step timed out after %d seconds: %w", config.TimeoutSeconds, lastErr)
+			stepLogger.Error().
+				Int("timeout_seconds", config.TimeoutSeconds).
+				Msg("Step execution timed out")
+		}
+
+		// Keep every attempt, persisted with the next update of the execution
+		record := gorkflow.AttemptRecord{
+			Attempt:     attempt,
+			StartedAt:   startTime,
+			CompletedAt: finishedAt,
+			DurationMs:  duration.Milliseconds(),
+		}
+		if lastErr != nil {
+			record.Error = lastErr.Error()
+		}
+		stepExec.Attempts = append(stepExec.Attempts, record)
+
 		if lastErr == nil {
 			// Success
 			stepExec.Status = gorkflow.StepStatusCompleted
-			stepExec.Output = outputBytes
-			completedAt := time.Now()
+			stepExec.Output = e.inlineOutput(step, outputBytes)
+			stepExec.OutputVersion = stepOutputVersion(step)
+			completedAt := e.now()
 			stepExec.CompletedAt = &completedAt
 			stepExec.UpdatedAt = completedAt
 
-			if err := e.store.UpdateStepExecution(ctx, stepExec); err != nil {
-				gorkflow.LogPersistenceError(e.logger, run.RunID, "update_step_execution_success", err)
+			// Record the result and save the output for downstream steps together, or leave
+			// both to the bulk save once the rest of the parallel group finishes
+			if batch != nil {
+				batch.add(writer, outputBytes)
+			} else {
+				writer.commit(storeCtx, outputBytes)
 			}
 
 			gorkflow.LogStepCompleted(e.logger, run.RunID, step.GetID(), duration.Milliseconds(), attemptsMade)
-
-			// Save output for downstream steps
-			if err := e.store.SaveStepOutput(ctx, run.RunID, step.GetID(), outputBytes); err != nil {
-				gorkflow.LogPersistenceError(e.logger, run.RunID, "save_step_output", err)
-			}
+			e.logPayload(stepLogger, step, "output", outputBytes)
+			breaker.record(breakerConfig, false, completedAt)
 
 			return &StepExecutionResult{
 				StepID:       step.GetID(),
@@ -150,36 +230,65 @@ func (e *Engine) executeStep(
 			}, nil
 		}
 
-		// Check if error is timeout
-		if execCtx.Err() == context.DeadlineExceeded {
-			lastErr = fmt.Errorf("step timed out after %d seconds: %w", config.TimeoutSeconds, lastErr)
-			stepLogger.Error().
-				Int("timeout_seconds", config.TimeoutSeconds).
-				Msg("Step execution timed out")
+		// Stop retrying once the workflow itself is cancelled or timed out
+		if ctx.Err() != nil {
+			gorkflow.LogStepFailed(e.logger, run.RunID, step.GetID(), lastErr, attempt, duration.Milliseconds())
+			break
 		}
 
 		gorkflow.LogStepFailed(e.logger, run.RunID, step.GetID(), lastErr, attempt, duration.Milliseconds())
+
+		// Permanent errors fail the step without using up the remaining retries
+		if attempt < config.MaxRetries && (errors.Is(lastErr, ErrOutputTooLarge) || !gorkflow.ShouldRetry(retryIf, lastErr)) {
+			stepLogger.Warn().
+				Err(lastErr).
+				Int("attempt", attempt).
+				Msg("Step error is not retryable")
+			break
+		}
+
+		// Retries are shared with the rest of the run
+		if attempt < config.MaxRetries {
+			remaining, ok := budget.take()
+			if !ok {
+				lastErr = fmt.Errorf("%w (%d retries across the run): %w", ErrRetryBudgetExhausted, budget.limit, lastErr)
+				stepLogger.Error().
+					Int("max_total_retries", budget.limit).
+					Msg("Retry budget exhausted, not retrying step")
+				break
+			}
+			if remaining >= 0 {
+				stepLogger.Info().
+					Int("retry_budget_remaining", remaining).
+					Msg("Retry taken from run budget")
+			}
+		}
 	}
 
-	// All retries exhausted
+	// All retries exhausted or the error is not retryable
 	stepExec.Status = gorkflow.StepStatusFailed
-	completedAt := time.Now()
+	completedAt := e.now()
 	stepExec.CompletedAt = &completedAt
 	stepExec.UpdatedAt = completedAt
-	stepExec.Error = &gorkflow.StepError{
-		Message: lastErr.Error(),
-		Code:    gorkflow.ErrCodeExecutionFailed,
-		Attempt: config.MaxRetries,
-	}
+	code, details := stepFailure(lastErr, ctx.Err() == context.Canceled)
 
-	if err := e.store.UpdateStepExecution(ctx, stepExec); err != nil {
-		gorkflow.LogPersistenceError(e.logger, run.RunID, "update_step_execution_failure", err)
+	// A cancelled run says nothing about the step's health
+	if ctx.Err() == nil {
+		breaker.record(breakerConfig, true, completedAt)
 	}
+	stepExec.Error = (&gorkflow.StepError{
+		Message: lastErr.Error(),
+		Code:    code,
+		Attempt: attemptsMade - 1,
+		Details: details,
+	}).WithCause(lastErr)
+
+	writer.final(storeCtx, "update_step_execution_failure")
 
 	stepLogger.Error().
 		Int("max_retries", config.MaxRetries).
 		Int("attempts_made", attemptsMade).
-		Msg("Step failed after all retries exhausted")
+		Msg("Step failed")
 
 	return &StepExecutionResult{
 		StepID:       step.GetID(),
@@ -189,3 +298,265 @@ func (e *Engine) executeStep(
 		AttemptsMade: attemptsMade,
 	}, fmt.Errorf("step %s failed after %d attempts: %w", step.GetID(), attemptsMade, lastErr)
 }
+
+// rejectOpenCircuit records a step that was not executed because its circuit is open
+func (e *Engine) rejectOpenCircuit(
+	ctx context.Context,
+	run *gorkflow.WorkflowRun,
+	step gorkflow.StepExecutor,
+	writer *stepWriter,
+	config *gorkflow.CircuitBreakerConfig,
+) (*StepExecutionResult, error) {
+	err := fmt.Errorf("%w after %d consecutive failures", ErrCircuitOpen, config.FailThreshold)
+
+	completedAt := e.now()
+	stepExec := writer.exec
+	stepExec.Status = gorkflow.StepStatusFailed
+	stepExec.CompletedAt = &completedAt
+	stepExec.UpdatedAt = completedAt
+	stepExec.Error = (&gorkflow.StepError{
+		Message: err.Error(),
+		Code:    gorkflow.ErrCodeCircuitOpen,
+	}).WithCause(err)
+
+	writer.final(ctx, "update_step_execution_circuit_open")
+
+	e.logger.Warn().
+		Str("run_id", run.RunID).
+		Str("step_id", step.GetID()).
+		Int("fail_threshold", config.FailThreshold).
+		Dur("cooldown", config.Cooldown).
+		Msg("Circuit open, step not executed")
+
+	return &StepExecutionResult{
+		StepID: step.GetID(),
+		Error:  err,
+	}, fmt.Errorf("step %s failed: %w", step.GetID(), err)
+}
+
+// executeSteps runs a group of independent steps, concurrently when there is more than one,
+// with at most maxParallel at a time (0 = no limit). It waits for every step and returns
+// their results and errors in the order of steps. The outputs of a concurrent group are saved
+// together once every step has finished, before the steps are recorded as completed.
+func (e *Engine) executeSteps(
+	ctx context.Context,
+	run *gorkflow.WorkflowRun,
+	steps []gorkflow.StepExecutor,
+	inputs [][]byte,
+	outputs gorkflow.StepOutputAccessor,
+	state gorkflow.StateAccessor,
+	customContext any,
+	maxParallel int,
+	budget *retryBudget,
+) ([]*StepExecutionResult, []error) {
+	results := make([]*StepExecutionResult, len(steps))
+	errs := make([]error, len(steps))
+
+	if len(steps) == 1 {
+		results[0], errs[0] = e.executeStep(ctx, run, steps[0], inputs[0], outputs, state, customContext, budget, nil)
+		return results, errs
+	}
+
+	if maxParallel <= 0 || maxParallel > len(steps) {
+		maxParallel = len(steps)
+	}
+	slots := make(chan struct{}, maxParallel)
+	batch := newOutputBatch(len(steps))
+
+	var wg sync.WaitGroup
+	for i, step := range steps {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(i int, step gorkflow.StepExecutor) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			results[i], errs[i] = e.executeStep(ctx, run, step, inputs[i], outputs, state, customContext, budget, batch)
+		}(i, step)
+	}
+	wg.Wait()
+
+	// A step only counts as completed once its output is stored; without it downstream
+	// steps could not read it, so a failed save fails every step of the batch
+	storeCtx := context.WithoutCancel(ctx)
+	if err := e.saveOutputBatch(storeCtx, run.RunID, batch); err != nil {
+		for i, step := range steps {
+			if writer, ok := batch.writers[step.GetID()]; ok {
+				results[i], errs[i] = e.failUnsavedOutput(storeCtx, writer, results[i], err)
+			}
+		}
+	}
+
+	return results, errs
+}
+
+// outputBatch collects the completed steps of a parallel group, whose outputs are saved
+// with one bulk write before their executions are recorded as completed
+type outputBatch struct {
+	mu      sync.Mutex
+	outputs map[string][]byte
+	writers map[string]*stepWriter
+}
+
+func newOutputBatch(size int) *outputBatch {
+	return &outputBatch{
+		outputs: make(map[string][]byte, size),
+		writers: make(map[string]*stepWriter, size),
+	}
+}
+
+func (b *outputBatch) add(writer *stepWriter, output []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.outputs[writer.exec.StepID] = output
+	b.writers[writer.exec.StepID] = writer
+}
+
+// saveOutputBatch writes the outputs collected from a parallel group with one store call and,
+// once they are stored, records the steps' executions as completed
+func (e *Engine) saveOutputBatch(ctx context.Context, runID string, batch *outputBatch) error {
+	if len(batch.outputs) == 0 || e.leaseLost(runID) {
+		return nil
+	}
+
+	saveCtx, cancel := e.persistCtx(ctx)
+	err := e.store.SaveStepOutputs(saveCtx, runID, batch.outputs)
+	cancel()
+	if err != nil {
+		gorkflow.LogPersistenceError(e.logger, runID, "save_step_outputs", err)
+		return fmt.Errorf("failed to save step outputs: %w", err)
+	}
+
+	for _, stepID := range slices.Sorted(maps.Keys(batch.writers)) {
+		batch.writers[stepID].final(ctx, "update_step_execution_success")
+	}
+	return nil
+}
+
+// failUnsavedOutput records a step whose handler succeeded as failed because its output could not be saved
+func (e *Engine) failUnsavedOutput(
+	ctx context.Context,
+	writer *stepWriter,
+	result *StepExecutionResult,
+	err error,
+) (*StepExecutionResult, error) {
+	completedAt := e.now()
+	stepExec := writer.exec
+	stepExec.Status = gorkflow.StepStatusFailed
+	stepExec.Output = nil
+	stepExec.CompletedAt = &completedAt
+	stepExec.UpdatedAt = completedAt
+	stepExec.Error = (&gorkflow.StepError{
+		Message: err.Error(),
+		Code:    gorkflow.ErrCodeInternalError,
+		Attempt: stepExec.Attempt,
+	}).WithCause(err)
+
+	writer.final(ctx, "update_step_execution_failure")
+
+	result.Output = nil
+	result.Error = err
+	return result, fmt.Errorf("step %s failed: %w", stepExec.StepID, err)
+}
+
+// stepFailure returns the error code and details recorded for a failed step
+func stepFailure(err error, cancelled bool) (string, map[string]interface{}) {
+	if cancelled {
+		return gorkflow.ErrCodeCancelled, nil
+	}
+
+	var panicked *panicError
+	switch {
+	case errors.As(err, &panicked):
+		return gorkflow.ErrCodePanic, map[string]interface{}{"stack": string(panicked.stack)}
+	case errors.Is(err, ErrOutputTooLarge):
+		return gorkflow.ErrCodeValidation, nil
+	default:
+		return gorkflow.ErrCodeExecutionFailed, nil
+	}
+}
+
+// stepRedaction returns the JSON paths redacted from the step's stored payloads
+func stepRedaction(step gorkflow.StepExecutor) []string {
+	if provider, ok := step.(interface{ GetRedaction() []string }); ok {
+		return provider.GetRedaction()
+	}
+	return nil
+}
+
+// stepTags returns a copy of the metadata set on the step with WithStepTags
+func stepTags(step gorkflow.StepExecutor) map[string]string {
+	if provider, ok := step.(interface{ GetTags() map[string]string }); ok {
+		return maps.Clone(provider.GetTags())
+	}
+	return nil
+}
+
+// stepOutputVersion returns the version set on the step with WithOutputVersion, 0 when unversioned
+func stepOutputVersion(step gorkflow.StepExecutor) int {
+	if provider, ok := step.(interface{ GetOutputVersion() int }); ok {
+		return provider.GetOutputVersion()
+	}
+	return 0
+}
+
+// stepLogger returns the logger handed to a step, carrying its run and tags
+func (e *Engine) stepLogger(runID string, step gorkflow.StepExecutor) zerolog.Logger {
+	logCtx := gorkflow.StepLogger(e.logger, step.GetID(), step.GetName(), 0).With().Str("run_id", runID)
+	if tags := stepTags(step); len(tags) > 0 {
+		fields := make(map[string]any, len(tags))
+		for key, value := range tags {
+			fields[key] = value
+		}
+		logCtx = logCtx.Dict("step_tags", zerolog.Dict().Fields(fields))
+	}
+	return logCtx.Logger()
+}
+
+// isRawStep reports whether the step passes raw bytes instead of JSON
+func isRawStep(step gorkflow.StepExecutor) bool {
+	provider, ok := step.(interface{ GetRawIO() bool })
+	return ok && provider.GetRawIO()
+}
+
+// stepPayload is the copy of a step payload kept on its execution record. Payloads are
+// redacted, and those of raw steps are left out since the record holds JSON.
+func stepPayload(step gorkflow.StepExecutor, data []byte) json.RawMessage {
+	if isRawStep(step) {
+		return nil
+	}
+	return gorkflow.RedactJSON(data, stepRedaction(step))
+}
+
+// stepCustomContext returns the step's own custom context, falling back to the workflow's
+func stepCustomContext(step gorkflow.StepExecutor, workflowContext any) any {
+	if provider, ok := step.(interface{ GetCustomContext() any }); ok {
+		if stepContext := provider.GetCustomContext(); stepContext != nil {
+			return stepContext
+		}
+	}
+	return workflowContext
+}
+
+// stepWeight returns the step's share of the run's progress, 1.0 unless the step sets a positive weight
+func stepWeight(wf *gorkflow.Workflow, stepID string) float64 {
+	step, err := wf.GetStep(stepID)
+	if err != nil {
+		return 1.0
+	}
+	if provider, ok := step.(interface{ GetWeight() float64 }); ok {
+		if weight := provider.GetWeight(); weight > 0 {
+			return weight
+		}
+	}
+	return 1.0
+}
+
+// withStepTimeout bounds a step attempt by its timeout; steps without one run under ctx alone
+func withStepTimeout(ctx context.Context, timeoutSeconds int) (context.Context, context.CancelFunc) {
+	if timeoutSeconds <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+}
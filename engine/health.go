@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// HealthStatus is a snapshot of the engine for health and readiness checks
+type HealthStatus struct {
+	Accepting  bool   `json:"accepting"`            // False once Shutdown was called and new runs are rejected
+	ActiveRuns int    `json:"activeRuns"`           // Runs executing in this engine, not counting paused ones
+	Capacity   int    `json:"capacity"`             // Most runs executing at once, EngineConfig.MaxConcurrentWorkflows (0 = no limit)
+	StoreOK    bool   `json:"storeOk"`              // True when the store answered its ping, or cannot be pinged
+	StoreError string `json:"storeError,omitempty"` // Why the ping failed
+}
+
+// Healthy reports whether the engine is accepting work and its store is reachable
+func (h HealthStatus) Healthy() bool {
+	return h.Accepting && h.StoreOK
+}
+
+// Health reports whether the engine is accepting work, how many runs it is executing and
+// whether its store is reachable, e.g. for a /health route. The store is pinged when it
+// implements gorkflow.Pinger, bounded by StoreTimeout.
+func (e *Engine) Health(ctx context.Context) HealthStatus {
+	status := HealthStatus{
+		Accepting:  e.accepting(),
+		ActiveRuns: e.executingRuns(),
+		Capacity:   e.config.MaxConcurrentWorkflows,
+		StoreOK:    true,
+	}
+
+	if pinger, ok := e.store.(gorkflow.Pinger); ok {
+		timeout := e.config.StoreTimeout
+		if timeout <= 0 {
+			timeout = DefaultStoreTimeout
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		if err := pinger.Ping(pingCtx); err != nil {
+			status.StoreOK = false
+			status.StoreError = err.Error()
+		}
+	}
+
+	return status
+}
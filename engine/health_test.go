@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPingStore is a store whose ping always fails
+type failingPingStore struct {
+	gorkflow.WorkflowStore
+}
+
+func (s *failingPingStore) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestEngine_Health_ActiveRuns(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	wf, err := builder.NewWorkflow("health", "Health").
+		ThenStep(gorkflow.NewStep("slow", "Slow",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				close(started)
+				<-release
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	health := engine.Health(context.Background())
+	assert.Equal(t, 0, health.ActiveRuns)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.NoError(t, err)
+
+	<-started
+	health = engine.Health(context.Background())
+	assert.True(t, health.Healthy())
+	assert.True(t, health.Accepting)
+	assert.True(t, health.StoreOK)
+	assert.Equal(t, 1, health.ActiveRuns)
+	assert.Equal(t, 10, health.Capacity)
+
+	close(release)
+	waitForCompletion(t, engine, runID, 5*time.Second)
+	assert.Eventually(t, func() bool {
+		return engine.Health(context.Background()).ActiveRuns == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestEngine_Health_Capacity(t *testing.T) {
+	engine := NewEngine(store.NewMemoryStore(), WithConfig(EngineConfig{MaxConcurrentWorkflows: 1}))
+
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	wf, err := builder.NewWorkflow("health", "Health").
+		ThenStep(gorkflow.NewStep("slow", "Slow",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				started <- struct{}{}
+				<-release
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	first, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "first"})
+	require.NoError(t, err)
+	<-started
+
+	health := engine.Health(context.Background())
+	assert.Equal(t, 1, health.ActiveRuns)
+	assert.Equal(t, 1, health.Capacity)
+
+	// The only slot is taken, so no second run is created
+	_, err = engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "second"})
+	assert.ErrorIs(t, err, ErrConcurrencyLimit)
+	assert.True(t, gorkflow.IsConcurrencyError(err))
+	_, err = engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "second"})
+	assert.ErrorIs(t, err, ErrConcurrencyLimit)
+
+	close(release)
+	assert.Equal(t, gorkflow.RunStatusCompleted, waitForCompletion(t, engine, first, 5*time.Second).Status)
+
+	// The slot is free again once the first run finishes
+	assert.Eventually(t, func() bool {
+		return engine.Health(context.Background()).ActiveRuns == 0
+	}, time.Second, 10*time.Millisecond)
+	second, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "second"})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, second.Status)
+}
+
+func TestEngine_Health_Shutdown(t *testing.T) {
+	engine, _ := createTestEngine(t)
+	require.NoError(t, engine.Shutdown(context.Background()))
+
+	health := engine.Health(context.Background())
+	assert.False(t, health.Accepting)
+	assert.False(t, health.Healthy())
+
+	// Not accepting means new runs are rejected
+	wf, err := builder.NewWorkflow("health", "Health").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("noop", "Noop")).
+		Build()
+	require.NoError(t, err)
+	_, err = engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test"})
+	assert.ErrorIs(t, err, ErrEngineShutdown)
+	_, err = engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	assert.ErrorIs(t, err, ErrEngineShutdown)
+}
+
+func TestEngine_Health_StorePingFails(t *testing.T) {
+	_, store := createTestEngine(t)
+	engine := NewEngine(&failingPingStore{WorkflowStore: store})
+
+	health := engine.Health(context.Background())
+	assert.True(t, health.Accepting)
+	assert.False(t, health.StoreOK)
+	assert.Equal(t, "connection refused", health.StoreError)
+	assert.False(t, health.Healthy())
+}
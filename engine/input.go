@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// resolveStepInput returns the serialized input for a step. Steps that declare WithInputFrom
+// receive those steps' outputs; otherwise a step receives the output of its predecessor in the
+// graph, and a join with several predecessors (e.g. after a parallel block) receives a JSON
+// object mapping each predecessor's step ID to its output. The entry step gets the workflow input.
+// Skipped upstream steps contribute JSON null. An empty or null input is replaced by the step's
+// WithInputDefault, and a step's WithInputMapper is applied last.
+func (e *Engine) resolveStepInput(
+	ctx context.Context,
+	wf *gorkflow.Workflow,
+	run *gorkflow.WorkflowRun,
+	step gorkflow.StepExecutor,
+) ([]byte, error) {
+	input, err := e.gatherStepInput(ctx, wf, run, step)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider, ok := step.(interface{ GetInputDefault() any }); ok && provider.GetInputDefault() != nil && isNullInput(input) {
+		input, err = e.codec.Marshal(provider.GetInputDefault())
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize input default for step %s: %w", step.GetID(), err)
+		}
+	}
+
+	provider, ok := step.(interface{ GetInputMapper() gorkflow.InputMapper })
+	if !ok || provider.GetInputMapper() == nil {
+		return input, nil
+	}
+	mapped, err := provider.GetInputMapper()(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map input for step %s: %w", step.GetID(), err)
+	}
+	return mapped, nil
+}
+
+// isNullInput reports whether a resolved input carries no value
+func isNullInput(input []byte) bool {
+	trimmed := bytes.TrimSpace(input)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
+
+// gatherStepInput returns a step's input before its default and input mapper are applied
+func (e *Engine) gatherStepInput(
+	ctx context.Context,
+	wf *gorkflow.Workflow,
+	run *gorkflow.WorkflowRun,
+	step gorkflow.StepExecutor,
+) ([]byte, error) {
+	var sources []string
+	if provider, ok := step.(interface{ GetInputFrom() []string }); ok {
+		sources = provider.GetInputFrom()
+	}
+	if len(sources) == 0 {
+		sources = wf.Graph().Predecessors(step.GetID())
+	}
+
+	switch len(sources) {
+	case 0:
+		// Entry step gets workflow input
+		return run.Input, nil
+
+	case 1:
+		return e.loadUpstreamOutput(ctx, wf, run, sources[0])
+
+	default:
+		inputs := make(map[string]json.RawMessage, len(sources))
+		for _, sourceID := range sources {
+			output, err := e.loadUpstreamOutput(ctx, wf, run, sourceID)
+			if err != nil {
+				return nil, err
+			}
+			inputs[sourceID] = output
+		}
+
+		input, err := e.codec.Marshal(inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize input for step %s: %w", step.GetID(), err)
+		}
+		return input, nil
+	}
+}
+
+// loadUpstreamOutput loads a step's output for use as downstream input.
+// A step that failed with ContinueOnError or was skipped has no output and yields JSON null.
+func (e *Engine) loadUpstreamOutput(ctx context.Context, wf *gorkflow.Workflow, run *gorkflow.WorkflowRun, stepID string) ([]byte, error) {
+	output, err := e.store.LoadStepOutput(ctx, run.RunID, stepID)
+	if err == nil {
+		// Outputs stored by an older deploy are upgraded to the shape the step now returns
+		return gorkflow.UpgradeStepOutput(ctx, e.store, wf, run.RunID, stepID, output)
+	}
+
+	// Check if upstream step had ContinueOnError set
+	upstream, stepErr := wf.GetStep(stepID)
+	if stepErr == nil && upstream.GetConfig().ContinueOnError {
+		e.logger.Warn().
+			Str("run_id", run.RunID).
+			Str("prev_step_id", stepID).
+			Msg("Previous step output not found, but ContinueOnError is true. Passing empty input.")
+		// Pass JSON null so unmarshaling works (results in zero value)
+		return []byte("null"), nil
+	}
+
+	// Steps on an inactive branch never produce output
+	if exec, execErr := e.store.GetStepExecution(ctx, run.RunID, stepID); execErr == nil && exec.Status == gorkflow.StepStatusSkipped {
+		return []byte("null"), nil
+	}
+
+	return nil, fmt.Errorf("failed to load output of step %s: %w", stepID, err)
+}
@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type joinInput struct {
+	Step2 DiscoverOutput `json:"step2"`
+	Step3 EnrichOutput   `json:"step3"`
+}
+
+func TestEngine_InputFrom_Diamond(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	step1 := gorkflow.NewStep("step1", "Step 1", discoverCompanies)
+	step2 := gorkflow.NewStep("step2", "Step 2",
+		func(ctx *gorkflow.StepContext, input DiscoverOutput) (DiscoverOutput, error) {
+			return DiscoverOutput{Companies: input.Companies[:1], Count: 1}, nil
+		},
+		gorkflow.WithInputFrom("step1"),
+	)
+	step3 := gorkflow.NewStep("step3", "Step 3",
+		func(ctx *gorkflow.StepContext, input DiscoverOutput) (EnrichOutput, error) {
+			enriched := make(map[string]interface{})
+			for _, company := range input.Companies {
+				enriched[company] = true
+			}
+			return EnrichOutput{Enriched: enriched}, nil
+		},
+		gorkflow.WithInputFrom("step1"),
+	)
+	join := gorkflow.NewStep("join", "Join",
+		func(ctx *gorkflow.StepContext, input joinInput) (FilterOutput, error) {
+			filtered := append([]string{}, input.Step2.Companies...)
+			for company := range input.Step3.Enriched {
+				filtered = append(filtered, company)
+			}
+			return FilterOutput{Filtered: filtered}, nil
+		},
+		gorkflow.WithInputFrom("step2", "step3"),
+	)
+
+	wf, err := builder.NewWorkflow("diamond_test", "Diamond Test").
+		ThenStep(step1).
+		Parallel(step2, step3).
+		ThenStep(join).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+
+	run := waitForCompletion(t, engine, runID, 10*time.Second)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	var output FilterOutput
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Len(t, output.Filtered, 4)
+	assert.ElementsMatch(t, []string{"CompanyA", "CompanyA", "CompanyB", "CompanyC"}, output.Filtered)
+
+	// The join received both declared outputs keyed by step ID
+	exec, err := engine.store.GetStepExecution(context.Background(), runID, "join")
+	require.NoError(t, err)
+	var input map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(exec.Input, &input))
+	assert.Contains(t, input, "step2")
+	assert.Contains(t, input, "step3")
+}
+
+func TestEngine_InputFrom_InvalidSource(t *testing.T) {
+	step1 := gorkflow.NewStep("step1", "Step 1", discoverCompanies)
+	step2 := gorkflow.NewStep("step2", "Step 2", discoverCompanies, gorkflow.WithInputFrom("missing"))
+
+	_, err := builder.NewWorkflow("invalid_source", "Invalid Source").
+		ThenStep(step1).
+		ThenStep(step2).
+		Build()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing")
+
+	// Sources must run before the consuming step
+	step3 := gorkflow.NewStep("step3", "Step 3", discoverCompanies, gorkflow.WithInputFrom("step4"))
+	step4 := gorkflow.NewStep("step4", "Step 4", discoverCompanies)
+
+	_, err = builder.NewWorkflow("downstream_source", "Downstream Source").
+		ThenStep(step3).
+		ThenStep(step4).
+		Build()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not run before")
+}
+
+type countInput struct {
+	N int `json:"n"`
+}
+
+func TestEngine_InputMapper(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	// Reshape step1's {"count": ...} into step2's {"n": ...}
+	toCount := func(prev []byte) ([]byte, error) {
+		var out DiscoverOutput
+		if err := json.Unmarshal(prev, &out); err != nil {
+			return nil, err
+		}
+		return json.Marshal(countInput{N: out.Count})
+	}
+
+	var received countInput
+	step2 := gorkflow.NewStep("step2", "Step 2",
+		func(ctx *gorkflow.StepContext, input countInput) (countInput, error) {
+			received = input
+			return input, nil
+		},
+		gorkflow.WithInputMapper(toCount),
+	)
+
+	wf, err := builder.NewWorkflow("mapper_test", "Mapper Test").
+		ThenStep(gorkflow.NewStep("step1", "Step 1", discoverCompanies)).
+		ThenStep(step2).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.Equal(t, 3, received.N)
+
+	// The stored input is the mapped one
+	exec, err := engine.GetStepExecution(context.Background(), run.RunID, "step2")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"n": 3}`, string(exec.Input))
+}
+
+func TestEngine_InputMapper_Error(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	step2 := gorkflow.NewStep("step2", "Step 2", enrichCompanies,
+		gorkflow.WithInputMapper(func(prev []byte) ([]byte, error) {
+			return nil, errors.New("unexpected shape")
+		}),
+	)
+
+	wf, err := builder.NewWorkflow("mapper_error", "Mapper Error").
+		ThenStep(gorkflow.NewStep("step1", "Step 1", discoverCompanies)).
+		ThenStep(step2).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.Error(t, err)
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	assert.Equal(t, "step2", run.Error.Step)
+	assert.Contains(t, run.Error.Message, "unexpected shape")
+}
+
+func TestEngine_InputDefault_SkippedUpstream(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	// Skipped, the conditional step outputs the zero value of its pointer output: null
+	enrich := gorkflow.NewStep("enrich", "Enrich",
+		func(ctx *gorkflow.StepContext, input DiscoverInput) (*DiscoverInput, error) {
+			return &input, nil
+		},
+	)
+	never := func(ctx *gorkflow.StepContext) (bool, error) { return false, nil }
+
+	var received DiscoverInput
+	report := gorkflow.NewStep("report", "Report",
+		func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+			received = input
+			return input, nil
+		},
+		gorkflow.WithInputDefault(DiscoverInput{Query: "fallback", Limit: 3}),
+	)
+
+	wf, err := builder.NewWorkflow("input_default", "Input Default").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("start", "Start")).
+		ThenStepIf(enrich, never, nil).
+		ThenStep(report).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	assert.Equal(t, DiscoverInput{Query: "fallback", Limit: 3}, received)
+
+	// The recorded input is the default, not null
+	exec, err := engine.GetStepExecution(context.Background(), run.RunID, "report")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"query":"fallback","limit":3}`, string(exec.Input))
+}
+
+func TestEngine_InputDefault_IgnoredWithInput(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("input_default_unused", "Input Default Unused").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("start", "Start")).
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("report", "Report",
+			gorkflow.WithInputDefault(DiscoverInput{Query: "fallback"}),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+
+	var output DiscoverInput
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Equal(t, DiscoverInput{Query: "test", Limit: 10}, output)
+}
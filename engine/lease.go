@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRunLeased is returned when another engine holds the lease on a run
+var ErrRunLeased = errors.New("workflow run is leased by another engine")
+
+// ErrLeaseLost is returned by a run's execution when another engine took over its lease midway
+var ErrLeaseLost = errors.New("workflow run lease taken over by another engine")
+
+// WithEngineID sets the owner name this engine leases runs under (default a random UUID).
+// Engines sharing a store must use distinct IDs.
+func WithEngineID(id string) EngineOption {
+	return func(e *Engine) {
+		if id != "" {
+			e.engineID = id
+		}
+	}
+}
+
+// WithRunLease makes the engine lease each run before executing it and renew the lease every
+// ttl/3 while it runs, so engines sharing a store never execute the same run twice. A run whose
+// engine stops is picked up by others once ttl passes without a renewal. An engine that finds its
+// lease taken over, e.g. after missing renewals, cancels the run and stops writing it.
+func WithRunLease(ttl time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.leaseTTL = ttl
+	}
+}
+
+// EngineID returns the owner name this engine leases runs under
+func (e *Engine) EngineID() string {
+	return e.engineID
+}
+
+// leaseRun takes or renews this engine's lease on a run. It always succeeds when leasing is off.
+func (e *Engine) leaseRun(ctx context.Context, runID string) (bool, error) {
+	if e.leaseTTL <= 0 {
+		return true, nil
+	}
+
+	leased, err := e.store.LeaseRun(ctx, runID, e.engineID, e.leaseTTL)
+	if err != nil {
+		return false, fmt.Errorf("failed to lease run: %w", err)
+	}
+	return leased, nil
+}
+
+// holdLease leases the run and keeps renewing it in the background until the returned func is called.
+// A renewal refused because another engine took over the lease cancels the run.
+func (e *Engine) holdLease(ctx context.Context, runID string) (func(), error) {
+	leased, err := e.leaseRun(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if !leased {
+		return nil, ErrRunLeased
+	}
+	if e.leaseTTL <= 0 {
+		return func() {}, nil
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(max(e.leaseTTL/3, time.Millisecond))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				persistCtx, cancel := e.persistCtx(ctx)
+				leased, err := e.leaseRun(persistCtx, runID)
+				cancel()
+				if err != nil {
+					e.logger.Warn().Err(err).Str("run_id", runID).Msg("Failed to renew run lease")
+				} else if !leased {
+					e.logger.Error().Str("run_id", runID).Msg("Run lease taken over by another engine, cancelling run")
+					e.loseLease(runID)
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}, nil
+}
@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_RunLease_HeldWhileExecuting(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+	WithEngineID("engine-a")(engine)
+	WithRunLease(time.Minute)(engine)
+
+	wf, err := builder.NewWorkflow("leased", "Leased").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.Equal(t, "engine-a", engine.EngineID())
+
+	// The lease outlives the run until its TTL passes
+	leased, err := wfStore.LeaseRun(context.Background(), run.RunID, "engine-b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, leased)
+}
+
+func TestEngine_RecoverOrphans_SkipsLeasedRuns(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+	WithRunLease(time.Minute)(engine)
+	registerOrphansWorkflow(t, engine)
+	defer engine.Shutdown(context.Background())
+
+	seedRunningRun(t, wfStore, "stale", "orphans", time.Now().Add(-time.Hour))
+	leased, err := wfStore.LeaseRun(context.Background(), "stale", "other-engine", time.Minute)
+	require.NoError(t, err)
+	require.True(t, leased)
+
+	recovered, err := engine.RecoverOrphans(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, recovered)
+
+	run, err := engine.GetRun(context.Background(), "stale")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusRunning, run.Status)
+}
+
+// partitionedLeaseStore fails one owner's lease calls while partitioned, as if it lost its connection
+type partitionedLeaseStore struct {
+	gorkflow.WorkflowStore
+	owner       string
+	partitioned atomic.Bool
+}
+
+func (s *partitionedLeaseStore) LeaseRun(ctx context.Context, runID, owner string, ttl time.Duration) (bool, error) {
+	if owner == s.owner && s.partitioned.Load() {
+		return false, errors.New("connection reset")
+	}
+	return s.WorkflowStore.LeaseRun(ctx, runID, owner, ttl)
+}
+
+func TestEngine_RunLease_LostToAnotherEngine(t *testing.T) {
+	wfStore := &partitionedLeaseStore{WorkflowStore: store.NewMemoryStore(), owner: "engine-a"}
+	ttl := 60 * time.Millisecond
+	engineA := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithEngineID("engine-a"), WithRunLease(ttl))
+	engineB := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithEngineID("engine-b"), WithRunLease(ttl))
+
+	started := make(chan struct{})
+	var nextRan atomic.Bool
+	wf, err := builder.NewWorkflow("lease_lost", "Lease Lost").
+		ThenStep(gorkflow.NewStep("slow", "Slow",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				close(started)
+				<-ctx.Done()
+				return input, ctx.Err()
+			},
+		)).
+		ThenStep(gorkflow.NewStep("next", "Next",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				nextRan.Store(true)
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	type result struct {
+		run *gorkflow.WorkflowRun
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		run, err := engineA.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "lease"})
+		done <- result{run, err}
+	}()
+	<-started
+
+	// Engine A misses its renewals until the lease expires, and engine B takes the run over
+	wfStore.partitioned.Store(true)
+	time.Sleep(2 * ttl)
+	runs, err := engineB.ListRuns(context.Background(), gorkflow.RunFilter{WorkflowID: "lease_lost"})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	runID := runs[0].RunID
+	leased, err := wfStore.LeaseRun(context.Background(), runID, engineB.EngineID(), time.Minute)
+	require.NoError(t, err)
+	require.True(t, leased)
+	wfStore.partitioned.Store(false)
+
+	// Engine A's next renewal is refused, which cancels its execution
+	var res result
+	select {
+	case res = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("engine A kept executing after losing its lease")
+	}
+	assert.ErrorIs(t, res.err, ErrLeaseLost)
+	assert.False(t, nextRan.Load())
+
+	// Engine A wrote nothing after losing the lease, leaving the run to engine B
+	stored, err := engineB.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusRunning, stored.Status)
+
+	exec, err := engineB.GetStepExecution(context.Background(), runID, "slow")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.StepStatusRunning, exec.Status)
+}
@@ -0,0 +1,26 @@
+package engine
+
+import "github.com/sicko7947/gorkflow"
+
+// StepHandlerFunc executes a step attempt with serialized input and output
+type StepHandlerFunc func(ctx *gorkflow.StepContext, input []byte) ([]byte, error)
+
+// StepMiddleware wraps step execution. The step ID and attempt are available on the
+// StepContext; middleware may short-circuit by not calling next or transform its output.
+type StepMiddleware func(next StepHandlerFunc) StepHandlerFunc
+
+// WithStepMiddleware adds middleware around every step attempt
+// Middleware runs in the order given, the first being the outermost
+func WithStepMiddleware(middleware ...StepMiddleware) EngineOption {
+	return func(e *Engine) {
+		e.middleware = append(e.middleware, middleware...)
+	}
+}
+
+// wrapStep applies the engine's middleware chain to a step handler
+func (e *Engine) wrapStep(handler StepHandlerFunc) StepHandlerFunc {
+	for i := len(e.middleware) - 1; i >= 0; i-- {
+		handler = e.middleware[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_StepMiddleware_RecordsAttempts(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+
+	recorder := func(next StepHandlerFunc) StepHandlerFunc {
+		return func(ctx *gorkflow.StepContext, input []byte) ([]byte, error) {
+			mu.Lock()
+			calls = append(calls, fmt.Sprintf("%s:%d", ctx.StepID, ctx.Attempt))
+			mu.Unlock()
+			return next(ctx, input)
+		}
+	}
+
+	engine := NewEngine(store.NewMemoryStore(),
+		WithLogger(zerolog.New(os.Stdout)),
+		WithStepMiddleware(recorder),
+	)
+
+	attempts := int32(0)
+	flakyStep := gorkflow.NewStep("flaky", "Flaky Step",
+		func(ctx *gorkflow.StepContext, input DiscoverOutput) (DiscoverOutput, error) {
+			if atomic.AddInt32(&attempts, 1) < 2 {
+				return DiscoverOutput{}, errors.New("temporary failure")
+			}
+			return input, nil
+		},
+		gorkflow.WithRetries(2),
+		gorkflow.WithRetryDelay(10*time.Millisecond),
+	)
+
+	wf, err := builder.NewWorkflow("middleware_test", "Middleware Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(flakyStep).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	assert.Equal(t, []string{"discover:0", "flaky:0", "flaky:1"}, calls)
+}
+
+func TestEngine_StepMiddleware_RewritesOutput(t *testing.T) {
+	var order []string
+
+	tagging := func(name string) StepMiddleware {
+		return func(next StepHandlerFunc) StepHandlerFunc {
+			return func(ctx *gorkflow.StepContext, input []byte) ([]byte, error) {
+				order = append(order, name)
+				return next(ctx, input)
+			}
+		}
+	}
+
+	rewrite := func(next StepHandlerFunc) StepHandlerFunc {
+		return func(ctx *gorkflow.StepContext, input []byte) ([]byte, error) {
+			output, err := next(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+
+			var result DiscoverOutput
+			if err := json.Unmarshal(output, &result); err != nil {
+				return nil, err
+			}
+			result.Companies = append(result.Companies, "Injected")
+			result.Count = len(result.Companies)
+			return json.Marshal(result)
+		}
+	}
+
+	engine := NewEngine(store.NewMemoryStore(),
+		WithLogger(zerolog.New(os.Stdout)),
+		WithStepMiddleware(tagging("outer"), tagging("inner")),
+		WithStepMiddleware(rewrite),
+	)
+
+	wf, err := builder.NewWorkflow("middleware_test", "Middleware Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"outer", "inner"}, order)
+
+	var output DiscoverOutput
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Equal(t, 4, output.Count)
+	assert.Contains(t, output.Companies, "Injected")
+}
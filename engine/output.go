@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// ErrRunNotCompleted is returned by GetRunOutput for a run that has not completed
+var ErrRunNotCompleted = errors.New("workflow run not completed")
+
+// GetRunOutput fetches a completed run and unmarshals its output into T with the engine's codec
+func GetRunOutput[T any](ctx context.Context, e *Engine, runID string) (T, error) {
+	var output T
+
+	run, err := e.store.GetRun(ctx, runID)
+	if err != nil {
+		return output, fmt.Errorf("failed to get run: %w", err)
+	}
+
+	if run.Status != gorkflow.RunStatusCompleted {
+		return output, fmt.Errorf("%w: run %s is %s", ErrRunNotCompleted, runID, run.Status)
+	}
+	if len(run.Output) == 0 {
+		return output, fmt.Errorf("run %s has no output", runID)
+	}
+
+	if err := e.codec.Unmarshal(run.Output, &output); err != nil {
+		return output, fmt.Errorf("failed to unmarshal output of run %s: %w", runID, err)
+	}
+	return output, nil
+}
+
+// WithInlineStepOutput sets whether a completed step's output is also written onto its
+// StepExecution record (default true). The canonical copy read by downstream steps is always
+// saved; turning the inline copy off halves the storage of large outputs, and
+// GetStepExecutionsWithOutputs fills it back in when needed.
+func WithInlineStepOutput(enabled bool) EngineOption {
+	return func(e *Engine) {
+		e.omitInlineOutput = !enabled
+	}
+}
+
+// inlineOutput returns the output recorded on a step's execution, nil when inline outputs are off
+func (e *Engine) inlineOutput(step gorkflow.StepExecutor, output []byte) json.RawMessage {
+	if e.omitInlineOutput {
+		return nil
+	}
+	return stepPayload(step, output)
+}
+
+// GetStepExecutionsWithOutputs retrieves all step executions for a run, loading the output of
+// completed executions recorded without one (see WithInlineStepOutput) with one batch read.
+// Loaded outputs are redacted like inline ones, using the steps of wf.
+func (e *Engine) GetStepExecutionsWithOutputs(ctx context.Context, wf *gorkflow.Workflow, runID string) ([]*gorkflow.StepExecution, error) {
+	executions, err := e.store.ListStepExecutions(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := make(map[string]gorkflow.StepExecutor)
+	var missing []string
+	for _, exec := range executions {
+		if exec.Status != gorkflow.StepStatusCompleted || len(exec.Output) > 0 {
+			continue
+		}
+		step, err := wf.GetStep(exec.StepID)
+		if err != nil || isRawStep(step) {
+			continue
+		}
+		steps[exec.StepID] = step
+		missing = append(missing, exec.StepID)
+	}
+	if len(missing) == 0 {
+		return executions, nil
+	}
+
+	outputs, err := gorkflow.LoadStepOutputs(ctx, e.store, runID, missing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load step outputs: %w", err)
+	}
+
+	for _, exec := range executions {
+		if output, ok := outputs[exec.StepID]; ok && steps[exec.StepID] != nil {
+			exec.Output = stepPayload(steps[exec.StepID], output)
+		}
+	}
+	return executions, nil
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRunOutput(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("typed_output", "Typed Output").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+
+	output, err := GetRunOutput[DiscoverOutput](context.Background(), engine, run.RunID)
+	require.NoError(t, err)
+	assert.Equal(t, DiscoverOutput{Companies: []string{"CompanyA", "CompanyB", "CompanyC"}, Count: 3}, output)
+
+	_, err = GetRunOutput[DiscoverOutput](context.Background(), engine, "missing")
+	assert.Error(t, err)
+}
+
+func TestGetRunOutput_NotCompleted(t *testing.T) {
+	engine, _ := createTestEngine(t)
+	defer engine.Shutdown(context.Background())
+
+	wf, err := builder.NewWorkflow("typed_output_pending", "Typed Output Pending").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.ScheduleWorkflow(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	_, err = GetRunOutput[DiscoverOutput](context.Background(), engine, runID)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrRunNotCompleted))
+	assert.Contains(t, err.Error(), "is PENDING")
+}
+
+type credentials struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+func TestEngine_WithInlineStepOutput_Disabled(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	engine := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithInlineStepOutput(false))
+
+	wf, err := builder.NewWorkflow("no_inline_output", "No Inline Output").
+		ThenStep(gorkflow.NewStep("login", "Login",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (credentials, error) {
+				return credentials{User: input.Query, Password: "hunter2"}, nil
+			},
+			gorkflow.WithRedaction([]string{"password"}),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "alice"})
+	require.NoError(t, err)
+
+	// The execution record carries no output
+	executions, err := engine.GetStepExecutions(context.Background(), run.RunID)
+	require.NoError(t, err)
+	require.Len(t, executions, 1)
+	assert.Equal(t, gorkflow.StepStatusCompleted, executions[0].Status)
+	assert.Empty(t, executions[0].Output)
+
+	// The canonical copy is still saved
+	output, err := wfStore.LoadStepOutput(context.Background(), run.RunID, "login")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"user":"alice","password":"hunter2"}`, string(output))
+
+	// Hydrated on demand, redacted like an inline output
+	executions, err = engine.GetStepExecutionsWithOutputs(context.Background(), wf, run.RunID)
+	require.NoError(t, err)
+	require.Len(t, executions, 1)
+	assert.JSONEq(t, `{"user":"alice","password":"[REDACTED]"}`, string(executions[0].Output))
+}
+
+func TestEngine_WithInlineStepOutput_Default(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("inline_output", "Inline Output").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("echo", "Echo")).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.NoError(t, err)
+
+	exec, err := engine.GetStepExecution(context.Background(), run.RunID, "echo")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"query":"test","limit":0}`, string(exec.Output))
+}
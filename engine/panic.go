@@ -0,0 +1,24 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStepPanicked is wrapped by the error recorded for a step handler that panicked,
+// so retry predicates can tell panics apart from returned errors
+var ErrStepPanicked = errors.New("step panicked")
+
+// panicError carries a recovered panic and the stack of the goroutine that panicked
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrStepPanicked, e.value)
+}
+
+func (e *panicError) Unwrap() error {
+	return ErrStepPanicked
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runPanickingWorkflow(t *testing.T, opts ...gorkflow.StepOption) (*gorkflow.StepExecution, int32) {
+	engine, _ := createTestEngine(t)
+
+	var calls atomic.Int32
+	opts = append([]gorkflow.StepOption{gorkflow.WithRetries(2), gorkflow.WithRetryDelay(time.Millisecond)}, opts...)
+
+	wf, err := builder.NewWorkflow("panic_test", "Panic Test").
+		ThenStep(gorkflow.NewStep("explode", "Explode",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				calls.Add(1)
+				var companies map[string]int
+				companies[input.Query]++ // nil map write
+				return DiscoverOutput{}, nil
+			},
+			opts...,
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech"})
+	require.Error(t, err)
+	require.Equal(t, gorkflow.RunStatusFailed, run.Status)
+
+	exec, err := engine.GetStepExecution(context.Background(), run.RunID, "explode")
+	require.NoError(t, err)
+	return exec, calls.Load()
+}
+
+func TestEngine_StepPanic(t *testing.T) {
+	exec, calls := runPanickingWorkflow(t)
+
+	// Panics are retried like any other failed attempt
+	assert.Equal(t, int32(3), calls)
+	assert.Len(t, exec.Attempts, 3)
+
+	require.NotNil(t, exec.Error)
+	assert.Equal(t, gorkflow.ErrCodePanic, exec.Error.Code)
+	assert.Contains(t, exec.Error.Message, "step panicked: assignment to entry in nil map")
+
+	stack, ok := exec.Error.Details["stack"].(string)
+	require.True(t, ok, "details should hold the stack")
+	assert.Contains(t, stack, "goroutine")
+	assert.Contains(t, stack, "panic_test.go")
+}
+
+func TestEngine_StepPanic_RetryIf(t *testing.T) {
+	exec, calls := runPanickingWorkflow(t, gorkflow.WithRetryIf(func(err error) bool {
+		return !errors.Is(err, ErrStepPanicked)
+	}))
+
+	assert.Equal(t, int32(1), calls)
+	require.NotNil(t, exec.Error)
+	assert.Equal(t, gorkflow.ErrCodePanic, exec.Error.Code)
+}
@@ -0,0 +1,285 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type branchOutput struct {
+	Branch string `json:"branch"`
+	Query  string `json:"query"`
+}
+
+type branchJoinInput struct {
+	BranchA branchOutput `json:"branchA"`
+	BranchB branchOutput `json:"branchB"`
+}
+
+func TestEngine_ParallelJoin(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	newBranch := func(id string) gorkflow.StepExecutor {
+		return gorkflow.NewStep(id, id,
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (branchOutput, error) {
+				return branchOutput{Branch: id, Query: input.Query}, nil
+			},
+		)
+	}
+
+	var fromOutputs branchOutput
+	join := gorkflow.NewStep("join", "Join",
+		func(ctx *gorkflow.StepContext, input branchJoinInput) (FilterOutput, error) {
+			// Branch outputs are also available individually
+			if err := ctx.Outputs.GetOutput("branchB", &fromOutputs); err != nil {
+				return FilterOutput{}, err
+			}
+			return FilterOutput{Filtered: []string{input.BranchA.Branch, input.BranchB.Branch}}, nil
+		},
+	)
+
+	wf, err := builder.NewWorkflow("parallel_join", "Parallel Join").
+		ThenStep(gorkflow.NewStep("start", "Start",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				return input, nil
+			},
+		)).
+		ParallelWithLimit(2, newBranch("branchA"), newBranch("branchB")).
+		ThenStep(join).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 5})
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	// Both branches received the start step's output, not each other's
+	var output FilterOutput
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Equal(t, []string{"branchA", "branchB"}, output.Filtered)
+	assert.Equal(t, branchOutput{Branch: "branchB", Query: "tech"}, fromOutputs)
+
+	// The join's recorded input is the aggregate keyed by step ID
+	exec, err := engine.store.GetStepExecution(context.Background(), run.RunID, "join")
+	require.NoError(t, err)
+	var input map[string]branchOutput
+	require.NoError(t, json.Unmarshal(exec.Input, &input))
+	assert.Equal(t, map[string]branchOutput{
+		"branchA": {Branch: "branchA", Query: "tech"},
+		"branchB": {Branch: "branchB", Query: "tech"},
+	}, input)
+}
+
+func TestEngine_ParallelWithLimit(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var running, maxRunning int32
+	newBranch := func(id string) gorkflow.StepExecutor {
+		return gorkflow.NewStep(id, id,
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				current := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					seen := atomic.LoadInt32(&maxRunning)
+					if current <= seen || atomic.CompareAndSwapInt32(&maxRunning, seen, current) {
+						break
+					}
+				}
+				time.Sleep(100 * time.Millisecond)
+				return input, nil
+			},
+		)
+	}
+
+	branches := make([]gorkflow.StepExecutor, 4)
+	for i := range branches {
+		branches[i] = newBranch(fmt.Sprintf("branch%d", i))
+	}
+
+	wf, err := builder.NewWorkflow("parallel_limit", "Parallel Limit").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ParallelWithLimit(2, branches...).
+		Build()
+	require.NoError(t, err)
+
+	started := time.Now()
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 5})
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	// Four 100ms branches, two at a time
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+	assert.GreaterOrEqual(t, time.Since(started), 200*time.Millisecond)
+	assert.Less(t, time.Since(started), 400*time.Millisecond)
+}
+
+func TestEngine_ParallelSharedAccessors(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	const branches = 8
+	branchIDs := make([]string, branches)
+	steps := make([]gorkflow.StepExecutor, branches)
+	for i := range branches {
+		id := fmt.Sprintf("branch%d", i)
+		branchIDs[i] = id
+		steps[i] = gorkflow.NewStep(id, id,
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (branchOutput, error) {
+				// Branches share the run's accessors: write distinct keys, read shared outputs
+				for round := range 20 {
+					if err := ctx.State.Set(fmt.Sprintf("%s/%d", id, round), round); err != nil {
+						return branchOutput{}, err
+					}
+					var start DiscoverInput
+					if err := ctx.Outputs.GetOutput("start", &start); err != nil {
+						return branchOutput{}, err
+					}
+					for _, other := range branchIDs {
+						ctx.Outputs.HasOutput(other)
+					}
+					if _, err := gorkflow.GetTyped[int](ctx.State, fmt.Sprintf("%s/%d", id, round)); err != nil {
+						return branchOutput{}, err
+					}
+				}
+				return branchOutput{Branch: id, Query: input.Query}, nil
+			},
+		)
+	}
+
+	var keys int
+	join := gorkflow.NewStep("join", "Join",
+		func(ctx *gorkflow.StepContext, input map[string]branchOutput) (FilterOutput, error) {
+			state, err := ctx.State.GetAll()
+			keys = len(state)
+			return FilterOutput{}, err
+		},
+	)
+
+	wf, err := builder.NewWorkflow("parallel_accessors", "Parallel Accessors").
+		ThenStep(gorkflow.NewStep("start", "Start",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				return input, nil
+			},
+		)).
+		ParallelWithLimit(branches, steps...).
+		ThenStep(join).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.Equal(t, branches*20, keys)
+}
+
+// outputSaveStore records how step outputs reach the wrapped store
+type outputSaveStore struct {
+	gorkflow.WorkflowStore
+	mu         sync.Mutex
+	commits    []string
+	batchSaves [][]string
+}
+
+func (s *outputSaveStore) CommitStepResult(ctx context.Context, exec *gorkflow.StepExecution, output []byte) error {
+	s.mu.Lock()
+	s.commits = append(s.commits, exec.StepID)
+	s.mu.Unlock()
+	return s.WorkflowStore.CommitStepResult(ctx, exec, output)
+}
+
+func (s *outputSaveStore) SaveStepOutputs(ctx context.Context, runID string, outputs map[string][]byte) error {
+	s.mu.Lock()
+	s.batchSaves = append(s.batchSaves, slices.Sorted(maps.Keys(outputs)))
+	s.mu.Unlock()
+	return s.WorkflowStore.SaveStepOutputs(ctx, runID, outputs)
+}
+
+func TestEngine_Parallel_SavesOutputsTogether(t *testing.T) {
+	wfStore := &outputSaveStore{WorkflowStore: store.NewMemoryStore()}
+	engine := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)))
+
+	newBranch := func(id string) gorkflow.StepExecutor {
+		return gorkflow.NewStep(id, id,
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (branchOutput, error) {
+				return branchOutput{Branch: id, Query: input.Query}, nil
+			},
+		)
+	}
+
+	wf, err := builder.NewWorkflow("parallel_save", "Parallel Save").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("start", "Start")).
+		Parallel(newBranch("branchA"), newBranch("branchB"), newBranch("branchC")).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech"})
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	// The sequential step commits on its own; the branches are saved in one call
+	assert.Equal(t, []string{"start"}, wfStore.commits)
+	assert.Equal(t, [][]string{{"branchA", "branchB", "branchC"}}, wfStore.batchSaves)
+
+	for _, stepID := range []string{"branchA", "branchB", "branchC"} {
+		exec, err := engine.GetStepExecution(context.Background(), run.RunID, stepID)
+		require.NoError(t, err)
+		assert.Equal(t, gorkflow.StepStatusCompleted, exec.Status)
+
+		var output branchOutput
+		data, err := wfStore.LoadStepOutput(context.Background(), run.RunID, stepID)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &output))
+		assert.Equal(t, branchOutput{Branch: stepID, Query: "tech"}, output)
+	}
+}
+
+// failingOutputSaveStore fails every bulk output save
+type failingOutputSaveStore struct {
+	gorkflow.WorkflowStore
+}
+
+func (s *failingOutputSaveStore) SaveStepOutputs(ctx context.Context, runID string, outputs map[string][]byte) error {
+	return errors.New("throttled")
+}
+
+func TestEngine_Parallel_FailedOutputSaveFailsSteps(t *testing.T) {
+	engine := NewEngine(&failingOutputSaveStore{WorkflowStore: store.NewMemoryStore()}, WithLogger(zerolog.New(os.Stdout)))
+
+	newBranch := func(id string) gorkflow.StepExecutor {
+		return gorkflow.PassthroughStep[DiscoverInput](id, id)
+	}
+
+	wf, err := builder.NewWorkflow("parallel_save_failure", "Parallel Save Failure").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("start", "Start")).
+		Parallel(newBranch("branchA"), newBranch("branchB")).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech"})
+	require.Error(t, err)
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+
+	// No branch is left COMPLETED without its output
+	for _, stepID := range []string{"branchA", "branchB"} {
+		exec, err := engine.GetStepExecution(context.Background(), run.RunID, stepID)
+		require.NoError(t, err)
+		assert.Equal(t, gorkflow.StepStatusFailed, exec.Status)
+		require.NotNil(t, exec.Error)
+		assert.Equal(t, gorkflow.ErrCodeInternalError, exec.Error.Code)
+		assert.Contains(t, exec.Error.Message, "throttled")
+	}
+}
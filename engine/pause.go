@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// Pause stops a running workflow before its next step. The step in progress finishes, and the run
+// stays PAUSED, keeping its progress, until Resume is called. Paused runs do not count as RUNNING.
+// A run executing in another engine sees the pause within CompletionPollInterval, so it may start
+// another step first.
+func (e *Engine) Pause(ctx context.Context, runID string) error {
+	run, err := e.store.GetRun(ctx, runID)
+	if err != nil {
+		return fmt.Errorf("failed to get run: %w", err)
+	}
+
+	if run.Status != gorkflow.RunStatusRunning {
+		return fmt.Errorf("cannot pause workflow in %s state", run.Status)
+	}
+
+	// Only the status is written, so progress saved meanwhile is kept, and a run finished
+	// meanwhile is not reopened
+	if err := e.store.UpdateRunStatus(ctx, runID, gorkflow.RunStatusPaused, nil); err != nil {
+		return fmt.Errorf("failed to update run on pause: %w", err)
+	}
+
+	e.pauseActiveRun(runID)
+	return nil
+}
+
+// Resume continues a paused workflow with its next step
+func (e *Engine) Resume(ctx context.Context, runID string) error {
+	run, err := e.store.GetRun(ctx, runID)
+	if err != nil {
+		return fmt.Errorf("failed to get run: %w", err)
+	}
+
+	if run.Status != gorkflow.RunStatusPaused {
+		return fmt.Errorf("cannot resume workflow in %s state", run.Status)
+	}
+
+	if err := e.store.UpdateRunStatus(ctx, runID, gorkflow.RunStatusRunning, nil); err != nil {
+		return fmt.Errorf("failed to update run on resume: %w", err)
+	}
+
+	e.resumeActiveRun(runID)
+	return nil
+}
+
+// pauseRequested reports whether the run was paused. Pauses through this engine are seen at once
+// without a store read; pauses through another engine are read from the store when polls allows.
+func (e *Engine) pauseRequested(ctx context.Context, run *gorkflow.WorkflowRun, polls *progressThrottle) bool {
+	if e.resumeSignal(run.RunID) != nil {
+		return true
+	}
+	if !polls.allow(e.now()) {
+		return false
+	}
+
+	stored, err := e.store.GetRun(ctx, run.RunID)
+	return err == nil && stored.Status == gorkflow.RunStatusPaused
+}
+
+// waitForResume blocks a paused run between steps. Runs paused through this engine are woken by
+// Resume; others are re-read from the store every CompletionPollInterval. It returns early once
+// ctx ends, leaving the caller to cancel or time out the run.
+func (e *Engine) waitForResume(ctx context.Context, run *gorkflow.WorkflowRun) {
+	resume := e.resumeSignal(run.RunID)
+
+	run.UpdatedAt = e.now()
+	run.SetStatus(gorkflow.RunStatusPaused, run.UpdatedAt)
+	e.persistRun(ctx, run, "update_run_paused")
+	gorkflow.LogWorkflowPaused(e.logger, run.RunID)
+
+	interval := e.config.CompletionPollInterval
+	if interval <= 0 {
+		interval = DefaultEngineConfig.CompletionPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for resumed := false; !resumed; {
+		select {
+		case <-ctx.Done():
+			return
+		case <-resume:
+			resumed = true
+		case <-ticker.C:
+			stored, err := e.store.GetRun(ctx, run.RunID)
+			if err != nil {
+				continue
+			}
+			switch stored.Status {
+			case gorkflow.RunStatusRunning:
+				resumed = true
+			case gorkflow.RunStatusCancelled:
+				// Cancelled by another engine while paused
+				e.cancelActiveRun(run.RunID)
+				return
+			}
+		}
+	}
+
+	run.UpdatedAt = e.now()
+	run.SetStatus(gorkflow.RunStatusRunning, run.UpdatedAt)
+	e.persistRun(ctx, run, "update_run_resumed")
+	gorkflow.LogWorkflowResumed(e.logger, run.RunID)
+}
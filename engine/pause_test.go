@@ -0,0 +1,318 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPausableWorkflow returns a two-step workflow whose first step signals started and waits for release
+func newPausableWorkflow(t *testing.T, started chan<- struct{}, release <-chan struct{}, secondRan *atomic.Bool) *gorkflow.Workflow {
+	wf, err := builder.NewWorkflow("pausable", "Pausable").
+		ThenStep(gorkflow.NewStep("first", "First",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				close(started)
+				<-release
+				return input, nil
+			},
+		)).
+		ThenStep(gorkflow.NewStep("second", "Second",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				secondRan.Store(true)
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+	return wf
+}
+
+func TestEngine_PauseResume(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var secondRan atomic.Bool
+	wf := newPausableWorkflow(t, started, release, &secondRan)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "pause"})
+	require.NoError(t, err)
+
+	// Pause while the first step runs, then let it finish
+	<-started
+	require.NoError(t, engine.Pause(context.Background(), runID))
+	close(release)
+
+	// The second step does not start while the run is paused
+	time.Sleep(300 * time.Millisecond)
+	assert.False(t, secondRan.Load())
+
+	run, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusPaused, run.Status)
+	assert.Equal(t, 0.5, run.Progress)
+
+	require.NoError(t, engine.Resume(context.Background(), runID))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	run, err = engine.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.True(t, secondRan.Load())
+
+	// Only running and paused runs can be paused and resumed
+	assert.Error(t, engine.Pause(context.Background(), runID))
+	assert.Error(t, engine.Resume(context.Background(), runID))
+}
+
+func TestEngine_Pause_Cancel(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var secondRan atomic.Bool
+	wf := newPausableWorkflow(t, started, release, &secondRan)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "pause"})
+	require.NoError(t, err)
+
+	<-started
+	require.NoError(t, engine.Pause(context.Background(), runID))
+	close(release)
+	time.Sleep(100 * time.Millisecond)
+
+	// A paused run can still be cancelled
+	require.NoError(t, engine.Cancel(context.Background(), runID))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	run, err := engine.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCancelled, run.Status)
+	assert.False(t, secondRan.Load())
+}
+
+// runReadStore counts the run reads reaching the wrapped store
+type runReadStore struct {
+	gorkflow.WorkflowStore
+	reads atomic.Int32
+}
+
+func (s *runReadStore) GetRun(ctx context.Context, runID string) (*gorkflow.WorkflowRun, error) {
+	s.reads.Add(1)
+	return s.WorkflowStore.GetRun(ctx, runID)
+}
+
+func TestEngine_Pause_NoReadsWithoutPause(t *testing.T) {
+	wfStore := &runReadStore{WorkflowStore: store.NewMemoryStore()}
+	engine := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)),
+		WithConfig(EngineConfig{DefaultTimeout: time.Minute, CompletionPollInterval: time.Hour}))
+
+	b := builder.NewWorkflow("no_pause", "No Pause")
+	for i := range 5 {
+		b.ThenStep(gorkflow.PassthroughStep[DiscoverInput](fmt.Sprintf("step%d", i), fmt.Sprintf("Step %d", i)))
+	}
+	wf, err := b.Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	// Within one poll interval no step boundary reads the run back to check for a pause
+	assert.Zero(t, wfStore.reads.Load())
+}
+
+// staleRunStore reads every run back as still RUNNING, as if it finished just after the read
+type staleRunStore struct {
+	gorkflow.WorkflowStore
+}
+
+func (s *staleRunStore) GetRun(ctx context.Context, runID string) (*gorkflow.WorkflowRun, error) {
+	run, err := s.WorkflowStore.GetRun(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	run.Status = gorkflow.RunStatusRunning
+	return run, nil
+}
+
+func TestEngine_Pause_FinishedMeanwhile(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	engine := NewEngine(&staleRunStore{WorkflowStore: memStore}, WithLogger(zerolog.Nop()))
+	defer engine.Shutdown(context.Background())
+
+	now := time.Now()
+	run := &gorkflow.WorkflowRun{RunID: "done", WorkflowID: "pausable", Progress: 1, CreatedAt: now, UpdatedAt: now}
+	run.SetStatus(gorkflow.RunStatusCompleted, now)
+	require.NoError(t, memStore.CreateRun(context.Background(), run))
+
+	// The pause loses the race with completion instead of reopening the run
+	err := engine.Pause(context.Background(), "done")
+	assert.ErrorIs(t, err, gorkflow.ErrRunFinished)
+
+	stored, err := memStore.GetRun(context.Background(), "done")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, stored.Status)
+	assert.Equal(t, 1.0, stored.Progress)
+}
+
+func TestEngine_PauseResume_AnotherEngine(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	config := EngineConfig{DefaultTimeout: time.Minute, CompletionPollInterval: 20 * time.Millisecond}
+	worker := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithConfig(config))
+	operator := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithConfig(config))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var secondRan atomic.Bool
+	wf := newPausableWorkflow(t, started, release, &secondRan)
+
+	runID, err := worker.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "pause"})
+	require.NoError(t, err)
+
+	// The worker notices the pause through the store once its poll interval has passed
+	<-started
+	require.NoError(t, operator.Pause(context.Background(), runID))
+	time.Sleep(2 * config.CompletionPollInterval)
+	close(release)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, secondRan.Load())
+
+	require.NoError(t, operator.Resume(context.Background(), runID))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	run, err := worker.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.True(t, secondRan.Load())
+}
+
+// outputLoadStore counts the step output reads reaching the wrapped store, by step
+type outputLoadStore struct {
+	gorkflow.WorkflowStore
+	mu         sync.Mutex
+	loads      map[string]int
+	batchLoads [][]string
+}
+
+func (s *outputLoadStore) LoadStepOutput(ctx context.Context, runID, stepID string) ([]byte, error) {
+	s.mu.Lock()
+	s.loads[stepID]++
+	s.mu.Unlock()
+	return s.WorkflowStore.LoadStepOutput(ctx, runID, stepID)
+}
+
+func (s *outputLoadStore) BatchLoadStepOutputs(ctx context.Context, runID string, stepIDs []string) (map[string][]byte, error) {
+	s.mu.Lock()
+	s.batchLoads = append(s.batchLoads, stepIDs)
+	s.mu.Unlock()
+	return s.WorkflowStore.(gorkflow.StepOutputBatchLoader).BatchLoadStepOutputs(ctx, runID, stepIDs)
+}
+
+func TestEngine_PauseResume_WarmsOutputCache(t *testing.T) {
+	wfStore := &outputLoadStore{WorkflowStore: store.NewMemoryStore(), loads: make(map[string]int)}
+	engine := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	readOutputs := func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+		for _, stepID := range []string{"first", "second"} {
+			var output DiscoverInput
+			if err := ctx.Outputs.GetOutput(stepID, &output); err != nil {
+				return input, err
+			}
+		}
+		return input, nil
+	}
+
+	wf, err := builder.NewWorkflow("warm_cache", "Warm Cache").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("first", "First")).
+		ThenStep(gorkflow.NewStep("second", "Second",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				close(started)
+				<-release
+				return input, nil
+			},
+		)).
+		ThenStep(gorkflow.NewStep("third", "Third", readOutputs)).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "warm"})
+	require.NoError(t, err)
+
+	<-started
+	require.NoError(t, engine.Pause(context.Background(), runID))
+	close(release)
+
+	require.Eventually(t, func() bool {
+		run, err := engine.GetRun(context.Background(), runID)
+		return err == nil && run.Status == gorkflow.RunStatusPaused && run.Progress > 0.5
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The second step read the first step's output as its input before the pause
+	wfStore.mu.Lock()
+	firstLoads := wfStore.loads["first"]
+	wfStore.mu.Unlock()
+	require.NoError(t, engine.Resume(context.Background(), runID))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	run, err := engine.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	// The completed steps' outputs were read in one batch, not one at a time
+	wfStore.mu.Lock()
+	defer wfStore.mu.Unlock()
+	assert.Equal(t, [][]string{{"first", "second"}}, wfStore.batchLoads)
+	assert.Equal(t, firstLoads, wfStore.loads["first"])
+}
+
+func TestEngine_Pause_FreesConcurrencySlot(t *testing.T) {
+	engine := NewEngine(store.NewMemoryStore(), WithConfig(EngineConfig{MaxConcurrentWorkflows: 1}))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var secondRan atomic.Bool
+	wf := newPausableWorkflow(t, started, release, &secondRan)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "pause"})
+	require.NoError(t, err)
+
+	<-started
+	require.NoError(t, engine.Pause(context.Background(), runID))
+	close(release)
+
+	// Once paused, the run no longer holds the only slot
+	assert.Eventually(t, func() bool {
+		return engine.Health(context.Background()).ActiveRuns == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	other, err := builder.NewWorkflow("other", "Other").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("noop", "Noop")).
+		Build()
+	require.NoError(t, err)
+	otherRun, err := engine.RunWorkflowSync(context.Background(), other, DiscoverInput{Query: "other"})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, otherRun.Status)
+
+	// Resuming takes the slot back and finishes the paused run
+	require.NoError(t, engine.Resume(context.Background(), runID))
+	run := waitForCompletion(t, engine, runID, 5*time.Second)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.True(t, secondRan.Load())
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+)
+
+// DefaultMaxLoggedPayloadBytes bounds each logged payload when EngineConfig.MaxLoggedPayloadBytes is not set
+const DefaultMaxLoggedPayloadBytes = 1024
+
+// WithPayloadLogging logs each step's input and output at debug level, for debugging (default off).
+// Payloads are redacted like the stored ones and truncated to EngineConfig.MaxLoggedPayloadBytes;
+// raw steps log only their payload size.
+func WithPayloadLogging(enabled bool) EngineOption {
+	return func(e *Engine) {
+		e.logPayloads = enabled
+	}
+}
+
+// logPayload logs a step's input or output, named by field, when payload logging is on
+func (e *Engine) logPayload(logger zerolog.Logger, step gorkflow.StepExecutor, field string, data []byte) {
+	if !e.logPayloads {
+		return
+	}
+
+	event := logger.Debug().Int(field+"_bytes", len(data))
+	if isRawStep(step) {
+		event.Msg("Step " + field)
+		return
+	}
+
+	limit := e.config.MaxLoggedPayloadBytes
+	if limit <= 0 {
+		limit = DefaultMaxLoggedPayloadBytes
+	}
+	event.Str(field, truncatePayload(stepPayload(step, data), limit)).Msg("Step " + field)
+}
+
+// truncatePayload cuts data to at most limit bytes without splitting a UTF-8 character, marking the cut
+func truncatePayload(data []byte, limit int) string {
+	if len(data) <= limit {
+		return string(data)
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return string(data[:cut]) + "...(truncated)"
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runLogged runs a login workflow on an engine logging to a buffer and returns the log
+func runLogged(t *testing.T, opts ...EngineOption) string {
+	var logs bytes.Buffer
+	engine := NewEngine(store.NewMemoryStore(), append([]EngineOption{WithLogger(zerolog.New(&logs))}, opts...)...)
+
+	wf, err := builder.NewWorkflow("payload_logging", "Payload Logging").
+		ThenStep(gorkflow.NewStep("login", "Login",
+			func(ctx *gorkflow.StepContext, input loginInput) (loginOutput, error) {
+				return loginOutput{Username: input.Username, Token: "secret-token"}, nil
+			},
+			gorkflow.WithRedaction([]string{"password", "token"}),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	_, err = engine.RunWorkflowSync(context.Background(), wf, loginInput{Username: "ada-lovelace", Password: "hunter2"})
+	require.NoError(t, err)
+	return logs.String()
+}
+
+func TestEngine_PayloadLogging(t *testing.T) {
+	logs := runLogged(t, WithPayloadLogging(true))
+
+	// Payloads are logged with the same redaction as the stored ones
+	assert.Contains(t, logs, `"message":"Step input"`)
+	assert.Contains(t, logs, `"message":"Step output"`)
+	assert.Contains(t, logs, `ada-lovelace`)
+	assert.Contains(t, logs, `[REDACTED]`)
+	assert.NotContains(t, logs, "hunter2")
+	assert.NotContains(t, logs, "secret-token")
+}
+
+func TestEngine_PayloadLogging_Disabled(t *testing.T) {
+	logs := runLogged(t)
+
+	assert.NotContains(t, logs, `"message":"Step input"`)
+	assert.NotContains(t, logs, "ada-lovelace")
+}
+
+func TestEngine_PayloadLogging_Truncated(t *testing.T) {
+	config := DefaultEngineConfig
+	config.MaxLoggedPayloadBytes = 16
+	logs := runLogged(t, WithPayloadLogging(true), WithConfig(config))
+
+	assert.Contains(t, logs, "...(truncated)")
+	assert.NotContains(t, logs, "ada-lovelace")
+}
+
+func TestTruncatePayload(t *testing.T) {
+	assert.Equal(t, `{"a":1}`, truncatePayload([]byte(`{"a":1}`), 16))
+	assert.Equal(t, `{"a"...(truncated)`, truncatePayload([]byte(`{"a":1}`), 4))
+
+	// A multi-byte character is never split
+	assert.Equal(t, `"h...(truncated)`, truncatePayload([]byte(`"héllo"`), 3))
+}
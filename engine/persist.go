@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// DefaultStoreTimeout bounds each result and status write when EngineConfig.StoreTimeout is not set
+const DefaultStoreTimeout = 10 * time.Second
+
+// persistCtx returns the context for writing a result or status to the store. It keeps ctx's
+// values but not its cancellation or deadline, so a step or run that timed out is still
+// recorded, and it is bounded by StoreTimeout so a hung store cannot block the run forever.
+func (e *Engine) persistCtx(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := e.config.StoreTimeout
+	if timeout <= 0 {
+		timeout = DefaultStoreTimeout
+	}
+	return context.WithTimeout(context.WithoutCancel(ctx), timeout)
+}
+
+// persistRun writes the run with persistCtx, unless its lease was lost, logging a failure as the given operation
+func (e *Engine) persistRun(ctx context.Context, run *gorkflow.WorkflowRun, operation string) {
+	if e.leaseLost(run.RunID) {
+		return
+	}
+	ctx, cancel := e.persistCtx(ctx)
+	defer cancel()
+	e.applyActiveTags(run)
+	if err := e.store.UpdateRun(ctx, run); err != nil {
+		gorkflow.LogPersistenceError(e.logger, run.RunID, operation, err)
+	}
+}
+
+// persistProgress writes only the run's progress fields with persistCtx, so it never overwrites
+// a status another engine recorded meanwhile, logging a failure as the given operation. Like
+// persistRun it writes nothing once the run's lease was lost.
+func (e *Engine) persistProgress(ctx context.Context, run *gorkflow.WorkflowRun, operation string) {
+	if e.leaseLost(run.RunID) {
+		return
+	}
+	ctx, cancel := e.persistCtx(ctx)
+	defer cancel()
+	if err := e.store.UpdateRunProgress(ctx, run); err != nil {
+		gorkflow.LogPersistenceError(e.logger, run.RunID, operation, err)
+	}
+}
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deadlineStore rejects writes whose context is already done, like a network store would,
+// and records whether each run write was bounded by a deadline
+type deadlineStore struct {
+	gorkflow.WorkflowStore
+
+	mu            sync.Mutex
+	runWrites     int
+	boundedWrites int
+}
+
+func (s *deadlineStore) UpdateRun(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.runWrites++
+	if _, ok := ctx.Deadline(); ok {
+		s.boundedWrites++
+	}
+	s.mu.Unlock()
+	return s.WorkflowStore.UpdateRun(ctx, run)
+}
+
+func (s *deadlineStore) UpdateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.WorkflowStore.UpdateStepExecution(ctx, exec)
+}
+
+func TestEngine_StepTimeoutIsPersisted(t *testing.T) {
+	wfStore := &deadlineStore{WorkflowStore: store.NewMemoryStore()}
+	engine := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)))
+
+	wf, err := builder.NewWorkflow("step_timeout", "Step Timeout").
+		ThenStep(gorkflow.NewStep("slow", "Slow Step",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				<-ctx.Done()
+				return DiscoverOutput{}, ctx.Err()
+			},
+			gorkflow.WithTimeout(50*time.Millisecond),
+			gorkflow.WithRetries(0),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.Error(t, err)
+
+	// The timed-out step and the run are recorded as failed, not left RUNNING
+	stored, err := engine.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusFailed, stored.Status)
+
+	exec, err := engine.GetStepExecution(context.Background(), run.RunID, "slow")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.StepStatusFailed, exec.Status)
+
+	// Every run write had its own deadline rather than none or the step's
+	wfStore.mu.Lock()
+	defer wfStore.mu.Unlock()
+	assert.Positive(t, wfStore.runWrites)
+	assert.Equal(t, wfStore.runWrites, wfStore.boundedWrites)
+}
+
+func TestEngine_WorkflowTimeoutIsPersisted(t *testing.T) {
+	wfStore := &deadlineStore{WorkflowStore: store.NewMemoryStore()}
+	engine := NewEngine(wfStore,
+		WithLogger(zerolog.New(os.Stdout)),
+		WithConfig(EngineConfig{MaxConcurrentWorkflows: 10, DefaultTimeout: 50 * time.Millisecond}),
+	)
+
+	wf, err := builder.NewWorkflow("workflow_timeout", "Workflow Timeout").
+		ThenStep(gorkflow.NewStep("slow", "Slow Step",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				<-ctx.Done()
+				return input, nil
+			},
+		)).
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10})
+	require.Error(t, err)
+
+	stored, err := engine.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusFailed, stored.Status)
+	require.NotNil(t, stored.Error)
+	assert.Equal(t, gorkflow.ErrCodeTimeout, stored.Error.Code)
+}
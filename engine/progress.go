@@ -0,0 +1,30 @@
+package engine
+
+import (
+	"time"
+)
+
+// WithProgressUpdateInterval coalesces the run writes made only to record progress and the
+// current step to at most one per interval (default 0, a write after every step). Status
+// changes and the final progress of 1.0 are still written immediately. The interval also spaces
+// out the run's UpdatedAt, so RecoverOrphans adds it to the WithOrphanRecovery staleness.
+func WithProgressUpdateInterval(d time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.progressInterval = d
+	}
+}
+
+// progressThrottle spaces out the progress-only writes, or the pause polls, of one run
+type progressThrottle struct {
+	interval time.Duration
+	last     time.Time
+}
+
+// allow reports whether a write or poll at now is due, recording it if so
+func (t *progressThrottle) allow(now time.Time) bool {
+	if t.interval > 0 && !t.last.IsZero() && now.Sub(t.last) < t.interval {
+		return false
+	}
+	t.last = now
+	return true
+}
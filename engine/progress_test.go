@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runWriteStore counts the run writes recording progress or the current step
+type runWriteStore struct {
+	gorkflow.WorkflowStore
+	mu             sync.Mutex
+	progressWrites int
+}
+
+func (s *runWriteStore) UpdateRunProgress(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	s.mu.Lock()
+	s.progressWrites++
+	s.mu.Unlock()
+	return s.WorkflowStore.UpdateRunProgress(ctx, run)
+}
+
+func runTenSteps(t *testing.T, opts ...EngineOption) (*gorkflow.WorkflowRun, int) {
+	wfStore := &runWriteStore{WorkflowStore: store.NewMemoryStore()}
+	engine := NewEngine(wfStore, append([]EngineOption{WithLogger(zerolog.New(os.Stdout))}, opts...)...)
+
+	b := builder.NewWorkflow("ten_steps", "Ten Steps")
+	for i := range 10 {
+		b.ThenStep(gorkflow.PassthroughStep[DiscoverInput](fmt.Sprintf("step%d", i), fmt.Sprintf("Step %d", i)))
+	}
+	wf, err := b.Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.NoError(t, err)
+
+	stored, err := engine.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+
+	wfStore.mu.Lock()
+	defer wfStore.mu.Unlock()
+	return stored, wfStore.progressWrites
+}
+
+func TestEngine_ProgressUpdateInterval(t *testing.T) {
+	_, unthrottled := runTenSteps(t)
+	assert.GreaterOrEqual(t, unthrottled, 10)
+
+	run, throttled := runTenSteps(t, WithProgressUpdateInterval(time.Hour))
+	assert.Less(t, throttled, 10)
+
+	// The final state is written regardless
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.Equal(t, 1.0, run.Progress)
+	assert.Empty(t, run.CurrentStep)
+}
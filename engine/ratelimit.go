@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"github.com/sicko7947/gorkflow"
+	"golang.org/x/time/rate"
+)
+
+// rateLimiterFor returns the limiter shared by every execution of a step, or nil when the
+// step is not rate limited
+func (e *Engine) rateLimiterFor(step gorkflow.StepExecutor) *rate.Limiter {
+	provider, ok := step.(interface {
+		GetRateLimit() *gorkflow.RateLimitConfig
+	})
+	if !ok {
+		return nil
+	}
+	config := provider.GetRateLimit()
+	if config == nil || config.RPS <= 0 {
+		return nil
+	}
+
+	burst := config.Burst
+	if burst < 1 {
+		burst = 1
+	}
+
+	e.limitersMu.Lock()
+	defer e.limitersMu.Unlock()
+
+	limiter, ok := e.limiters[step.GetID()]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(config.RPS), burst)
+		e.limiters[step.GetID()] = limiter
+		return limiter
+	}
+
+	// Keep the shared limiter in line with the latest registration of the step
+	if limiter.Limit() != rate.Limit(config.RPS) {
+		limiter.SetLimit(rate.Limit(config.RPS))
+	}
+	if limiter.Burst() != burst {
+		limiter.SetBurst(burst)
+	}
+	return limiter
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitedWorkflow(t *testing.T, calls *int32, rps float64, burst int) *gorkflow.Workflow {
+	wf, err := builder.NewWorkflow("rate_limited", "Rate Limited").
+		ThenStep(gorkflow.NewStep("call_api", "Call API",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				atomic.AddInt32(calls, 1)
+				return input, nil
+			},
+			gorkflow.WithRateLimit(rps, burst),
+		)).
+		Build()
+	require.NoError(t, err)
+	return wf
+}
+
+func TestEngine_RateLimit_SharedAcrossRuns(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var calls int32
+	wf := newRateLimitedWorkflow(t, &calls, 5, 1)
+
+	// Ten concurrent runs share the step's 5 rps limit
+	started := time.Now()
+	runIDs := make([]string, 10)
+	for i := range runIDs {
+		runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test"})
+		require.NoError(t, err)
+		runIDs[i] = runID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, runID := range runIDs {
+		run, err := engine.WaitForCompletion(ctx, runID)
+		require.NoError(t, err)
+		assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	}
+	elapsed := time.Since(started)
+
+	assert.Equal(t, int32(10), atomic.LoadInt32(&calls))
+	assert.GreaterOrEqual(t, elapsed, 1700*time.Millisecond)
+	assert.Less(t, elapsed, 3*time.Second)
+}
+
+func TestEngine_RateLimit_WaitRespectsCancellation(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var calls int32
+	wf := newRateLimitedWorkflow(t, &calls, 0.1, 1)
+
+	// The first run uses the only token for the next ten seconds
+	_, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, engine.Cancel(context.Background(), runID))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	run, err := engine.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCancelled, run.Status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_RawSteps(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+
+	// A payload that is not JSON, e.g. an encoded image
+	payload := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0x1a}
+
+	wf, err := builder.NewWorkflow("raw_test", "Raw Test").
+		ThenStep(gorkflow.NewRawStep("receive", "Receive", func(ctx *gorkflow.StepContext, input []byte) ([]byte, error) {
+			return input, nil
+		})).
+		ThenStep(gorkflow.NewRawStep("reverse", "Reverse", func(ctx *gorkflow.StepContext, input []byte) ([]byte, error) {
+			reversed := slices.Clone(input)
+			slices.Reverse(reversed)
+			return reversed, nil
+		})).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, payload)
+	require.NoError(t, err)
+	assert.Equal(t, payload, []byte(run.Input))
+
+	received, err := wfStore.LoadStepOutput(context.Background(), run.RunID, "receive")
+	require.NoError(t, err)
+	assert.Equal(t, payload, received)
+
+	reversed := []byte{0x1a, 0xff, 0x00, 'G', 'N', 'P', 0x89}
+	assert.Equal(t, reversed, []byte(run.Output))
+
+	// Raw payloads stay out of the JSON execution record
+	exec, err := wfStore.GetStepExecution(context.Background(), run.RunID, "receive")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.StepStatusCompleted, exec.Status)
+	assert.Nil(t, exec.Input)
+	assert.Nil(t, exec.Output)
+}
@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// OrphanAction is what RecoverOrphans does with a run left RUNNING by an engine that stopped
+type OrphanAction int
+
+const (
+	// OrphanFail marks the run, and any of its unfinished steps, FAILED with code INTERRUPTED
+	OrphanFail OrphanAction = iota
+
+	// OrphanRestart executes the run again from its first step, so its steps should be idempotent
+	OrphanRestart
+)
+
+// DefaultOrphanStaleness is how long a RUNNING run must go without an update before
+// RecoverOrphans treats it as orphaned, unless WithOrphanRecovery sets another threshold
+const DefaultOrphanStaleness = 10 * time.Minute
+
+// WithOrphanRecovery sets how RecoverOrphans treats runs: those RUNNING without an update for longer
+// than staleness are orphaned and handled with action (default DefaultOrphanStaleness and OrphanFail).
+// Runs update when each step starts and ends, or at most once per WithProgressUpdateInterval, which
+// RecoverOrphans adds to staleness; staleness itself must exceed the longest step. Runs waiting for a
+// signal do not update at all, so without WithRunLease they are never treated as orphaned.
+func WithOrphanRecovery(staleness time.Duration, action OrphanAction) EngineOption {
+	return func(e *Engine) {
+		e.orphanStaleness = staleness
+		e.orphanAction = action
+	}
+}
+
+// RecoverOrphans finds runs left RUNNING by an engine that crashed or was killed mid-run and fails
+// or restarts them, as set by WithOrphanRecovery. Only runs of workflows registered with
+// RegisterWorkflow are considered, so register them before calling it on startup. It returns how
+// many runs it recovered. Runs executing in this engine are never touched. With OrphanRestart it
+// returns ErrEngineShutdown after Shutdown rather than start new executions.
+func (e *Engine) RecoverOrphans(ctx context.Context) (int, error) {
+	staleness := e.orphanStaleness
+	if staleness <= 0 {
+		staleness = DefaultOrphanStaleness
+	}
+	// Coalesced progress writes leave a live run's UpdatedAt up to one interval older
+	staleness += e.progressInterval
+	cutoff := e.now().Add(-staleness)
+
+	// Stores such as DynamoDB only index runs by workflow or resource, so each workflow is listed on its own
+	status := gorkflow.RunStatusRunning
+	var runs []*gorkflow.WorkflowRun
+	for _, workflowID := range e.registeredWorkflowIDs() {
+		workflowRuns, err := e.store.ListRuns(ctx, gorkflow.RunFilter{
+			WorkflowID:    workflowID,
+			Status:        &status,
+			CreatedBefore: &cutoff,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to list running runs: %w", err)
+		}
+		runs = append(runs, workflowRuns...)
+	}
+
+	recovered := 0
+	for _, run := range runs {
+		if !run.UpdatedAt.Before(cutoff) || e.completionSignal(run.RunID) != nil {
+			continue
+		}
+
+		// A run parked on a signal step is idle, not stale; only a lease can tell whether its engine is alive
+		if e.leaseTTL <= 0 && e.awaitingSignal(ctx, run.RunID) {
+			continue
+		}
+
+		// A restarted run needs a slot; without one it is left for a later sweep. Nothing is
+		// restarted once Shutdown was called.
+		var slot *runSlot
+		if e.orphanAction == OrphanRestart {
+			if !e.accepting() {
+				return recovered, ErrEngineShutdown
+			}
+			var err error
+			if slot, err = e.takeSlot(); err != nil {
+				continue
+			}
+		}
+
+		// With leasing on, a run still leased by a live engine is not orphaned
+		leased, err := e.leaseRun(ctx, run.RunID)
+		if err != nil {
+			slot.release()
+			return recovered, err
+		}
+		if !leased {
+			slot.release()
+			continue
+		}
+
+		if e.orphanAction == OrphanRestart {
+			e.logger.Warn().Str("run_id", run.RunID).Msg("Restarting orphaned workflow run")
+			run.Progress = 0
+			run.Error = nil
+			run.PartialOutputs = nil
+			run.CompletedAt = nil
+			wf := e.registeredWorkflow(run.WorkflowID)
+			e.executeAsync(context.WithoutCancel(ctx), wf, run, slot, e.runSettings(&gorkflow.StartOptions{}))
+			recovered++
+			continue
+		}
+
+		e.logger.Warn().Str("run_id", run.RunID).Msg("Failing orphaned workflow run")
+		e.interruptSteps(ctx, run.RunID)
+		e.failWorkflowWithCode(ctx, run, gorkflow.ErrCodeInterrupted,
+			fmt.Errorf("run interrupted: not updated since %s", run.UpdatedAt.Format(time.RFC3339)))
+		recovered++
+	}
+
+	return recovered, nil
+}
+
+// awaitingSignal reports whether the run has a step WAITING for its signal
+func (e *Engine) awaitingSignal(ctx context.Context, runID string) bool {
+	executions, err := e.store.ListStepExecutions(ctx, runID)
+	if err != nil {
+		gorkflow.LogPersistenceError(e.logger, runID, "list_step_executions", err)
+		return true
+	}
+
+	return slices.ContainsFunc(executions, func(exec *gorkflow.StepExecution) bool {
+		return exec.Status == gorkflow.StepStatusWaiting
+	})
+}
+
+// interruptSteps fails the steps of an orphaned run that never finished
+func (e *Engine) interruptSteps(ctx context.Context, runID string) {
+	executions, err := e.store.ListStepExecutions(ctx, runID)
+	if err != nil {
+		gorkflow.LogPersistenceError(e.logger, runID, "list_step_executions", err)
+		return
+	}
+
+	for _, exec := range executions {
+		if exec.Status.IsTerminal() {
+			continue
+		}
+
+		completedAt := e.now()
+		exec.Status = gorkflow.StepStatusFailed
+		exec.Error = gorkflow.NewStepError(gorkflow.ErrCodeInterrupted, "step interrupted", exec.Attempt)
+		exec.CompletedAt = &completedAt
+		exec.UpdatedAt = completedAt
+		writer := e.newStepWriter(exec)
+		writer.created = true
+		writer.final(ctx, "update_interrupted_step_execution")
+	}
+}
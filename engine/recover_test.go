@@ -0,0 +1,254 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedRunningRun stores a run left RUNNING, last updated at updatedAt
+func seedRunningRun(t *testing.T, wfStore gorkflow.WorkflowStore, runID, workflowID string, updatedAt time.Time) {
+	t.Helper()
+
+	input, err := json.Marshal(DiscoverInput{Query: "tech", Limit: 10})
+	require.NoError(t, err)
+
+	run := &gorkflow.WorkflowRun{
+		RunID:      runID,
+		WorkflowID: workflowID,
+		Input:      input,
+		CreatedAt:  updatedAt,
+		StartedAt:  &updatedAt,
+		UpdatedAt:  updatedAt,
+	}
+	run.SetStatus(gorkflow.RunStatusRunning, updatedAt)
+	require.NoError(t, wfStore.CreateRun(context.Background(), run))
+	require.NoError(t, wfStore.CreateStepExecution(context.Background(), &gorkflow.StepExecution{
+		RunID:     runID,
+		StepID:    "discover",
+		Status:    gorkflow.StepStatusRunning,
+		StartedAt: &updatedAt,
+		UpdatedAt: updatedAt,
+	}))
+}
+
+// registerOrphansWorkflow registers the workflow seeded runs belong to, which RecoverOrphans only recovers when registered
+func registerOrphansWorkflow(t *testing.T, engine *Engine) {
+	t.Helper()
+
+	wf, err := builder.NewWorkflow("orphans", "Orphans").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Build()
+	require.NoError(t, err)
+	engine.RegisterWorkflow(wf)
+}
+
+func TestEngine_RecoverOrphans_Fail(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+	registerOrphansWorkflow(t, engine)
+	defer engine.Shutdown(context.Background())
+
+	seedRunningRun(t, wfStore, "stale", "orphans", time.Now().Add(-time.Hour))
+	seedRunningRun(t, wfStore, "fresh", "orphans", time.Now())
+
+	recovered, err := engine.RecoverOrphans(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+
+	stale, err := engine.GetRun(context.Background(), "stale")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusFailed, stale.Status)
+	require.NotNil(t, stale.Error)
+	assert.Equal(t, gorkflow.ErrCodeInterrupted, stale.Error.Code)
+
+	step, err := engine.GetStepExecution(context.Background(), "stale", "discover")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.StepStatusFailed, step.Status)
+	require.NotNil(t, step.Error)
+	assert.Equal(t, gorkflow.ErrCodeInterrupted, step.Error.Code)
+
+	// A run updated recently may still be executing elsewhere
+	fresh, err := engine.GetRun(context.Background(), "fresh")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusRunning, fresh.Status)
+}
+
+func TestEngine_RecoverOrphans_ProgressUpdateInterval(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+	WithOrphanRecovery(10*time.Minute, OrphanFail)(engine)
+	WithProgressUpdateInterval(time.Hour)(engine)
+	registerOrphansWorkflow(t, engine)
+	defer engine.Shutdown(context.Background())
+
+	// Older than the staleness, but a live run may go an interval without a progress write
+	seedRunningRun(t, wfStore, "between-writes", "orphans", time.Now().Add(-30*time.Minute))
+	seedRunningRun(t, wfStore, "stale", "orphans", time.Now().Add(-2*time.Hour))
+
+	recovered, err := engine.RecoverOrphans(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+
+	live, err := engine.GetRun(context.Background(), "between-writes")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusRunning, live.Status)
+
+	stale, err := engine.GetRun(context.Background(), "stale")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusFailed, stale.Status)
+}
+
+func TestEngine_RecoverOrphans_SkipsSignalWaits(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+	registerOrphansWorkflow(t, engine)
+	defer engine.Shutdown(context.Background())
+
+	// A run waiting for approval writes nothing until its signal arrives
+	waitingSince := time.Now().Add(-time.Hour)
+	seedRunningRun(t, wfStore, "waiting", "orphans", waitingSince)
+	require.NoError(t, wfStore.CreateStepExecution(context.Background(), &gorkflow.StepExecution{
+		RunID:     "waiting",
+		StepID:    "approve",
+		Status:    gorkflow.StepStatusWaiting,
+		StartedAt: &waitingSince,
+		UpdatedAt: waitingSince,
+	}))
+
+	recovered, err := engine.RecoverOrphans(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, recovered)
+
+	run, err := engine.GetRun(context.Background(), "waiting")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusRunning, run.Status)
+
+	// With leasing on, an expired lease shows its engine is gone
+	WithRunLease(time.Minute)(engine)
+	recovered, err = engine.RecoverOrphans(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+}
+
+func TestEngine_RecoverOrphans_SkipsUnregisteredWorkflows(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+
+	seedRunningRun(t, wfStore, "stale", "elsewhere", time.Now().Add(-time.Hour))
+
+	recovered, err := engine.RecoverOrphans(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, recovered)
+}
+
+func TestEngine_RecoverOrphans_Restart(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+	WithOrphanRecovery(time.Minute, OrphanRestart)(engine)
+	registerOrphansWorkflow(t, engine)
+	defer engine.Shutdown(context.Background())
+
+	seedRunningRun(t, wfStore, "stale", "orphans", time.Now().Add(-time.Hour))
+
+	recovered, err := engine.RecoverOrphans(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+
+	run := waitForCompletion(t, engine, "stale", 5*time.Second)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	output, err := GetRunOutput[DiscoverOutput](context.Background(), engine, "stale")
+	require.NoError(t, err)
+	assert.Equal(t, 3, output.Count)
+}
+
+func TestEngine_RecoverOrphans_RestartAfterShutdown(t *testing.T) {
+	engine, wfStore := createTestEngine(t)
+	WithOrphanRecovery(time.Minute, OrphanRestart)(engine)
+	registerOrphansWorkflow(t, engine)
+	require.NoError(t, engine.Shutdown(context.Background()))
+
+	seedRunningRun(t, wfStore, "stale", "orphans", time.Now().Add(-time.Hour))
+
+	// A stopped engine starts no new executions
+	recovered, err := engine.RecoverOrphans(context.Background())
+	assert.ErrorIs(t, err, ErrEngineShutdown)
+	assert.Equal(t, 0, recovered)
+
+	run, err := engine.GetRun(context.Background(), "stale")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusRunning, run.Status)
+	assert.Equal(t, 0, engine.Health(context.Background()).ActiveRuns)
+}
+
+// orphanDynamoDBClient serves one stale RUNNING run from GSI1 and records the run items written
+type orphanDynamoDBClient struct {
+	store.DynamoDBClient
+	run  map[string]types.AttributeValue
+	puts []map[string]types.AttributeValue
+}
+
+func (c *orphanDynamoDBClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	pk := params.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value
+	if aws.ToString(params.IndexName) == store.IndexStatusIndex && pk == "WF#orphans#STATUS#RUNNING" {
+		return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{c.run}}, nil
+	}
+	return &dynamodb.QueryOutput{}, nil
+}
+
+func (c *orphanDynamoDBClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.puts = append(c.puts, params.Item)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *orphanDynamoDBClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	for _, item := range params.TransactItems {
+		if item.Put != nil {
+			c.puts = append(c.puts, item.Put.Item)
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (c *orphanDynamoDBClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{}, nil
+}
+
+func TestEngine_RecoverOrphans_DynamoDB(t *testing.T) {
+	updatedAt := time.Now().Add(-time.Hour).UTC()
+	run := &gorkflow.WorkflowRun{
+		RunID:      "stale",
+		WorkflowID: "orphans",
+		CreatedAt:  updatedAt,
+		StartedAt:  &updatedAt,
+		UpdatedAt:  updatedAt,
+	}
+	run.SetStatus(gorkflow.RunStatusRunning, updatedAt)
+	item, err := attributevalue.MarshalMap(run)
+	require.NoError(t, err)
+
+	client := &orphanDynamoDBClient{run: item}
+	engine := NewEngine(store.NewDynamoDBStore(client, "workflows"), WithLogger(zerolog.Nop()))
+	registerOrphansWorkflow(t, engine)
+	defer engine.Shutdown(context.Background())
+
+	recovered, err := engine.RecoverOrphans(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+
+	// The failed run is written back with its new status
+	var failed gorkflow.WorkflowRun
+	require.NotEmpty(t, client.puts)
+	require.NoError(t, attributevalue.UnmarshalMap(client.puts[len(client.puts)-1], &failed))
+	assert.Equal(t, "stale", failed.RunID)
+	assert.Equal(t, gorkflow.RunStatusFailed, failed.Status)
+	assert.Equal(t, gorkflow.ErrCodeInterrupted, failed.Error.Code)
+}
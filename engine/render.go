@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// RenderRunGraph renders the graph a run executes in Graphviz DOT format, with each step
+// colored by its current status, e.g. for a live dashboard. The graph comes from the
+// definition recorded on the run, or the registered workflow for runs without one.
+func (e *Engine) RenderRunGraph(ctx context.Context, runID string) (string, error) {
+	run, err := e.store.GetRun(ctx, runID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get run: %w", err)
+	}
+
+	def, err := e.runDefinition(run)
+	if err != nil {
+		return "", err
+	}
+
+	executions, err := e.store.ListStepExecutions(ctx, runID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list step executions: %w", err)
+	}
+
+	statuses := make(map[string]gorkflow.StepStatus, len(executions))
+	for _, exec := range executions {
+		statuses[exec.StepID] = exec.Status
+	}
+
+	return def.ToStatusDOT(statuses), nil
+}
+
+// runDefinition returns the workflow definition recorded on a run, falling back to the
+// registered workflow
+func (e *Engine) runDefinition(run *gorkflow.WorkflowRun) (gorkflow.WorkflowDefinition, error) {
+	var def gorkflow.WorkflowDefinition
+
+	if len(run.Definition) > 0 {
+		if err := json.Unmarshal(run.Definition, &def); err != nil {
+			return def, fmt.Errorf("failed to unmarshal workflow definition: %w", err)
+		}
+		return def, nil
+	}
+
+	wf := e.registeredWorkflow(run.WorkflowID)
+	if wf == nil {
+		return def, fmt.Errorf("run %s has no recorded definition and workflow %s is not registered", run.RunID, run.WorkflowID)
+	}
+	return wf.Definition(), nil
+}
@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_RenderRunGraph(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("render", "Render").
+		ThenStep(gorkflow.NewStep("fetch", "Fetch",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				return input, nil
+			},
+		)).
+		ThenStep(gorkflow.NewStep("store", "Store",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				return input, errors.New("store failed")
+			},
+			gorkflow.WithRetries(0),
+		)).
+		ThenStep(gorkflow.NewStep("notify", "Notify",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.Error(t, err)
+
+	dot, err := engine.RenderRunGraph(context.Background(), run.RunID)
+	require.NoError(t, err)
+
+	assert.Contains(t, dot, "digraph \"render\" {\n")
+	assert.Contains(t, dot, `"fetch" [label="Fetch", shape=box, style="rounded,filled", fillcolor=palegreen, tooltip="COMPLETED", peripheries=2];`)
+	assert.Contains(t, dot, `"store" [label="Store", shape=box, style="rounded,filled", fillcolor=lightcoral, tooltip="FAILED"];`)
+	assert.Contains(t, dot, `"notify" [label="Notify", shape=box, style="rounded,filled", fillcolor=white, tooltip="PENDING"];`)
+	assert.Contains(t, dot, `"fetch" -> "store";`)
+	assert.Contains(t, dot, `"store" -> "notify";`)
+}
+
+func TestEngine_RenderRunGraph_NotFound(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	_, err := engine.RenderRunGraph(context.Background(), "missing")
+	assert.Error(t, err)
+}
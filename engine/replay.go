@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// Replay starts a fresh run of wf with the stored input of a past run, e.g. to check a fix
+// against the input that failed. The new run copies the source run's resource ID and tags
+// unless opts set their own, and records the source run as its trigger.
+func (e *Engine) Replay(
+	ctx context.Context,
+	wf *gorkflow.Workflow,
+	sourceRunID string,
+	opts ...gorkflow.StartOption,
+) (string, error) {
+	source, err := e.store.GetRun(ctx, sourceRunID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get run: %w", err)
+	}
+
+	replay := func(opts *gorkflow.StartOptions) {
+		opts.ResourceID = source.ResourceID
+		opts.Tags = maps.Clone(source.Tags)
+		opts.TriggerType = "replay"
+		opts.TriggerSource = sourceRunID
+	}
+
+	// Raw entry steps take the bytes as they are; JSON input is passed through unchanged
+	var input interface{} = json.RawMessage(source.Input)
+	if entryIsRaw(wf) {
+		input = []byte(source.Input)
+	}
+
+	return e.StartWorkflow(ctx, wf, input, append([]gorkflow.StartOption{replay}, opts...)...)
+}
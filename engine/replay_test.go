@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Replay(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("replay", "Replay").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("echo", "Echo")).
+		Build()
+	require.NoError(t, err)
+
+	source, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "a&b <c>", Limit: 7},
+		gorkflow.WithResourceID("customer-1"),
+		gorkflow.WithTags(map[string]string{"env": "prod"}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, source.Status)
+
+	runID, err := engine.Replay(context.Background(), wf, source.RunID, gorkflow.WithSynchronousExecution())
+	require.NoError(t, err)
+	require.NotEqual(t, source.RunID, runID)
+
+	replayed, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, replayed.Status)
+
+	// The stored input is byte-for-byte the source run's
+	assert.Equal(t, []byte(source.Input), []byte(replayed.Input))
+	assert.Equal(t, source.Output, replayed.Output)
+
+	assert.Equal(t, "customer-1", replayed.ResourceID)
+	assert.Equal(t, map[string]string{"env": "prod"}, replayed.Tags)
+	require.NotNil(t, replayed.Trigger)
+	assert.Equal(t, "replay", replayed.Trigger.Type)
+	assert.Equal(t, source.RunID, replayed.Trigger.Source)
+}
+
+func TestEngine_Replay_OverridesSourceOptions(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("replay_override", "Replay Override").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("echo", "Echo")).
+		Build()
+	require.NoError(t, err)
+
+	source, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"},
+		gorkflow.WithResourceID("customer-1"),
+		gorkflow.WithTags(map[string]string{"env": "prod"}),
+	)
+	require.NoError(t, err)
+
+	runID, err := engine.Replay(context.Background(), wf, source.RunID,
+		gorkflow.WithSynchronousExecution(),
+		gorkflow.WithResourceID(""),
+		gorkflow.WithTags(map[string]string{"env": "debug"}),
+	)
+	require.NoError(t, err)
+
+	replayed, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	assert.Empty(t, replayed.ResourceID)
+	assert.Equal(t, map[string]string{"env": "debug"}, replayed.Tags)
+}
+
+func TestEngine_Replay_NotFound(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("replay_missing", "Replay Missing").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("echo", "Echo")).
+		Build()
+	require.NoError(t, err)
+
+	_, err = engine.Replay(context.Background(), wf, "missing")
+	assert.Error(t, err)
+}
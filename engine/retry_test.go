@@ -3,12 +3,17 @@ package engine
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"os"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/sicko7947/gorkflow"
 	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -87,14 +92,15 @@ func TestEngine_RetryExhaustion(t *testing.T) {
 }
 
 func TestEngine_LinearBackoff(t *testing.T) {
-	engine, _ := createTestEngine(t)
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	engine := NewEngine(store.NewMemoryStore(), WithLogger(zerolog.New(os.Stdout)), WithClock(clock))
 
 	attemptTimes := make([]time.Time, 0, 4)
 	attemptCount := int32(0)
 
 	retryStep := gorkflow.NewStep("backoff", "Backoff Test",
 		func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
-			attemptTimes = append(attemptTimes, time.Now())
+			attemptTimes = append(attemptTimes, clock.Now())
 			count := atomic.AddInt32(&attemptCount, 1)
 			if count < 4 {
 				return DiscoverOutput{}, errors.New("retry")
@@ -111,35 +117,27 @@ func TestEngine_LinearBackoff(t *testing.T) {
 		Build()
 	require.NoError(t, err)
 
-	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	_, err = engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
 	require.NoError(t, err)
 
-	waitForCompletion(t, engine, runID, 15*time.Second)
-
-	// Verify linear backoff delays
+	// Delays increase linearly: 200ms, 400ms, 600ms of virtual time
+	assert.Equal(t, []time.Duration{200 * time.Millisecond, 400 * time.Millisecond, 600 * time.Millisecond}, clock.Sleeps())
 	require.Len(t, attemptTimes, 4)
-
-	// Delays should increase linearly: 200ms, 400ms, 600ms
-	delay1 := attemptTimes[1].Sub(attemptTimes[0])
-	delay2 := attemptTimes[2].Sub(attemptTimes[1])
-	delay3 := attemptTimes[3].Sub(attemptTimes[2])
-
-	// Allow 50ms tolerance
-	tolerance := 50 * time.Millisecond
-	assert.InDelta(t, 200*time.Millisecond, delay1, float64(tolerance))
-	assert.InDelta(t, 400*time.Millisecond, delay2, float64(tolerance))
-	assert.InDelta(t, 600*time.Millisecond, delay3, float64(tolerance))
+	assert.Equal(t, 200*time.Millisecond, attemptTimes[1].Sub(attemptTimes[0]))
+	assert.Equal(t, 400*time.Millisecond, attemptTimes[2].Sub(attemptTimes[1]))
+	assert.Equal(t, 600*time.Millisecond, attemptTimes[3].Sub(attemptTimes[2]))
 }
 
 func TestEngine_ExponentialBackoff(t *testing.T) {
-	engine, _ := createTestEngine(t)
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	engine := NewEngine(store.NewMemoryStore(), WithLogger(zerolog.New(os.Stdout)), WithClock(clock))
 
 	attemptTimes := make([]time.Time, 0, 4)
 	attemptCount := int32(0)
 
 	retryStep := gorkflow.NewStep("exp_backoff", "Exponential Backoff",
 		func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
-			attemptTimes = append(attemptTimes, time.Now())
+			attemptTimes = append(attemptTimes, clock.Now())
 			count := atomic.AddInt32(&attemptCount, 1)
 			if count < 4 {
 				return DiscoverOutput{}, errors.New("retry")
@@ -156,23 +154,15 @@ func TestEngine_ExponentialBackoff(t *testing.T) {
 		Build()
 	require.NoError(t, err)
 
-	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	_, err = engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
 	require.NoError(t, err)
 
-	waitForCompletion(t, engine, runID, 15*time.Second)
-
-	// Verify exponential backoff
+	// Delays increase exponentially: 100ms, 200ms, 400ms of virtual time
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}, clock.Sleeps())
 	require.Len(t, attemptTimes, 4)
-
-	// Delays should increase exponentially: 100ms, 200ms, 400ms
-	delay1 := attemptTimes[1].Sub(attemptTimes[0])
-	delay2 := attemptTimes[2].Sub(attemptTimes[1])
-	delay3 := attemptTimes[3].Sub(attemptTimes[2])
-
-	tolerance := 50 * time.Millisecond
-	assert.InDelta(t, 100*time.Millisecond, delay1, float64(tolerance))
-	assert.InDelta(t, 200*time.Millisecond, delay2, float64(tolerance))
-	assert.InDelta(t, 400*time.Millisecond, delay3, float64(tolerance))
+	assert.Equal(t, 100*time.Millisecond, attemptTimes[1].Sub(attemptTimes[0]))
+	assert.Equal(t, 200*time.Millisecond, attemptTimes[2].Sub(attemptTimes[1]))
+	assert.Equal(t, 400*time.Millisecond, attemptTimes[3].Sub(attemptTimes[2]))
 }
 
 func TestEngine_NoBackoff(t *testing.T) {
@@ -251,6 +241,31 @@ func TestEngine_Timeout(t *testing.T) {
 	assert.Equal(t, gorkflow.StepStatusFailed, steps[0].Status)
 }
 
+func TestEngine_ZeroTimeoutMeansNoTimeout(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	step := gorkflow.NewStep("untimed", "Untimed Step",
+		func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+			select {
+			case <-time.After(50 * time.Millisecond):
+				return DiscoverOutput{Companies: []string{"Done"}, Count: 1}, nil
+			case <-ctx.Done():
+				return DiscoverOutput{}, ctx.Err()
+			}
+		},
+	)
+	step.Config = gorkflow.ExecutionConfig{RetryBackoff: gorkflow.BackoffNone}
+
+	wf, err := builder.NewWorkflow("zero_timeout_test", "Zero Timeout Test").
+		ThenStep(step).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+}
+
 func TestEngine_TimeoutWithRetry(t *testing.T) {
 	engine, _ := createTestEngine(t)
 
@@ -292,6 +307,138 @@ func TestEngine_TimeoutWithRetry(t *testing.T) {
 	assert.Equal(t, int32(3), atomic.LoadInt32(&attemptCount))
 }
 
+func TestEngine_WorkflowTimeout(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var secondStarted, secondInterrupted atomic.Bool
+	newSlowStep := func(id string, onStart, onInterrupt *atomic.Bool) *gorkflow.Step[DiscoverInput, DiscoverInput] {
+		return gorkflow.NewStep(id, id,
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				if onStart != nil {
+					onStart.Store(true)
+				}
+				select {
+				case <-time.After(250 * time.Millisecond):
+					return input, nil
+				case <-ctx.Done():
+					if onInterrupt != nil {
+						onInterrupt.Store(true)
+					}
+					return DiscoverInput{}, ctx.Err()
+				}
+			},
+		)
+	}
+
+	wf, err := builder.NewWorkflow("workflow_timeout_test", "Workflow Timeout Test").
+		ThenStep(newSlowStep("first", nil, nil)).
+		ThenStep(newSlowStep("second", &secondStarted, &secondInterrupted)).
+		Build()
+	require.NoError(t, err)
+
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10},
+		gorkflow.WithWorkflowTimeout(300*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	run := waitForCompletion(t, engine, runID, 5*time.Second)
+
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	require.NotNil(t, run.Error)
+	assert.Equal(t, gorkflow.ErrCodeTimeout, run.Error.Code)
+
+	// Deadline hit during the second step, whose context was cancelled
+	assert.True(t, secondStarted.Load())
+	assert.True(t, secondInterrupted.Load())
+
+	steps, err := engine.GetStepExecutions(context.Background(), runID)
+	require.NoError(t, err)
+	statuses := make(map[string]gorkflow.StepStatus)
+	for _, step := range steps {
+		statuses[step.StepID] = step.Status
+	}
+	assert.Equal(t, gorkflow.StepStatusCompleted, statuses["first"])
+	assert.Equal(t, gorkflow.StepStatusFailed, statuses["second"])
+}
+
+func TestEngine_WorkflowTimeout_InterruptsRetryBackoff(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var attempts atomic.Int32
+	wf, err := builder.NewWorkflow("backoff_timeout_test", "Backoff Timeout Test").
+		ThenStep(gorkflow.NewStep("flaky", "Flaky",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				attempts.Add(1)
+				return DiscoverInput{}, errors.New("temporary failure")
+			},
+			gorkflow.WithRetries(3),
+			gorkflow.WithRetryDelay(time.Minute),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	started := time.Now()
+	runID, err := engine.StartWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10},
+		gorkflow.WithWorkflowTimeout(200*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	// The deadline ends the minute-long backoff instead of waiting it out
+	run := waitForCompletion(t, engine, runID, 5*time.Second)
+	assert.Less(t, time.Since(started), 5*time.Second)
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	require.NotNil(t, run.Error)
+	assert.Equal(t, gorkflow.ErrCodeTimeout, run.Error.Code)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestEngine_CallerDeadline_Sync(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var thirdStarted atomic.Bool
+	wf, err := builder.NewWorkflow("caller_deadline_test", "Caller Deadline Test").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("first", "First")).
+		ThenStep(gorkflow.NewStep("slow", "Slow",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				select {
+				case <-time.After(5 * time.Second):
+					return input, nil
+				case <-ctx.Done():
+					return DiscoverInput{}, ctx.Err()
+				}
+			},
+			gorkflow.WithTimeout(10),
+		)).
+		ThenStep(gorkflow.NewStep("third", "Third",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				thirdStarted.Store(true)
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	// The caller's deadline is much shorter than the step timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	run, err := engine.RunWorkflowSync(ctx, wf, DiscoverInput{Query: "test", Limit: 10})
+	require.Error(t, err)
+	assert.Less(t, time.Since(started), 2*time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NotNil(t, run)
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	require.NotNil(t, run.Error)
+	assert.Equal(t, gorkflow.ErrCodeTimeout, run.Error.Code)
+	assert.False(t, thirdStarted.Load())
+
+	stored, err := engine.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusFailed, stored.Status)
+}
+
 func TestEngine_ContinueOnError(t *testing.T) {
 	engine, _ := createTestEngine(t)
 
@@ -327,3 +474,229 @@ func TestEngine_ContinueOnError(t *testing.T) {
 	assert.Equal(t, gorkflow.StepStatusFailed, steps[0].Status)
 	assert.Equal(t, gorkflow.StepStatusCompleted, steps[1].Status)
 }
+
+// errStatus is a test error carrying an HTTP-like status code
+type errStatus struct{ code int }
+
+func (e *errStatus) Error() string { return "status " + http.StatusText(e.code) }
+
+func retryOnServerErrors(err error) bool {
+	var status *errStatus
+	return errors.As(err, &status) && status.code >= 500
+}
+
+func runRetryIfWorkflow(t *testing.T, fail error, opts ...gorkflow.StepOption) (*gorkflow.WorkflowRun, *gorkflow.StepExecution, int32) {
+	engine, _ := createTestEngine(t)
+
+	var attempts int32
+	opts = append([]gorkflow.StepOption{
+		gorkflow.WithRetries(3),
+		gorkflow.WithBackoff(gorkflow.BackoffNone),
+	}, opts...)
+
+	wf, err := builder.NewWorkflow("retry_if", "Retry If").
+		ThenStep(gorkflow.NewStep("call", "Call",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				atomic.AddInt32(&attempts, 1)
+				return DiscoverOutput{}, fail
+			},
+			opts...,
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10})
+	require.Error(t, err)
+
+	step, err := engine.GetStepExecution(context.Background(), run.RunID, "call")
+	require.NoError(t, err)
+	return run, step, atomic.LoadInt32(&attempts)
+}
+
+func TestEngine_RetryIf_PermanentError(t *testing.T) {
+	run, step, attempts := runRetryIfWorkflow(t, &errStatus{code: 400}, gorkflow.WithRetryIf(retryOnServerErrors))
+
+	// No retries beyond the first attempt
+	assert.Equal(t, int32(1), attempts)
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	assert.Equal(t, gorkflow.StepStatusFailed, step.Status)
+	assert.Equal(t, 0, step.Error.Attempt)
+}
+
+func TestEngine_RetryIf_TransientError(t *testing.T) {
+	run, step, attempts := runRetryIfWorkflow(t, &errStatus{code: 503}, gorkflow.WithRetryIf(retryOnServerErrors))
+
+	// Retried to exhaustion
+	assert.Equal(t, int32(4), attempts)
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	assert.Equal(t, 3, step.Error.Attempt)
+}
+
+func TestEngine_RetryIf_ErrDoNotRetry(t *testing.T) {
+	permanent := fmt.Errorf("invalid account: %w", gorkflow.ErrDoNotRetry)
+
+	// Without a predicate
+	_, _, attempts := runRetryIfWorkflow(t, permanent)
+	assert.Equal(t, int32(1), attempts)
+
+	// The sentinel wins over a predicate that would retry
+	_, _, attempts = runRetryIfWorkflow(t, permanent, gorkflow.WithRetryIf(func(error) bool { return true }))
+	assert.Equal(t, int32(1), attempts)
+}
+
+func TestEngine_RetryBudget(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	// Each step fails twice before succeeding, well within its own retry limit
+	var calls [3]int32
+	flaky := func(i int) gorkflow.StepExecutor {
+		return gorkflow.NewStep(fmt.Sprintf("flaky_%d", i), "Flaky",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				if atomic.AddInt32(&calls[i], 1) <= 2 {
+					return input, errors.New("temporary failure")
+				}
+				return input, nil
+			},
+			gorkflow.WithRetries(5),
+			gorkflow.WithBackoff(gorkflow.BackoffNone),
+		)
+	}
+
+	wf, err := builder.NewWorkflow("retry_budget", "Retry Budget").
+		Sequence(flaky(0), flaky(1), flaky(2)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"},
+		gorkflow.WithMaxTotalRetries(3),
+	)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrRetryBudgetExhausted)
+
+	// The first step spends two retries, the second gets one more and then runs out
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	require.NotNil(t, run.Error)
+	assert.Equal(t, "flaky_1", run.Error.Step)
+	assert.Contains(t, run.Error.Message, "retry budget exhausted")
+	assert.Equal(t, [3]int32{3, 2, 0}, calls)
+}
+
+func TestEngine_RetryBudget_EngineDefault(t *testing.T) {
+	engine := NewEngine(store.NewMemoryStore(),
+		WithLogger(zerolog.New(os.Stdout)),
+		WithConfig(EngineConfig{DefaultTimeout: time.Minute, MaxTotalRetries: 1}),
+	)
+
+	var attempts int32
+	wf, err := builder.NewWorkflow("retry_budget_default", "Retry Budget Default").
+		ThenStep(gorkflow.NewStep("flaky", "Flaky",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				atomic.AddInt32(&attempts, 1)
+				return input, errors.New("temporary failure")
+			},
+			gorkflow.WithRetries(5),
+			gorkflow.WithBackoff(gorkflow.BackoffNone),
+			gorkflow.WithContinueOnError(true),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	// ContinueOnError does not cover an exhausted budget
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.Error(t, err)
+	assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	// A per-run budget overrides the engine's
+	atomic.StoreInt32(&attempts, 0)
+	_, err = engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"},
+		gorkflow.WithMaxTotalRetries(3),
+	)
+	require.Error(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+}
+
+func TestEngine_AttemptHistory(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	var attempts int32
+	wf, err := builder.NewWorkflow("attempt_history", "Attempt History").
+		ThenStep(gorkflow.NewStep("flaky", "Flaky",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				switch atomic.AddInt32(&attempts, 1) {
+				case 1:
+					return input, errors.New("connection refused")
+				case 2:
+					return input, errors.New("service unavailable")
+				}
+				return input, nil
+			},
+			gorkflow.WithRetries(3),
+			gorkflow.WithBackoff(gorkflow.BackoffNone),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.NoError(t, err)
+
+	steps, err := engine.GetStepExecutions(context.Background(), run.RunID)
+	require.NoError(t, err)
+	require.Len(t, steps, 1)
+
+	history := steps[0].Attempts
+	require.Len(t, history, 3)
+	assert.Equal(t, "connection refused", history[0].Error)
+	assert.Equal(t, "service unavailable", history[1].Error)
+	assert.Empty(t, history[2].Error)
+
+	for i, record := range history {
+		assert.Equal(t, i, record.Attempt)
+		assert.False(t, record.StartedAt.IsZero())
+		assert.False(t, record.CompletedAt.Before(record.StartedAt))
+	}
+	assert.Equal(t, 2, steps[0].Attempt)
+}
+
+func TestEngine_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []gorkflow.StepOption
+	}{
+		{name: "longer backoff", opts: []gorkflow.StepOption{gorkflow.WithRetryDelay(5 * time.Second)}},
+		{name: "no backoff", opts: []gorkflow.StepOption{gorkflow.WithBackoff(gorkflow.BackoffNone)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, _ := createTestEngine(t)
+
+			var attempts int32
+			opts := append([]gorkflow.StepOption{gorkflow.WithRetries(1)}, tt.opts...)
+			wf, err := builder.NewWorkflow("retry_after", "Retry After").
+				ThenStep(gorkflow.NewStep("limited", "Rate Limited",
+					func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+						if atomic.AddInt32(&attempts, 1) == 1 {
+							return input, gorkflow.NewRetryAfterError(errors.New("too many requests"), 400*time.Millisecond)
+						}
+						return input, nil
+					},
+					opts...,
+				)).
+				Build()
+			require.NoError(t, err)
+
+			run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+			require.NoError(t, err)
+
+			steps, err := engine.GetStepExecutions(context.Background(), run.RunID)
+			require.NoError(t, err)
+			require.Len(t, steps[0].Attempts, 2)
+
+			wait := steps[0].Attempts[1].StartedAt.Sub(steps[0].Attempts[0].CompletedAt)
+			assert.GreaterOrEqual(t, wait, 400*time.Millisecond)
+			assert.Less(t, wait, time.Second)
+			assert.Equal(t, "too many requests (retry after 400ms)", steps[0].Attempts[0].Error)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// scheduleBatchSize caps the due runs started per poll
+const scheduleBatchSize = 100
+
+// ScheduleWorkflow persists a pending run that the engine starts once runAt has passed.
+// Due runs are found by polling the store every SchedulePollInterval, so any engine
+// instance that registered the workflow may start it. Scheduled runs execute with the
+// engine's DefaultTimeout.
+func (e *Engine) ScheduleWorkflow(
+	ctx context.Context,
+	wf *gorkflow.Workflow,
+	input interface{},
+	runAt time.Time,
+	opts ...gorkflow.StartOption,
+) (string, error) {
+	options := applyStartOptions(opts)
+	if options.TriggerType == "" {
+		options.TriggerType = "schedule"
+	}
+
+	run, err := e.newRun(wf, input, options)
+	if err != nil {
+		return "", err
+	}
+	scheduledAt := runAt.UTC()
+	run.ScheduledAt = &scheduledAt
+
+	e.RegisterWorkflow(wf)
+
+	if err := e.insertRun(ctx, run); err != nil {
+		return "", err
+	}
+
+	return run.RunID, nil
+}
+
+// RegisterWorkflow makes a workflow available to the scheduler and starts polling for due runs.
+// Engines that only execute runs scheduled elsewhere must register the workflows they serve.
+func (e *Engine) RegisterWorkflow(wf *gorkflow.Workflow) {
+	e.workflowsMu.Lock()
+	e.workflows[wf.ID()] = wf
+	e.workflowsMu.Unlock()
+
+	e.schedulerOnce.Do(func() {
+		e.startBackground(e.runScheduler)
+	})
+}
+
+// registeredWorkflow returns the workflow registered under the given ID, if any
+func (e *Engine) registeredWorkflow(workflowID string) *gorkflow.Workflow {
+	e.workflowsMu.RLock()
+	defer e.workflowsMu.RUnlock()
+	return e.workflows[workflowID]
+}
+
+// registeredWorkflowIDs returns the IDs of the registered workflows in sorted order
+func (e *Engine) registeredWorkflowIDs() []string {
+	e.workflowsMu.RLock()
+	defer e.workflowsMu.RUnlock()
+	return slices.Sorted(maps.Keys(e.workflows))
+}
+
+// runScheduler polls the store for due runs until the engine shuts down
+func (e *Engine) runScheduler() {
+	interval := e.config.SchedulePollInterval
+	if interval <= 0 {
+		interval = DefaultEngineConfig.SchedulePollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+			e.startDueRuns(context.Background())
+		}
+	}
+}
+
+// startDueRuns claims and starts every due run of a registered workflow
+func (e *Engine) startDueRuns(ctx context.Context) {
+	runs, err := e.store.ListDueRuns(ctx, e.now(), scheduleBatchSize)
+	if err != nil {
+		e.logger.Error().Err(err).Msg("Failed to list due scheduled runs")
+		return
+	}
+
+	for _, run := range runs {
+		// Another engine instance may serve this workflow
+		wf := e.registeredWorkflow(run.WorkflowID)
+		if wf == nil {
+			continue
+		}
+
+		// Runs left unclaimed while every slot is taken are picked up by a later poll
+		slot, err := e.takeSlot()
+		if err != nil {
+			return
+		}
+
+		claimed, err := e.store.ClaimScheduledRun(ctx, run.RunID)
+		if err != nil {
+			slot.release()
+			gorkflow.LogPersistenceError(e.logger, run.RunID, "claim_scheduled_run", err)
+			continue
+		}
+		if !claimed {
+			slot.release()
+			continue
+		}
+
+		e.executeAsync(ctx, wf, run, slot, e.runSettings(&gorkflow.StartOptions{}))
+	}
+}
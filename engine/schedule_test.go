@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSchedulingEngine() *Engine {
+	config := DefaultEngineConfig
+	config.SchedulePollInterval = 20 * time.Millisecond
+
+	return NewEngine(store.NewMemoryStore(),
+		WithLogger(zerolog.New(os.Stdout)),
+		WithConfig(config),
+	)
+}
+
+func TestEngine_ScheduleWorkflow(t *testing.T) {
+	engine := newSchedulingEngine()
+
+	startedAt := make(chan time.Time, 1)
+	wf, err := builder.NewWorkflow("schedule_test", "Schedule Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				startedAt <- time.Now()
+				return discoverCompanies(ctx, input)
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	scheduledAt := time.Now().Add(300 * time.Millisecond)
+	runID, err := engine.ScheduleWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10}, scheduledAt)
+	require.NoError(t, err)
+
+	run, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusPending, run.Status)
+	require.NotNil(t, run.ScheduledAt)
+	assert.Equal(t, "schedule", run.Trigger.Type)
+
+	select {
+	case started := <-startedAt:
+		assert.False(t, started.Before(scheduledAt), "run started before its scheduled time")
+		assert.WithinDuration(t, scheduledAt, started, 200*time.Millisecond)
+	case <-time.After(2 * time.Second):
+		t.Fatal("scheduled run did not start")
+	}
+
+	run = waitForCompletion(t, engine, runID, 2*time.Second)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+}
+
+func TestEngine_ScheduleWorkflow_StartedOnce(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	config := DefaultEngineConfig
+	config.SchedulePollInterval = 10 * time.Millisecond
+
+	// Two engines polling the same store must not both start the run
+	first := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithConfig(config))
+	second := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithConfig(config))
+
+	starts := make(chan struct{}, 2)
+	wf, err := builder.NewWorkflow("schedule_once_test", "Schedule Once Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				starts <- struct{}{}
+				return discoverCompanies(ctx, input)
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	second.RegisterWorkflow(wf)
+	runID, err := first.ScheduleWorkflow(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10},
+		time.Now().Add(50*time.Millisecond))
+	require.NoError(t, err)
+
+	run := waitForCompletion(t, first, runID, 2*time.Second)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, starts, 1)
+}
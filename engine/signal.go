@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// SendSignal delivers a named signal to a run, completing the WaitForSignalStep waiting for it
+// with payload as its output. A signal sent before the run reaches that step is kept until it
+// does. The payload must be JSON; an empty payload is sent as null.
+func (e *Engine) SendSignal(ctx context.Context, runID, signal string, payload []byte) error {
+	run, err := e.store.GetRun(ctx, runID)
+	if err != nil {
+		return fmt.Errorf("failed to get run: %w", err)
+	}
+
+	if run.Status.IsTerminal() {
+		return fmt.Errorf("cannot signal workflow in %s state", run.Status)
+	}
+
+	if len(payload) == 0 {
+		payload = []byte("null")
+	}
+	if !json.Valid(payload) {
+		return fmt.Errorf("payload for signal %s is not valid JSON", signal)
+	}
+
+	if err := e.store.SaveState(ctx, runID, gorkflow.SignalStateKey(signal), payload); err != nil {
+		return fmt.Errorf("failed to save signal %s: %w", signal, err)
+	}
+
+	e.notifySignal(runID, signal)
+	return nil
+}
+
+// awaitSignal parks a signal step as WAITING until its signal is delivered. Signals sent through
+// this engine wake it at once; signals sent elsewhere are found by re-reading the run state every
+// CompletionPollInterval. The run frees its concurrency slot while it waits and takes one back,
+// waiting for it if need be, once the signal or the timeout arrives.
+func (e *Engine) awaitSignal(
+	ctx context.Context,
+	run *gorkflow.WorkflowRun,
+	step gorkflow.StepExecutor,
+	signal string,
+	writer *stepWriter,
+) (*StepExecutionResult, error) {
+	storeCtx := context.WithoutCancel(ctx)
+	stepLogger := e.stepLogger(run.RunID, step)
+
+	startedAt := e.now()
+	stepExec := writer.exec
+	stepExec.Status = gorkflow.StepStatusWaiting
+	stepExec.StartedAt = &startedAt
+	stepExec.UpdatedAt = startedAt
+
+	writer.progress(storeCtx, "update_step_execution_waiting")
+
+	stepLogger.Info().Str("signal", signal).Msg("Waiting for signal")
+
+	delivered := e.signalWaiter(run.RunID, signal)
+	defer e.removeSignalWaiter(run.RunID, signal)
+
+	interval := e.config.CompletionPollInterval
+	if interval <= 0 {
+		interval = DefaultEngineConfig.CompletionPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// An optional signal timeout fails the step or completes it with a default output
+	var expired <-chan time.Time
+	timeout, fallback := signalTimeout(step)
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	parked := false
+	defer func() {
+		if parked {
+			e.unparkRun(ctx, run.RunID)
+		}
+	}()
+
+	for {
+		payload, err := e.store.LoadState(storeCtx, run.RunID, gorkflow.SignalStateKey(signal))
+		if err == nil {
+			return e.completeSignalStep(storeCtx, run, step, writer, payload)
+		}
+
+		if !parked {
+			e.parkRun(run.RunID)
+			parked = true
+		}
+
+		select {
+		case <-ctx.Done():
+			code := gorkflow.ErrCodeExecutionFailed
+			if ctx.Err() == context.Canceled {
+				code = gorkflow.ErrCodeCancelled
+			}
+			return e.failSignalStep(storeCtx, run, step, writer, code,
+				fmt.Errorf("stopped waiting for signal %s: %w", signal, ctx.Err()))
+		case <-expired:
+			if fallback != nil {
+				stepLogger.Warn().Str("signal", signal).Dur("timeout", timeout).Msg("Signal not received, using default")
+				return e.completeSignalStep(storeCtx, run, step, writer, fallback)
+			}
+			return e.failSignalStep(storeCtx, run, step, writer, gorkflow.ErrCodeTimeout,
+				fmt.Errorf("signal %s not received within %s", signal, timeout))
+		case <-delivered:
+			delivered = nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// completeSignalStep records the signal payload as the step's output
+func (e *Engine) completeSignalStep(
+	ctx context.Context,
+	run *gorkflow.WorkflowRun,
+	step gorkflow.StepExecutor,
+	writer *stepWriter,
+	payload []byte,
+) (*StepExecutionResult, error) {
+	completedAt := e.now()
+	stepExec := writer.exec
+	stepExec.Status = gorkflow.StepStatusCompleted
+	if !e.omitInlineOutput {
+		stepExec.Output = payload
+	}
+	stepExec.CompletedAt = &completedAt
+	stepExec.UpdatedAt = completedAt
+	stepExec.DurationMs = completedAt.Sub(*stepExec.StartedAt).Milliseconds()
+
+	writer.commit(ctx, payload)
+
+	gorkflow.LogStepCompleted(e.logger, run.RunID, step.GetID(), stepExec.DurationMs, 1)
+
+	return &StepExecutionResult{
+		StepID:       step.GetID(),
+		Output:       payload,
+		DurationMs:   stepExec.DurationMs,
+		AttemptsMade: 1,
+	}, nil
+}
+
+// failSignalStep records a signal step that stopped waiting without its signal
+func (e *Engine) failSignalStep(
+	ctx context.Context,
+	run *gorkflow.WorkflowRun,
+	step gorkflow.StepExecutor,
+	writer *stepWriter,
+	code string,
+	err error,
+) (*StepExecutionResult, error) {
+	completedAt := e.now()
+	stepExec := writer.exec
+	stepExec.Status = gorkflow.StepStatusFailed
+	stepExec.CompletedAt = &completedAt
+	stepExec.UpdatedAt = completedAt
+	stepExec.DurationMs = completedAt.Sub(*stepExec.StartedAt).Milliseconds()
+	stepExec.Error = (&gorkflow.StepError{
+		Message: err.Error(),
+		Code:    code,
+	}).WithCause(err)
+
+	writer.final(ctx, "update_step_execution_failure")
+
+	gorkflow.LogStepFailed(e.logger, run.RunID, step.GetID(), err, 0, stepExec.DurationMs)
+
+	return &StepExecutionResult{
+		StepID:       step.GetID(),
+		Error:        err,
+		DurationMs:   stepExec.DurationMs,
+		AttemptsMade: 1,
+	}, fmt.Errorf("step %s failed: %w", step.GetID(), err)
+}
+
+// signalWaiter registers interest in a signal for a run executing in this engine and returns a
+// channel closed when it is sent here. It is nil for runs this engine is not executing.
+func (e *Engine) signalWaiter(runID, signal string) <-chan struct{} {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	active, ok := e.running[runID]
+	if !ok {
+		return nil
+	}
+	if active.signals == nil {
+		active.signals = make(map[string]chan struct{})
+	}
+	if _, exists := active.signals[signal]; !exists {
+		active.signals[signal] = make(chan struct{})
+	}
+	return active.signals[signal]
+}
+
+// removeSignalWaiter drops a waiter registered by signalWaiter
+func (e *Engine) removeSignalWaiter(runID, signal string) {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	if active, ok := e.running[runID]; ok {
+		delete(active.signals, signal)
+	}
+}
+
+// notifySignal wakes a step of a run executing in this engine that waits for the signal
+func (e *Engine) notifySignal(runID, signal string) {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	if active, ok := e.running[runID]; ok {
+		if delivered, waiting := active.signals[signal]; waiting {
+			close(delivered)
+			delete(active.signals, signal)
+		}
+	}
+}
+
+// signalTimeout returns the step's signal timeout and the output used when it passes, if any
+func signalTimeout(step gorkflow.StepExecutor) (time.Duration, []byte) {
+	var timeout time.Duration
+	if provider, ok := step.(interface{ GetSignalTimeout() time.Duration }); ok {
+		timeout = provider.GetSignalTimeout()
+	}
+
+	var fallback []byte
+	if provider, ok := step.(interface{ GetSignalDefault() []byte }); ok {
+		fallback = provider.GetSignalDefault()
+	}
+	return timeout, fallback
+}
@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type approval struct {
+	Approved bool   `json:"approved"`
+	By       string `json:"by"`
+}
+
+func newApprovalWorkflow(t *testing.T) *gorkflow.Workflow {
+	wf, err := builder.NewWorkflow("approval", "Approval").
+		ThenStep(gorkflow.NewStep("request", "Request Approval",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				return input, nil
+			},
+		)).
+		ThenStep(gorkflow.NewWaitForSignalStep("approve", "Await Approval", "approval")).
+		Build()
+	require.NoError(t, err)
+	return wf
+}
+
+func waitForStepStatus(t *testing.T, engine *Engine, runID, stepID string, status gorkflow.StepStatus) {
+	require.Eventually(t, func() bool {
+		step, err := engine.GetStepExecution(context.Background(), runID, stepID)
+		return err == nil && step.Status == status
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestEngine_SendSignal(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	runID, err := engine.StartWorkflow(context.Background(), newApprovalWorkflow(t), DiscoverInput{Query: "approve"})
+	require.NoError(t, err)
+
+	// The run parks on the signal step
+	waitForStepStatus(t, engine, runID, "approve", gorkflow.StepStatusWaiting)
+
+	run, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusRunning, run.Status)
+
+	payload := []byte(`{"approved":true,"by":"ops"}`)
+	require.NoError(t, engine.SendSignal(context.Background(), runID, "approval", payload))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	run, err = engine.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	var output approval
+	require.NoError(t, json.Unmarshal(run.Output, &output))
+	assert.Equal(t, approval{Approved: true, By: "ops"}, output)
+
+	// Finished runs cannot be signalled
+	assert.Error(t, engine.SendSignal(context.Background(), runID, "approval", payload))
+}
+
+func TestEngine_SendSignal_InvalidPayload(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	runID, err := engine.StartWorkflow(context.Background(), newApprovalWorkflow(t), DiscoverInput{Query: "approve"})
+	require.NoError(t, err)
+	defer engine.Cancel(context.Background(), runID)
+
+	err = engine.SendSignal(context.Background(), runID, "approval", []byte("yes"))
+	assert.ErrorContains(t, err, "not valid JSON")
+}
+
+func TestEngine_SendSignal_AnotherEngine(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	config := EngineConfig{DefaultTimeout: time.Minute, CompletionPollInterval: 20 * time.Millisecond}
+	worker := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithConfig(config))
+	api := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithConfig(config))
+
+	runID, err := worker.StartWorkflow(context.Background(), newApprovalWorkflow(t), DiscoverInput{Query: "approve"})
+	require.NoError(t, err)
+
+	// The waiting state is visible to, and the signal sent from, another engine
+	waitForStepStatus(t, api, runID, "approve", gorkflow.StepStatusWaiting)
+	require.NoError(t, api.SendSignal(context.Background(), runID, "approval", []byte(`{"approved":false}`)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	run, err := worker.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.JSONEq(t, `{"approved":false}`, string(run.Output))
+}
+
+func TestEngine_SignalTimeout(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	newWorkflow := func(opts ...gorkflow.StepOption) *gorkflow.Workflow {
+		opts = append([]gorkflow.StepOption{gorkflow.WithSignalTimeout(200 * time.Millisecond)}, opts...)
+		wf, err := builder.NewWorkflow("approval_timeout", "Approval Timeout").
+			ThenStep(gorkflow.NewWaitForSignalStep("approve", "Await Approval", "approval", opts...)).
+			Build()
+		require.NoError(t, err)
+		return wf
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("no signal", func(t *testing.T) {
+		started := time.Now()
+		run, err := engine.RunWorkflowSync(ctx, newWorkflow(), DiscoverInput{Query: "approve"})
+		require.Error(t, err)
+		assert.Equal(t, gorkflow.RunStatusFailed, run.Status)
+		assert.Less(t, time.Since(started), 2*time.Second)
+
+		step, err := engine.GetStepExecution(ctx, run.RunID, "approve")
+		require.NoError(t, err)
+		assert.Equal(t, gorkflow.StepStatusFailed, step.Status)
+		require.NotNil(t, step.Error)
+		assert.Equal(t, gorkflow.ErrCodeTimeout, step.Error.Code)
+	})
+
+	t.Run("signal within window", func(t *testing.T) {
+		runID, err := engine.StartWorkflow(ctx, newWorkflow(), DiscoverInput{Query: "approve"})
+		require.NoError(t, err)
+
+		waitForStepStatus(t, engine, runID, "approve", gorkflow.StepStatusWaiting)
+		require.NoError(t, engine.SendSignal(ctx, runID, "approval", []byte(`{"approved":true}`)))
+
+		run, err := engine.WaitForCompletion(ctx, runID)
+		require.NoError(t, err)
+		assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+		assert.JSONEq(t, `{"approved":true}`, string(run.Output))
+	})
+
+	t.Run("default output", func(t *testing.T) {
+		run, err := engine.RunWorkflowSync(ctx, newWorkflow(gorkflow.WithSignalDefault([]byte(`{"approved":false}`))), DiscoverInput{Query: "approve"})
+		require.NoError(t, err)
+		assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+		assert.JSONEq(t, `{"approved":false}`, string(run.Output))
+	})
+}
+
+func TestEngine_AwaitSignal_FreesConcurrencySlot(t *testing.T) {
+	engine := NewEngine(store.NewMemoryStore(), WithConfig(EngineConfig{MaxConcurrentWorkflows: 1}))
+
+	runID, err := engine.StartWorkflow(context.Background(), newApprovalWorkflow(t), DiscoverInput{Query: "approve"})
+	require.NoError(t, err)
+	waitForStepStatus(t, engine, runID, "approve", gorkflow.StepStatusWaiting)
+
+	// A run waiting for its signal leaves the only slot to other runs
+	assert.Eventually(t, func() bool {
+		return engine.Health(context.Background()).ActiveRuns == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	other, err := builder.NewWorkflow("other", "Other").
+		ThenStep(gorkflow.PassthroughStep[DiscoverInput]("noop", "Noop")).
+		Build()
+	require.NoError(t, err)
+	otherRun, err := engine.RunWorkflowSync(context.Background(), other, DiscoverInput{Query: "other"})
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, otherRun.Status)
+
+	require.NoError(t, engine.SendSignal(context.Background(), runID, "approval", []byte(`{"approved":true}`)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	run, err := engine.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+}
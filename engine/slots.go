@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// ErrConcurrencyLimit is returned when a run is started while MaxConcurrentWorkflows runs are executing
+var ErrConcurrencyLimit = errors.New("too many concurrent workflow runs")
+
+// runSlot is a run's hold on one of the engine's MaxConcurrentWorkflows slots
+type runSlot struct {
+	mu     sync.Mutex
+	slots  chan struct{} // nil when the engine has no limit
+	held   bool
+	parked int // Waits in progress that do not need the slot, such as a pause
+}
+
+// takeSlot reserves a slot for a new run without waiting, failing with CONCURRENCY_LIMIT when
+// every slot is taken
+func (e *Engine) takeSlot() (*runSlot, error) {
+	slot := &runSlot{slots: e.slots}
+	if slot.slots == nil {
+		return slot, nil
+	}
+
+	select {
+	case slot.slots <- struct{}{}:
+		slot.held = true
+		return slot, nil
+	default:
+		err := fmt.Errorf("%w: limit of %d reached", ErrConcurrencyLimit, cap(e.slots))
+		return nil, gorkflow.NewWorkflowError(gorkflow.ErrCodeConcurrency, err.Error()).WithCause(err)
+	}
+}
+
+// release frees the slot; it is safe to call more than once, or on a nil slot
+func (s *runSlot) release() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.held {
+		<-s.slots
+		s.held = false
+	}
+}
+
+// park frees the slot while the run waits on something other than its own work, e.g. a pause.
+// Parallel steps may park the same run; the slot is freed by the first.
+func (s *runSlot) park() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.parked++
+	if s.held {
+		<-s.slots
+		s.held = false
+	}
+}
+
+// unpark takes a slot back once the last wait of a parked run ends, waiting for one to free up.
+// It reports false when ctx ends first, leaving the run to be cancelled or timed out without a slot.
+func (s *runSlot) unpark(ctx context.Context) bool {
+	s.mu.Lock()
+	if s.parked > 1 || s.held || s.slots == nil {
+		s.parked--
+		s.mu.Unlock()
+		return true
+	}
+	s.mu.Unlock()
+
+	acquired := false
+	select {
+	case s.slots <- struct{}{}:
+		acquired = true
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parked--
+	s.held = acquired
+	return acquired
+}
+
+// executing reports whether the run is executing rather than parked
+func (s *runSlot) executing() bool {
+	if s == nil {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.parked == 0
+}
+
+// parkRun frees the slot of a run executing in this engine while it waits
+func (e *Engine) parkRun(runID string) {
+	e.runningMu.Lock()
+	active, ok := e.running[runID]
+	e.runningMu.Unlock()
+
+	if ok {
+		active.slot.park()
+	}
+}
+
+// unparkRun takes a slot back for a run parked by parkRun, see runSlot.unpark
+func (e *Engine) unparkRun(ctx context.Context, runID string) bool {
+	e.runningMu.Lock()
+	active, ok := e.running[runID]
+	e.runningMu.Unlock()
+
+	return !ok || active.slot.unpark(ctx)
+}
+
+// executingRuns counts the runs executing in this engine, leaving out paused and parked ones
+func (e *Engine) executingRuns() int {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	executing := 0
+	for _, active := range e.running {
+		if active.slot.executing() {
+			executing++
+		}
+	}
+	return executing
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// AddTags merges tags into a run's tags, overwriting existing values for the same keys, e.g. to
+// record a customer ID a step discovered. A run executing in this engine keeps the tags in its
+// later writes; one executing in another engine may overwrite them until it finishes.
+func (e *Engine) AddTags(ctx context.Context, runID string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	run, err := e.store.GetRun(ctx, runID)
+	if err != nil {
+		return fmt.Errorf("failed to get run: %w", err)
+	}
+
+	e.addActiveTags(runID, tags)
+
+	run.Tags = mergeTags(run.Tags, tags)
+	run.UpdatedAt = e.now()
+	if err := e.store.UpdateRun(ctx, run); err != nil {
+		return fmt.Errorf("failed to update run tags: %w", err)
+	}
+
+	return nil
+}
+
+// addActiveTags records tags added to a run executing in this engine, so its own writes carry them
+func (e *Engine) addActiveTags(runID string, tags map[string]string) {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	if active, ok := e.running[runID]; ok {
+		active.tags = mergeTags(active.tags, tags)
+	}
+}
+
+// applyActiveTags merges the tags added with AddTags while the run executes into the run
+func (e *Engine) applyActiveTags(run *gorkflow.WorkflowRun) {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+
+	if active, ok := e.running[run.RunID]; ok && len(active.tags) > 0 {
+		run.Tags = mergeTags(run.Tags, active.tags)
+	}
+}
+
+// mergeTags returns a copy of tags with added merged in, leaving both maps untouched
+func mergeTags(tags, added map[string]string) map[string]string {
+	merged := make(map[string]string, len(tags)+len(added))
+	maps.Copy(merged, tags)
+	maps.Copy(merged, added)
+	return merged
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_AddTags_MidRun(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	// The first step learns the customer and tags the run with it
+	var seen map[string]string
+	wf, err := builder.NewWorkflow("add_tags", "Add Tags").
+		ThenStep(gorkflow.NewStep("identify", "Identify",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				return input, engine.AddTags(ctx.Context, ctx.RunID, map[string]string{"customer": "cust-42"})
+			},
+		)).
+		ThenStep(gorkflow.NewStep("inspect", "Inspect",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				run, err := engine.GetRun(ctx.Context, ctx.RunID)
+				if err != nil {
+					return input, err
+				}
+				seen = run.Tags
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+
+	startTags := map[string]string{"env": "test"}
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"}, gorkflow.WithTags(startTags))
+	require.NoError(t, err)
+	require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+	// The tag is visible mid-run and survives the run's own later writes
+	want := map[string]string{"env": "test", "customer": "cust-42"}
+	assert.Equal(t, want, seen)
+
+	stored, err := engine.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+	assert.Equal(t, want, stored.Tags)
+	assert.Equal(t, map[string]string{"env": "test"}, startTags, "the caller's map is not modified")
+
+	runs, err := engine.ListRuns(context.Background(), gorkflow.RunFilter{Tags: map[string]string{"customer": "cust-42"}})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, run.RunID, runs[0].RunID)
+}
+
+func TestEngine_AddTags_OverwritesKeys(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("overwrite_tags", "Overwrite Tags").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test", Limit: 10},
+		gorkflow.WithTags(map[string]string{"tier": "free", "env": "test"}))
+	require.NoError(t, err)
+
+	require.NoError(t, engine.AddTags(context.Background(), run.RunID, map[string]string{"tier": "pro"}))
+
+	stored, err := engine.GetRun(context.Background(), run.RunID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"tier": "pro", "env": "test"}, stored.Tags)
+	assert.Equal(t, gorkflow.RunStatusCompleted, stored.Status)
+
+	assert.Error(t, engine.AddTags(context.Background(), "missing", map[string]string{"tier": "pro"}))
+}
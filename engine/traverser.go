@@ -2,6 +2,8 @@ package engine
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/sicko7947/gorkflow"
 )
@@ -18,7 +20,8 @@ func NewGraphTraverser(graph *gorkflow.ExecutionGraph) *GraphTraverser {
 	}
 }
 
-// GetExecutionOrder returns the step IDs in topological order
+// GetExecutionOrder returns the step IDs in topological order, sorting the graph on every
+// call; Workflow.ExecutionOrder caches the order of a built workflow
 func (t *GraphTraverser) GetExecutionOrder() ([]string, error) {
 	// Perform topological sort
 	return t.graph.TopologicalSort()
@@ -44,12 +47,49 @@ func (t *GraphTraverser) IsParallel(stepID string) bool {
 	return node.Type == gorkflow.NodeTypeParallel
 }
 
-// HasConditions checks if a step has conditional execution
+// HasConditions checks if any outgoing edge of a step is conditional
 func (t *GraphTraverser) HasConditions(stepID string) bool {
 	node, exists := t.graph.Nodes[stepID]
 	if !exists {
 		return false
 	}
 
-	return node.Type == gorkflow.NodeTypeConditional && len(node.Conditions) > 0
+	return slices.ContainsFunc(node.Conditions, func(condition gorkflow.Condition) bool {
+		return condition != nil
+	})
+}
+
+// GetStepGroups splits the execution order into groups executed one after another.
+// Parallel steps sharing the same predecessors form one group that runs concurrently,
+// placed where its first step appears; every other step is a group of its own.
+func (t *GraphTraverser) GetStepGroups(executionOrder []string) [][]string {
+	var groups [][]string
+	blocks := make(map[string]int) // predecessor set -> index of its parallel group
+
+	for _, stepID := range executionOrder {
+		if !t.IsParallel(stepID) {
+			groups = append(groups, []string{stepID})
+			continue
+		}
+
+		key := strings.Join(t.graph.Predecessors(stepID), "\x00")
+		if index, exists := blocks[key]; exists {
+			groups[index] = append(groups[index], stepID)
+			continue
+		}
+
+		blocks[key] = len(groups)
+		groups = append(groups, []string{stepID})
+	}
+
+	return groups
+}
+
+// GetMaxParallel returns the concurrency limit of the parallel block containing the step (0 = unlimited)
+func (t *GraphTraverser) GetMaxParallel(stepID string) int {
+	node, exists := t.graph.Nodes[stepID]
+	if !exists {
+		return 0
+	}
+	return node.MaxParallel
 }
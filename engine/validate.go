@@ -0,0 +1,15 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+)
+
+// Validate checks a workflow without running any step: graph structure, step registration,
+// declared input sources and the compatibility of types passed between steps. Unlike Build,
+// it reports every problem at once, joined into a single error (one problem per line).
+func (e *Engine) Validate(wf *gorkflow.Workflow) error {
+	return errors.Join(builder.ValidateAll(wf)...)
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countLabelInput struct {
+	Count string `json:"count"`
+}
+
+func TestEngine_Validate(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	wf, err := builder.NewWorkflow("valid", "Valid").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	assert.NoError(t, engine.Validate(wf))
+}
+
+func TestEngine_Validate_ReportsEveryProblem(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	// Assembled by hand, since Build would stop at the first problem
+	wf := gorkflow.NewWorkflowInstance("broken", "Broken")
+	label := gorkflow.NewStep("label", "Label",
+		func(ctx *gorkflow.StepContext, input countLabelInput) (FilterOutput, error) {
+			return FilterOutput{}, nil
+		},
+	)
+	report := gorkflow.NewStep("report", "Report", filterCompanies, gorkflow.WithInputFrom("missing"))
+	for _, step := range []gorkflow.StepExecutor{
+		gorkflow.NewStep("discover", "Discover Companies", discoverCompanies),
+		label,
+		report,
+		gorkflow.NewStep("orphan", "Orphan", filterCompanies),
+	} {
+		wf.AddStep(step)
+	}
+
+	graph := wf.Graph()
+	for _, id := range []string{"discover", "label", "report", "orphan", "ghost"} {
+		graph.AddNode(id, gorkflow.NodeTypeSequential)
+	}
+	require.NoError(t, graph.AddEdge("discover", "label"))
+	require.NoError(t, graph.AddEdge("discover", "report"))
+	require.NoError(t, graph.AddEdge("label", "ghost"))
+
+	err := engine.Validate(wf)
+	require.Error(t, err)
+
+	problems := strings.Split(err.Error(), "\n")
+	assert.ElementsMatch(t, []string{
+		"node orphan is not reachable from entry point",
+		"step ghost referenced in graph but not registered",
+		"step report takes input from unknown step missing",
+		"step discover output type engine.DiscoverOutput is not compatible with step label input type engine.countLabelInput",
+	}, problems)
+}
@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// WaitForCompletion blocks until the run reaches a terminal status and returns it, or until ctx is done.
+// Runs executing in this engine wake the caller as soon as they finish; runs executing in another
+// process are re-read every CompletionPollInterval.
+func (e *Engine) WaitForCompletion(ctx context.Context, runID string) (*gorkflow.WorkflowRun, error) {
+	interval := e.config.CompletionPollInterval
+	if interval <= 0 {
+		interval = DefaultEngineConfig.CompletionPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Taken before reading the run so a finish in between is not missed
+		finished := e.completionSignal(runID)
+
+		run, err := e.store.GetRun(ctx, runID)
+		if err != nil {
+			return nil, err
+		}
+		// A cancelled run is terminal before its executor has stopped; wait for it to wind down
+		if run.Status.IsTerminal() && finished == nil {
+			return run, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-finished:
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBlockingWorkflow(t *testing.T, release <-chan struct{}) *gorkflow.Workflow {
+	wf, err := builder.NewWorkflow("blocking", "Blocking").
+		ThenStep(gorkflow.NewStep("wait", "Wait",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverInput, error) {
+				<-release
+				return input, nil
+			},
+		)).
+		Build()
+	require.NoError(t, err)
+	return wf
+}
+
+func TestEngine_WaitForCompletion(t *testing.T) {
+	// Polling alone would not notice the run finishing within the test
+	engine := NewEngine(store.NewMemoryStore(),
+		WithLogger(zerolog.New(os.Stdout)),
+		WithConfig(EngineConfig{DefaultTimeout: time.Minute, CompletionPollInterval: time.Hour}),
+	)
+
+	release := make(chan struct{})
+	runID, err := engine.StartWorkflow(context.Background(), newBlockingWorkflow(t, release), DiscoverInput{Query: "wait"})
+	require.NoError(t, err)
+
+	time.AfterFunc(100*time.Millisecond, func() { close(release) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	started := time.Now()
+	run, err := engine.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+	assert.Less(t, time.Since(started), time.Second)
+
+	// A finished run is returned immediately
+	run, err = engine.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+}
+
+func TestEngine_WaitForCompletion_ContextCancelled(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	runID, err := engine.StartWorkflow(context.Background(), newBlockingWorkflow(t, release), DiscoverInput{Query: "wait"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	run, err := engine.WaitForCompletion(ctx, runID)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, run)
+}
+
+func TestEngine_WaitForCompletion_RunInAnotherEngine(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	worker := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)))
+	waiter := NewEngine(wfStore,
+		WithLogger(zerolog.New(os.Stdout)),
+		WithConfig(EngineConfig{DefaultTimeout: time.Minute, CompletionPollInterval: 20 * time.Millisecond}),
+	)
+
+	release := make(chan struct{})
+	runID, err := worker.StartWorkflow(context.Background(), newBlockingWorkflow(t, release), DiscoverInput{Query: "wait"})
+	require.NoError(t, err)
+
+	time.AfterFunc(100*time.Millisecond, func() { close(release) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The waiting engine falls back to polling the shared store
+	run, err := waiter.WaitForCompletion(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+}
+
+func TestEngine_WaitForCompletion_UnknownRun(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	_, err := engine.WaitForCompletion(context.Background(), "missing")
+	assert.Error(t, err)
+}
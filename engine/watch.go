@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"slices"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// stepWatchBuffer is how many updates a watcher may fall behind before further ones are dropped
+const stepWatchBuffer = 64
+
+// stepWatcher receives the step execution updates of one run
+type stepWatcher struct {
+	updates chan *gorkflow.StepExecution
+	done    chan struct{}
+	closed  bool
+}
+
+// WatchStepExecutions streams a copy of a step execution each time this engine changes a step's
+// status in the run, so live views need not poll GetStepExecutions. The channel is closed when
+// the run finishes executing in this engine, when ctx is done or when the returned stop func is
+// called. Updates are dropped for a watcher that falls more than 64 behind.
+func (e *Engine) WatchStepExecutions(ctx context.Context, runID string) (<-chan *gorkflow.StepExecution, func()) {
+	watcher := &stepWatcher{
+		updates: make(chan *gorkflow.StepExecution, stepWatchBuffer),
+		done:    make(chan struct{}),
+	}
+
+	e.watchersMu.Lock()
+	e.watchers[runID] = append(e.watchers[runID], watcher)
+	e.watchersMu.Unlock()
+
+	stop := func() {
+		e.watchersMu.Lock()
+		defer e.watchersMu.Unlock()
+
+		if watcher.closed {
+			return
+		}
+		e.watchers[runID] = slices.DeleteFunc(e.watchers[runID], func(w *stepWatcher) bool { return w == watcher })
+		if len(e.watchers[runID]) == 0 {
+			delete(e.watchers, runID)
+		}
+		watcher.close()
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop()
+		case <-watcher.done:
+		}
+	}()
+
+	return watcher.updates, stop
+}
+
+// publishStepExecution sends a copy of the execution to the run's watchers
+func (e *Engine) publishStepExecution(stepExec *gorkflow.StepExecution) {
+	e.watchersMu.Lock()
+	defer e.watchersMu.Unlock()
+
+	watchers := e.watchers[stepExec.RunID]
+	if len(watchers) == 0 {
+		return
+	}
+
+	snapshot := *stepExec
+	snapshot.Attempts = slices.Clone(stepExec.Attempts)
+
+	for _, watcher := range watchers {
+		update := snapshot
+		select {
+		case watcher.updates <- &update:
+		default:
+			e.logger.Warn().
+				Str("run_id", stepExec.RunID).
+				Str("step_id", stepExec.StepID).
+				Msg("Step execution watcher is behind, dropping update")
+		}
+	}
+}
+
+// closeStepWatchers ends every watch on a run once it stops executing in this engine
+func (e *Engine) closeStepWatchers(runID string) {
+	e.watchersMu.Lock()
+	defer e.watchersMu.Unlock()
+
+	for _, watcher := range e.watchers[runID] {
+		watcher.close()
+	}
+	delete(e.watchers, runID)
+}
+
+// close closes the updates channel; callers hold watchersMu
+func (w *stepWatcher) close() {
+	if !w.closed {
+		w.closed = true
+		close(w.updates)
+		close(w.done)
+	}
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_WatchStepExecutions(t *testing.T) {
+	engine := newSchedulingEngine()
+	defer engine.Shutdown(context.Background())
+
+	wf, err := builder.NewWorkflow("watch_test", "Watch Test").
+		ThenStep(gorkflow.NewStep("discover", "Discover Companies", discoverCompanies)).
+		ThenStep(gorkflow.NewStep("enrich", "Enrich Companies", enrichCompanies)).
+		ThenStep(gorkflow.NewStep("filter", "Filter Companies", filterCompanies)).
+		Build()
+	require.NoError(t, err)
+
+	// Scheduling leaves time to start watching before the first step runs
+	runID, err := engine.ScheduleWorkflow(context.Background(), wf, DiscoverInput{Query: "tech", Limit: 10}, time.Now().Add(50*time.Millisecond))
+	require.NoError(t, err)
+
+	updates, stop := engine.WatchStepExecutions(context.Background(), runID)
+	defer stop()
+
+	var transitions []string
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case exec, ok := <-updates:
+			if !ok {
+				// Closed once the run finishes
+				done = true
+				break
+			}
+			assert.Equal(t, runID, exec.RunID)
+			transitions = append(transitions, exec.StepID+":"+string(exec.Status))
+		case <-timeout:
+			t.Fatalf("watch did not end, got %v", transitions)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"discover:PENDING", "discover:RUNNING", "discover:COMPLETED",
+		"enrich:PENDING", "enrich:RUNNING", "enrich:COMPLETED",
+		"filter:PENDING", "filter:RUNNING", "filter:COMPLETED",
+	}, transitions)
+}
+
+func TestEngine_WatchStepExecutions_Stop(t *testing.T) {
+	engine, _ := createTestEngine(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, stop := engine.WatchStepExecutions(ctx, "run-1")
+	_, stopOther := engine.WatchStepExecutions(context.Background(), "run-1")
+
+	// Cancelling ctx closes the channel, and stop is safe to call afterwards
+	cancel()
+	select {
+	case _, ok := <-updates:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after ctx was cancelled")
+	}
+	stop()
+
+	stopOther()
+	engine.watchersMu.Lock()
+	assert.Empty(t, engine.watchers)
+	engine.watchersMu.Unlock()
+}
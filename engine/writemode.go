@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// StepWriteMode controls how often step executions are written to the store
+type StepWriteMode int
+
+const (
+	// StepWriteFull persists every transition: pending, each retry, each attempt and the final state
+	StepWriteFull StepWriteMode = iota
+
+	// StepWriteMinimal persists a step when it first starts and when it finishes. Retries and
+	// later attempts are tracked in memory and written with the final state.
+	StepWriteMinimal
+)
+
+// WithStepWriteMode sets how often step executions are persisted (default StepWriteFull)
+func WithStepWriteMode(mode StepWriteMode) EngineOption {
+	return func(e *Engine) {
+		e.stepWriteMode = mode
+	}
+}
+
+// stepWriter persists the transitions of one step execution according to the engine's StepWriteMode
+type stepWriter struct {
+	engine  *Engine
+	exec    *gorkflow.StepExecution
+	created bool
+}
+
+func (e *Engine) newStepWriter(stepExec *gorkflow.StepExecution) *stepWriter {
+	return &stepWriter{engine: e, exec: stepExec}
+}
+
+// pending records the step before it starts; minimal mode defers the record until it does
+func (w *stepWriter) pending(ctx context.Context) error {
+	if w.engine.leaseLost(w.exec.RunID) {
+		return ErrLeaseLost
+	}
+	if w.engine.stepWriteMode != StepWriteMinimal {
+		ctx, cancel := w.engine.persistCtx(ctx)
+		defer cancel()
+		if err := w.engine.store.CreateStepExecution(ctx, w.exec); err != nil {
+			return err
+		}
+		w.created = true
+	}
+
+	w.engine.publishStepExecution(w.exec)
+	return nil
+}
+
+// progress records an intermediate transition. In minimal mode only the first is written.
+func (w *stepWriter) progress(ctx context.Context, operation string) {
+	if w.engine.stepWriteMode != StepWriteMinimal || !w.created {
+		w.save(ctx, operation)
+	}
+	w.engine.publishStepExecution(w.exec)
+}
+
+// final records the step's terminal state
+func (w *stepWriter) final(ctx context.Context, operation string) {
+	w.save(ctx, operation)
+	w.engine.publishStepExecution(w.exec)
+}
+
+// commit records a completed step together with its output in one atomic write
+func (w *stepWriter) commit(ctx context.Context, output []byte) {
+	if w.engine.leaseLost(w.exec.RunID) {
+		return
+	}
+	ctx, cancel := w.engine.persistCtx(ctx)
+	defer cancel()
+
+	if err := w.engine.store.CommitStepResult(ctx, w.exec, output); err != nil {
+		gorkflow.LogPersistenceError(w.engine.logger, w.exec.RunID, "commit_step_result", err)
+	} else {
+		w.created = true
+	}
+	w.engine.publishStepExecution(w.exec)
+}
+
+// save writes the execution, creating its record if it has not been written yet. Nothing is
+// written once another engine took over the run's lease.
+func (w *stepWriter) save(ctx context.Context, operation string) {
+	if w.engine.leaseLost(w.exec.RunID) {
+		return
+	}
+	ctx, cancel := w.engine.persistCtx(ctx)
+	defer cancel()
+
+	var err error
+	if w.created {
+		err = w.engine.store.UpdateStepExecution(ctx, w.exec)
+	} else if err = w.engine.store.CreateStepExecution(ctx, w.exec); err == nil {
+		w.created = true
+	}
+
+	if err != nil {
+		gorkflow.LogPersistenceError(w.engine.logger, w.exec.RunID, operation, err)
+	}
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/builder"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts the step execution writes reaching the wrapped store
+type countingStore struct {
+	gorkflow.WorkflowStore
+	stepWrites atomic.Int32
+}
+
+func (s *countingStore) CreateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
+	s.stepWrites.Add(1)
+	return s.WorkflowStore.CreateStepExecution(ctx, exec)
+}
+
+func (s *countingStore) UpdateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
+	s.stepWrites.Add(1)
+	return s.WorkflowStore.UpdateStepExecution(ctx, exec)
+}
+
+func (s *countingStore) CommitStepResult(ctx context.Context, exec *gorkflow.StepExecution, output []byte) error {
+	s.stepWrites.Add(1)
+	return s.WorkflowStore.CommitStepResult(ctx, exec, output)
+}
+
+func TestEngine_StepWriteMode(t *testing.T) {
+	runFlaky := func(t *testing.T, mode StepWriteMode) (int32, *gorkflow.StepExecution) {
+		wfStore := &countingStore{WorkflowStore: store.NewMemoryStore()}
+		engine := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithStepWriteMode(mode))
+
+		// Fails twice, then succeeds
+		var calls atomic.Int32
+		wf, err := builder.NewWorkflow("write_mode", "Write Mode").
+			ThenStep(gorkflow.NewStep("flaky", "Flaky",
+				func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+					if calls.Add(1) < 3 {
+						return DiscoverOutput{}, errors.New("temporary failure")
+					}
+					return DiscoverOutput{Count: 1}, nil
+				},
+				gorkflow.WithRetries(2),
+				gorkflow.WithRetryDelay(time.Millisecond),
+			)).
+			Build()
+		require.NoError(t, err)
+
+		run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+		require.NoError(t, err)
+		require.Equal(t, gorkflow.RunStatusCompleted, run.Status)
+
+		exec, err := engine.store.GetStepExecution(context.Background(), run.RunID, "flaky")
+		require.NoError(t, err)
+		return wfStore.stepWrites.Load(), exec
+	}
+
+	fullWrites, fullExec := runFlaky(t, StepWriteFull)
+	minimalWrites, minimalExec := runFlaky(t, StepWriteMinimal)
+
+	// Pending, three running attempts, two retries and the result
+	assert.Equal(t, int32(7), fullWrites)
+	// First running transition and the result
+	assert.Equal(t, int32(2), minimalWrites)
+
+	// Both modes end with the same record
+	for _, exec := range []*gorkflow.StepExecution{fullExec, minimalExec} {
+		assert.Equal(t, gorkflow.StepStatusCompleted, exec.Status)
+		assert.Equal(t, 2, exec.Attempt)
+		assert.Len(t, exec.Attempts, 3)
+		assert.NotNil(t, exec.StartedAt)
+		assert.NotNil(t, exec.CompletedAt)
+	}
+}
+
+func TestEngine_StepWriteMode_MinimalFailure(t *testing.T) {
+	wfStore := &countingStore{WorkflowStore: store.NewMemoryStore()}
+	engine := NewEngine(wfStore, WithLogger(zerolog.New(os.Stdout)), WithStepWriteMode(StepWriteMinimal))
+
+	wf, err := builder.NewWorkflow("write_mode_fail", "Write Mode Fail").
+		ThenStep(gorkflow.NewStep("fail", "Fail",
+			func(ctx *gorkflow.StepContext, input DiscoverInput) (DiscoverOutput, error) {
+				return DiscoverOutput{}, errors.New("persistent failure")
+			},
+			gorkflow.WithRetries(2),
+			gorkflow.WithRetryDelay(time.Millisecond),
+		)).
+		Build()
+	require.NoError(t, err)
+
+	run, err := engine.RunWorkflowSync(context.Background(), wf, DiscoverInput{Query: "test"})
+	require.Error(t, err)
+	require.Equal(t, gorkflow.RunStatusFailed, run.Status)
+
+	exec, err := engine.store.GetStepExecution(context.Background(), run.RunID, "fail")
+	require.NoError(t, err)
+	assert.Equal(t, gorkflow.StepStatusFailed, exec.Status)
+	assert.Len(t, exec.Attempts, 3)
+	require.NotNil(t, exec.Error)
+	assert.Equal(t, 2, exec.Error.Attempt)
+	assert.Equal(t, int32(2), wfStore.stepWrites.Load())
+}
@@ -15,6 +15,8 @@ const (
 	ErrCodeExecutionFailed = "EXECUTION_FAILED"
 	ErrCodeCancelled       = "CANCELLED"
 	ErrCodePanic           = "PANIC"
+	ErrCodeCircuitOpen     = "CIRCUIT_OPEN"
+	ErrCodeInterrupted     = "INTERRUPTED"
 	ErrCodeInternalError   = "INTERNAL_ERROR"
 )
 
@@ -25,6 +27,9 @@ type WorkflowError struct {
 	Step      string    `json:"step,omitempty" dynamodbav:"step,omitempty"`
 	Timestamp time.Time `json:"timestamp" dynamodbav:"timestamp"`
 	Details   map[string]interface{} `json:"details,omitempty" dynamodbav:"details,omitempty"`
+
+	// Underlying error, reachable with errors.Is and errors.As; not serialized
+	cause error
 }
 
 // Error implements the error interface
@@ -60,6 +65,55 @@ func (e *WorkflowError) WithDetails(details map[string]interface{}) *WorkflowErr
 	return e
 }
 
+// WithCause records the error this one wraps
+func (e *WorkflowError) WithCause(cause error) *WorkflowError {
+	e.cause = cause
+	return e
+}
+
+// Unwrap returns the wrapped error, nil for errors read back from a store
+func (e *WorkflowError) Unwrap() error {
+	return e.cause
+}
+
+// ErrorCategory groups error codes by how a caller should react to them
+type ErrorCategory string
+
+const (
+	ErrorCategoryValidation  ErrorCategory = "VALIDATION"  // The request was invalid or named something that does not exist
+	ErrorCategoryTimeout     ErrorCategory = "TIMEOUT"     // A step or run ran out of time
+	ErrorCategoryConcurrency ErrorCategory = "CONCURRENCY" // Capacity was exhausted: a concurrency limit or an open circuit
+	ErrorCategoryCancelled   ErrorCategory = "CANCELLED"   // The run was cancelled
+	ErrorCategoryInternal    ErrorCategory = "INTERNAL"    // Everything else, including unknown codes
+)
+
+// Category classifies the error by its code, e.g. for choosing between a 4xx and a 5xx response
+func (e *WorkflowError) Category() ErrorCategory {
+	switch e.Code {
+	case ErrCodeValidation, ErrCodeNotFound:
+		return ErrorCategoryValidation
+	case ErrCodeTimeout:
+		return ErrorCategoryTimeout
+	case ErrCodeConcurrency, ErrCodeCircuitOpen:
+		return ErrorCategoryConcurrency
+	case ErrCodeCancelled:
+		return ErrorCategoryCancelled
+	default:
+		return ErrorCategoryInternal
+	}
+}
+
+// IsRetryable reports whether running the workflow again may succeed without changing its input:
+// timeouts, exhausted capacity and runs interrupted by a stopped engine
+func (e *WorkflowError) IsRetryable() bool {
+	switch e.Category() {
+	case ErrorCategoryTimeout, ErrorCategoryConcurrency:
+		return true
+	default:
+		return e.Code == ErrCodeInterrupted
+	}
+}
+
 // StepError represents an error during step execution
 type StepError struct {
 	Message   string                 `json:"message" dynamodbav:"message"`
@@ -67,6 +121,9 @@ type StepError struct {
 	Timestamp time.Time              `json:"timestamp" dynamodbav:"timestamp"`
 	Attempt   int                    `json:"attempt" dynamodbav:"attempt"`
 	Details   map[string]interface{} `json:"details,omitempty" dynamodbav:"details,omitempty"`
+
+	// Underlying error, reachable with errors.Is and errors.As; not serialized
+	cause error
 }
 
 // Error implements the error interface
@@ -90,6 +147,17 @@ func (e *StepError) WithDetails(details map[string]interface{}) *StepError {
 	return e
 }
 
+// WithCause records the error this one wraps
+func (e *StepError) WithCause(cause error) *StepError {
+	e.cause = cause
+	return e
+}
+
+// Unwrap returns the wrapped error, nil for errors read back from a store
+func (e *StepError) Unwrap() error {
+	return e.cause
+}
+
 // Helper functions to convert Go errors to workflow errors
 func toWorkflowError(err error) *WorkflowError {
 	if err == nil {
@@ -106,6 +174,7 @@ func toWorkflowError(err error) *WorkflowError {
 		Message:   err.Error(),
 		Code:      ErrCodeInternalError,
 		Timestamp: time.Now(),
+		cause:     err,
 	}
 }
 
@@ -126,6 +195,7 @@ func toStepError(err error, attempt int) *StepError {
 			Code:      ErrCodeTimeout,
 			Timestamp: time.Now(),
 			Attempt:   attempt,
+			cause:     err,
 		}
 	}
 
@@ -135,6 +205,7 @@ func toStepError(err error, attempt int) *StepError {
 		Code:      ErrCodeExecutionFailed,
 		Timestamp: time.Now(),
 		Attempt:   attempt,
+		cause:     err,
 	}
 }
 
@@ -0,0 +1,35 @@
+package gorkflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowError_Category(t *testing.T) {
+	tests := []struct {
+		code      string
+		category  ErrorCategory
+		retryable bool
+	}{
+		{ErrCodeValidation, ErrorCategoryValidation, false},
+		{ErrCodeNotFound, ErrorCategoryValidation, false},
+		{ErrCodeTimeout, ErrorCategoryTimeout, true},
+		{ErrCodeConcurrency, ErrorCategoryConcurrency, true},
+		{ErrCodeCircuitOpen, ErrorCategoryConcurrency, true},
+		{ErrCodeCancelled, ErrorCategoryCancelled, false},
+		{ErrCodeExecutionFailed, ErrorCategoryInternal, false},
+		{ErrCodePanic, ErrorCategoryInternal, false},
+		{ErrCodeInterrupted, ErrorCategoryInternal, true},
+		{ErrCodeInternalError, ErrorCategoryInternal, false},
+		{"SOMETHING_NEW", ErrorCategoryInternal, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			err := NewWorkflowError(tt.code, "failed")
+			assert.Equal(t, tt.category, err.Category())
+			assert.Equal(t, tt.retryable, err.IsRetryable())
+		})
+	}
+}
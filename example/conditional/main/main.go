@@ -72,10 +72,18 @@ func initializeApp() {
 func registerRoutes(app *fiber.App) {
 	// Health check endpoint
 	app.Get("/health", func(c fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "healthy",
+		health := wfEngine.Health(c.Context())
+
+		status, code := "healthy", fiber.StatusOK
+		if !health.Healthy() {
+			status, code = "unhealthy", fiber.StatusServiceUnavailable
+		}
+
+		return c.Status(code).JSON(fiber.Map{
+			"status":  status,
 			"service": "conditional-workflow-server",
 			"version": "1.0.0",
+			"engine":  health,
 		})
 	})
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"os/signal"
 	"syscall"
@@ -72,10 +73,18 @@ func initializeApp() {
 func registerRoutes(app *fiber.App) {
 	// Health check endpoint
 	app.Get("/health", func(c fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "healthy",
+		health := wfEngine.Health(c.Context())
+
+		status, code := "healthy", fiber.StatusOK
+		if !health.Healthy() {
+			status, code = "unhealthy", fiber.StatusServiceUnavailable
+		}
+
+		return c.Status(code).JSON(fiber.Map{
+			"status":  status,
 			"service": "tendor-email-agent-simple-math",
 			"version": "1.0.0",
+			"engine":  health,
 		})
 	})
 
@@ -128,7 +137,7 @@ func handleStartWorkflow(c fiber.Ctx) error {
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to start workflow")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(errorStatus(err)).JSON(fiber.Map{
 			"error": "Failed to start workflow",
 		})
 	}
@@ -140,6 +149,25 @@ func handleStartWorkflow(c fiber.Ctx) error {
 	})
 }
 
+// errorStatus maps a workflow error to an HTTP status by its category
+func errorStatus(err error) int {
+	var wfErr *gorkflow.WorkflowError
+	if !errors.As(err, &wfErr) {
+		return fiber.StatusInternalServerError
+	}
+
+	switch wfErr.Category() {
+	case gorkflow.ErrorCategoryValidation:
+		return fiber.StatusBadRequest
+	case gorkflow.ErrorCategoryConcurrency:
+		return fiber.StatusTooManyRequests
+	case gorkflow.ErrorCategoryTimeout:
+		return fiber.StatusGatewayTimeout
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
 // handleGetStatus retrieves workflow status
 func handleGetStatus(c fiber.Ctx) error {
 	runID := c.Params("runId")
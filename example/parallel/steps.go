@@ -47,12 +47,12 @@ func NewMultiplyStep() *gorkflow.Step[WorkflowInput, MultiplyOutput] {
 	)
 }
 
-func NewFormatStep() *gorkflow.Step[MultiplyOutput, FormatOutput] {
+func NewFormatStep() *gorkflow.Step[FormatInput, FormatOutput] {
 	return gorkflow.NewStep(
 		"format",
 		"Format Output",
-		func(ctx *gorkflow.StepContext, input MultiplyOutput) (FormatOutput, error) {
-			msg := fmt.Sprintf("The final result is %d", input.Value)
+		func(ctx *gorkflow.StepContext, input FormatInput) (FormatOutput, error) {
+			msg := fmt.Sprintf("The sum is %d and the product is %d", input.Add.Value, input.Multiply.Value)
 			ctx.Logger.Info().Str("message", msg).Msg("Formatting output")
 			return FormatOutput{Message: msg}, nil
 		},
@@ -29,9 +29,10 @@ type MultiplyOutput struct {
 	Value int `json:"value"`
 }
 
-// Step 3: Format
+// Step 3: Format (joins the parallel block, outputs keyed by step ID)
 type FormatInput struct {
-	Number int `json:"value"`
+	Add      AddOutput      `json:"add"`
+	Multiply MultiplyOutput `json:"multiply"`
 }
 
 type FormatOutput struct {
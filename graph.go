@@ -2,6 +2,8 @@ package gorkflow
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 )
 
 // ExecutionGraph defines the workflow execution flow
@@ -12,10 +14,15 @@ type ExecutionGraph struct {
 
 // GraphNode represents a node in the execution graph
 type GraphNode struct {
-	StepID     string
-	Type       NodeType
-	Next       []string
+	StepID string
+	Type   NodeType
+	Next   []string
+
+	// Conditions[i] guards the edge to Next[i]; nil or missing entries are unconditional
 	Conditions []Condition
+
+	// Concurrency limit for the parallel block this node belongs to (0 = unlimited)
+	MaxParallel int
 }
 
 // NewExecutionGraph creates a new execution graph
@@ -42,8 +49,13 @@ func (g *ExecutionGraph) AddNode(stepID string, nodeType NodeType) {
 	}
 }
 
-// AddEdge adds a directed edge from one step to another
+// AddEdge adds a directed edge from one step to another. Self-edges are rejected
+// since they are never valid in a DAG.
 func (g *ExecutionGraph) AddEdge(fromStepID, toStepID string) error {
+	if fromStepID == toStepID {
+		return fmt.Errorf("step %s cannot have an edge to itself", fromStepID)
+	}
+
 	fromNode, exists := g.Nodes[fromStepID]
 	if !exists {
 		return fmt.Errorf("source node %s not found", fromStepID)
@@ -58,6 +70,43 @@ func (g *ExecutionGraph) AddEdge(fromStepID, toStepID string) error {
 	return nil
 }
 
+// AddConditionalEdge adds an edge that is only followed when condition returns true at runtime.
+// The source node becomes a CONDITIONAL node unless it belongs to a parallel block.
+func (g *ExecutionGraph) AddConditionalEdge(fromStepID, toStepID string, condition Condition) error {
+	if condition == nil {
+		return fmt.Errorf("condition for edge %s -> %s is nil", fromStepID, toStepID)
+	}
+
+	if err := g.AddEdge(fromStepID, toStepID); err != nil {
+		return err
+	}
+
+	fromNode := g.Nodes[fromStepID]
+	for len(fromNode.Conditions) < len(fromNode.Next)-1 {
+		fromNode.Conditions = append(fromNode.Conditions, nil)
+	}
+	fromNode.Conditions = append(fromNode.Conditions, condition)
+
+	if fromNode.Type == NodeTypeSequential {
+		fromNode.Type = NodeTypeConditional
+	}
+	return nil
+}
+
+// EdgeCondition returns the condition guarding the edge between two steps, or nil if it is unconditional
+func (g *ExecutionGraph) EdgeCondition(fromStepID, toStepID string) Condition {
+	node, exists := g.Nodes[fromStepID]
+	if !exists {
+		return nil
+	}
+
+	i := slices.Index(node.Next, toStepID)
+	if i < 0 || i >= len(node.Conditions) {
+		return nil
+	}
+	return node.Conditions[i]
+}
+
 // SetEntryPoint sets the entry point of the graph
 func (g *ExecutionGraph) SetEntryPoint(stepID string) error {
 	if _, exists := g.Nodes[stepID]; !exists {
@@ -77,20 +126,12 @@ func (g *ExecutionGraph) Validate() error {
 		return fmt.Errorf("entry point %s not found in graph", g.EntryPoint)
 	}
 
-	// Check for cycles (simple DFS-based cycle detection)
-	visited := make(map[string]bool)
-	recStack := make(map[string]bool)
-
-	for nodeID := range g.Nodes {
-		if !visited[nodeID] {
-			if g.hasCycle(nodeID, visited, recStack) {
-				return fmt.Errorf("execution graph contains cycles")
-			}
-		}
+	if g.HasCycle() {
+		return fmt.Errorf("execution graph contains cycles")
 	}
 
 	// Check that all nodes are reachable from entry point
-	reachable := g.getReachableNodes(g.EntryPoint)
+	reachable := g.Reachable(g.EntryPoint)
 	if len(reachable) != len(g.Nodes) {
 		return fmt.Errorf("not all nodes are reachable from entry point")
 	}
@@ -98,43 +139,69 @@ func (g *ExecutionGraph) Validate() error {
 	return nil
 }
 
-// hasCycle performs DFS to detect cycles
-func (g *ExecutionGraph) hasCycle(nodeID string, visited, recStack map[string]bool) bool {
-	visited[nodeID] = true
-	recStack[nodeID] = true
+// HasCycle reports whether the graph contains a cycle. The walk is iterative, so very
+// large graphs cannot overflow the stack.
+func (g *ExecutionGraph) HasCycle() bool {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int, len(g.Nodes))
+
+	// frame is a node on the DFS path and the index of its next edge to follow
+	type frame struct {
+		nodeID string
+		next   int
+	}
 
-	node := g.Nodes[nodeID]
-	for _, nextID := range node.Next {
-		if !visited[nextID] {
-			if g.hasCycle(nextID, visited, recStack) {
+	for _, rootID := range slices.Sorted(maps.Keys(g.Nodes)) {
+		if state[rootID] != unvisited {
+			continue
+		}
+
+		state[rootID] = inProgress
+		path := []frame{{nodeID: rootID}}
+		for len(path) > 0 {
+			top := &path[len(path)-1]
+			node := g.Nodes[top.nodeID]
+			if node == nil || top.next >= len(node.Next) {
+				state[top.nodeID] = done
+				path = path[:len(path)-1]
+				continue
+			}
+
+			nextID := node.Next[top.next]
+			top.next++
+			switch state[nextID] {
+			case inProgress:
 				return true
+			case unvisited:
+				state[nextID] = inProgress
+				path = append(path, frame{nodeID: nextID})
 			}
-		} else if recStack[nextID] {
-			return true
 		}
 	}
 
-	recStack[nodeID] = false
 	return false
 }
 
-// getReachableNodes returns all nodes reachable from the given start node
-func (g *ExecutionGraph) getReachableNodes(startID string) map[string]bool {
+// Reachable returns the nodes reachable from the given start nodes, including them
+func (g *ExecutionGraph) Reachable(startIDs ...string) map[string]bool {
 	reachable := make(map[string]bool)
-	g.dfsReachable(startID, reachable)
-	return reachable
-}
-
-// dfsReachable performs DFS to find all reachable nodes
-func (g *ExecutionGraph) dfsReachable(nodeID string, reachable map[string]bool) {
-	reachable[nodeID] = true
-
-	node := g.Nodes[nodeID]
-	for _, nextID := range node.Next {
-		if !reachable[nextID] {
-			g.dfsReachable(nextID, reachable)
+	pending := slices.Clone(startIDs)
+	for len(pending) > 0 {
+		nodeID := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		node, exists := g.Nodes[nodeID]
+		if reachable[nodeID] || !exists {
+			continue
 		}
+		reachable[nodeID] = true
+		pending = append(pending, node.Next...)
 	}
+	return reachable
 }
 
 // TopologicalSort returns nodes in topological order
@@ -149,35 +216,36 @@ func (g *ExecutionGraph) GetTopologicalOrder() ([]string, error) {
 		return nil, err
 	}
 
-	visited := make(map[string]bool)
-	stack := []string{}
+	// Depth-first from the entry point, following edges in order; a node is
+	// finished once all its successors are, and the reversed finish order is
+	// topological. An explicit stack keeps deep graphs off the call stack.
+	type frame struct {
+		nodeID string
+		next   int
+	}
 
-	// Perform topological sort using DFS
-	var visit func(string) error
-	visit = func(nodeID string) error {
-		if visited[nodeID] {
-			return nil
+	visited := map[string]bool{g.EntryPoint: true}
+	finished := make([]string, 0, len(g.Nodes))
+	path := []frame{{nodeID: g.EntryPoint}}
+	for len(path) > 0 {
+		top := &path[len(path)-1]
+		node := g.Nodes[top.nodeID]
+		if top.next >= len(node.Next) {
+			finished = append(finished, top.nodeID)
+			path = path[:len(path)-1]
+			continue
 		}
 
-		visited[nodeID] = true
-
-		node := g.Nodes[nodeID]
-		for _, nextID := range node.Next {
-			if err := visit(nextID); err != nil {
-				return err
-			}
+		nextID := node.Next[top.next]
+		top.next++
+		if !visited[nextID] {
+			visited[nextID] = true
+			path = append(path, frame{nodeID: nextID})
 		}
-
-		stack = append([]string{nodeID}, stack...)
-		return nil
-	}
-
-	// Start from entry point
-	if err := visit(g.EntryPoint); err != nil {
-		return nil, err
 	}
 
-	return stack, nil
+	slices.Reverse(finished)
+	return finished, nil
 }
 
 // GetNextSteps returns the next steps to execute after the given step
@@ -190,6 +258,51 @@ func (g *ExecutionGraph) GetNextSteps(stepID string) ([]string, error) {
 	return node.Next, nil
 }
 
+// Predecessors returns the steps with an edge into the given step, sorted by step ID
+func (g *ExecutionGraph) Predecessors(stepID string) []string {
+	var predecessors []string
+	for id, node := range g.Nodes {
+		if slices.Contains(node.Next, stepID) {
+			predecessors = append(predecessors, id)
+		}
+	}
+	slices.Sort(predecessors)
+	return predecessors
+}
+
+// FindRoots returns the steps without predecessors, sorted by step ID. A sound
+// graph has one root, its entry point.
+func (g *ExecutionGraph) FindRoots() []string {
+	hasPredecessor := make(map[string]bool, len(g.Nodes))
+	for _, node := range g.Nodes {
+		for _, nextID := range node.Next {
+			hasPredecessor[nextID] = true
+		}
+	}
+
+	var roots []string
+	for stepID := range g.Nodes {
+		if !hasPredecessor[stepID] {
+			roots = append(roots, stepID)
+		}
+	}
+	slices.Sort(roots)
+	return roots
+}
+
+// TerminalNodes returns the steps without outgoing edges, sorted by step ID. Their outputs
+// make up the result of a run.
+func (g *ExecutionGraph) TerminalNodes() []string {
+	var terminals []string
+	for stepID, node := range g.Nodes {
+		if len(node.Next) == 0 {
+			terminals = append(terminals, stepID)
+		}
+	}
+	slices.Sort(terminals)
+	return terminals
+}
+
 // IsTerminal returns true if the step has no outgoing edges
 func (g *ExecutionGraph) IsTerminal(stepID string) bool {
 	node, exists := g.Nodes[stepID]
@@ -211,7 +324,9 @@ func (g *ExecutionGraph) Clone() *ExecutionGraph {
 			StepID: node.StepID,
 			Type:   node.Type,
 			Next:   append([]string{}, node.Next...),
-			// Note: Conditions are not cloned as they're functions
+			// Condition funcs are shared with the original
+			Conditions:  append([]Condition{}, node.Conditions...),
+			MaxParallel: node.MaxParallel,
 		}
 	}
 
@@ -1,6 +1,7 @@
 package gorkflow
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -60,6 +61,21 @@ func TestExecutionGraph_AddEdge_NonExistentTo(t *testing.T) {
 	assert.Contains(t, err.Error(), "step2 not found")
 }
 
+func TestExecutionGraph_AddEdge_SelfEdge(t *testing.T) {
+	graph := NewExecutionGraph()
+	graph.AddNode("step1", NodeTypeSequential)
+
+	err := graph.AddEdge("step1", "step1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "step1")
+	assert.Empty(t, graph.Nodes["step1"].Next)
+
+	// Conditional edges go through the same check
+	err = graph.AddConditionalEdge("step1", "step1", func(ctx *StepContext) (bool, error) { return true, nil })
+	require.Error(t, err)
+	assert.Empty(t, graph.Nodes["step1"].Conditions)
+}
+
 func TestExecutionGraph_SetEntryPoint(t *testing.T) {
 	graph := NewExecutionGraph()
 	graph.AddNode("step1", NodeTypeSequential)
@@ -127,6 +143,24 @@ func TestExecutionGraph_Validate_UnreachableNodes(t *testing.T) {
 	assert.Contains(t, err.Error(), "not all nodes are reachable")
 }
 
+func TestExecutionGraph_FindRoots(t *testing.T) {
+	graph := NewExecutionGraph()
+	graph.AddNode("b_root", NodeTypeSequential)
+	graph.AddNode("a_root", NodeTypeSequential)
+	graph.AddNode("join", NodeTypeSequential)
+
+	graph.AddEdge("a_root", "join")
+	graph.AddEdge("b_root", "join")
+
+	assert.Equal(t, []string{"a_root", "b_root"}, graph.FindRoots())
+
+	graph.AddNode("start", NodeTypeSequential)
+	graph.AddEdge("start", "a_root")
+	graph.AddEdge("start", "b_root")
+
+	assert.Equal(t, []string{"start"}, graph.FindRoots())
+}
+
 func TestExecutionGraph_Validate_ValidSequential(t *testing.T) {
 	graph := NewExecutionGraph()
 	graph.AddNode("step1", NodeTypeSequential)
@@ -261,6 +295,25 @@ func TestExecutionGraph_IsTerminal(t *testing.T) {
 	assert.True(t, graph.IsTerminal("step2"))
 }
 
+func TestExecutionGraph_TerminalNodes(t *testing.T) {
+	graph := NewExecutionGraph()
+	graph.AddNode("start", NodeTypeSequential)
+	assert.Equal(t, []string{"start"}, graph.TerminalNodes())
+
+	// A fork ends in both branches
+	graph.AddNode("b_branch", NodeTypeParallel)
+	graph.AddNode("a_branch", NodeTypeParallel)
+	graph.AddEdge("start", "a_branch")
+	graph.AddEdge("start", "b_branch")
+	assert.Equal(t, []string{"a_branch", "b_branch"}, graph.TerminalNodes())
+
+	// Joining them leaves a single terminal
+	graph.AddNode("join", NodeTypeSequential)
+	graph.AddEdge("a_branch", "join")
+	graph.AddEdge("b_branch", "join")
+	assert.Equal(t, []string{"join"}, graph.TerminalNodes())
+}
+
 func TestExecutionGraph_Clone(t *testing.T) {
 	graph := NewExecutionGraph()
 	graph.AddNode("step1", NodeTypeSequential)
@@ -309,8 +362,56 @@ func TestExecutionGraph_ComplexTopologicalSort(t *testing.T) {
 	assert.Equal(t, "step5", order[4])
 }
 
+func TestExecutionGraph_LongChain(t *testing.T) {
+	const length = 10000
+
+	graph := NewExecutionGraph()
+	for i := range length {
+		graph.AddNode(fmt.Sprintf("step%d", i), NodeTypeSequential)
+		if i > 0 {
+			graph.AddEdge(fmt.Sprintf("step%d", i-1), fmt.Sprintf("step%d", i))
+		}
+	}
+	graph.SetEntryPoint("step0")
+
+	require.NoError(t, graph.Validate())
+	order, err := graph.TopologicalSort()
+	require.NoError(t, err)
+	require.Len(t, order, length)
+	assert.Equal(t, "step9999", order[length-1])
+
+	// Closing the chain into a loop is still found
+	graph.AddEdge("step9999", "step0")
+	assert.True(t, graph.HasCycle())
+}
+
 func TestNodeType_String(t *testing.T) {
 	assert.Equal(t, "SEQUENTIAL", NodeTypeSequential.String())
 	assert.Equal(t, "PARALLEL", NodeTypeParallel.String())
 	assert.Equal(t, "CONDITIONAL", NodeTypeConditional.String())
 }
+
+func TestExecutionGraph_AddConditionalEdge(t *testing.T) {
+	graph := NewExecutionGraph()
+	graph.AddNode("route", NodeTypeSequential)
+	graph.AddNode("audit", NodeTypeSequential)
+	graph.AddNode("small", NodeTypeSequential)
+	graph.AddNode("large", NodeTypeSequential)
+
+	always := func(ctx *StepContext) (bool, error) { return true, nil }
+
+	require.NoError(t, graph.AddEdge("route", "audit"))
+	require.NoError(t, graph.AddConditionalEdge("route", "small", always))
+	require.NoError(t, graph.AddConditionalEdge("route", "large", always))
+
+	assert.Equal(t, []string{"audit", "small", "large"}, graph.Nodes["route"].Next)
+	assert.Equal(t, NodeTypeConditional, graph.Nodes["route"].Type)
+
+	assert.Nil(t, graph.EdgeCondition("route", "audit"))
+	assert.NotNil(t, graph.EdgeCondition("route", "small"))
+	assert.NotNil(t, graph.EdgeCondition("route", "large"))
+	assert.Nil(t, graph.EdgeCondition("route", "missing"))
+
+	assert.Error(t, graph.AddConditionalEdge("route", "missing", always))
+	assert.Error(t, graph.AddConditionalEdge("route", "small", nil))
+}
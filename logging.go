@@ -15,6 +15,8 @@ const (
 	EventWorkflowCompleted = "workflow_completed"
 	EventWorkflowFailed    = "workflow_failed"
 	EventWorkflowCancelled = "workflow_cancelled"
+	EventWorkflowPaused    = "workflow_paused"
+	EventWorkflowResumed   = "workflow_resumed"
 
 	// Step-level events
 	EventStepStarted   = "step_started"
@@ -82,6 +84,22 @@ func LogWorkflowCancelled(logger zerolog.Logger, runID string) {
 		Msg("Workflow cancelled")
 }
 
+// LogWorkflowPaused logs when a run stops between steps because it was paused
+func LogWorkflowPaused(logger zerolog.Logger, runID string) {
+	logger.Info().
+		Str("event", EventWorkflowPaused).
+		Str("run_id", runID).
+		Msg("Workflow paused")
+}
+
+// LogWorkflowResumed logs when a paused run continues
+func LogWorkflowResumed(logger zerolog.Logger, runID string) {
+	logger.Info().
+		Str("event", EventWorkflowResumed).
+		Str("run_id", runID).
+		Msg("Workflow resumed")
+}
+
 // LogStepStarted logs when a step starts execution
 func LogStepStarted(logger zerolog.Logger, runID, stepID, stepName string, stepNum, totalSteps int) {
 	logger.Info().
@@ -11,6 +11,7 @@ type RunStatus string
 const (
 	RunStatusPending   RunStatus = "PENDING"
 	RunStatusRunning   RunStatus = "RUNNING"
+	RunStatusPaused    RunStatus = "PAUSED"
 	RunStatusCompleted RunStatus = "COMPLETED"
 	RunStatusFailed    RunStatus = "FAILED"
 	RunStatusCancelled RunStatus = "CANCELLED"
@@ -36,6 +37,7 @@ const (
 	StepStatusFailed    StepStatus = "FAILED"
 	StepStatusSkipped   StepStatus = "SKIPPED"
 	StepStatusRetrying  StepStatus = "RETRYING"
+	StepStatusWaiting   StepStatus = "WAITING" // Parked until a signal is sent to the run
 )
 
 // IsTerminal returns true if the status is a final state
@@ -59,16 +61,31 @@ type WorkflowRun struct {
 	Status   RunStatus `json:"status" dynamodbav:"status"`
 	Progress float64   `json:"progress" dynamodbav:"progress"` // 0.0 to 1.0
 
+	// Step being executed, or the comma-separated steps of a parallel block; empty between steps
+	CurrentStep string `json:"currentStep,omitempty" dynamodbav:"current_step,omitempty"`
+
+	// Step attempts made so far across the run, retries included
+	TotalAttempts int `json:"totalAttempts,omitempty" dynamodbav:"total_attempts,omitempty"`
+
+	// Every status the run has entered, oldest first
+	StatusHistory []StatusTransition `json:"statusHistory,omitempty" dynamodbav:"status_history,omitempty"`
+
 	// Timing
 	CreatedAt   time.Time  `json:"createdAt" dynamodbav:"created_at"`
 	StartedAt   *time.Time `json:"startedAt,omitempty" dynamodbav:"started_at,omitempty"`
 	CompletedAt *time.Time `json:"completedAt,omitempty" dynamodbav:"completed_at,omitempty"`
 	UpdatedAt   time.Time  `json:"updatedAt" dynamodbav:"updated_at"`
 
+	// Due time for runs created with ScheduleWorkflow
+	ScheduledAt *time.Time `json:"scheduledAt,omitempty" dynamodbav:"scheduled_at,omitempty"`
+
 	// Input/Output (serialized as JSON bytes)
 	Input  json.RawMessage `json:"input,omitempty" dynamodbav:"input,omitempty"`
 	Output json.RawMessage `json:"output,omitempty" dynamodbav:"output,omitempty"`
 
+	// Outputs of the steps that completed before a run failed, as a JSON object keyed by step ID
+	PartialOutputs json.RawMessage `json:"partialOutputs,omitempty" dynamodbav:"partial_outputs,omitempty"`
+
 	// Error handling
 	Error *WorkflowError `json:"error,omitempty" dynamodbav:"error,omitempty"`
 
@@ -80,10 +97,29 @@ type WorkflowRun struct {
 	// Custom context (serialized as JSON bytes)
 	Context json.RawMessage `json:"context,omitempty" dynamodbav:"context,omitempty"`
 
+	// Workflow structure the run executed, from Workflow.MarshalDefinition
+	Definition json.RawMessage `json:"definition,omitempty" dynamodbav:"definition,omitempty"`
+
 	// DynamoDB TTL
 	TTL int64 `json:"-" dynamodbav:"ttl,omitempty"`
 }
 
+// StatusTransition records a run entering a status
+type StatusTransition struct {
+	Status RunStatus `json:"status" dynamodbav:"status"`
+	At     time.Time `json:"at" dynamodbav:"at"`
+}
+
+// SetStatus moves the run to status, recording the transition in StatusHistory.
+// Setting the status the run already has records nothing.
+func (r *WorkflowRun) SetStatus(status RunStatus, at time.Time) {
+	if r.Status == status && len(r.StatusHistory) > 0 {
+		return
+	}
+	r.Status = status
+	r.StatusHistory = append(r.StatusHistory, StatusTransition{Status: status, At: at})
+}
+
 // TriggerInfo captures what initiated the workflow
 type TriggerInfo struct {
 	Type      string            `json:"type" dynamodbav:"type"`     // "api", "schedule", "event"
@@ -115,11 +151,29 @@ type StepExecution struct {
 	Error   *StepError `json:"error,omitempty" dynamodbav:"error,omitempty"`
 	Attempt int        `json:"attempt" dynamodbav:"attempt"` // Current retry attempt
 
+	// Every attempt made so far, oldest first
+	Attempts []AttemptRecord `json:"attempts,omitempty" dynamodbav:"attempts,omitempty"`
+
+	// Metadata set on the step with WithStepTags
+	Tags map[string]string `json:"tags,omitempty" dynamodbav:"tags,omitempty"`
+
+	// Step's WithOutputVersion when it stored its output
+	OutputVersion int `json:"outputVersion,omitempty" dynamodbav:"output_version,omitempty"`
+
 	// Metadata
 	CreatedAt time.Time `json:"createdAt" dynamodbav:"created_at"`
 	UpdatedAt time.Time `json:"updatedAt" dynamodbav:"updated_at"`
 }
 
+// AttemptRecord describes a single attempt of a step execution
+type AttemptRecord struct {
+	Attempt     int       `json:"attempt" dynamodbav:"attempt"`
+	StartedAt   time.Time `json:"startedAt" dynamodbav:"started_at"`
+	CompletedAt time.Time `json:"completedAt" dynamodbav:"completed_at"`
+	DurationMs  int64     `json:"durationMs" dynamodbav:"duration_ms"`
+	Error       string    `json:"error,omitempty" dynamodbav:"error,omitempty"` // Empty for a successful attempt
+}
+
 // WorkflowState holds business data separate from execution metadata
 type WorkflowState struct {
 	RunID     string            `json:"runId" dynamodbav:"run_id"`
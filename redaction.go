@@ -0,0 +1,75 @@
+package gorkflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// RedactedValue replaces redacted values in stored and logged payloads
+const RedactedValue = "[REDACTED]"
+
+// WithRedaction redacts the given JSON paths from the step's input and output wherever the
+// engine stores or logs them. Paths are dot-separated object keys, e.g. "password" or
+// "user.credentials.token"; arrays along a path are redacted element by element. Downstream
+// steps still receive the original output.
+func WithRedaction(paths []string) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetRedaction([]string) }); ok {
+			step.SetRedaction(paths)
+		}
+	})
+}
+
+// RedactJSON returns a copy of data with the value at each path replaced by RedactedValue.
+// Payloads that are not JSON are replaced entirely, since their contents cannot be inspected.
+func RedactJSON(data []byte, paths []string) []byte {
+	if len(paths) == 0 || len(data) == 0 {
+		return data
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		redacted, _ := json.Marshal(RedactedValue)
+		return redacted
+	}
+
+	for _, path := range paths {
+		if path != "" {
+			value = redactPath(value, strings.Split(path, "."))
+		}
+	}
+
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		redacted, _ = json.Marshal(RedactedValue)
+	}
+	return redacted
+}
+
+// redactPath replaces the value at keys below value, descending into arrays
+func redactPath(value interface{}, keys []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, ok := v[keys[0]]
+		if !ok {
+			return v
+		}
+		if len(keys) == 1 {
+			v[keys[0]] = RedactedValue
+		} else {
+			v[keys[0]] = redactPath(child, keys[1:])
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = redactPath(v[i], keys)
+		}
+		return v
+	default:
+		return value
+	}
+}
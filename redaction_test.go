@@ -0,0 +1,71 @@
+package gorkflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		paths []string
+		want  string
+	}{
+		{
+			name:  "top-level field",
+			data:  `{"user":"ada","password":"hunter2"}`,
+			paths: []string{"password"},
+			want:  `{"password":"[REDACTED]","user":"ada"}`,
+		},
+		{
+			name:  "nested field",
+			data:  `{"user":{"name":"ada","credentials":{"token":"abc","scope":"read"}}}`,
+			paths: []string{"user.credentials.token"},
+			want:  `{"user":{"credentials":{"scope":"read","token":"[REDACTED]"},"name":"ada"}}`,
+		},
+		{
+			name:  "field of array elements",
+			data:  `{"users":[{"ssn":"1","id":1},{"ssn":"2","id":2}]}`,
+			paths: []string{"users.ssn"},
+			want:  `{"users":[{"id":1,"ssn":"[REDACTED]"},{"id":2,"ssn":"[REDACTED]"}]}`,
+		},
+		{
+			name:  "whole object",
+			data:  `{"card":{"number":"4111"},"amount":10.50}`,
+			paths: []string{"card"},
+			want:  `{"amount":10.50,"card":"[REDACTED]"}`,
+		},
+		{
+			name:  "missing path is left alone",
+			data:  `{"user":"ada"}`,
+			paths: []string{"password", "user.password"},
+			want:  `{"user":"ada"}`,
+		},
+		{
+			name:  "not JSON",
+			data:  `password=hunter2`,
+			paths: []string{"password"},
+			want:  `"[REDACTED]"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(RedactJSON([]byte(tt.data), tt.paths)))
+		})
+	}
+
+	// Without paths the payload is returned as is
+	data := []byte(`{"password":"hunter2"}`)
+	assert.Equal(t, data, RedactJSON(data, nil))
+}
+
+func TestWithRedaction(t *testing.T) {
+	step := NewStep("test", "Test", testHandler)
+
+	ApplyStepOptions(step, WithRedaction([]string{"password"}))
+
+	assert.Equal(t, []string{"password"}, step.GetRedaction())
+}
@@ -0,0 +1,74 @@
+package gorkflow
+
+import (
+	"fmt"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// jsonSchema is a compiled JSON Schema used to validate step payloads
+type jsonSchema struct {
+	source []byte
+	schema *gojsonschema.Schema
+	err    error
+}
+
+// compileJSONSchema compiles a schema document; compile errors are reported on first validation
+func compileJSONSchema(source []byte) *jsonSchema {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(source))
+	return &jsonSchema{
+		source: source,
+		schema: schema,
+		err:    err,
+	}
+}
+
+// validate checks data against the schema. A nil schema accepts anything.
+func (s *jsonSchema) validate(data []byte) error {
+	if s == nil {
+		return nil
+	}
+	if s.err != nil {
+		return fmt.Errorf("invalid JSON schema: %w", s.err)
+	}
+
+	result, err := s.schema.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return fmt.Errorf("failed to validate against JSON schema: %w", err)
+	}
+	if !result.Valid() {
+		return &schemaError{errors: result.Errors()}
+	}
+	return nil
+}
+
+// schemaError lists JSON Schema violations
+type schemaError struct {
+	errors []gojsonschema.ResultError
+}
+
+func (e *schemaError) Error() string {
+	msg := "schema validation failed:\n"
+	for _, err := range e.errors {
+		msg += fmt.Sprintf("  - %s\n", err.String())
+	}
+	return msg
+}
+
+// WithInputSchema validates step input against a JSON Schema document
+func WithInputSchema(schema []byte) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetInputSchema([]byte) }); ok {
+			step.SetInputSchema(schema)
+		}
+	})
+}
+
+// WithOutputSchema validates step output against a JSON Schema document
+func WithOutputSchema(schema []byte) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetOutputSchema([]byte) }); ok {
+			step.SetOutputSchema(schema)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package gorkflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var requireNameSchema = []byte(`{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1}
+	},
+	"required": ["name"]
+}`)
+
+func TestStep_InputSchema(t *testing.T) {
+	step := NewStep("test-step", "Test Step", testHandler, WithInputSchema(requireNameSchema))
+
+	assert.Equal(t, requireNameSchema, step.InputSchema())
+	assert.NoError(t, step.ValidateInput([]byte(`{"value": 1, "name": "ok"}`)))
+
+	err := step.ValidateInput([]byte(`{"value": 1}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "name")
+	assert.Contains(t, err.Error(), "test-step")
+}
+
+func TestStep_OutputSchema(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {"result": {"type": "integer", "maximum": 10}}}`)
+	step := NewStep("test-step", "Test Step", testHandler, WithOutputSchema(schema))
+
+	assert.NoError(t, step.ValidateOutput([]byte(`{"result": 5}`)))
+	assert.Error(t, step.ValidateOutput([]byte(`{"result": 50}`)))
+
+	ctx := &StepContext{
+		Context: context.Background(),
+		RunID:   "test-run",
+		StepID:  "test-step",
+		Logger:  zerolog.Nop(),
+	}
+
+	// Handler doubles the value, so 21 produces a result above the maximum
+	_, err := step.Execute(ctx, []byte(`{"value": 21, "name": "test"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "output validation failed")
+
+	_, err = step.Execute(ctx, []byte(`{"value": 2, "name": "test"}`))
+	assert.NoError(t, err)
+}
+
+func TestStep_InvalidSchema(t *testing.T) {
+	step := NewStep("test-step", "Test Step", testHandler, WithInputSchema([]byte(`{not json`)))
+
+	err := step.ValidateInput([]byte(`{"name": "ok"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid JSON schema")
+}
+
+func TestValidateStruct_NonStruct(t *testing.T) {
+	step := NewStep("string-step", "String Step",
+		func(ctx *StepContext, input string) (string, error) {
+			return input, nil
+		},
+	)
+
+	assert.NoError(t, step.ValidateInput([]byte(`"hello"`)))
+	assert.NoError(t, step.ValidateOutput([]byte(`"hello"`)))
+}
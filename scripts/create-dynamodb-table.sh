@@ -18,6 +18,8 @@ aws dynamodb create-table \
     AttributeName=GSI1SK,AttributeType=S \
     AttributeName=GSI2PK,AttributeType=S \
     AttributeName=GSI2SK,AttributeType=S \
+    AttributeName=GSI3PK,AttributeType=S \
+    AttributeName=GSI3SK,AttributeType=S \
   --key-schema \
     AttributeName=PK,KeyType=HASH \
     AttributeName=SK,KeyType=RANGE \
@@ -39,6 +41,14 @@ aws dynamodb create-table \
           {\"AttributeName\": \"GSI2SK\", \"KeyType\": \"RANGE\"}
         ],
         \"Projection\": {\"ProjectionType\": \"ALL\"}
+      },
+      {
+        \"IndexName\": \"GSI3\",
+        \"KeySchema\": [
+          {\"AttributeName\": \"GSI3PK\", \"KeyType\": \"HASH\"},
+          {\"AttributeName\": \"GSI3SK\", \"KeyType\": \"RANGE\"}
+        ],
+        \"Projection\": {\"ProjectionType\": \"ALL\"}
       }
     ]" \
   --tags \
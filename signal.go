@@ -0,0 +1,188 @@
+package gorkflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// signalStatePrefix namespaces delivered signal payloads within the run state
+const signalStatePrefix = "signal:"
+
+// signalPollInterval is how often WaitForSignalStep.Execute checks the run state when run outside the engine
+const signalPollInterval = time.Second
+
+// SignalStateKey returns the run state key a signal's payload is stored under once it is sent
+func SignalStateKey(signal string) string {
+	return signalStatePrefix + signal
+}
+
+// WaitForSignalStep parks the workflow until the named signal is sent to the run, e.g. a
+// human approval. The signal's payload becomes the step's output. The engine records the
+// step as WAITING while it waits, so the signal may be sent from another process.
+type WaitForSignalStep struct {
+	ID          string
+	Name        string
+	Description string
+	Signal      string
+	Config      ExecutionConfig
+
+	// How long to wait for the signal (0 = until the workflow times out)
+	Timeout time.Duration
+
+	// Output used when Timeout passes without a signal; nil fails the step instead
+	Default json.RawMessage
+
+	// Share of the run's progress, non-positive weights count as 1.0
+	weight float64
+}
+
+// NewWaitForSignalStep creates a step that waits for the named signal.
+// Signal steps are never retried.
+func NewWaitForSignalStep(id, name, signal string, opts ...StepOption) *WaitForSignalStep {
+	config := DefaultExecutionConfig
+	config.MaxRetries = 0
+
+	s := &WaitForSignalStep{
+		ID:     id,
+		Name:   name,
+		Signal: signal,
+		Config: config,
+		weight: 1.0,
+	}
+
+	for _, opt := range opts {
+		opt.applyStep(s)
+	}
+
+	return s
+}
+
+func (s *WaitForSignalStep) GetID() string {
+	return s.ID
+}
+
+func (s *WaitForSignalStep) GetName() string {
+	return s.Name
+}
+
+func (s *WaitForSignalStep) GetDescription() string {
+	return s.Description
+}
+
+func (s *WaitForSignalStep) GetConfig() ExecutionConfig {
+	return s.Config
+}
+
+// GetSignal returns the name of the signal the step waits for
+func (s *WaitForSignalStep) GetSignal() string {
+	return s.Signal
+}
+
+// GetSignalTimeout returns how long the step waits for its signal (0 = no limit)
+func (s *WaitForSignalStep) GetSignalTimeout() time.Duration {
+	return s.Timeout
+}
+
+// GetSignalDefault returns the output used when the signal timeout passes, if any
+func (s *WaitForSignalStep) GetSignalDefault() []byte {
+	return s.Default
+}
+
+// GetWeight returns the step's share of the run's progress
+func (s *WaitForSignalStep) GetWeight() float64 {
+	return s.weight
+}
+
+// InputType accepts any input; the step ignores it
+func (s *WaitForSignalStep) InputType() reflect.Type {
+	return reflect.TypeOf((*any)(nil)).Elem()
+}
+
+// OutputType is the raw signal payload
+func (s *WaitForSignalStep) OutputType() reflect.Type {
+	return reflect.TypeOf(json.RawMessage{})
+}
+
+// Execute waits for the signal by polling the run state. The engine does not call it for
+// signal steps it runs directly; it is used when the step is wrapped, e.g. by ThenStepIf.
+func (s *WaitForSignalStep) Execute(ctx *StepContext, input []byte) ([]byte, error) {
+	ticker := time.NewTicker(signalPollInterval)
+	defer ticker.Stop()
+
+	var expired <-chan time.Time
+	if s.Timeout > 0 {
+		timer := time.NewTimer(s.Timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		var payload json.RawMessage
+		if err := ctx.State.Get(SignalStateKey(s.Signal), &payload); err == nil {
+			return payload, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for signal %s: %w", s.Signal, ctx.Err())
+		case <-expired:
+			if s.Default != nil {
+				return s.Default, nil
+			}
+			return nil, fmt.Errorf("signal %s not received within %s", s.Signal, s.Timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *WaitForSignalStep) ValidateInput(data []byte) error {
+	return nil
+}
+
+func (s *WaitForSignalStep) ValidateOutput(data []byte) error {
+	return nil
+}
+
+// Setters for options
+
+func (s *WaitForSignalStep) SetName(name string) {
+	s.Name = name
+}
+
+func (s *WaitForSignalStep) SetContinueOnError(continueOnError bool) {
+	s.Config.ContinueOnError = continueOnError
+}
+
+func (s *WaitForSignalStep) SetWeight(weight float64) {
+	s.weight = weight
+}
+
+func (s *WaitForSignalStep) SetSignalTimeout(d time.Duration) {
+	s.Timeout = d
+}
+
+func (s *WaitForSignalStep) SetSignalDefault(payload []byte) {
+	s.Default = payload
+}
+
+// WithSignalTimeout bounds how long a WaitForSignalStep waits. When no signal arrives in time the
+// step fails with ErrCodeTimeout, unless WithSignalDefault provides an output to continue with.
+func WithSignalTimeout(d time.Duration) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetSignalTimeout(time.Duration) }); ok {
+			step.SetSignalTimeout(d)
+		}
+	})
+}
+
+// WithSignalDefault sets the JSON output a WaitForSignalStep completes with when its signal
+// timeout passes without a signal
+func WithSignalDefault(payload []byte) StepOption {
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetSignalDefault([]byte) }); ok {
+			step.SetSignalDefault(payload)
+		}
+	})
+}
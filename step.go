@@ -3,7 +3,9 @@ package gorkflow
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"reflect"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -27,6 +29,53 @@ type Step[TIn, TOut any] struct {
 	// Validation configuration (internal)
 	validationConfig *validationConfig
 
+	// Optional JSON Schemas for input/output payloads
+	inputSchema  *jsonSchema
+	outputSchema *jsonSchema
+
+	// Step-specific custom context, overrides the workflow context
+	customContext any
+
+	// Compensating action run when a later step fails the workflow
+	compensation CompensationHandler
+
+	// Upstream steps whose outputs form this step's input
+	inputFrom []string
+
+	// Reshapes the resolved input before the step receives it, nil passes it unchanged
+	inputMapper InputMapper
+
+	// Input used when the resolved input is empty or null, nil leaves it as is
+	inputDefault any
+
+	// Decides whether a failed attempt is retried, nil retries every error
+	retryIf RetryPredicate
+
+	// Fast-fails the step across runs after repeated failures, nil disables it
+	circuitBreaker *CircuitBreakerConfig
+
+	// Limits executions of the step across runs, nil means unlimited
+	rateLimit *RateLimitConfig
+
+	// Share of the run's progress, non-positive weights count as 1.0
+	weight float64
+
+	// Largest serialized output the step may return, 0 means unlimited
+	maxOutputBytes int
+
+	// JSON paths redacted from the stored and logged input and output
+	redactPaths []string
+
+	// Input and output are passed as bytes instead of being JSON-marshaled
+	rawIO bool
+
+	// Arbitrary metadata (owner, SLA, criticality) copied to executions and logs
+	tags map[string]string
+
+	// Version stamped on stored outputs, and migrations keyed by the version they upgrade from
+	outputVersion    int
+	outputMigrations map[int]OutputMigration
+
 	// Type information (for runtime reflection/validation)
 	inputType  reflect.Type
 	outputType reflect.Type
@@ -64,6 +113,7 @@ func NewStep[TIn, TOut any](
 		Handler:          handler,
 		Config:           DefaultExecutionConfig,
 		validationConfig: defaultValidationConfig, // Validation enabled by default
+		weight:           1.0,
 		inputType:        reflect.TypeOf((*TIn)(nil)).Elem(),
 		outputType:       reflect.TypeOf((*TOut)(nil)).Elem(),
 	}
@@ -76,6 +126,41 @@ func NewStep[TIn, TOut any](
 	return s
 }
 
+// RawHandler processes a step payload as bytes
+type RawHandler func(ctx *StepContext, input []byte) ([]byte, error)
+
+// NewRawStep creates a step whose input and output are raw bytes, such as images or
+// protobuf messages, passed and stored as-is instead of being JSON-marshaled
+func NewRawStep(id, name string, handler RawHandler, opts ...StepOption) *Step[[]byte, []byte] {
+	return NewStep(id, name, StepHandler[[]byte, []byte](handler), append([]StepOption{WithRawIO()}, opts...)...)
+}
+
+// PassthroughStep creates a step that returns its input unchanged, for use as an explicit
+// entry or join node in a graph
+func PassthroughStep[T any](id, name string, opts ...StepOption) *Step[T, T] {
+	return NewStep(id, name, func(ctx *StepContext, input T) (T, error) {
+		return input, nil
+	}, opts...)
+}
+
+// DelayStep creates a step that waits for d, e.g. for eventual consistency, and passes its input
+// through unchanged. The wait ends early when the run is cancelled. The step has no timeout
+// and no retries unless given by opts.
+func DelayStep(id, name string, d time.Duration, opts ...StepOption) *Step[json.RawMessage, json.RawMessage] {
+	handler := func(ctx *StepContext, input json.RawMessage) (json.RawMessage, error) {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return input, nil
+		}
+	}
+	return NewStep(id, name, handler, append([]StepOption{WithTimeout(0), WithRetries(0)}, opts...)...)
+}
+
 // Implement StepExecutor interface
 
 func (s *Step[TIn, TOut]) GetID() string {
@@ -102,10 +187,109 @@ func (s *Step[TIn, TOut]) OutputType() reflect.Type {
 	return s.outputType
 }
 
+// GetCustomContext returns the step-specific custom context, if any
+func (s *Step[TIn, TOut]) GetCustomContext() any {
+	return s.customContext
+}
+
+// GetInputFrom returns the upstream steps whose outputs form this step's input
+func (s *Step[TIn, TOut]) GetInputFrom() []string {
+	return s.inputFrom
+}
+
+// GetInputMapper returns the mapper applied to the step's input, if any
+func (s *Step[TIn, TOut]) GetInputMapper() InputMapper {
+	return s.inputMapper
+}
+
+// GetInputDefault returns the input used when the resolved input is empty or null, if any
+func (s *Step[TIn, TOut]) GetInputDefault() any {
+	return s.inputDefault
+}
+
+// GetCompensation returns the step's compensating action, if any
+func (s *Step[TIn, TOut]) GetCompensation() CompensationHandler {
+	return s.compensation
+}
+
+// GetRetryIf returns the step's retry predicate
+func (s *Step[TIn, TOut]) GetRetryIf() RetryPredicate {
+	return s.retryIf
+}
+
+// GetCircuitBreaker returns the step's circuit breaker settings, if any
+func (s *Step[TIn, TOut]) GetCircuitBreaker() *CircuitBreakerConfig {
+	return s.circuitBreaker
+}
+
+// GetRateLimit returns the step's rate limit, if any
+func (s *Step[TIn, TOut]) GetRateLimit() *RateLimitConfig {
+	return s.rateLimit
+}
+
+// GetWeight returns the step's share of the run's progress
+func (s *Step[TIn, TOut]) GetWeight() float64 {
+	return s.weight
+}
+
+// GetMaxOutputBytes returns the largest serialized output the step may return, 0 if unlimited
+func (s *Step[TIn, TOut]) GetMaxOutputBytes() int {
+	return s.maxOutputBytes
+}
+
+// GetRedaction returns the JSON paths redacted from the step's stored and logged payloads
+func (s *Step[TIn, TOut]) GetRedaction() []string {
+	return s.redactPaths
+}
+
+// GetRawIO reports whether the step's input and output are raw bytes rather than JSON
+func (s *Step[TIn, TOut]) GetRawIO() bool {
+	return s.rawIO
+}
+
+// GetTags returns the metadata set on the step with WithStepTags
+func (s *Step[TIn, TOut]) GetTags() map[string]string {
+	return s.tags
+}
+
+// GetOutputVersion returns the version stamped on the step's stored outputs, 0 when unversioned
+func (s *Step[TIn, TOut]) GetOutputVersion() int {
+	return s.outputVersion
+}
+
+// GetOutputMigrations returns the migrations registered with WithOutputMigration
+func (s *Step[TIn, TOut]) GetOutputMigrations() map[int]OutputMigration {
+	return s.outputMigrations
+}
+
+// InputSchema returns the JSON Schema the step input must satisfy, if any
+func (s *Step[TIn, TOut]) InputSchema() []byte {
+	if s.inputSchema == nil {
+		return nil
+	}
+	return s.inputSchema.source
+}
+
+// OutputSchema returns the JSON Schema the step output must satisfy, if any
+func (s *Step[TIn, TOut]) OutputSchema() []byte {
+	if s.outputSchema == nil {
+		return nil
+	}
+	return s.outputSchema.source
+}
+
 // Execute runs the step handler with type-safe marshaling and validation
 func (s *Step[TIn, TOut]) Execute(ctx *StepContext, inputBytes []byte) ([]byte, error) {
+	if s.rawIO {
+		return s.executeRaw(ctx, inputBytes)
+	}
+
+	if err := s.inputSchema.validate(inputBytes); err != nil {
+		return nil, fmt.Errorf("input validation failed: %w", err)
+	}
+
 	// Unmarshal and validate input
-	input, err := validateInputData[TIn](inputBytes, s.validationConfig)
+	input, err := validateInputData[TIn](ctx.codec(), inputBytes, s.validationConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -117,27 +301,67 @@ func (s *Step[TIn, TOut]) Execute(ctx *StepContext, inputBytes []byte) ([]byte,
 	}
 
 	// Validate and marshal output
-	outputBytes, err := validateOutputData(output, s.validationConfig)
+	outputBytes, err := validateOutputData(ctx.codec(), output, s.validationConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.outputSchema.validate(outputBytes); err != nil {
+		return nil, fmt.Errorf("output validation failed: %w", err)
+	}
+
+	return outputBytes, nil
+}
+
+// executeRaw hands the input bytes to the handler and returns its output bytes untouched
+func (s *Step[TIn, TOut]) executeRaw(ctx *StepContext, inputBytes []byte) ([]byte, error) {
+	input, ok := any(inputBytes).(TIn)
+	if !ok {
+		return nil, fmt.Errorf("raw step %s must take []byte input, not %s", s.ID, s.inputType)
+	}
+
+	output, err := s.Handler(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 
+	outputBytes, ok := any(output).([]byte)
+	if !ok {
+		return nil, fmt.Errorf("raw step %s must return []byte output, not %s", s.ID, s.outputType)
+	}
 	return outputBytes, nil
 }
 
-// ValidateInput validates that data can be unmarshaled to TIn and passes validation
+// ValidateInput validates that data matches the input schema, can be unmarshaled to TIn and passes validation
 func (s *Step[TIn, TOut]) ValidateInput(data []byte) error {
-	_, err := validateInputData[TIn](data, s.validationConfig)
+	// Raw payloads are opaque
+	if s.rawIO {
+		return nil
+	}
+
+	if err := s.inputSchema.validate(data); err != nil {
+		return fmt.Errorf("invalid input for step %s: %w", s.ID, err)
+	}
+
+	_, err := validateInputData[TIn](DefaultCodec, data, s.validationConfig)
 	if err != nil {
 		return fmt.Errorf("invalid input for step %s: %w", s.ID, err)
 	}
 	return nil
 }
 
-// ValidateOutput validates that data can be unmarshaled to TOut and passes validation
+// ValidateOutput validates that data matches the output schema, can be unmarshaled to TOut and passes validation
 func (s *Step[TIn, TOut]) ValidateOutput(data []byte) error {
+	if s.rawIO {
+		return nil
+	}
+
+	if err := s.outputSchema.validate(data); err != nil {
+		return fmt.Errorf("invalid output for step %s: %w", s.ID, err)
+	}
+
 	var output TOut
-	if err := json.Unmarshal(data, &output); err != nil {
+	if err := DefaultCodec.Unmarshal(data, &output); err != nil {
 		return fmt.Errorf("invalid output for step %s: %w", s.ID, err)
 	}
 
@@ -153,6 +377,10 @@ func (s *Step[TIn, TOut]) ValidateOutput(data []byte) error {
 
 // Configuration setters (for functional options)
 
+func (s *Step[TIn, TOut]) SetName(name string) {
+	s.Name = name
+}
+
 func (s *Step[TIn, TOut]) SetMaxRetries(max int) {
 	s.Config.MaxRetries = max
 }
@@ -187,6 +415,102 @@ func (s *Step[TIn, TOut]) DisableValidation() {
 	s.validationConfig = nil
 }
 
+func (s *Step[TIn, TOut]) SetCustomContext(ctx any) {
+	s.customContext = ctx
+}
+
+func (s *Step[TIn, TOut]) SetInputFrom(stepIDs []string) {
+	s.inputFrom = stepIDs
+}
+
+func (s *Step[TIn, TOut]) SetInputMapper(mapper InputMapper) {
+	s.inputMapper = mapper
+}
+
+func (s *Step[TIn, TOut]) SetInputDefault(value any) {
+	s.inputDefault = value
+}
+
+func (s *Step[TIn, TOut]) SetCompensation(handler CompensationHandler) {
+	s.compensation = handler
+}
+
+func (s *Step[TIn, TOut]) SetRetryIf(predicate RetryPredicate) {
+	s.retryIf = predicate
+}
+
+func (s *Step[TIn, TOut]) SetCircuitBreaker(config *CircuitBreakerConfig) {
+	s.circuitBreaker = config
+}
+
+func (s *Step[TIn, TOut]) SetRateLimit(config *RateLimitConfig) {
+	s.rateLimit = config
+}
+
+func (s *Step[TIn, TOut]) SetWeight(weight float64) {
+	s.weight = weight
+}
+
+func (s *Step[TIn, TOut]) SetMaxOutputBytes(n int) {
+	s.maxOutputBytes = n
+}
+
+func (s *Step[TIn, TOut]) SetRedaction(paths []string) {
+	s.redactPaths = paths
+}
+
+func (s *Step[TIn, TOut]) SetRawIO(raw bool) {
+	s.rawIO = raw
+}
+
+func (s *Step[TIn, TOut]) SetTags(tags map[string]string) {
+	if s.tags == nil {
+		s.tags = make(map[string]string, len(tags))
+	}
+	maps.Copy(s.tags, tags)
+}
+
+func (s *Step[TIn, TOut]) SetOutputVersion(version int) {
+	s.outputVersion = version
+}
+
+func (s *Step[TIn, TOut]) AddOutputMigration(from int, migrate OutputMigration) {
+	if s.outputMigrations == nil {
+		s.outputMigrations = make(map[int]OutputMigration)
+	}
+	s.outputMigrations[from] = migrate
+}
+
+func (s *Step[TIn, TOut]) SetInputSchema(schema []byte) {
+	s.inputSchema = compileJSONSchema(schema)
+}
+
+func (s *Step[TIn, TOut]) SetOutputSchema(schema []byte) {
+	s.outputSchema = compileJSONSchema(schema)
+}
+
+// CompensationHandler undoes a completed step given its serialized output
+type CompensationHandler func(ctx *StepContext, output []byte) error
+
+// WithCompensation registers a compensating action for a step. When a later step
+// fails the workflow, compensators of completed steps run in reverse order with
+// each step's stored output.
+func WithCompensation[TOut any](handler func(ctx *StepContext, output TOut) error) StepOption {
+	compensation := func(ctx *StepContext, outputBytes []byte) error {
+		var output TOut
+		if err := ctx.codec().Unmarshal(outputBytes, &output); err != nil {
+			return fmt.Errorf("failed to unmarshal output for compensation: %w", err)
+		}
+		return handler(ctx, output)
+	}
+
+	return stepOptionFunc(func(s interface{}) {
+		if step, ok := s.(interface{ SetCompensation(CompensationHandler) }); ok {
+			step.SetCompensation(compensation)
+		}
+	})
+}
+
 // Condition is a function that determines if a step should execute
 type Condition func(ctx *StepContext) (bool, error)
 
@@ -223,6 +547,66 @@ func (cs *ConditionalStep[TIn, TOut]) OutputType() reflect.Type {
 	return cs.Step.OutputType()
 }
 
+func (cs *ConditionalStep[TIn, TOut]) GetCustomContext() any {
+	return cs.Step.GetCustomContext()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetInputFrom() []string {
+	return cs.Step.GetInputFrom()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetInputMapper() InputMapper {
+	return cs.Step.GetInputMapper()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetInputDefault() any {
+	return cs.Step.GetInputDefault()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetCompensation() CompensationHandler {
+	return cs.Step.GetCompensation()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetRetryIf() RetryPredicate {
+	return cs.Step.GetRetryIf()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetCircuitBreaker() *CircuitBreakerConfig {
+	return cs.Step.GetCircuitBreaker()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetRateLimit() *RateLimitConfig {
+	return cs.Step.GetRateLimit()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetWeight() float64 {
+	return cs.Step.GetWeight()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetMaxOutputBytes() int {
+	return cs.Step.GetMaxOutputBytes()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetRedaction() []string {
+	return cs.Step.GetRedaction()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetRawIO() bool {
+	return cs.Step.GetRawIO()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetTags() map[string]string {
+	return cs.Step.GetTags()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetOutputVersion() int {
+	return cs.Step.GetOutputVersion()
+}
+
+func (cs *ConditionalStep[TIn, TOut]) GetOutputMigrations() map[int]OutputMigration {
+	return cs.Step.GetOutputMigrations()
+}
+
 func (cs *ConditionalStep[TIn, TOut]) Execute(ctx *StepContext, inputBytes []byte) ([]byte, error) {
 	// Evaluate condition
 	shouldRun, err := cs.Condition(ctx)
@@ -234,10 +618,10 @@ func (cs *ConditionalStep[TIn, TOut]) Execute(ctx *StepContext, inputBytes []byt
 		LogStepSkipped(ctx.Logger, ctx.RunID, ctx.StepID, "condition_not_met")
 		// Step skipped - return default or zero value
 		if cs.Default != nil {
-			return json.Marshal(cs.Default)
+			return ctx.codec().Marshal(cs.Default)
 		}
 		var zero TOut
-		return json.Marshal(zero)
+		return ctx.codec().Marshal(zero)
 	}
 
 	// Execute the wrapped step
@@ -297,6 +681,115 @@ func (w *conditionalStepWrapper) OutputType() reflect.Type {
 	return w.step.OutputType()
 }
 
+func (w *conditionalStepWrapper) GetCustomContext() any {
+	if provider, ok := w.step.(interface{ GetCustomContext() any }); ok {
+		return provider.GetCustomContext()
+	}
+	return nil
+}
+
+func (w *conditionalStepWrapper) GetInputFrom() []string {
+	if provider, ok := w.step.(interface{ GetInputFrom() []string }); ok {
+		return provider.GetInputFrom()
+	}
+	return nil
+}
+
+func (w *conditionalStepWrapper) GetInputMapper() InputMapper {
+	if provider, ok := w.step.(interface{ GetInputMapper() InputMapper }); ok {
+		return provider.GetInputMapper()
+	}
+	return nil
+}
+
+func (w *conditionalStepWrapper) GetInputDefault() any {
+	if provider, ok := w.step.(interface{ GetInputDefault() any }); ok {
+		return provider.GetInputDefault()
+	}
+	return nil
+}
+
+func (w *conditionalStepWrapper) GetCompensation() CompensationHandler {
+	if provider, ok := w.step.(interface{ GetCompensation() CompensationHandler }); ok {
+		return provider.GetCompensation()
+	}
+	return nil
+}
+
+func (w *conditionalStepWrapper) GetRetryIf() RetryPredicate {
+	if provider, ok := w.step.(interface{ GetRetryIf() RetryPredicate }); ok {
+		return provider.GetRetryIf()
+	}
+	return nil
+}
+
+func (w *conditionalStepWrapper) GetCircuitBreaker() *CircuitBreakerConfig {
+	if provider, ok := w.step.(interface {
+		GetCircuitBreaker() *CircuitBreakerConfig
+	}); ok {
+		return provider.GetCircuitBreaker()
+	}
+	return nil
+}
+
+func (w *conditionalStepWrapper) GetRateLimit() *RateLimitConfig {
+	if provider, ok := w.step.(interface{ GetRateLimit() *RateLimitConfig }); ok {
+		return provider.GetRateLimit()
+	}
+	return nil
+}
+
+func (w *conditionalStepWrapper) GetWeight() float64 {
+	if provider, ok := w.step.(interface{ GetWeight() float64 }); ok {
+		return provider.GetWeight()
+	}
+	return 1.0
+}
+
+func (w *conditionalStepWrapper) GetMaxOutputBytes() int {
+	if provider, ok := w.step.(interface{ GetMaxOutputBytes() int }); ok {
+		return provider.GetMaxOutputBytes()
+	}
+	return 0
+}
+
+func (w *conditionalStepWrapper) GetRedaction() []string {
+	if provider, ok := w.step.(interface{ GetRedaction() []string }); ok {
+		return provider.GetRedaction()
+	}
+	return nil
+}
+
+func (w *conditionalStepWrapper) GetRawIO() bool {
+	if provider, ok := w.step.(interface{ GetRawIO() bool }); ok {
+		return provider.GetRawIO()
+	}
+	return false
+}
+
+func (w *conditionalStepWrapper) GetTags() map[string]string {
+	if provider, ok := w.step.(interface{ GetTags() map[string]string }); ok {
+		return provider.GetTags()
+	}
+	return nil
+}
+
+func (w *conditionalStepWrapper) GetOutputVersion() int {
+	if provider, ok := w.step.(interface{ GetOutputVersion() int }); ok {
+		return provider.GetOutputVersion()
+	}
+	return 0
+}
+
+func (w *conditionalStepWrapper) GetOutputMigrations() map[int]OutputMigration {
+	if provider, ok := w.step.(interface {
+		GetOutputMigrations() map[int]OutputMigration
+	}); ok {
+		return provider.GetOutputMigrations()
+	}
+	return nil
+}
+
 func (w *conditionalStepWrapper) Execute(ctx *StepContext, inputBytes []byte) ([]byte, error) {
 	// Evaluate condition
 	shouldRun, err := w.condition(ctx)
@@ -308,17 +801,22 @@ func (w *conditionalStepWrapper) Execute(ctx *StepContext, inputBytes []byte) ([
 		LogStepSkipped(ctx.Logger, ctx.RunID, ctx.StepID, "condition_not_met")
 		// Step skipped - return default or zero value
 		if w.defaultValue != nil {
-			return json.Marshal(w.defaultValue)
+			return ctx.codec().Marshal(w.defaultValue)
 		}
 		// Return zero value for the output type
 		zeroVal := reflect.Zero(w.step.OutputType()).Interface()
-		return json.Marshal(zeroVal)
+		return ctx.codec().Marshal(zeroVal)
 	}
 
 	// Execute the wrapped step
 	return w.step.Execute(ctx, inputBytes)
 }
 
+// GetDefaultValue returns the output used when the condition is false, nil for the zero value
+func (w *conditionalStepWrapper) GetDefaultValue() any {
+	return w.defaultValue
+}
+
 func (w *conditionalStepWrapper) ValidateInput(data []byte) error {
 	return w.step.ValidateInput(data)
 }
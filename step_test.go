@@ -59,6 +59,21 @@ func TestNewStep_WithOptions(t *testing.T) {
 	assert.True(t, config.ContinueOnError)
 }
 
+func TestWithStepTags(t *testing.T) {
+	tags := map[string]string{"team": "growth"}
+	step := NewStep("test-step", "Test Step", testHandler,
+		WithStepTags(tags),
+		WithStepTags(map[string]string{"sla": "5m"}),
+	)
+
+	// Repeated options merge, without changing the caller's map
+	assert.Equal(t, map[string]string{"team": "growth", "sla": "5m"}, step.GetTags())
+	assert.Len(t, tags, 1)
+
+	conditional := NewConditionalStep(step, func(ctx *StepContext) (bool, error) { return true, nil }, nil)
+	assert.Equal(t, step.GetTags(), conditional.GetTags())
+}
+
 func TestStep_Execute_Success(t *testing.T) {
 	step := NewStep("test-step", "Test Step", testHandler)
 
@@ -124,6 +139,43 @@ func TestStep_Execute_HandlerError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestNewRawStep_Execute(t *testing.T) {
+	// Not valid JSON, and not valid UTF-8
+	payload := []byte{0x00, 0xff, 0x10, '{', 0x80}
+
+	step := NewRawStep("raw", "Raw Step", func(ctx *StepContext, input []byte) ([]byte, error) {
+		return append([]byte{0x01}, input...), nil
+	})
+	assert.True(t, step.GetRawIO())
+
+	ctx := &StepContext{Context: context.Background(), Logger: zerolog.Nop()}
+	output, err := step.Execute(ctx, payload)
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{0x01}, payload...), output)
+
+	assert.NoError(t, step.ValidateInput(payload))
+	assert.NoError(t, step.ValidateOutput(output))
+}
+
+func TestWithRawIO_TypeMismatch(t *testing.T) {
+	step := NewStep("typed", "Typed Step", testHandler, WithRawIO())
+
+	ctx := &StepContext{Context: context.Background(), Logger: zerolog.Nop()}
+	_, err := step.Execute(ctx, []byte(`{"value":1}`))
+	assert.ErrorContains(t, err, "must take []byte input")
+}
+
+func TestPassthroughStep_Execute(t *testing.T) {
+	step := PassthroughStep[TestInput]("join", "Join")
+	assert.Equal(t, step.InputType(), step.OutputType())
+
+	input := []byte(`{"value":42,"name":"test"}`)
+	ctx := &StepContext{Context: context.Background(), Logger: zerolog.Nop()}
+	output, err := step.Execute(ctx, input)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(input), string(output))
+}
+
 func TestStep_ValidateInput(t *testing.T) {
 	step := NewStep("test-step", "Test Step", testHandler)
 
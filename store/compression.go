@@ -0,0 +1,143 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression selects the algorithm used to compress persisted payloads
+type Compression string
+
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+// compressionMagic prefixes compressed payloads; payloads without it, such as items written
+// before compression was enabled, are returned as-is. Raw and byte step payloads may begin with
+// the magic themselves, so compressPayload escapes those behind a header of their own.
+var compressionMagic = []byte{0x00, 'g', 'k', 'z'}
+
+// Algorithm flags stored after the magic header
+const (
+	compressionFlagStored byte = 0 // Uncompressed payload that begins with the magic
+	compressionFlagGzip   byte = 1
+	compressionFlagZstd   byte = 2
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// zstdCodec lazily creates the shared zstd encoder/decoder (both are safe for concurrent use)
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+// compressPayload compresses data with the given algorithm and prepends the header.
+// Data is left untouched when compression is disabled or would not reduce its size,
+// unless it begins with the magic header.
+func compressPayload(algo Compression, data []byte) ([]byte, error) {
+	var flag byte
+	var compressed []byte
+
+	switch algo {
+	case CompressionNone:
+		return storedPayload(data), nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to gzip payload: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("failed to gzip payload: %w", err)
+		}
+		flag, compressed = compressionFlagGzip, buf.Bytes()
+	case CompressionZstd:
+		enc, _, err := zstdCodec()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+		}
+		flag, compressed = compressionFlagZstd, enc.EncodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", algo)
+	}
+
+	if len(compressionMagic)+1+len(compressed) >= len(data) {
+		return storedPayload(data), nil
+	}
+
+	return withCompressionHeader(flag, compressed), nil
+}
+
+// storedPayload returns uncompressed data as it is stored: unchanged, unless it begins with the
+// magic header and would be misread as compressed
+func storedPayload(data []byte) []byte {
+	if !bytes.HasPrefix(data, compressionMagic) {
+		return data
+	}
+	return withCompressionHeader(compressionFlagStored, data)
+}
+
+func withCompressionHeader(flag byte, body []byte) []byte {
+	out := make([]byte, 0, len(compressionMagic)+1+len(body))
+	out = append(out, compressionMagic...)
+	out = append(out, flag)
+	return append(out, body...)
+}
+
+// decompressPayload reverses compressPayload. Payloads without the header are returned unchanged.
+func decompressPayload(data []byte) ([]byte, error) {
+	if len(data) <= len(compressionMagic) || !bytes.HasPrefix(data, compressionMagic) {
+		return data, nil
+	}
+
+	flag := data[len(compressionMagic)]
+	body := data[len(compressionMagic)+1:]
+
+	switch flag {
+	case compressionFlagStored:
+		return body, nil
+	case compressionFlagGzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to gunzip payload: %w", err)
+		}
+		defer r.Close()
+
+		out, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to gunzip payload: %w", err)
+		}
+		return out, nil
+	case compressionFlagZstd:
+		_, dec, err := zstdCodec()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+		}
+		out, err := dec.DecodeAll(body, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress zstd payload: %w", err)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unknown compression flag %d", flag)
+	}
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// repetitiveJSON builds a large, highly compressible JSON payload
+func repetitiveJSON() []byte {
+	var sb strings.Builder
+	sb.WriteString(`{"items":[`)
+	for i := 0; i < 2000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(fmt.Sprintf(`{"id":%d,"status":"active","region":"ap-southeast-2"}`, i))
+	}
+	sb.WriteString(`]}`)
+	return []byte(sb.String())
+}
+
+func TestCompressPayload_RoundTrip(t *testing.T) {
+	payload := repetitiveJSON()
+
+	for _, algo := range []Compression{CompressionGzip, CompressionZstd} {
+		t.Run(string(algo), func(t *testing.T) {
+			compressed, err := compressPayload(algo, payload)
+			if err != nil {
+				t.Fatalf("compressPayload() failed: %v", err)
+			}
+			if len(compressed) >= len(payload) {
+				t.Errorf("compressed size = %d, want < %d", len(compressed), len(payload))
+			}
+			if !bytes.HasPrefix(compressed, compressionMagic) {
+				t.Error("compressed payload is missing header")
+			}
+
+			decompressed, err := decompressPayload(compressed)
+			if err != nil {
+				t.Fatalf("decompressPayload() failed: %v", err)
+			}
+			if !bytes.Equal(decompressed, payload) {
+				t.Error("decompressed payload does not match original")
+			}
+		})
+	}
+}
+
+func TestCompressPayload_SmallPayloadUnchanged(t *testing.T) {
+	payload := []byte(`{"ok":true}`)
+
+	compressed, err := compressPayload(CompressionGzip, payload)
+	if err != nil {
+		t.Fatalf("compressPayload() failed: %v", err)
+	}
+	if !bytes.Equal(compressed, payload) {
+		t.Error("small payload should be stored uncompressed")
+	}
+}
+
+func TestDecompressPayload_Uncompressed(t *testing.T) {
+	payload := []byte(`{"legacy":"item"}`)
+
+	decompressed, err := decompressPayload(payload)
+	if err != nil {
+		t.Fatalf("decompressPayload() failed: %v", err)
+	}
+	if !bytes.Equal(decompressed, payload) {
+		t.Errorf("decompressPayload() = %s, want %s", decompressed, payload)
+	}
+}
+
+func TestCompressPayload_MagicPrefixedRawPayload(t *testing.T) {
+	// A byte payload that happens to look like a gzip header
+	payload := append(append([]byte{}, compressionMagic...), compressionFlagGzip, 'r', 'a', 'w')
+
+	for name, algo := range map[string]Compression{"none": CompressionNone, "gzip": CompressionGzip, "zstd": CompressionZstd} {
+		t.Run(name, func(t *testing.T) {
+			stored, err := compressPayload(algo, payload)
+			if err != nil {
+				t.Fatalf("compressPayload() failed: %v", err)
+			}
+
+			decompressed, err := decompressPayload(stored)
+			if err != nil {
+				t.Fatalf("decompressPayload() failed: %v", err)
+			}
+			if !bytes.Equal(decompressed, payload) {
+				t.Errorf("decompressPayload() = %q, want %q", decompressed, payload)
+			}
+		})
+	}
+}
+
+func TestMemoryStore_Compression(t *testing.T) {
+	payload := repetitiveJSON()
+
+	for _, algo := range []Compression{CompressionGzip, CompressionZstd} {
+		t.Run(string(algo), func(t *testing.T) {
+			store := NewMemoryStore(WithMemoryCompression(algo)).(*MemoryStore)
+			ctx := context.Background()
+
+			if err := store.SaveStepOutput(ctx, "run-1", "step-1", payload); err != nil {
+				t.Fatalf("SaveStepOutput() failed: %v", err)
+			}
+			if err := store.SaveState(ctx, "run-1", "key", payload); err != nil {
+				t.Fatalf("SaveState() failed: %v", err)
+			}
+
+			if stored := store.stepOutputs["run-1"]["step-1"]; len(stored) >= len(payload) {
+				t.Errorf("stored output size = %d, want < %d", len(stored), len(payload))
+			}
+			if stored := store.state["run-1"]["key"]; len(stored) >= len(payload) {
+				t.Errorf("stored state size = %d, want < %d", len(stored), len(payload))
+			}
+
+			output, err := store.LoadStepOutput(ctx, "run-1", "step-1")
+			if err != nil {
+				t.Fatalf("LoadStepOutput() failed: %v", err)
+			}
+			if !bytes.Equal(output, payload) {
+				t.Error("LoadStepOutput() did not return the original payload")
+			}
+
+			value, err := store.LoadState(ctx, "run-1", "key")
+			if err != nil {
+				t.Fatalf("LoadState() failed: %v", err)
+			}
+			if !bytes.Equal(value, payload) {
+				t.Error("LoadState() did not return the original payload")
+			}
+
+			all, err := store.GetAllState(ctx, "run-1")
+			if err != nil {
+				t.Fatalf("GetAllState() failed: %v", err)
+			}
+			if !bytes.Equal(all["key"], payload) {
+				t.Error("GetAllState() did not return the original payload")
+			}
+		})
+	}
+}
+
+func TestDynamoDBStore_Compression(t *testing.T) {
+	client, items := newItemStoringClient()
+	store := NewDynamoDBStore(client, "test-table", WithCompression(CompressionZstd))
+	ctx := context.Background()
+
+	payload := repetitiveJSON()
+	if err := store.SaveStepOutput(ctx, "run-1", "step-1", payload); err != nil {
+		t.Fatalf("SaveStepOutput() failed: %v", err)
+	}
+
+	item := items[stepOutputPK("run-1")+"|"+stepOutputSK("step-1")]
+	stored := item["output"].(*types.AttributeValueMemberB).Value
+	if len(stored) >= len(payload) {
+		t.Errorf("stored output size = %d, want < %d", len(stored), len(payload))
+	}
+
+	loaded, err := store.LoadStepOutput(ctx, "run-1", "step-1")
+	if err != nil {
+		t.Fatalf("LoadStepOutput() failed: %v", err)
+	}
+	if !bytes.Equal(loaded, payload) {
+		t.Error("LoadStepOutput() did not return the original payload")
+	}
+}
+
+func TestDynamoDBStore_Compression_ReadsUncompressedItems(t *testing.T) {
+	client, _ := newItemStoringClient()
+	ctx := context.Background()
+
+	// Written before compression was enabled
+	payload := []byte(`{"legacy":true}`)
+	if err := NewDynamoDBStore(client, "test-table").SaveState(ctx, "run-1", "key", payload); err != nil {
+		t.Fatalf("SaveState() failed: %v", err)
+	}
+
+	store := NewDynamoDBStore(client, "test-table", WithCompression(CompressionGzip))
+	loaded, err := store.LoadState(ctx, "run-1", "key")
+	if err != nil {
+		t.Fatalf("LoadState() failed: %v", err)
+	}
+	if !bytes.Equal(loaded, payload) {
+		t.Errorf("LoadState() = %s, want %s", loaded, payload)
+	}
+}
@@ -0,0 +1,15 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// parseCounter decodes a state value holding a JSON integer
+func parseCounter(key string, value []byte) (int64, error) {
+	var counter int64
+	if err := json.Unmarshal(value, &counter); err != nil {
+		return 0, fmt.Errorf("state key %s does not hold an integer: %w", key, err)
+	}
+	return counter, nil
+}
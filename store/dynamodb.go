@@ -2,7 +2,13 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,18 +18,113 @@ import (
 	"github.com/sicko7947/gorkflow"
 )
 
+// runStatuses lists every run status; the GSIs are partitioned by status
+var runStatuses = []gorkflow.RunStatus{
+	gorkflow.RunStatusPending,
+	gorkflow.RunStatusRunning,
+	gorkflow.RunStatusPaused,
+	gorkflow.RunStatusCompleted,
+	gorkflow.RunStatusFailed,
+	gorkflow.RunStatusCancelled,
+}
+
 // DynamoDBStore implements gorkflow.WorkflowStore using AWS DynamoDB
 type DynamoDBStore struct {
 	client    DynamoDBClient
 	tableName string
+
+	// Optional S3 offloading for payloads above the item size threshold
+	largeObjects *largeObjectStore
+
+	// Compression applied to step outputs and state values
+	compression Compression
+
+	// How far back ListDueRuns looks for overdue scheduled runs
+	scheduleLookback time.Duration
+
+	// Source of the timestamps the store writes itself
+	clock func() time.Time
+
+	// How many runs the TTL and index key caches hold
+	runCacheSize int
+
+	// Run TTLs cached so child items share the run's expiry
+	runTTLs *runCache[int64]
+
+	// What the GSI keys of unfinished runs are built from, so status changes need no read
+	runKeys *runCache[runIndexKeys]
+}
+
+// runIndexKeys are the run fields its status-partitioned GSI keys are built from
+type runIndexKeys struct {
+	WorkflowID string `dynamodbav:"workflow_id"`
+	ResourceID string `dynamodbav:"resource_id"`
+}
+
+// DynamoDBStoreOption configures a DynamoDBStore
+type DynamoDBStoreOption func(*DynamoDBStore)
+
+// WithCompression compresses step outputs and state values before they are written.
+// Items written without compression remain readable.
+func WithCompression(algo Compression) DynamoDBStoreOption {
+	return func(s *DynamoDBStore) {
+		s.compression = algo
+	}
+}
+
+// WithScheduleLookback sets how far back ListDueRuns searches for scheduled runs that are overdue.
+// Runs left unclaimed for longer than this are no longer picked up. Defaults to 24 hours.
+func WithScheduleLookback(d time.Duration) DynamoDBStoreOption {
+	return func(s *DynamoDBStore) {
+		s.scheduleLookback = d
+	}
+}
+
+// WithRunCacheSize sets how many runs the store caches TTLs and index keys for. Runs
+// beyond it, and runs written by other engines, cost one small projected read the next
+// time their status changes or a step item is written. Zero disables the caches.
+// Defaults to DefaultRunCacheSize.
+func WithRunCacheSize(size int) DynamoDBStoreOption {
+	return func(s *DynamoDBStore) {
+		s.runCacheSize = size
+	}
+}
+
+// WithClock sets the time source for the timestamps the store writes itself, such as status
+// changes, state and output items and lease expiries (default time.Now). Pass the engine's clock,
+// e.g. store.WithClock(clock.Now), so they agree with the run times the engine records.
+// Run and step execution UpdatedAt values set by the caller are stored as given.
+func WithClock(now func() time.Time) DynamoDBStoreOption {
+	return func(s *DynamoDBStore) {
+		if now != nil {
+			s.clock = now
+		}
+	}
+}
+
+// now returns the current time from the store clock, in UTC
+func (s *DynamoDBStore) now() time.Time {
+	return s.clock().UTC()
 }
 
 // NewDynamoDBStore creates a new DynamoDB-backed workflow store
-func NewDynamoDBStore(client DynamoDBClient, tableName string) gorkflow.WorkflowStore {
-	return &DynamoDBStore{
-		client:    client,
-		tableName: tableName,
+func NewDynamoDBStore(client DynamoDBClient, tableName string, opts ...DynamoDBStoreOption) gorkflow.WorkflowStore {
+	s := &DynamoDBStore{
+		client:           client,
+		tableName:        tableName,
+		scheduleLookback: 24 * time.Hour,
+		runCacheSize:     DefaultRunCacheSize,
+		clock:            time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	s.runTTLs = newRunCache[int64](s.runCacheSize)
+	s.runKeys = newRunCache[runIndexKeys](s.runCacheSize)
+
+	return s
 }
 
 // Workflow run operations
@@ -46,7 +147,7 @@ func (s *DynamoDBStore) CreateRun(ctx context.Context, run *gorkflow.WorkflowRun
 			Value: workflowRunGSI1PK(run.WorkflowID, string(run.Status)),
 		}
 		item[AttrGSI1SK] = &types.AttributeValueMemberS{
-			Value: workflowRunGSI1SK(run.CreatedAt.Format(time.RFC3339)),
+			Value: workflowRunGSI1SK(run.CreatedAt.UTC().Format(time.RFC3339)),
 		}
 	}
 
@@ -55,10 +156,12 @@ func (s *DynamoDBStore) CreateRun(ctx context.Context, run *gorkflow.WorkflowRun
 			Value: workflowRunGSI2PK(run.ResourceID, string(run.Status)),
 		}
 		item[AttrGSI2SK] = &types.AttributeValueMemberS{
-			Value: workflowRunGSI2SK(run.CreatedAt.Format(time.RFC3339)),
+			Value: workflowRunGSI2SK(run.CreatedAt.UTC().Format(time.RFC3339)),
 		}
 	}
 
+	addScheduleIndexKeys(item, run)
+
 	// Put item
 	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: aws.String(s.tableName),
@@ -68,6 +171,9 @@ func (s *DynamoDBStore) CreateRun(ctx context.Context, run *gorkflow.WorkflowRun
 		return fmt.Errorf("failed to create workflow run: %w", err)
 	}
 
+	s.cacheRunTTL(run.RunID, run.TTL)
+	s.cacheRunKeys(run)
+
 	return nil
 }
 
@@ -95,8 +201,105 @@ func (s *DynamoDBStore) GetRun(ctx context.Context, runID string) (*gorkflow.Wor
 	return &run, nil
 }
 
+func (s *DynamoDBStore) BatchGetRuns(ctx context.Context, runIDs []string) (map[string]*gorkflow.WorkflowRun, error) {
+	runs := make(map[string]*gorkflow.WorkflowRun, len(runIDs))
+
+	// Duplicate keys are rejected by BatchGetItem
+	seen := make(map[string]bool, len(runIDs))
+	keys := make([]map[string]types.AttributeValue, 0, len(runIDs))
+	for _, runID := range runIDs {
+		if seen[runID] {
+			continue
+		}
+		seen[runID] = true
+		keys = append(keys, map[string]types.AttributeValue{
+			AttrPK: &types.AttributeValueMemberS{Value: workflowRunPK(runID)},
+			AttrSK: &types.AttributeValueMemberS{Value: workflowRunSK()},
+		})
+	}
+
+	for start := 0; start < len(keys); start += maxBatchGetItems {
+		end := min(start+maxBatchGetItems, len(keys))
+
+		items, err := s.batchGet(ctx, keys[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to batch get workflow runs: %w", err)
+		}
+
+		for _, item := range items {
+			var run gorkflow.WorkflowRun
+			if err := attributevalue.UnmarshalMap(item, &run); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal workflow run: %w", err)
+			}
+			runs[run.RunID] = &run
+		}
+	}
+
+	return runs, nil
+}
+
+// batchGet issues a BatchGetItem call, resubmitting unprocessed keys until all are read
+func (s *DynamoDBStore) batchGet(ctx context.Context, keys []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+	pending := map[string]types.KeysAndAttributes{s.tableName: {Keys: keys}}
+
+	for attempt := 1; len(pending[s.tableName].Keys) > 0; attempt++ {
+		if attempt > 1 {
+			if err := waitUnprocessed(ctx, attempt); err != nil {
+				return nil, fmt.Errorf("%d keys unprocessed: %w", len(pending[s.tableName].Keys), err)
+			}
+		}
+
+		result, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, result.Responses[s.tableName]...)
+		pending = result.UnprocessedKeys
+	}
+
+	return items, nil
+}
+
+// Unprocessed batch items mean DynamoDB is throttling, so they are resubmitted after a jittered
+// wait that doubles from unprocessedBaseDelay up to unprocessedMaxDelay, in at most
+// maxBatchAttempts requests per batch
+var (
+	unprocessedBaseDelay = 50 * time.Millisecond
+	unprocessedMaxDelay  = 5 * time.Second
+)
+
+const maxBatchAttempts = 8
+
+// waitUnprocessed waits before batch request attempt (2 for the first resubmission). It fails once
+// the attempts run out or ctx ends.
+func waitUnprocessed(ctx context.Context, attempt int) error {
+	if attempt > maxBatchAttempts {
+		return fmt.Errorf("batch request gave up after %d attempts", maxBatchAttempts)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Full jitter keeps engines throttled together from resubmitting in step
+	delay := min(unprocessedBaseDelay<<(attempt-2), unprocessedMaxDelay)
+	timer := time.NewTimer(rand.N(delay) + 1)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func (s *DynamoDBStore) UpdateRun(ctx context.Context, run *gorkflow.WorkflowRun) error {
-	run.UpdatedAt = time.Now()
+	if run.UpdatedAt.IsZero() {
+		run.UpdatedAt = s.now()
+	}
 
 	// Marshal the run
 	item, err := attributevalue.MarshalMap(run)
@@ -115,7 +318,7 @@ func (s *DynamoDBStore) UpdateRun(ctx context.Context, run *gorkflow.WorkflowRun
 			Value: workflowRunGSI1PK(run.WorkflowID, string(run.Status)),
 		}
 		item[AttrGSI1SK] = &types.AttributeValueMemberS{
-			Value: workflowRunGSI1SK(run.CreatedAt.Format(time.RFC3339)),
+			Value: workflowRunGSI1SK(run.CreatedAt.UTC().Format(time.RFC3339)),
 		}
 	}
 
@@ -124,10 +327,12 @@ func (s *DynamoDBStore) UpdateRun(ctx context.Context, run *gorkflow.WorkflowRun
 			Value: workflowRunGSI2PK(run.ResourceID, string(run.Status)),
 		}
 		item[AttrGSI2SK] = &types.AttributeValueMemberS{
-			Value: workflowRunGSI2SK(run.CreatedAt.Format(time.RFC3339)),
+			Value: workflowRunGSI2SK(run.CreatedAt.UTC().Format(time.RFC3339)),
 		}
 	}
 
+	addScheduleIndexKeys(item, run)
+
 	// Use transaction for atomic update
 	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
 		TransactItems: []types.TransactWriteItem{
@@ -143,126 +348,381 @@ func (s *DynamoDBStore) UpdateRun(ctx context.Context, run *gorkflow.WorkflowRun
 		return fmt.Errorf("failed to update workflow run: %w", err)
 	}
 
+	// No further child items are expected once the run is finished
+	if run.Status.IsTerminal() {
+		s.evictRunTTL(run.RunID)
+		s.evictRunKeys(run.RunID)
+	} else {
+		s.cacheRunTTL(run.RunID, run.TTL)
+		s.cacheRunKeys(run)
+	}
+
 	return nil
 }
 
+// UpdateRunStatus changes the status with a single UpdateItem, so concurrent progress updates
+// are not overwritten, conditioned on the run not having finished. The transition is appended to the status history, even when the status
+// is unchanged, and the status GSI keys are moved; a scheduled run leaves the schedule index
+// once it is no longer pending.
 func (s *DynamoDBStore) UpdateRunStatus(ctx context.Context, runID string, status gorkflow.RunStatus, wfErr *gorkflow.WorkflowError) error {
-	// Load current run
-	run, err := s.GetRun(ctx, runID)
+	keys, err := s.runIndexKeys(ctx, runID)
 	if err != nil {
 		return err
 	}
 
-	// Update status and error
-	run.Status = status
-	run.Error = wfErr
-	run.UpdatedAt = time.Now()
-
-	if status.IsTerminal() {
-		now := time.Now()
-		run.CompletedAt = &now
+	now := s.now()
+	history, err := attributevalue.Marshal([]gorkflow.StatusTransition{{Status: status, At: now}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal status transition: %w", err)
+	}
+	timestamp, err := attributevalue.Marshal(now)
+	if err != nil {
+		return fmt.Errorf("failed to marshal timestamp: %w", err)
 	}
 
-	// Save
-	return s.UpdateRun(ctx, run)
-}
-
-func (s *DynamoDBStore) ListRuns(ctx context.Context, filter gorkflow.RunFilter) ([]*gorkflow.WorkflowRun, error) {
-	// TODO: Implement with Query using GSI1 or GSI2 based on filter
-	// For now, return empty list
-	return []*gorkflow.WorkflowRun{}, nil
-}
-
-// Step execution operations
+	sets := []string{
+		"#status = :status",
+		"updated_at = :now",
+		"status_history = list_append(if_not_exists(status_history, :empty), :transition)",
+	}
+	var removes []string
+	names := map[string]string{"#status": "status", "#error": "error"}
+	values := map[string]types.AttributeValue{
+		":status":     &types.AttributeValueMemberS{Value: string(status)},
+		":now":        timestamp,
+		":empty":      &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+		":transition": history,
+		":completed":  &types.AttributeValueMemberS{Value: string(gorkflow.RunStatusCompleted)},
+		":failed":     &types.AttributeValueMemberS{Value: string(gorkflow.RunStatusFailed)},
+		":cancelled":  &types.AttributeValueMemberS{Value: string(gorkflow.RunStatusCancelled)},
+	}
 
-func (s *DynamoDBStore) CreateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
-	exec.UpdatedAt = time.Now()
+	if wfErr != nil {
+		errValue, err := attributevalue.Marshal(wfErr)
+		if err != nil {
+			return fmt.Errorf("failed to marshal workflow error: %w", err)
+		}
+		sets = append(sets, "#error = :error")
+		values[":error"] = errValue
+	} else {
+		removes = append(removes, "#error")
+	}
 
-	// Marshal
-	item, err := attributevalue.MarshalMap(exec)
-	if err != nil {
-		return fmt.Errorf("failed to marshal step execution: %w", err)
+	if status.IsTerminal() {
+		sets = append(sets, "completed_at = :now")
+	}
+	if keys.WorkflowID != "" {
+		sets = append(sets, AttrGSI1PK+" = :gsi1pk")
+		values[":gsi1pk"] = &types.AttributeValueMemberS{Value: workflowRunGSI1PK(keys.WorkflowID, string(status))}
+	}
+	if keys.ResourceID != "" {
+		sets = append(sets, AttrGSI2PK+" = :gsi2pk")
+		values[":gsi2pk"] = &types.AttributeValueMemberS{Value: workflowRunGSI2PK(keys.ResourceID, string(status))}
+	}
+	if status != gorkflow.RunStatusPending {
+		removes = append(removes, AttrGSI3PK, AttrGSI3SK)
 	}
 
-	// Add keys
-	item[AttrPK] = &types.AttributeValueMemberS{Value: stepExecutionPK(exec.RunID)}
-	item[AttrSK] = &types.AttributeValueMemberS{Value: stepExecutionSK(exec.StepID)}
-	item[AttrEntityType] = &types.AttributeValueMemberS{Value: EntityTypeStepExecution}
+	update := "SET " + strings.Join(sets, ", ")
+	if len(removes) > 0 {
+		update += " REMOVE " + strings.Join(removes, ", ")
+	}
 
-	// Put item
-	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName: aws.String(s.tableName),
-		Item:      item,
+		Key: map[string]types.AttributeValue{
+			AttrPK: &types.AttributeValueMemberS{Value: workflowRunPK(runID)},
+			AttrSK: &types.AttributeValueMemberS{Value: workflowRunSK()},
+		},
+		UpdateExpression:                    aws.String(update),
+		ConditionExpression:                 aws.String("attribute_exists(PK) AND NOT #status IN (:completed, :failed, :cancelled)"),
+		ExpressionAttributeNames:            names,
+		ExpressionAttributeValues:           values,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create step execution: %w", err)
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			// The old item comes back only when the run exists, i.e. it had finished
+			if old, ok := conditionFailed.Item["status"].(*types.AttributeValueMemberS); ok {
+				s.evictRunTTL(runID)
+				s.evictRunKeys(runID)
+				return fmt.Errorf("workflow run %s is %s: %w", runID, old.Value, gorkflow.ErrRunFinished)
+			}
+			return fmt.Errorf("workflow run %s not found", runID)
+		}
+		return fmt.Errorf("failed to update workflow run status: %w", err)
+	}
+
+	if status.IsTerminal() {
+		s.evictRunTTL(runID)
+		s.evictRunKeys(runID)
 	}
 
 	return nil
 }
 
-func (s *DynamoDBStore) GetStepExecution(ctx context.Context, runID, stepID string) (*gorkflow.StepExecution, error) {
-	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+// UpdateRunProgress sets the progress attributes with one UpdateItem, so a status written
+// meanwhile, e.g. by Pause on another engine, is kept
+func (s *DynamoDBStore) UpdateRunProgress(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	timestamp, err := attributevalue.Marshal(run.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to marshal timestamp: %w", err)
+	}
+
+	sets := []string{"progress = :progress", "total_attempts = :attempts", "updated_at = :updated"}
+	values := map[string]types.AttributeValue{
+		":progress": &types.AttributeValueMemberN{Value: strconv.FormatFloat(run.Progress, 'f', -1, 64)},
+		":attempts": &types.AttributeValueMemberN{Value: strconv.Itoa(run.TotalAttempts)},
+		":updated":  timestamp,
+	}
+
+	update := "SET "
+	if run.CurrentStep != "" {
+		sets = append(sets, "current_step = :step")
+		values[":step"] = &types.AttributeValueMemberS{Value: run.CurrentStep}
+		update += strings.Join(sets, ", ")
+	} else {
+		update += strings.Join(sets, ", ") + " REMOVE current_step"
+	}
+
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName: aws.String(s.tableName),
 		Key: map[string]types.AttributeValue{
-			AttrPK: &types.AttributeValueMemberS{Value: stepExecutionPK(runID)},
-			AttrSK: &types.AttributeValueMemberS{Value: stepExecutionSK(stepID)},
+			AttrPK: &types.AttributeValueMemberS{Value: workflowRunPK(run.RunID)},
+			AttrSK: &types.AttributeValueMemberS{Value: workflowRunSK()},
 		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(PK)"),
+		ExpressionAttributeValues: values,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get step execution: %w", err)
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return fmt.Errorf("workflow run %s not found", run.RunID)
+		}
+		return fmt.Errorf("failed to update workflow run progress: %w", err)
 	}
 
-	if result.Item == nil {
-		return nil, fmt.Errorf("step execution %s/%s not found", runID, stepID)
+	return nil
+}
+
+// ListRuns queries GSI1 when a WorkflowID is given and GSI2 when only a ResourceID is given.
+// Both indexes are partitioned by status, so without a status filter every status is queried.
+// Filters without a WorkflowID or ResourceID cannot use an index and return no runs.
+// Tags are matched with a filter expression, so the partitions are still read in full.
+func (s *DynamoDBStore) ListRuns(ctx context.Context, filter gorkflow.RunFilter) ([]*gorkflow.WorkflowRun, error) {
+	index, ok := runIndexFor(filter)
+	if !ok {
+		return []*gorkflow.WorkflowRun{}, nil
 	}
 
-	var exec gorkflow.StepExecution
-	if err := attributevalue.UnmarshalMap(result.Item, &exec); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal step execution: %w", err)
+	statuses := filterStatuses(filter)
+
+	runs := []*gorkflow.WorkflowRun{}
+	for _, status := range statuses {
+		statusRuns, err := s.queryRunIndex(ctx, index, status, filter)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, statusRuns...)
 	}
 
-	return &exec, nil
+	// Each partition is already ordered by the sort key; merge across partitions
+	if len(statuses) > 1 {
+		sortRunsByCreatedAt(runs, filter.SortDescending)
+	}
+
+	if filter.Limit > 0 && len(runs) > filter.Limit {
+		runs = runs[:filter.Limit]
+	}
+
+	return runs, nil
 }
 
-func (s *DynamoDBStore) UpdateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
-	exec.UpdatedAt = time.Now()
+// CountRuns counts the runs ListRuns would return, using Select COUNT on the same index partitions
+func (s *DynamoDBStore) CountRuns(ctx context.Context, filter gorkflow.RunFilter) (int, error) {
+	index, ok := runIndexFor(filter)
+	if !ok {
+		return 0, nil
+	}
 
-	// Marshal
-	item, err := attributevalue.MarshalMap(exec)
-	if err != nil {
-		return fmt.Errorf("failed to marshal step execution: %w", err)
+	count := 0
+	for _, status := range filterStatuses(filter) {
+		queryInput := index.queryInput(s.tableName, status, filter)
+		queryInput.Select = types.SelectCount
+
+		// Paginate through all results
+		for {
+			result, err := s.client.Query(ctx, queryInput)
+			if err != nil {
+				return 0, fmt.Errorf("failed to count runs: %w", err)
+			}
+			count += int(result.Count)
+
+			if result.LastEvaluatedKey == nil {
+				break
+			}
+			queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+		}
 	}
 
-	// Add keys
-	item[AttrPK] = &types.AttributeValueMemberS{Value: stepExecutionPK(exec.RunID)}
-	item[AttrSK] = &types.AttributeValueMemberS{Value: stepExecutionSK(exec.StepID)}
-	item[AttrEntityType] = &types.AttributeValueMemberS{Value: EntityTypeStepExecution}
+	return count, nil
+}
 
-	// Put item
-	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(s.tableName),
-		Item:      item,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to update step execution: %w", err)
+// runIndex is the GSI a run filter is served from
+type runIndex struct {
+	name, pkAttr, skAttr string
+	partitionKey         func(status string) string
+}
+
+// runIndexFor picks GSI1 when the filter has a WorkflowID and GSI2 when it only has a ResourceID
+func runIndexFor(filter gorkflow.RunFilter) (runIndex, bool) {
+	switch {
+	case filter.WorkflowID != "":
+		return runIndex{
+			name: IndexStatusIndex, pkAttr: AttrGSI1PK, skAttr: AttrGSI1SK,
+			partitionKey: func(status string) string {
+				return workflowRunGSI1PK(filter.WorkflowID, status)
+			},
+		}, true
+	case filter.ResourceID != "":
+		return runIndex{
+			name: IndexResourceIndex, pkAttr: AttrGSI2PK, skAttr: AttrGSI2SK,
+			partitionKey: func(status string) string {
+				return workflowRunGSI2PK(filter.ResourceID, status)
+			},
+		}, true
+	default:
+		return runIndex{}, false
 	}
+}
 
-	return nil
+// filterStatuses returns the status partitions a filter covers, every status when none is set
+func filterStatuses(filter gorkflow.RunFilter) []gorkflow.RunStatus {
+	if filter.Status != nil {
+		return []gorkflow.RunStatus{*filter.Status}
+	}
+	return runStatuses
 }
 
-func (s *DynamoDBStore) ListStepExecutions(ctx context.Context, runID string) ([]*gorkflow.StepExecution, error) {
-	var executions []*gorkflow.StepExecution
+// queryInput builds the query for one status partition, applying the creation window as a sort-key range.
+// Sort keys are UTC timestamps, so the bounds are converted to UTC to compare correctly as strings.
+func (idx runIndex) queryInput(tableName string, status gorkflow.RunStatus, filter gorkflow.RunFilter) *dynamodb.QueryInput {
+	keyCondition := idx.pkAttr + " = :pk"
+	values := map[string]types.AttributeValue{
+		":pk": &types.AttributeValueMemberS{Value: idx.partitionKey(string(status))},
+	}
+
+	after, before := filter.CreatedAfter, filter.CreatedBefore
+	switch {
+	case after != nil && before != nil:
+		keyCondition += " AND " + idx.skAttr + " BETWEEN :after AND :before"
+	case after != nil:
+		keyCondition += " AND " + idx.skAttr + " >= :after"
+	case before != nil:
+		keyCondition += " AND " + idx.skAttr + " <= :before"
+	}
+	if after != nil {
+		values[":after"] = &types.AttributeValueMemberS{Value: workflowRunGSI1SK(after.UTC().Format(time.RFC3339))}
+	}
+	if before != nil {
+		values[":before"] = &types.AttributeValueMemberS{Value: workflowRunGSI1SK(before.UTC().Format(time.RFC3339))}
+	}
+
+	// GSI1 is used when both IDs are given, so narrow by resource afterwards
+	var conditions []string
+	if idx.name == IndexStatusIndex && filter.ResourceID != "" {
+		conditions = append(conditions, "resource_id = :rid")
+		values[":rid"] = &types.AttributeValueMemberS{Value: filter.ResourceID}
+	}
+
+	// Tags are not indexed; matching runs are filtered after they are read
+	var names map[string]string
+	for i, key := range slices.Sorted(maps.Keys(filter.Tags)) {
+		if names == nil {
+			names = map[string]string{"#tags": "tags"}
+		}
+		name, value := fmt.Sprintf("#tag%d", i), fmt.Sprintf(":tag%d", i)
+		conditions = append(conditions, "#tags."+name+" = "+value)
+		names[name] = key
+		values[value] = &types.AttributeValueMemberS{Value: filter.Tags[key]}
+	}
+
+	var filterExpression *string
+	if len(conditions) > 0 {
+		filterExpression = aws.String(strings.Join(conditions, " AND "))
+	}
+
+	return &dynamodb.QueryInput{
+		TableName:                 aws.String(tableName),
+		IndexName:                 aws.String(idx.name),
+		KeyConditionExpression:    aws.String(keyCondition),
+		FilterExpression:          filterExpression,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ScanIndexForward:          aws.Bool(!filter.SortDescending),
+	}
+}
+
+// queryRunIndex reads all runs in one status partition of the index
+func (s *DynamoDBStore) queryRunIndex(
+	ctx context.Context,
+	index runIndex,
+	status gorkflow.RunStatus,
+	filter gorkflow.RunFilter,
+) ([]*gorkflow.WorkflowRun, error) {
+	queryInput := index.queryInput(s.tableName, status, filter)
+
+	var runs []*gorkflow.WorkflowRun
 	var lastEvaluatedKey map[string]types.AttributeValue
 
 	// Paginate through all results
+	for {
+		if lastEvaluatedKey != nil {
+			queryInput.ExclusiveStartKey = lastEvaluatedKey
+		}
+
+		result, err := s.client.Query(ctx, queryInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list workflow runs: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var run gorkflow.WorkflowRun
+			if err := attributevalue.UnmarshalMap(item, &run); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal workflow run: %w", err)
+			}
+			runs = append(runs, &run)
+		}
+
+		if filter.Limit > 0 && len(runs) >= filter.Limit {
+			break
+		}
+
+		// Check if there are more results
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		lastEvaluatedKey = result.LastEvaluatedKey
+	}
+
+	return runs, nil
+}
+
+func (s *DynamoDBStore) DeleteRun(ctx context.Context, runID string) error {
+	var keys []map[string]types.AttributeValue
+	var objectRefs []string
+	var lastEvaluatedKey map[string]types.AttributeValue
+
+	// Collect the keys of every item in the run partition (META, STEP#, OUTPUT#, STATE#)
 	for {
 		queryInput := &dynamodb.QueryInput{
 			TableName:              aws.String(s.tableName),
-			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+			KeyConditionExpression: aws.String("PK = :pk"),
+			ProjectionExpression:   aws.String("PK, SK, " + AttrObjectRef),
 			ExpressionAttributeValues: map[string]types.AttributeValue{
-				":pk": &types.AttributeValueMemberS{Value: stepExecutionPK(runID)},
-				":sk": &types.AttributeValueMemberS{Value: stepPrefix()},
+				":pk": &types.AttributeValueMemberS{Value: workflowRunPK(runID)},
 			},
 		}
 
@@ -272,49 +732,312 @@ func (s *DynamoDBStore) ListStepExecutions(ctx context.Context, runID string) ([
 
 		result, err := s.client.Query(ctx, queryInput)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list step executions: %w", err)
+			return fmt.Errorf("failed to query run items: %w", err)
 		}
 
 		for _, item := range result.Items {
-			var exec gorkflow.StepExecution
-			if err := attributevalue.UnmarshalMap(item, &exec); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal step execution: %w", err)
+			keys = append(keys, map[string]types.AttributeValue{
+				AttrPK: item[AttrPK],
+				AttrSK: item[AttrSK],
+			})
+			if ref, ok := item[AttrObjectRef].(*types.AttributeValueMemberS); ok {
+				objectRefs = append(objectRefs, ref.Value)
 			}
-			executions = append(executions, &exec)
 		}
 
-		// Check if there are more results
 		if result.LastEvaluatedKey == nil {
 			break
 		}
 		lastEvaluatedKey = result.LastEvaluatedKey
 	}
 
-	return executions, nil
-}
-
-// Step output operations
+	if len(keys) == 0 {
+		return fmt.Errorf("workflow run %s not found", runID)
+	}
 
-func (s *DynamoDBStore) SaveStepOutput(ctx context.Context, runID, stepID string, output []byte) error {
-	item := map[string]types.AttributeValue{
-		AttrPK:         &types.AttributeValueMemberS{Value: stepOutputPK(runID)},
-		AttrSK:         &types.AttributeValueMemberS{Value: stepOutputSK(stepID)},
-		AttrEntityType: &types.AttributeValueMemberS{Value: EntityTypeStepOutput},
-		"output":       &types.AttributeValueMemberB{Value: output},
-		"updated_at":   &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339)},
+	// Remove offloaded payloads before their pointers disappear
+	if s.largeObjects != nil {
+		for _, ref := range objectRefs {
+			if err := s.largeObjects.delete(ctx, ref); err != nil {
+				return err
+			}
+		}
 	}
 
-	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(s.tableName),
-		Item:      item,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to save step output: %w", err)
+	// Delete in batches
+	for start := 0; start < len(keys); start += maxBatchWriteItems {
+		end := min(start+maxBatchWriteItems, len(keys))
+
+		requests := make([]types.WriteRequest, 0, end-start)
+		for _, key := range keys[start:end] {
+			requests = append(requests, types.WriteRequest{
+				DeleteRequest: &types.DeleteRequest{Key: key},
+			})
+		}
+
+		if err := s.batchWrite(ctx, requests); err != nil {
+			return fmt.Errorf("failed to delete run items: %w", err)
+		}
 	}
 
+	s.evictRunTTL(runID)
+	s.evictRunKeys(runID)
+
 	return nil
 }
 
+// batchWrite issues a BatchWriteItem call, resubmitting unprocessed items until all are written
+func (s *DynamoDBStore) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	pending := map[string][]types.WriteRequest{s.tableName: requests}
+
+	for attempt := 1; len(pending[s.tableName]) > 0; attempt++ {
+		if attempt > 1 {
+			if err := waitUnprocessed(ctx, attempt); err != nil {
+				return fmt.Errorf("%d items unprocessed: %w", len(pending[s.tableName]), err)
+			}
+		}
+
+		result, err := s.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			return err
+		}
+		pending = result.UnprocessedItems
+	}
+
+	return nil
+}
+
+// Step execution operations
+
+func (s *DynamoDBStore) CreateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
+	item, err := s.stepExecutionItem(ctx, exec)
+	if err != nil {
+		return fmt.Errorf("failed to create step execution: %w", err)
+	}
+
+	// Put item
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create step execution: %w", err)
+	}
+
+	return nil
+}
+
+func (s *DynamoDBStore) GetStepExecution(ctx context.Context, runID, stepID string) (*gorkflow.StepExecution, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			AttrPK: &types.AttributeValueMemberS{Value: stepExecutionPK(runID)},
+			AttrSK: &types.AttributeValueMemberS{Value: stepExecutionSK(stepID)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get step execution: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("step execution %s/%s not found", runID, stepID)
+	}
+
+	var exec gorkflow.StepExecution
+	if err := attributevalue.UnmarshalMap(result.Item, &exec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal step execution: %w", err)
+	}
+
+	return &exec, nil
+}
+
+func (s *DynamoDBStore) UpdateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
+	item, err := s.stepExecutionItem(ctx, exec)
+	if err != nil {
+		return fmt.Errorf("failed to update step execution: %w", err)
+	}
+
+	// Put item
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update step execution: %w", err)
+	}
+
+	return nil
+}
+
+// stepExecutionItem builds the item stored for a step execution
+func (s *DynamoDBStore) stepExecutionItem(ctx context.Context, exec *gorkflow.StepExecution) (map[string]types.AttributeValue, error) {
+	if exec.UpdatedAt.IsZero() {
+		exec.UpdatedAt = s.now()
+	}
+
+	// Marshal
+	item, err := attributevalue.MarshalMap(exec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal step execution: %w", err)
+	}
+
+	// Add keys
+	item[AttrPK] = &types.AttributeValueMemberS{Value: stepExecutionPK(exec.RunID)}
+	item[AttrSK] = &types.AttributeValueMemberS{Value: stepExecutionSK(exec.StepID)}
+	item[AttrEntityType] = &types.AttributeValueMemberS{Value: EntityTypeStepExecution}
+
+	if err := s.applyRunTTL(ctx, exec.RunID, item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func (s *DynamoDBStore) ListStepExecutions(ctx context.Context, runID string) ([]*gorkflow.StepExecution, error) {
+	var executions []*gorkflow.StepExecution
+	var lastEvaluatedKey map[string]types.AttributeValue
+
+	// Paginate through all results
+	for {
+		queryInput := &dynamodb.QueryInput{
+			TableName:              aws.String(s.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: stepExecutionPK(runID)},
+				":sk": &types.AttributeValueMemberS{Value: stepPrefix()},
+			},
+		}
+
+		if lastEvaluatedKey != nil {
+			queryInput.ExclusiveStartKey = lastEvaluatedKey
+		}
+
+		result, err := s.client.Query(ctx, queryInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list step executions: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var exec gorkflow.StepExecution
+			if err := attributevalue.UnmarshalMap(item, &exec); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal step execution: %w", err)
+			}
+			executions = append(executions, &exec)
+		}
+
+		// Check if there are more results
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		lastEvaluatedKey = result.LastEvaluatedKey
+	}
+
+	return executions, nil
+}
+
+// Step output operations
+
+func (s *DynamoDBStore) SaveStepOutput(ctx context.Context, runID, stepID string, output []byte) error {
+	item, err := s.stepOutputItem(ctx, runID, stepID, output)
+	if err != nil {
+		return fmt.Errorf("failed to save step output: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save step output: %w", err)
+	}
+
+	return nil
+}
+
+// SaveStepOutputs writes the outputs of several steps, keyed by step ID, with BatchWriteItem.
+// The batch is not atomic: on error some outputs may already be saved.
+func (s *DynamoDBStore) SaveStepOutputs(ctx context.Context, runID string, outputs map[string][]byte) error {
+	requests := make([]types.WriteRequest, 0, len(outputs))
+	for _, stepID := range slices.Sorted(maps.Keys(outputs)) {
+		item, err := s.stepOutputItem(ctx, runID, stepID, outputs[stepID])
+		if err != nil {
+			return fmt.Errorf("failed to save step outputs: %w", err)
+		}
+		requests = append(requests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
+	}
+
+	for start := 0; start < len(requests); start += maxBatchWriteItems {
+		end := min(start+maxBatchWriteItems, len(requests))
+		if err := s.batchWrite(ctx, requests[start:end]); err != nil {
+			return fmt.Errorf("failed to save step outputs: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CommitStepResult writes a finished step execution and its output in one transaction, so
+// a crash cannot leave a completed step without its output
+func (s *DynamoDBStore) CommitStepResult(ctx context.Context, exec *gorkflow.StepExecution, output []byte) error {
+	execItem, err := s.stepExecutionItem(ctx, exec)
+	if err != nil {
+		return fmt.Errorf("failed to commit step result: %w", err)
+	}
+
+	outputItem, err := s.stepOutputItem(ctx, exec.RunID, exec.StepID, output)
+	if err != nil {
+		return fmt.Errorf("failed to commit step result: %w", err)
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{TableName: aws.String(s.tableName), Item: execItem}},
+			{Put: &types.Put{TableName: aws.String(s.tableName), Item: outputItem}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit step result: %w", err)
+	}
+
+	return nil
+}
+
+// stepOutputItem builds the item stored for a step output, offloading large outputs to S3
+func (s *DynamoDBStore) stepOutputItem(ctx context.Context, runID, stepID string, output []byte) (map[string]types.AttributeValue, error) {
+	output, err := compressPayload(s.compression, output)
+	if err != nil {
+		return nil, err
+	}
+
+	item := map[string]types.AttributeValue{
+		AttrPK:         &types.AttributeValueMemberS{Value: stepOutputPK(runID)},
+		AttrSK:         &types.AttributeValueMemberS{Value: stepOutputSK(stepID)},
+		AttrEntityType: &types.AttributeValueMemberS{Value: EntityTypeStepOutput},
+		"updated_at":   &types.AttributeValueMemberS{Value: s.now().Format(time.RFC3339)},
+	}
+
+	// Offload large outputs to S3 and keep a pointer in the item
+	if s.largeObjects.shouldOffload(output) {
+		ref, err := s.largeObjects.put(ctx, stepOutputObjectKey(runID, stepID), output)
+		if err != nil {
+			return nil, err
+		}
+		item[AttrObjectRef] = &types.AttributeValueMemberS{Value: ref}
+	} else {
+		item["output"] = &types.AttributeValueMemberB{Value: output}
+	}
+
+	if err := s.applyRunTTL(ctx, runID, item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
 func (s *DynamoDBStore) LoadStepOutput(ctx context.Context, runID, stepID string) ([]byte, error) {
 	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: aws.String(s.tableName),
@@ -331,7 +1054,59 @@ func (s *DynamoDBStore) LoadStepOutput(ctx context.Context, runID, stepID string
 		return nil, fmt.Errorf("step output %s/%s not found", runID, stepID)
 	}
 
-	outputAttr, ok := result.Item["output"]
+	return s.stepOutputFromItem(ctx, runID, stepID, result.Item)
+}
+
+func (s *DynamoDBStore) BatchLoadStepOutputs(ctx context.Context, runID string, stepIDs []string) (map[string][]byte, error) {
+	outputs := make(map[string][]byte, len(stepIDs))
+
+	// Duplicate keys are rejected by BatchGetItem
+	stepBySK := make(map[string]string, len(stepIDs))
+	keys := make([]map[string]types.AttributeValue, 0, len(stepIDs))
+	for _, stepID := range stepIDs {
+		sk := stepOutputSK(stepID)
+		if _, seen := stepBySK[sk]; seen {
+			continue
+		}
+		stepBySK[sk] = stepID
+		keys = append(keys, map[string]types.AttributeValue{
+			AttrPK: &types.AttributeValueMemberS{Value: stepOutputPK(runID)},
+			AttrSK: &types.AttributeValueMemberS{Value: sk},
+		})
+	}
+
+	for start := 0; start < len(keys); start += maxBatchGetItems {
+		end := min(start+maxBatchGetItems, len(keys))
+
+		items, err := s.batchGet(ctx, keys[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to batch load step outputs: %w", err)
+		}
+
+		for _, item := range items {
+			sk, ok := item[AttrSK].(*types.AttributeValueMemberS)
+			if !ok {
+				continue
+			}
+			stepID := stepBySK[sk.Value]
+			output, err := s.stepOutputFromItem(ctx, runID, stepID, item)
+			if err != nil {
+				return nil, err
+			}
+			outputs[stepID] = output
+		}
+	}
+
+	return outputs, nil
+}
+
+// stepOutputFromItem reads the output held by a step output item, or by the S3 object it points to
+func (s *DynamoDBStore) stepOutputFromItem(ctx context.Context, runID, stepID string, item map[string]types.AttributeValue) ([]byte, error) {
+	if refAttr, ok := item[AttrObjectRef]; ok {
+		return s.loadObjectRef(ctx, refAttr)
+	}
+
+	outputAttr, ok := item["output"]
 	if !ok {
 		return nil, fmt.Errorf("step output %s/%s has no output field", runID, stepID)
 	}
@@ -341,21 +1116,40 @@ func (s *DynamoDBStore) LoadStepOutput(ctx context.Context, runID, stepID string
 		return nil, fmt.Errorf("step output %s/%s output field is not binary", runID, stepID)
 	}
 
-	return outputBytes.Value, nil
+	return decompressPayload(outputBytes.Value)
 }
 
 // State operations
 
 func (s *DynamoDBStore) SaveState(ctx context.Context, runID, key string, value []byte) error {
+	value, err := compressPayload(s.compression, value)
+	if err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
 	item := map[string]types.AttributeValue{
 		AttrPK:         &types.AttributeValueMemberS{Value: statePK(runID)},
 		AttrSK:         &types.AttributeValueMemberS{Value: stateSK(key)},
 		AttrEntityType: &types.AttributeValueMemberS{Value: EntityTypeState},
-		"value":        &types.AttributeValueMemberB{Value: value},
-		"updated_at":   &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339)},
+		"updated_at":   &types.AttributeValueMemberS{Value: s.now().Format(time.RFC3339)},
 	}
 
-	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+	// Offload large values to S3 and keep a pointer in the item
+	if s.largeObjects.shouldOffload(value) {
+		ref, err := s.largeObjects.put(ctx, stateObjectKey(runID, key), value)
+		if err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		item[AttrObjectRef] = &types.AttributeValueMemberS{Value: ref}
+	} else {
+		item["value"] = &types.AttributeValueMemberB{Value: value}
+	}
+
+	if err := s.applyRunTTL(ctx, runID, item); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: aws.String(s.tableName),
 		Item:      item,
 	})
@@ -382,17 +1176,16 @@ func (s *DynamoDBStore) LoadState(ctx context.Context, runID, key string) ([]byt
 		return nil, fmt.Errorf("state key %s not found", key)
 	}
 
-	valueAttr, ok := result.Item["value"]
-	if !ok {
-		return nil, fmt.Errorf("state key %s has no value field", key)
+	if refAttr, ok := result.Item[AttrObjectRef]; ok {
+		return s.loadObjectRef(ctx, refAttr)
 	}
 
-	valueBytes, ok := valueAttr.(*types.AttributeValueMemberB)
+	valueAttr, ok := result.Item["value"]
 	if !ok {
-		return nil, fmt.Errorf("state key %s value field is not binary", key)
+		return nil, fmt.Errorf("state key %s has no value field", key)
 	}
 
-	return valueBytes.Value, nil
+	return stateValue(key, valueAttr)
 }
 
 func (s *DynamoDBStore) DeleteState(ctx context.Context, runID, key string) error {
@@ -411,6 +1204,15 @@ func (s *DynamoDBStore) DeleteState(ctx context.Context, runID, key string) erro
 }
 
 func (s *DynamoDBStore) GetAllState(ctx context.Context, runID string) (map[string][]byte, error) {
+	stateData, err := s.ListState(ctx, runID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all state: %w", err)
+	}
+	return stateData, nil
+}
+
+// ListState queries the run's state items with begins_with(SK, STATE#{prefix})
+func (s *DynamoDBStore) ListState(ctx context.Context, runID, prefix string) (map[string][]byte, error) {
 	stateData := make(map[string][]byte)
 	var lastEvaluatedKey map[string]types.AttributeValue
 
@@ -421,7 +1223,7 @@ func (s *DynamoDBStore) GetAllState(ctx context.Context, runID string) (map[stri
 			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
 			ExpressionAttributeValues: map[string]types.AttributeValue{
 				":pk": &types.AttributeValueMemberS{Value: statePK(runID)},
-				":sk": &types.AttributeValueMemberS{Value: statePrefix()},
+				":sk": &types.AttributeValueMemberS{Value: stateSK(prefix)},
 			},
 		}
 
@@ -431,7 +1233,7 @@ func (s *DynamoDBStore) GetAllState(ctx context.Context, runID string) (map[stri
 
 		result, err := s.client.Query(ctx, queryInput)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get all state: %w", err)
+			return nil, fmt.Errorf("failed to list state: %w", err)
 		}
 
 		for _, item := range result.Items {
@@ -443,12 +1245,24 @@ func (s *DynamoDBStore) GetAllState(ctx context.Context, runID string) (map[stri
 			sk := skAttr.(*types.AttributeValueMemberS).Value
 			key := sk[len(statePrefix()):] // Remove STATE# prefix
 
+			if refAttr, ok := item[AttrObjectRef]; ok {
+				valueBytes, err := s.loadObjectRef(ctx, refAttr)
+				if err != nil {
+					return nil, fmt.Errorf("failed to list state: %w", err)
+				}
+				stateData[key] = valueBytes
+				continue
+			}
+
 			valueAttr, ok := item["value"]
 			if !ok {
 				continue
 			}
 
-			valueBytes := valueAttr.(*types.AttributeValueMemberB).Value
+			valueBytes, err := stateValue(key, valueAttr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list state: %w", err)
+			}
 			stateData[key] = valueBytes
 		}
 
@@ -462,6 +1276,280 @@ func (s *DynamoDBStore) GetAllState(ctx context.Context, runID string) (map[stri
 	return stateData, nil
 }
 
+// maxIncrementAttempts bounds retries when converting a value written by SaveState into a counter
+const maxIncrementAttempts = 5
+
+// IncrementState adds delta with an ADD update expression. Counters are stored as numbers;
+// integer values previously written with SaveState are converted on first increment.
+func (s *DynamoDBStore) IncrementState(ctx context.Context, runID, key string, delta int64) (int64, error) {
+	ttl, err := s.runTTL(ctx, runID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment state: %w", err)
+	}
+
+	for attempt := 0; attempt < maxIncrementAttempts; attempt++ {
+		total, err := s.addState(ctx, runID, key, delta, ttl)
+		if err == nil {
+			return total, nil
+		}
+
+		var conditionFailed *types.ConditionalCheckFailedException
+		if !errors.As(err, &conditionFailed) {
+			return 0, fmt.Errorf("failed to increment state: %w", err)
+		}
+
+		// The key holds a binary value; convert it unless another writer changed it first
+		total, converted, err := s.convertStateCounter(ctx, runID, key, delta, ttl)
+		if err != nil {
+			return 0, fmt.Errorf("failed to increment state: %w", err)
+		}
+		if converted {
+			return total, nil
+		}
+	}
+
+	return 0, fmt.Errorf("failed to increment state: key %s kept changing during conversion", key)
+}
+
+// addState atomically adds delta to a numeric state value, creating it if missing
+func (s *DynamoDBStore) addState(ctx context.Context, runID, key string, delta, ttl int64) (int64, error) {
+	update := "ADD #value :delta SET #type = :type, updated_at = :now"
+	names := map[string]string{
+		"#value": "value",
+		"#type":  AttrEntityType,
+		"#ref":   AttrObjectRef,
+	}
+	values := map[string]types.AttributeValue{
+		":delta":  &types.AttributeValueMemberN{Value: strconv.FormatInt(delta, 10)},
+		":type":   &types.AttributeValueMemberS{Value: EntityTypeState},
+		":now":    &types.AttributeValueMemberS{Value: s.now().Format(time.RFC3339)},
+		":number": &types.AttributeValueMemberS{Value: string(types.ScalarAttributeTypeN)},
+	}
+	if ttl > 0 {
+		update += ", #ttl = :ttl"
+		names["#ttl"] = AttrTTL
+		values[":ttl"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(ttl, 10)}
+	}
+
+	result, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			AttrPK: &types.AttributeValueMemberS{Value: statePK(runID)},
+			AttrSK: &types.AttributeValueMemberS{Value: stateSK(key)},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_not_exists(#ref) AND (attribute_not_exists(#value) OR attribute_type(#value, :number))"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	totalAttr, ok := result.Attributes["value"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("state key %s returned no numeric value", key)
+	}
+	return strconv.ParseInt(totalAttr.Value, 10, 64)
+}
+
+// convertStateCounter replaces a binary integer value with a number holding value+delta.
+// It reports false when the value changed concurrently and the increment should be retried.
+func (s *DynamoDBStore) convertStateCounter(ctx context.Context, runID, key string, delta, ttl int64) (int64, bool, error) {
+	stateKey := map[string]types.AttributeValue{
+		AttrPK: &types.AttributeValueMemberS{Value: statePK(runID)},
+		AttrSK: &types.AttributeValueMemberS{Value: stateSK(key)},
+	}
+
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            stateKey,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	if _, ok := result.Item[AttrObjectRef]; ok {
+		return 0, false, fmt.Errorf("state key %s does not hold an integer", key)
+	}
+
+	stored, ok := result.Item["value"].(*types.AttributeValueMemberB)
+	if !ok {
+		// Deleted or already converted by another writer
+		return 0, false, nil
+	}
+
+	current, err := decompressPayload(stored.Value)
+	if err != nil {
+		return 0, false, err
+	}
+	counter, err := parseCounter(key, current)
+	if err != nil {
+		return 0, false, err
+	}
+	total := counter + delta
+
+	update := "SET #value = :total, updated_at = :now"
+	names := map[string]string{"#value": "value"}
+	values := map[string]types.AttributeValue{
+		":total": &types.AttributeValueMemberN{Value: strconv.FormatInt(total, 10)},
+		":old":   stored,
+		":now":   &types.AttributeValueMemberS{Value: s.now().Format(time.RFC3339)},
+	}
+	if ttl > 0 {
+		update += ", #ttl = :ttl"
+		names["#ttl"] = AttrTTL
+		values[":ttl"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(ttl, 10)}
+	}
+
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       stateKey,
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("#value = :old"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return total, true, nil
+}
+
+// stateValue decodes a state item's value: binary (possibly compressed) JSON, or a number written by IncrementState
+func stateValue(key string, attr types.AttributeValue) ([]byte, error) {
+	switch v := attr.(type) {
+	case *types.AttributeValueMemberB:
+		return decompressPayload(v.Value)
+	case *types.AttributeValueMemberN:
+		return []byte(v.Value), nil
+	default:
+		return nil, fmt.Errorf("state key %s value field is not binary or numeric", key)
+	}
+}
+
+// loadObjectRef resolves an s3:// pointer stored in place of an inline payload
+func (s *DynamoDBStore) loadObjectRef(ctx context.Context, attr types.AttributeValue) ([]byte, error) {
+	ref, ok := attr.(*types.AttributeValueMemberS)
+	if !ok {
+		return nil, fmt.Errorf("object reference is not a string")
+	}
+
+	if s.largeObjects == nil {
+		return nil, fmt.Errorf("item references %s but no large object store is configured", ref.Value)
+	}
+
+	data, err := s.largeObjects.get(ctx, ref.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return decompressPayload(data)
+}
+
+// applyRunTTL copies the owning run's TTL onto a child item so it expires with the run
+func (s *DynamoDBStore) applyRunTTL(ctx context.Context, runID string, item map[string]types.AttributeValue) error {
+	ttl, err := s.runTTL(ctx, runID)
+	if err != nil {
+		return err
+	}
+
+	if ttl > 0 {
+		item[AttrTTL] = &types.AttributeValueMemberN{Value: strconv.FormatInt(ttl, 10)}
+	}
+
+	return nil
+}
+
+// runTTL returns the run's TTL, reading it from the run item only on the first lookup
+func (s *DynamoDBStore) runTTL(ctx context.Context, runID string) (int64, error) {
+	ttl, ok := s.runTTLs.get(runID)
+	if ok {
+		return ttl, nil
+	}
+
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			AttrPK: &types.AttributeValueMemberS{Value: workflowRunPK(runID)},
+			AttrSK: &types.AttributeValueMemberS{Value: workflowRunSK()},
+		},
+		ProjectionExpression:     aws.String("#ttl"),
+		ExpressionAttributeNames: map[string]string{"#ttl": AttrTTL},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to load run TTL: %w", err)
+	}
+
+	// Unknown runs get no TTL and are not cached, so a later CreateRun still applies
+	if result.Item == nil {
+		return 0, nil
+	}
+
+	if ttlAttr, ok := result.Item[AttrTTL].(*types.AttributeValueMemberN); ok {
+		ttl, err = strconv.ParseInt(ttlAttr.Value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid run TTL %q: %w", ttlAttr.Value, err)
+		}
+	}
+
+	s.cacheRunTTL(runID, ttl)
+	return ttl, nil
+}
+
+func (s *DynamoDBStore) cacheRunTTL(runID string, ttl int64) {
+	s.runTTLs.put(runID, ttl)
+}
+
+func (s *DynamoDBStore) evictRunTTL(runID string) {
+	s.runTTLs.delete(runID)
+}
+
+// runIndexKeys returns what the run's GSI keys are built from, reading only those
+// attributes from the run item when the run is not cached
+func (s *DynamoDBStore) runIndexKeys(ctx context.Context, runID string) (runIndexKeys, error) {
+	keys, ok := s.runKeys.get(runID)
+	if ok {
+		return keys, nil
+	}
+
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			AttrPK: &types.AttributeValueMemberS{Value: workflowRunPK(runID)},
+			AttrSK: &types.AttributeValueMemberS{Value: workflowRunSK()},
+		},
+		ProjectionExpression: aws.String("workflow_id, resource_id"),
+	})
+	if err != nil {
+		return runIndexKeys{}, fmt.Errorf("failed to load run index keys: %w", err)
+	}
+	if result.Item == nil {
+		return runIndexKeys{}, fmt.Errorf("workflow run %s not found", runID)
+	}
+
+	if err := attributevalue.UnmarshalMap(result.Item, &keys); err != nil {
+		return runIndexKeys{}, fmt.Errorf("failed to unmarshal run index keys: %w", err)
+	}
+
+	s.runKeys.put(runID, keys)
+	return keys, nil
+}
+
+func (s *DynamoDBStore) cacheRunKeys(run *gorkflow.WorkflowRun) {
+	s.runKeys.put(run.RunID, runIndexKeys{WorkflowID: run.WorkflowID, ResourceID: run.ResourceID})
+}
+
+func (s *DynamoDBStore) evictRunKeys(runID string) {
+	s.runKeys.delete(runID)
+}
+
 // Query operations
 
 func (s *DynamoDBStore) CountRunsByStatus(ctx context.Context, resourceID string, status gorkflow.RunStatus) (int, error) {
@@ -481,3 +1569,140 @@ func (s *DynamoDBStore) CountRunsByStatus(ctx context.Context, resourceID string
 
 	return int(result.Count), nil
 }
+
+// Scheduled run operations
+
+// addScheduleIndexKeys indexes a run on GSI3 while it is pending with a due time.
+// The keys are dropped as soon as the run is written in any other status.
+func addScheduleIndexKeys(item map[string]types.AttributeValue, run *gorkflow.WorkflowRun) {
+	if run.ScheduledAt == nil || run.Status != gorkflow.RunStatusPending {
+		return
+	}
+
+	item[AttrGSI3PK] = &types.AttributeValueMemberS{Value: scheduledRunGSI3PK(*run.ScheduledAt)}
+	item[AttrGSI3SK] = &types.AttributeValueMemberS{Value: scheduledRunGSI3SK(*run.ScheduledAt)}
+}
+
+// ListDueRuns queries the GSI3 day buckets from the lookback window up to dueBefore, oldest first
+func (s *DynamoDBStore) ListDueRuns(ctx context.Context, dueBefore time.Time, limit int) ([]*gorkflow.WorkflowRun, error) {
+	dueBefore = dueBefore.UTC()
+	day := dueBefore.Add(-s.scheduleLookback).Truncate(24 * time.Hour)
+
+	runs := []*gorkflow.WorkflowRun{}
+	for ; !day.After(dueBefore); day = day.Add(24 * time.Hour) {
+		var lastEvaluatedKey map[string]types.AttributeValue
+
+		for {
+			result, err := s.client.Query(ctx, &dynamodb.QueryInput{
+				TableName:              aws.String(s.tableName),
+				IndexName:              aws.String(IndexScheduleIndex),
+				KeyConditionExpression: aws.String("GSI3PK = :pk AND GSI3SK <= :due"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":pk":  &types.AttributeValueMemberS{Value: scheduledRunGSI3PK(day)},
+					":due": &types.AttributeValueMemberS{Value: scheduledRunGSI3SK(dueBefore)},
+				},
+				ExclusiveStartKey: lastEvaluatedKey,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list due runs: %w", err)
+			}
+
+			for _, item := range result.Items {
+				var run gorkflow.WorkflowRun
+				if err := attributevalue.UnmarshalMap(item, &run); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal workflow run: %w", err)
+				}
+				runs = append(runs, &run)
+
+				if limit > 0 && len(runs) >= limit {
+					return runs, nil
+				}
+			}
+
+			if result.LastEvaluatedKey == nil {
+				break
+			}
+			lastEvaluatedKey = result.LastEvaluatedKey
+		}
+	}
+
+	return runs, nil
+}
+
+// ClaimScheduledRun removes the run from GSI3 with a conditional update so exactly one poller wins it
+func (s *DynamoDBStore) ClaimScheduledRun(ctx context.Context, runID string) (bool, error) {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			AttrPK: &types.AttributeValueMemberS{Value: workflowRunPK(runID)},
+			AttrSK: &types.AttributeValueMemberS{Value: workflowRunSK()},
+		},
+		UpdateExpression:    aws.String("REMOVE GSI3PK, GSI3SK"),
+		ConditionExpression: aws.String("attribute_exists(GSI3PK) AND #status = :pending"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending": &types.AttributeValueMemberS{Value: string(gorkflow.RunStatusPending)},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim scheduled run: %w", err)
+	}
+
+	return true, nil
+}
+
+// LeaseRun takes or renews the lease item with a conditional put, which only succeeds when the
+// lease is free, expired or already held by owner
+func (s *DynamoDBStore) LeaseRun(ctx context.Context, runID, owner string, ttl time.Duration) (bool, error) {
+	now := s.now()
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item: map[string]types.AttributeValue{
+			AttrPK:           &types.AttributeValueMemberS{Value: workflowRunPK(runID)},
+			AttrSK:           &types.AttributeValueMemberS{Value: runLeaseSK()},
+			AttrLeaseOwner:   &types.AttributeValueMemberS{Value: owner},
+			AttrLeaseExpires: &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(ttl).UnixMilli(), 10)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(PK) OR #owner = :owner OR #expires < :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#owner":   AttrLeaseOwner,
+			"#expires": AttrLeaseExpires,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner": &types.AttributeValueMemberS{Value: owner},
+			":now":   &types.AttributeValueMemberN{Value: strconv.FormatInt(now.UnixMilli(), 10)},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to lease run: %w", err)
+	}
+
+	return true, nil
+}
+
+// Ping reads a key no item is stored under, which checks that the table is reachable and
+// readable without touching workflow data
+func (s *DynamoDBStore) Ping(ctx context.Context) error {
+	_, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			AttrPK: &types.AttributeValueMemberS{Value: healthCheckPK()},
+			AttrSK: &types.AttributeValueMemberS{Value: healthCheckSK()},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ping store: %w", err)
+	}
+
+	return nil
+}
@@ -27,6 +27,8 @@ func createTestTable(ctx context.Context, client *dynamodb.Client, tableName str
 			{AttributeName: aws.String("GSI1SK"), AttributeType: types.ScalarAttributeTypeS},
 			{AttributeName: aws.String("GSI2PK"), AttributeType: types.ScalarAttributeTypeS},
 			{AttributeName: aws.String("GSI2SK"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("GSI3PK"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("GSI3SK"), AttributeType: types.ScalarAttributeTypeS},
 		},
 		KeySchema: []types.KeySchemaElement{
 			{AttributeName: aws.String("PK"), KeyType: types.KeyTypeHash},
@@ -49,6 +51,14 @@ func createTestTable(ctx context.Context, client *dynamodb.Client, tableName str
 				},
 				Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
 			},
+			{
+				IndexName: aws.String("GSI3"),
+				KeySchema: []types.KeySchemaElement{
+					{AttributeName: aws.String("GSI3PK"), KeyType: types.KeyTypeHash},
+					{AttributeName: aws.String("GSI3SK"), KeyType: types.KeyTypeRange},
+				},
+				Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+			},
 		},
 		BillingMode: types.BillingModePayPerRequest,
 	})
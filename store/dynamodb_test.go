@@ -3,9 +3,12 @@ package store
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/sicko7947/gorkflow"
@@ -16,7 +19,10 @@ type mockDynamoDBClient struct {
 	putItemFunc            func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
 	getItemFunc            func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
 	queryFunc              func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	updateItemFunc         func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
 	deleteItemFunc         func(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	batchGetItemFunc       func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	batchWriteItemFunc     func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
 	transactWriteItemsFunc func(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
 }
 
@@ -41,6 +47,13 @@ func (m *mockDynamoDBClient) Query(ctx context.Context, params *dynamodb.QueryIn
 	return &dynamodb.QueryOutput{}, nil
 }
 
+func (m *mockDynamoDBClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if m.updateItemFunc != nil {
+		return m.updateItemFunc(ctx, params, optFns...)
+	}
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
 func (m *mockDynamoDBClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
 	if m.deleteItemFunc != nil {
 		return m.deleteItemFunc(ctx, params, optFns...)
@@ -48,6 +61,20 @@ func (m *mockDynamoDBClient) DeleteItem(ctx context.Context, params *dynamodb.De
 	return &dynamodb.DeleteItemOutput{}, nil
 }
 
+func (m *mockDynamoDBClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	if m.batchGetItemFunc != nil {
+		return m.batchGetItemFunc(ctx, params, optFns...)
+	}
+	return &dynamodb.BatchGetItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	if m.batchWriteItemFunc != nil {
+		return m.batchWriteItemFunc(ctx, params, optFns...)
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
 func (m *mockDynamoDBClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
 	if m.transactWriteItemsFunc != nil {
 		return m.transactWriteItemsFunc(ctx, params, optFns...)
@@ -243,6 +270,132 @@ func TestDynamoDBStore_GetRun_Error(t *testing.T) {
 	}
 }
 
+func TestDynamoDBStore_BatchGetRuns(t *testing.T) {
+	// Every even run exists
+	existing := make(map[string]string)
+	var runIDs []string
+	for i := 0; i < 150; i++ {
+		runID := fmt.Sprintf("run-%d", i)
+		runIDs = append(runIDs, runID)
+		if i%2 == 0 {
+			existing[workflowRunPK(runID)] = runID
+		}
+	}
+
+	var batchSizes []int
+	unprocessedOnce := true
+
+	client := &mockDynamoDBClient{
+		batchGetItemFunc: func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+			keys := params.RequestItems["test-table"].Keys
+			batchSizes = append(batchSizes, len(keys))
+
+			// Leave one key unprocessed on the first call
+			var unprocessed map[string]types.KeysAndAttributes
+			if unprocessedOnce {
+				unprocessedOnce = false
+				unprocessed = map[string]types.KeysAndAttributes{"test-table": {Keys: keys[:1]}}
+				keys = keys[1:]
+			}
+
+			var items []map[string]types.AttributeValue
+			for _, key := range keys {
+				pk := key[AttrPK].(*types.AttributeValueMemberS).Value
+				if runID, ok := existing[pk]; ok {
+					items = append(items, map[string]types.AttributeValue{
+						"run_id": &types.AttributeValueMemberS{Value: runID},
+						"status": &types.AttributeValueMemberS{Value: string(gorkflow.RunStatusCompleted)},
+					})
+				}
+			}
+			return &dynamodb.BatchGetItemOutput{
+				Responses:       map[string][]map[string]types.AttributeValue{"test-table": items},
+				UnprocessedKeys: unprocessed,
+			}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+
+	// Duplicates are fetched once
+	runs, err := store.BatchGetRuns(context.Background(), append(runIDs, "run-0"))
+	if err != nil {
+		t.Fatalf("BatchGetRuns() failed: %v", err)
+	}
+
+	expected := []int{100, 1, 50}
+	if fmt.Sprint(batchSizes) != fmt.Sprint(expected) {
+		t.Errorf("batch sizes = %v, want %v", batchSizes, expected)
+	}
+
+	if len(runs) != len(existing) {
+		t.Errorf("len(runs) = %d, want %d", len(runs), len(existing))
+	}
+	for i, runID := range runIDs {
+		run, ok := runs[runID]
+		if ok != (i%2 == 0) {
+			t.Errorf("run %s present = %v, want %v", runID, ok, i%2 == 0)
+			continue
+		}
+		if ok && run.Status != gorkflow.RunStatusCompleted {
+			t.Errorf("run %s Status = %s, want %s", runID, run.Status, gorkflow.RunStatusCompleted)
+		}
+	}
+}
+
+// withFastUnprocessedBackoff shortens the waits before unprocessed batch items are resubmitted
+func withFastUnprocessedBackoff(t *testing.T) {
+	base, maxDelay := unprocessedBaseDelay, unprocessedMaxDelay
+	unprocessedBaseDelay, unprocessedMaxDelay = time.Millisecond, 4*time.Millisecond
+	t.Cleanup(func() { unprocessedBaseDelay, unprocessedMaxDelay = base, maxDelay })
+}
+
+func TestDynamoDBStore_BatchGetRuns_Unprocessed(t *testing.T) {
+	withFastUnprocessedBackoff(t)
+
+	// Throttled: every key comes back unprocessed
+	calls := 0
+	client := &mockDynamoDBClient{
+		batchGetItemFunc: func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+			calls++
+			return &dynamodb.BatchGetItemOutput{UnprocessedKeys: params.RequestItems}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+
+	if _, err := store.BatchGetRuns(context.Background(), []string{"run-1", "run-2"}); err == nil {
+		t.Fatal("BatchGetRuns() should fail once its attempts run out")
+	}
+	if calls != maxBatchAttempts {
+		t.Errorf("BatchGetItem called %d times, want %d", calls, maxBatchAttempts)
+	}
+
+	// A cancelled context ends the backoff
+	calls = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := store.BatchGetRuns(ctx, []string{"run-1"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("BatchGetRuns() error = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("BatchGetItem called %d times after cancellation, want 1", calls)
+	}
+}
+
+func TestDynamoDBStore_BatchGetRuns_Error(t *testing.T) {
+	client := &mockDynamoDBClient{
+		batchGetItemFunc: func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+			return nil, errors.New("throttled")
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+
+	if _, err := store.BatchGetRuns(context.Background(), []string{"run-1"}); err == nil {
+		t.Error("BatchGetRuns() should have failed")
+	}
+}
+
 func TestDynamoDBStore_UpdateRun(t *testing.T) {
 	var capturedInput *dynamodb.TransactWriteItemsInput
 
@@ -285,294 +438,995 @@ func TestDynamoDBStore_UpdateRun(t *testing.T) {
 	}
 }
 
-func TestDynamoDBStore_UpdateRunStatus(t *testing.T) {
-	now := time.Now()
-	runID := "test-run-1"
-
-	var capturedGetInput *dynamodb.GetItemInput
-	var capturedTransactInput *dynamodb.TransactWriteItemsInput
-
+func TestDynamoDBStore_UpdateRunProgress(t *testing.T) {
+	var updates []*dynamodb.UpdateItemInput
 	client := &mockDynamoDBClient{
-		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
-			capturedGetInput = params
-			return &dynamodb.GetItemOutput{
-				Item: map[string]types.AttributeValue{
-					"RunID":      &types.AttributeValueMemberS{Value: runID},
-					"WorkflowID": &types.AttributeValueMemberS{Value: "test-workflow"},
-					"Status":     &types.AttributeValueMemberS{Value: string(gorkflow.RunStatusRunning)},
-					"CreatedAt":  &types.AttributeValueMemberS{Value: now.Format(time.RFC3339Nano)},
-					"UpdatedAt":  &types.AttributeValueMemberS{Value: now.Format(time.RFC3339Nano)},
-				},
-			}, nil
-		},
-		transactWriteItemsFunc: func(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
-			capturedTransactInput = params
-			return &dynamodb.TransactWriteItemsOutput{}, nil
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			updates = append(updates, params)
+			return &dynamodb.UpdateItemOutput{}, nil
 		},
 	}
-
 	store := NewDynamoDBStore(client, "test-table")
 	ctx := context.Background()
 
-	wfErr := &gorkflow.WorkflowError{
-		Code:    "TEST_ERROR",
-		Message: "test error",
+	run := &gorkflow.WorkflowRun{RunID: "run-1", Status: gorkflow.RunStatusRunning, Progress: 0.25, CurrentStep: "a,b", TotalAttempts: 2, UpdatedAt: time.Now()}
+	if err := store.UpdateRunProgress(ctx, run); err != nil {
+		t.Fatalf("UpdateRunProgress() failed: %v", err)
 	}
-
-	err := store.UpdateRunStatus(ctx, runID, gorkflow.RunStatusFailed, wfErr)
-	if err != nil {
-		t.Fatalf("UpdateRunStatus() failed: %v", err)
+	run.CurrentStep = ""
+	if err := store.UpdateRunProgress(ctx, run); err != nil {
+		t.Fatalf("UpdateRunProgress() failed: %v", err)
 	}
 
-	if capturedGetInput == nil {
-		t.Fatal("GetItem was not called")
+	if len(updates) != 2 {
+		t.Fatalf("UpdateItem called %d times, want 2", len(updates))
 	}
-
-	if capturedTransactInput == nil {
-		t.Fatal("TransactWriteItems was not called")
+	if got, want := *updates[0].UpdateExpression, "SET progress = :progress, total_attempts = :attempts, updated_at = :updated, current_step = :step"; got != want {
+		t.Errorf("UpdateExpression = %q, want %q", got, want)
 	}
-
-	// Verify the correct keys were used for GetItem
-	pk := capturedGetInput.Key[AttrPK].(*types.AttributeValueMemberS).Value
-	expectedPK := workflowRunPK(runID)
-	if pk != expectedPK {
-		t.Errorf("GetItem PK = %s, want %s", pk, expectedPK)
+	if got, want := *updates[1].UpdateExpression, "SET progress = :progress, total_attempts = :attempts, updated_at = :updated REMOVE current_step"; got != want {
+		t.Errorf("UpdateExpression = %q, want %q", got, want)
+	}
+	if got := updates[0].ExpressionAttributeValues[":progress"].(*types.AttributeValueMemberN).Value; got != "0.25" {
+		t.Errorf(":progress = %s, want 0.25", got)
+	}
+	// The status is never written, so a pause recorded meanwhile is kept
+	if _, ok := updates[0].ExpressionAttributeValues[":status"]; ok {
+		t.Error("UpdateRunProgress() wrote the status")
 	}
 }
 
-func TestDynamoDBStore_UpdateRunStatus_TerminalStatus(t *testing.T) {
-	now := time.Now()
-	runID := "test-run-1"
-
+func TestDynamoDBStore_UpdateRunStatus(t *testing.T) {
+	var updates []*dynamodb.UpdateItemInput
 	client := &mockDynamoDBClient{
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			return &dynamodb.PutItemOutput{}, nil
+		},
 		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
-			return &dynamodb.GetItemOutput{
-				Item: map[string]types.AttributeValue{
-					"RunID":      &types.AttributeValueMemberS{Value: runID},
-					"WorkflowID": &types.AttributeValueMemberS{Value: "test-workflow"},
-					"Status":     &types.AttributeValueMemberS{Value: string(gorkflow.RunStatusRunning)},
-					"CreatedAt":  &types.AttributeValueMemberS{Value: now.Format(time.RFC3339Nano)},
-					"UpdatedAt":  &types.AttributeValueMemberS{Value: now.Format(time.RFC3339Nano)},
-				},
-			}, nil
+			t.Error("UpdateRunStatus() read the run")
+			return &dynamodb.GetItemOutput{}, nil
 		},
 		transactWriteItemsFunc: func(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+			t.Error("UpdateRunStatus() rewrote the whole run")
 			return &dynamodb.TransactWriteItemsOutput{}, nil
 		},
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			updates = append(updates, params)
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
 	}
 
 	store := NewDynamoDBStore(client, "test-table")
 	ctx := context.Background()
 
-	// Test with terminal status (Completed)
-	err := store.UpdateRunStatus(ctx, runID, gorkflow.RunStatusCompleted, nil)
-	if err != nil {
-		t.Fatalf("UpdateRunStatus() failed: %v", err)
+	run := &gorkflow.WorkflowRun{RunID: "test-run-1", WorkflowID: "test-workflow", ResourceID: "resource-1", Status: gorkflow.RunStatusRunning, CreatedAt: time.Now()}
+	if err := store.CreateRun(ctx, run); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
 	}
-}
 
-func TestDynamoDBStore_ListRuns(t *testing.T) {
-	client := &mockDynamoDBClient{}
-	store := NewDynamoDBStore(client, "test-table")
-	ctx := context.Background()
+	wfErr := &gorkflow.WorkflowError{Code: "TEST_ERROR", Message: "test error"}
+	if err := store.UpdateRunStatus(ctx, run.RunID, gorkflow.RunStatusFailed, wfErr); err != nil {
+		t.Fatalf("UpdateRunStatus() failed: %v", err)
+	}
 
-	// ListRuns is not fully implemented (returns empty list)
-	runs, err := store.ListRuns(ctx, gorkflow.RunFilter{})
-	if err != nil {
-		t.Fatalf("ListRuns() failed: %v", err)
+	if len(updates) != 1 {
+		t.Fatalf("UpdateItem called %d times, want 1", len(updates))
+	}
+	update := updates[0]
+	if pk := update.Key[AttrPK].(*types.AttributeValueMemberS).Value; pk != workflowRunPK(run.RunID) {
+		t.Errorf("UpdateItem PK = %s, want %s", pk, workflowRunPK(run.RunID))
 	}
 
-	if len(runs) != 0 {
-		t.Errorf("ListRuns() returned %d runs, want 0 (not fully implemented)", len(runs))
+	expression := *update.UpdateExpression
+	for _, part := range []string{"#status = :status", "#error = :error", "completed_at = :now", "list_append(", "GSI1PK = :gsi1pk", "GSI2PK = :gsi2pk", "REMOVE GSI3PK, GSI3SK"} {
+		if !strings.Contains(expression, part) {
+			t.Errorf("UpdateExpression %q is missing %q", expression, part)
+		}
+	}
+	if got := update.ExpressionAttributeValues[":gsi1pk"].(*types.AttributeValueMemberS).Value; got != workflowRunGSI1PK("test-workflow", string(gorkflow.RunStatusFailed)) {
+		t.Errorf(":gsi1pk = %s", got)
+	}
+	if got := update.ExpressionAttributeValues[":gsi2pk"].(*types.AttributeValueMemberS).Value; got != workflowRunGSI2PK("resource-1", string(gorkflow.RunStatusFailed)) {
+		t.Errorf(":gsi2pk = %s", got)
 	}
 }
 
-func TestDynamoDBStore_CreateStepExecution(t *testing.T) {
-	var capturedInput *dynamodb.PutItemInput
+func TestDynamoDBStore_WithClock(t *testing.T) {
+	fixed := time.Date(2030, 6, 1, 8, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	engineTime := time.Date(2030, 6, 1, 5, 30, 0, 0, time.UTC)
 
+	var puts []*dynamodb.PutItemInput
+	var update *dynamodb.UpdateItemInput
 	client := &mockDynamoDBClient{
 		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
-			capturedInput = params
+			puts = append(puts, params)
 			return &dynamodb.PutItemOutput{}, nil
 		},
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			update = params
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
 	}
 
-	store := NewDynamoDBStore(client, "test-table")
+	store := NewDynamoDBStore(client, "test-table", WithClock(func() time.Time { return fixed }))
 	ctx := context.Background()
 
-	exec := &gorkflow.StepExecution{
-		RunID:     "test-run-1",
-		StepID:    "step-1",
-		Status:    gorkflow.StepStatusPending,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	// The run's UpdatedAt is the engine's, not overwritten by the store
+	run := &gorkflow.WorkflowRun{RunID: "run-1", WorkflowID: "wf", Status: gorkflow.RunStatusRunning, CreatedAt: engineTime, UpdatedAt: engineTime}
+	if err := store.CreateRun(ctx, run); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
 	}
-
-	err := store.CreateStepExecution(ctx, exec)
-	if err != nil {
-		t.Fatalf("CreateStepExecution() failed: %v", err)
+	if err := store.UpdateRun(ctx, run); err != nil {
+		t.Fatalf("UpdateRun() failed: %v", err)
 	}
-
-	if capturedInput == nil {
-		t.Fatal("PutItem was not called")
+	if got := puts[len(puts)-1].Item["updated_at"].(*types.AttributeValueMemberS).Value; got != engineTime.Format(time.RFC3339Nano) {
+		t.Errorf("UpdateRun updated_at = %s, want %s", got, engineTime.Format(time.RFC3339Nano))
 	}
 
-	// Verify keys
-	pk := capturedInput.Item[AttrPK].(*types.AttributeValueMemberS).Value
-	expectedPK := stepExecutionPK(exec.RunID)
-	if pk != expectedPK {
-		t.Errorf("PK = %s, want %s", pk, expectedPK)
+	// Timestamps the store writes itself come from its clock, in UTC
+	if err := store.UpdateRunStatus(ctx, run.RunID, gorkflow.RunStatusCompleted, nil); err != nil {
+		t.Fatalf("UpdateRunStatus() failed: %v", err)
 	}
-
-	sk := capturedInput.Item[AttrSK].(*types.AttributeValueMemberS).Value
-	expectedSK := stepExecutionSK(exec.StepID)
-	if sk != expectedSK {
-		t.Errorf("SK = %s, want %s", sk, expectedSK)
+	if got := update.ExpressionAttributeValues[":now"].(*types.AttributeValueMemberS).Value; got != "2030-06-01T06:00:00Z" {
+		t.Errorf("UpdateRunStatus :now = %s, want 2030-06-01T06:00:00Z", got)
 	}
 
-	entityType := capturedInput.Item[AttrEntityType].(*types.AttributeValueMemberS).Value
-	if entityType != EntityTypeStepExecution {
-		t.Errorf("EntityType = %s, want %s", entityType, EntityTypeStepExecution)
+	if err := store.SaveState(ctx, run.RunID, "key", []byte(`1`)); err != nil {
+		t.Fatalf("SaveState() failed: %v", err)
+	}
+	if got := puts[len(puts)-1].Item["updated_at"].(*types.AttributeValueMemberS).Value; got != "2030-06-01T06:00:00Z" {
+		t.Errorf("SaveState updated_at = %s, want 2030-06-01T06:00:00Z", got)
 	}
 }
 
-func TestDynamoDBStore_GetStepExecution(t *testing.T) {
-	runID := "test-run-1"
-	stepID := "step-1"
-
+func TestDynamoDBStore_UpdateRunStatus_UncachedRun(t *testing.T) {
+	var get *dynamodb.GetItemInput
+	var update *dynamodb.UpdateItemInput
 	client := &mockDynamoDBClient{
 		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
-			now := time.Now()
+			get = params
 			return &dynamodb.GetItemOutput{
 				Item: map[string]types.AttributeValue{
-					"run_id":          &types.AttributeValueMemberS{Value: runID},
-					"step_id":         &types.AttributeValueMemberS{Value: stepID},
-					"execution_index": &types.AttributeValueMemberN{Value: "0"},
-					"status":          &types.AttributeValueMemberS{Value: string(gorkflow.StepStatusPending)},
-					"duration_ms":     &types.AttributeValueMemberN{Value: "0"},
-					"created_at":      &types.AttributeValueMemberS{Value: now.Format(time.RFC3339Nano)},
-					"updated_at":      &types.AttributeValueMemberS{Value: now.Format(time.RFC3339Nano)},
+					"workflow_id": &types.AttributeValueMemberS{Value: "test-workflow"},
 				},
 			}, nil
 		},
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			update = params
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
 	}
 
 	store := NewDynamoDBStore(client, "test-table")
-	ctx := context.Background()
 
-	exec, err := store.GetStepExecution(ctx, runID, stepID)
-	if err != nil {
-		t.Fatalf("GetStepExecution() failed: %v", err)
+	// Without a cached run only its index fields are read
+	if err := store.UpdateRunStatus(context.Background(), "test-run-1", gorkflow.RunStatusCompleted, nil); err != nil {
+		t.Fatalf("UpdateRunStatus() failed: %v", err)
 	}
-
-	if exec.StepID != stepID {
-		t.Errorf("StepID = %s, want %s", exec.StepID, stepID)
+	if get == nil || aws.ToString(get.ProjectionExpression) != "workflow_id, resource_id" {
+		t.Fatalf("GetItem = %+v, want a read of the index fields", get)
+	}
+	if update == nil {
+		t.Fatal("UpdateItem was not called")
+	}
+	if got := update.ExpressionAttributeValues[":gsi1pk"].(*types.AttributeValueMemberS).Value; got != workflowRunGSI1PK("test-workflow", string(gorkflow.RunStatusCompleted)) {
+		t.Errorf(":gsi1pk = %s", got)
+	}
+	if _, ok := update.ExpressionAttributeValues[":gsi2pk"]; ok {
+		t.Error("runs without a resource ID should not get GSI2 keys")
+	}
+	if !strings.Contains(*update.UpdateExpression, "REMOVE #error") {
+		t.Errorf("UpdateExpression %q should clear the error", *update.UpdateExpression)
 	}
 }
 
-func TestDynamoDBStore_GetStepExecution_NotFound(t *testing.T) {
+func TestDynamoDBStore_UpdateRunStatus_NotFound(t *testing.T) {
 	client := &mockDynamoDBClient{
 		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
-			return &dynamodb.GetItemOutput{Item: nil}, nil
+			return &dynamodb.GetItemOutput{}, nil
 		},
 	}
 
 	store := NewDynamoDBStore(client, "test-table")
-	ctx := context.Background()
-
-	_, err := store.GetStepExecution(ctx, "non-existent-run", "non-existent-step")
-	if err == nil {
-		t.Error("GetStepExecution() should have failed for non-existent execution")
+	if err := store.UpdateRunStatus(context.Background(), "missing", gorkflow.RunStatusCompleted, nil); err == nil {
+		t.Error("UpdateRunStatus() should have failed for a missing run")
 	}
 }
 
-func TestDynamoDBStore_UpdateStepExecution(t *testing.T) {
-	var capturedInput *dynamodb.PutItemInput
-
+func TestDynamoDBStore_UpdateRunStatus_Finished(t *testing.T) {
+	var update *dynamodb.UpdateItemInput
 	client := &mockDynamoDBClient{
-		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
-			capturedInput = params
-			return &dynamodb.PutItemOutput{}, nil
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					"workflow_id": &types.AttributeValueMemberS{Value: "test-workflow"},
+				},
+			}, nil
+		},
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			update = params
+			return nil, &types.ConditionalCheckFailedException{
+				Item: map[string]types.AttributeValue{
+					"status": &types.AttributeValueMemberS{Value: string(gorkflow.RunStatusCompleted)},
+				},
+			}
 		},
 	}
 
 	store := NewDynamoDBStore(client, "test-table")
-	ctx := context.Background()
-
-	now := time.Now()
-	exec := &gorkflow.StepExecution{
-		RunID:     "test-run-1",
-		StepID:    "step-1",
-		Status:    gorkflow.StepStatusCompleted,
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
 
-	err := store.UpdateStepExecution(ctx, exec)
-	if err != nil {
-		t.Fatalf("UpdateStepExecution() failed: %v", err)
+	// A run completed since it was read is not reopened
+	err := store.UpdateRunStatus(context.Background(), "test-run-1", gorkflow.RunStatusPaused, nil)
+	if !errors.Is(err, gorkflow.ErrRunFinished) {
+		t.Fatalf("UpdateRunStatus() error = %v, want ErrRunFinished", err)
 	}
-
-	if capturedInput == nil {
-		t.Fatal("PutItem was not called")
+	if !strings.Contains(aws.ToString(update.ConditionExpression), "NOT #status IN (:completed, :failed, :cancelled)") {
+		t.Errorf("ConditionExpression = %q, want a check that the run has not finished", aws.ToString(update.ConditionExpression))
 	}
-
-	// Verify UpdatedAt was updated
-	if exec.UpdatedAt.Before(now) {
-		t.Error("UpdatedAt should have been updated")
+	if update.ReturnValuesOnConditionCheckFailure != types.ReturnValuesOnConditionCheckFailureAllOld {
+		t.Error("UpdateRunStatus() should ask for the old item to tell a finished run from a missing one")
 	}
 }
 
-func TestDynamoDBStore_ListStepExecutions(t *testing.T) {
-	runID := "test-run-1"
-
+func TestDynamoDBStore_RunCacheSize(t *testing.T) {
+	var reads []string
 	client := &mockDynamoDBClient{
-		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
-			return &dynamodb.QueryOutput{
-				Items: []map[string]types.AttributeValue{
-					{
-						"RunID":     &types.AttributeValueMemberS{Value: runID},
-						"StepID":    &types.AttributeValueMemberS{Value: "step-1"},
-						"Status":    &types.AttributeValueMemberS{Value: string(gorkflow.StepStatusPending)},
-						"CreatedAt": &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339Nano)},
-						"UpdatedAt": &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339Nano)},
-					},
-					{
-						"RunID":     &types.AttributeValueMemberS{Value: runID},
-						"StepID":    &types.AttributeValueMemberS{Value: "step-2"},
-						"Status":    &types.AttributeValueMemberS{Value: string(gorkflow.StepStatusCompleted)},
-						"CreatedAt": &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339Nano)},
-						"UpdatedAt": &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339Nano)},
-					},
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			reads = append(reads, params.Key[AttrPK].(*types.AttributeValueMemberS).Value)
+			return &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					"workflow_id": &types.AttributeValueMemberS{Value: "test-workflow"},
 				},
 			}, nil
 		},
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
 	}
 
-	store := NewDynamoDBStore(client, "test-table")
+	store := NewDynamoDBStore(client, "test-table", WithRunCacheSize(2)).(*DynamoDBStore)
 	ctx := context.Background()
 
-	executions, err := store.ListStepExecutions(ctx, runID)
-	if err != nil {
-		t.Fatalf("ListStepExecutions() failed: %v", err)
+	for i := range 5 {
+		run := &gorkflow.WorkflowRun{RunID: fmt.Sprintf("run-%d", i), WorkflowID: "test-workflow", Status: gorkflow.RunStatusRunning, CreatedAt: time.Now()}
+		if err := store.CreateRun(ctx, run); err != nil {
+			t.Fatalf("CreateRun() failed: %v", err)
+		}
 	}
-
-	if len(executions) != 2 {
-		t.Errorf("ListStepExecutions() returned %d executions, want 2", len(executions))
+	if got := store.runKeys.len(); got != 2 {
+		t.Errorf("runKeys holds %d runs, want 2", got)
+	}
+	if got := store.runTTLs.len(); got != 2 {
+		t.Errorf("runTTLs holds %d runs, want 2", got)
 	}
-}
 
-func TestDynamoDBStore_ListStepExecutions_Error(t *testing.T) {
-	client := &mockDynamoDBClient{
-		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
-			return nil, errors.New("dynamodb error")
-		},
+	// The most recent runs are still cached, evicted ones cost one projected read
+	for _, runID := range []string{"run-4", "run-0"} {
+		if err := store.UpdateRunStatus(ctx, runID, gorkflow.RunStatusPaused, nil); err != nil {
+			t.Fatalf("UpdateRunStatus(%s) failed: %v", runID, err)
+		}
+	}
+	if len(reads) != 1 || reads[0] != workflowRunPK("run-0") {
+		t.Errorf("GetItem reads = %v, want one read of run-0", reads)
 	}
+}
 
+func TestDynamoDBStore_ListRuns(t *testing.T) {
+	client := &mockDynamoDBClient{}
 	store := NewDynamoDBStore(client, "test-table")
 	ctx := context.Background()
 
-	_, err := store.ListStepExecutions(ctx, "test-run")
+	// Without a workflow or resource ID no index applies
+	runs, err := store.ListRuns(ctx, gorkflow.RunFilter{})
+	if err != nil {
+		t.Fatalf("ListRuns() failed: %v", err)
+	}
+
+	if len(runs) != 0 {
+		t.Errorf("ListRuns() returned %d runs, want 0", len(runs))
+	}
+}
+
+func TestDynamoDBStore_ListRuns_CreatedWindow(t *testing.T) {
+	base := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	offsets := []time.Duration{-48 * time.Hour, -time.Hour, 0, time.Hour, 48 * time.Hour}
+
+	// Index items as they would be written by CreateRun
+	var indexed []map[string]types.AttributeValue
+	for i, offset := range offsets {
+		createdAt := base.Add(offset).Format(time.RFC3339)
+		indexed = append(indexed, map[string]types.AttributeValue{
+			AttrGSI1PK:    &types.AttributeValueMemberS{Value: workflowRunGSI1PK("workflow-1", string(gorkflow.RunStatusCompleted))},
+			AttrGSI1SK:    &types.AttributeValueMemberS{Value: workflowRunGSI1SK(createdAt)},
+			"run_id":      &types.AttributeValueMemberS{Value: fmt.Sprintf("run-%d", i)},
+			"workflow_id": &types.AttributeValueMemberS{Value: "workflow-1"},
+			"status":      &types.AttributeValueMemberS{Value: string(gorkflow.RunStatusCompleted)},
+		})
+	}
+
+	var queries []*dynamodb.QueryInput
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			queries = append(queries, params)
+
+			// Evaluate the partition key and sort-key range the way DynamoDB would
+			value := func(name string) string {
+				if v, ok := params.ExpressionAttributeValues[name].(*types.AttributeValueMemberS); ok {
+					return v.Value
+				}
+				return ""
+			}
+			pk, after, before := value(":pk"), value(":after"), value(":before")
+
+			var items []map[string]types.AttributeValue
+			for _, item := range indexed {
+				sk := item[AttrGSI1SK].(*types.AttributeValueMemberS).Value
+				if item[AttrGSI1PK].(*types.AttributeValueMemberS).Value != pk {
+					continue
+				}
+				if (after != "" && sk < after) || (before != "" && sk > before) {
+					continue
+				}
+				items = append(items, item)
+			}
+			return &dynamodb.QueryOutput{Items: items}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	after := base.Add(-time.Hour)
+	before := base.Add(time.Hour)
+	status := gorkflow.RunStatusCompleted
+
+	runs, err := store.ListRuns(ctx, gorkflow.RunFilter{
+		WorkflowID:    "workflow-1",
+		Status:        &status,
+		CreatedAfter:  &after,
+		CreatedBefore: &before,
+	})
+	if err != nil {
+		t.Fatalf("ListRuns() failed: %v", err)
+	}
+
+	if len(queries) != 1 {
+		t.Fatalf("ListRuns() issued %d queries, want 1", len(queries))
+	}
+	if got := *queries[0].IndexName; got != IndexStatusIndex {
+		t.Errorf("IndexName = %q, want %q", got, IndexStatusIndex)
+	}
+	if got, want := *queries[0].KeyConditionExpression, "GSI1PK = :pk AND GSI1SK BETWEEN :after AND :before"; got != want {
+		t.Errorf("KeyConditionExpression = %q, want %q", got, want)
+	}
+
+	if len(runs) != 3 {
+		t.Fatalf("ListRuns() returned %d runs, want 3", len(runs))
+	}
+	for i, run := range runs {
+		if want := fmt.Sprintf("run-%d", i+1); run.RunID != want {
+			t.Errorf("runs[%d].RunID = %q, want %q", i, run.RunID, want)
+		}
+	}
+
+	// Open-ended window without a status queries every status partition
+	queries = nil
+	runs, err = store.ListRuns(ctx, gorkflow.RunFilter{
+		WorkflowID:   "workflow-1",
+		CreatedAfter: &after,
+	})
+	if err != nil {
+		t.Fatalf("ListRuns() failed: %v", err)
+	}
+	if len(queries) != len(runStatuses) {
+		t.Errorf("ListRuns() issued %d queries, want %d", len(queries), len(runStatuses))
+	}
+	if got, want := *queries[0].KeyConditionExpression, "GSI1PK = :pk AND GSI1SK >= :after"; got != want {
+		t.Errorf("KeyConditionExpression = %q, want %q", got, want)
+	}
+	if len(runs) != 4 {
+		t.Errorf("ListRuns() returned %d runs, want 4", len(runs))
+	}
+
+	// Bounds in another time zone select the same window as their UTC equivalents
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	zonedAfter, zonedBefore := after.In(zone), before.In(zone)
+	runs, err = store.ListRuns(ctx, gorkflow.RunFilter{
+		WorkflowID:    "workflow-1",
+		Status:        &status,
+		CreatedAfter:  &zonedAfter,
+		CreatedBefore: &zonedBefore,
+	})
+	if err != nil {
+		t.Fatalf("ListRuns() failed: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Errorf("ListRuns() with zoned bounds returned %d runs, want 3", len(runs))
+	}
+}
+
+func TestDynamoDBStore_ListRuns_Tags(t *testing.T) {
+	var queries []*dynamodb.QueryInput
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			queries = append(queries, params)
+			return &dynamodb.QueryOutput{}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+	status := gorkflow.RunStatusCompleted
+
+	tests := []struct {
+		name       string
+		filter     gorkflow.RunFilter
+		expression string
+		tags       map[string]string
+	}{
+		{
+			name:       "single tag",
+			filter:     gorkflow.RunFilter{WorkflowID: "workflow-1", Status: &status, Tags: map[string]string{"env": "prod"}},
+			expression: "#tags.#tag0 = :tag0",
+			tags:       map[string]string{"#tag0": "env"},
+		},
+		{
+			name: "multiple tags with resource",
+			filter: gorkflow.RunFilter{
+				WorkflowID: "workflow-1",
+				ResourceID: "resource-1",
+				Status:     &status,
+				Tags:       map[string]string{"team": "growth", "env": "prod"},
+			},
+			expression: "resource_id = :rid AND #tags.#tag0 = :tag0 AND #tags.#tag1 = :tag1",
+			tags:       map[string]string{"#tag0": "env", "#tag1": "team"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries = nil
+			if _, err := store.ListRuns(ctx, tt.filter); err != nil {
+				t.Fatalf("ListRuns() failed: %v", err)
+			}
+			if len(queries) != 1 {
+				t.Fatalf("ListRuns() issued %d queries, want 1", len(queries))
+			}
+
+			query := queries[0]
+			if query.FilterExpression == nil || *query.FilterExpression != tt.expression {
+				t.Fatalf("FilterExpression = %v, want %q", query.FilterExpression, tt.expression)
+			}
+			if query.ExpressionAttributeNames["#tags"] != "tags" {
+				t.Errorf("#tags = %q, want %q", query.ExpressionAttributeNames["#tags"], "tags")
+			}
+			for name, key := range tt.tags {
+				if query.ExpressionAttributeNames[name] != key {
+					t.Errorf("%s = %q, want %q", name, query.ExpressionAttributeNames[name], key)
+				}
+				value := query.ExpressionAttributeValues[":"+name[1:]].(*types.AttributeValueMemberS).Value
+				if value != tt.filter.Tags[key] {
+					t.Errorf("value of tag %s = %q, want %q", key, value, tt.filter.Tags[key])
+				}
+			}
+		})
+	}
+
+	// Without tags no attribute names are sent
+	queries = nil
+	if _, err := store.ListRuns(ctx, gorkflow.RunFilter{WorkflowID: "workflow-1", Status: &status}); err != nil {
+		t.Fatalf("ListRuns() failed: %v", err)
+	}
+	if queries[0].FilterExpression != nil || queries[0].ExpressionAttributeNames != nil {
+		t.Errorf("unexpected filter %v with names %v", queries[0].FilterExpression, queries[0].ExpressionAttributeNames)
+	}
+}
+
+func TestDynamoDBStore_CountRuns(t *testing.T) {
+	var queries []*dynamodb.QueryInput
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			queries = append(queries, params)
+
+			// Two pages of completed runs, one page for every other status
+			pk := params.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value
+			if pk == workflowRunGSI1PK("workflow-1", string(gorkflow.RunStatusCompleted)) && params.ExclusiveStartKey == nil {
+				return &dynamodb.QueryOutput{
+					Count:            3,
+					LastEvaluatedKey: map[string]types.AttributeValue{AttrPK: &types.AttributeValueMemberS{Value: "page"}},
+				}, nil
+			}
+			return &dynamodb.QueryOutput{Count: 1}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	// By workflow ID across every status
+	count, err := store.CountRuns(ctx, gorkflow.RunFilter{WorkflowID: "workflow-1"})
+	if err != nil {
+		t.Fatalf("CountRuns() failed: %v", err)
+	}
+	if want := 3 + len(runStatuses); count != want {
+		t.Errorf("CountRuns() = %d, want %d", count, want)
+	}
+	if len(queries) != len(runStatuses)+1 {
+		t.Errorf("CountRuns() issued %d queries, want %d", len(queries), len(runStatuses)+1)
+	}
+	for _, query := range queries {
+		if query.Select != types.SelectCount {
+			t.Errorf("Select = %q, want %q", query.Select, types.SelectCount)
+		}
+		if got := *query.IndexName; got != IndexStatusIndex {
+			t.Errorf("IndexName = %q, want %q", got, IndexStatusIndex)
+		}
+	}
+
+	// By resource ID, status and time range
+	queries = nil
+	after := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	before := after.Add(24 * time.Hour)
+	status := gorkflow.RunStatusFailed
+
+	count, err = store.CountRuns(ctx, gorkflow.RunFilter{
+		ResourceID:    "resource-1",
+		Status:        &status,
+		CreatedAfter:  &after,
+		CreatedBefore: &before,
+	})
+	if err != nil {
+		t.Fatalf("CountRuns() failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("CountRuns() = %d, want 1", count)
+	}
+	if len(queries) != 1 {
+		t.Fatalf("CountRuns() issued %d queries, want 1", len(queries))
+	}
+	if got := *queries[0].IndexName; got != IndexResourceIndex {
+		t.Errorf("IndexName = %q, want %q", got, IndexResourceIndex)
+	}
+	if got, want := *queries[0].KeyConditionExpression, "GSI2PK = :pk AND GSI2SK BETWEEN :after AND :before"; got != want {
+		t.Errorf("KeyConditionExpression = %q, want %q", got, want)
+	}
+
+	// Filters without an index count nothing, like ListRuns
+	count, err = store.CountRuns(ctx, gorkflow.RunFilter{})
+	if err != nil || count != 0 {
+		t.Errorf("CountRuns() = %d, %v, want 0, nil", count, err)
+	}
+}
+
+func TestDynamoDBStore_ListRuns_SortDescending(t *testing.T) {
+	base := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	// One run per status partition, with distinct creation times
+	createdAt := map[gorkflow.RunStatus]time.Time{
+		gorkflow.RunStatusPending:   base.Add(time.Hour),
+		gorkflow.RunStatusRunning:   base.Add(-time.Hour),
+		gorkflow.RunStatusCompleted: base.Add(3 * time.Hour),
+		gorkflow.RunStatusFailed:    base,
+		gorkflow.RunStatusCancelled: base.Add(-2 * time.Hour),
+	}
+
+	var queries []*dynamodb.QueryInput
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			queries = append(queries, params)
+			pk := params.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value
+			for status, created := range createdAt {
+				if pk != workflowRunGSI1PK("workflow-1", string(status)) {
+					continue
+				}
+				return &dynamodb.QueryOutput{
+					Items: []map[string]types.AttributeValue{{
+						"run_id":     &types.AttributeValueMemberS{Value: "run-" + string(status)},
+						"status":     &types.AttributeValueMemberS{Value: string(status)},
+						"created_at": &types.AttributeValueMemberS{Value: created.Format(time.RFC3339Nano)},
+					}},
+				}, nil
+			}
+			return &dynamodb.QueryOutput{}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+
+	runs, err := store.ListRuns(context.Background(), gorkflow.RunFilter{
+		WorkflowID:     "workflow-1",
+		SortDescending: true,
+	})
+	if err != nil {
+		t.Fatalf("ListRuns() failed: %v", err)
+	}
+
+	for _, q := range queries {
+		if q.ScanIndexForward == nil || *q.ScanIndexForward {
+			t.Errorf("ScanIndexForward = %v, want false", q.ScanIndexForward)
+		}
+	}
+
+	if len(runs) != len(createdAt) {
+		t.Fatalf("ListRuns() returned %d runs, want %d", len(runs), len(createdAt))
+	}
+	if want := "run-" + string(gorkflow.RunStatusCompleted); runs[0].RunID != want {
+		t.Errorf("runs[0].RunID = %q, want most recent %q", runs[0].RunID, want)
+	}
+	for i := 1; i < len(runs); i++ {
+		if runs[i].CreatedAt.After(runs[i-1].CreatedAt) {
+			t.Errorf("runs not in descending order at index %d", i)
+		}
+	}
+}
+
+func TestDynamoDBStore_DeleteRun(t *testing.T) {
+	var queryInput *dynamodb.QueryInput
+	var deletedKeys []string
+
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			queryInput = params
+			pk := &types.AttributeValueMemberS{Value: workflowRunPK("run-1")}
+			return &dynamodb.QueryOutput{
+				Items: []map[string]types.AttributeValue{
+					{AttrPK: pk, AttrSK: &types.AttributeValueMemberS{Value: workflowRunSK()}},
+					{AttrPK: pk, AttrSK: &types.AttributeValueMemberS{Value: stepExecutionSK("step-1")}},
+					{AttrPK: pk, AttrSK: &types.AttributeValueMemberS{Value: stepOutputSK("step-1")}},
+					{AttrPK: pk, AttrSK: &types.AttributeValueMemberS{Value: stateSK("key")}},
+				},
+			}, nil
+		},
+		batchWriteItemFunc: func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			for _, req := range params.RequestItems["test-table"] {
+				if req.DeleteRequest == nil {
+					t.Fatal("expected only delete requests")
+				}
+				pk := req.DeleteRequest.Key[AttrPK].(*types.AttributeValueMemberS).Value
+				sk := req.DeleteRequest.Key[AttrSK].(*types.AttributeValueMemberS).Value
+				deletedKeys = append(deletedKeys, pk+"|"+sk)
+			}
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+
+	if err := store.DeleteRun(context.Background(), "run-1"); err != nil {
+		t.Fatalf("DeleteRun() failed: %v", err)
+	}
+
+	pk := queryInput.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value
+	if pk != workflowRunPK("run-1") {
+		t.Errorf("query PK = %s, want %s", pk, workflowRunPK("run-1"))
+	}
+
+	expected := []string{
+		workflowRunPK("run-1") + "|" + workflowRunSK(),
+		workflowRunPK("run-1") + "|" + stepExecutionSK("step-1"),
+		workflowRunPK("run-1") + "|" + stepOutputSK("step-1"),
+		workflowRunPK("run-1") + "|" + stateSK("key"),
+	}
+	if len(deletedKeys) != len(expected) {
+		t.Fatalf("deleted %d items, want %d", len(deletedKeys), len(expected))
+	}
+	for i, key := range expected {
+		if deletedKeys[i] != key {
+			t.Errorf("deleted[%d] = %s, want %s", i, deletedKeys[i], key)
+		}
+	}
+}
+
+func TestDynamoDBStore_DeleteRun_Batches(t *testing.T) {
+	var batchSizes []int
+	unprocessedOnce := true
+
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			pk := &types.AttributeValueMemberS{Value: workflowRunPK("run-1")}
+			items := []map[string]types.AttributeValue{}
+			for i := 0; i < 30; i++ {
+				items = append(items, map[string]types.AttributeValue{
+					AttrPK: pk,
+					AttrSK: &types.AttributeValueMemberS{Value: stateSK(fmt.Sprintf("key-%d", i))},
+				})
+			}
+			return &dynamodb.QueryOutput{Items: items}, nil
+		},
+		batchWriteItemFunc: func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			reqs := params.RequestItems["test-table"]
+			batchSizes = append(batchSizes, len(reqs))
+
+			// Leave one item unprocessed on the first call
+			if unprocessedOnce {
+				unprocessedOnce = false
+				return &dynamodb.BatchWriteItemOutput{
+					UnprocessedItems: map[string][]types.WriteRequest{"test-table": reqs[:1]},
+				}, nil
+			}
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+
+	if err := store.DeleteRun(context.Background(), "run-1"); err != nil {
+		t.Fatalf("DeleteRun() failed: %v", err)
+	}
+
+	expected := []int{25, 1, 5}
+	if len(batchSizes) != len(expected) {
+		t.Fatalf("batch sizes = %v, want %v", batchSizes, expected)
+	}
+	for i, size := range expected {
+		if batchSizes[i] != size {
+			t.Errorf("batch sizes = %v, want %v", batchSizes, expected)
+			break
+		}
+	}
+}
+
+func TestDynamoDBStore_SaveStepOutputs_Unprocessed(t *testing.T) {
+	withFastUnprocessedBackoff(t)
+
+	// Throttled: every item comes back unprocessed
+	calls := 0
+	client := &mockDynamoDBClient{
+		batchWriteItemFunc: func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			calls++
+			return &dynamodb.BatchWriteItemOutput{UnprocessedItems: params.RequestItems}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+	outputs := map[string][]byte{"a": []byte(`{}`), "b": []byte(`{}`)}
+
+	if err := store.SaveStepOutputs(context.Background(), "run-1", outputs); err == nil {
+		t.Fatal("SaveStepOutputs() should fail once its attempts run out")
+	}
+	if calls != maxBatchAttempts {
+		t.Errorf("BatchWriteItem called %d times, want %d", calls, maxBatchAttempts)
+	}
+
+	// A cancelled context ends the backoff
+	calls = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := store.SaveStepOutputs(ctx, "run-1", outputs); !errors.Is(err, context.Canceled) {
+		t.Errorf("SaveStepOutputs() error = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("BatchWriteItem called %d times after cancellation, want 1", calls)
+	}
+}
+
+func TestDynamoDBStore_DeleteRun_NotFound(t *testing.T) {
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+
+	if err := store.DeleteRun(context.Background(), "non-existent"); err == nil {
+		t.Error("DeleteRun() should have failed for non-existent run")
+	}
+}
+
+func TestDynamoDBStore_DeleteRun_RemovesOffloadedObjects(t *testing.T) {
+	s3Client := newMockS3Client()
+	s3Client.objects["bucket/"+stepOutputObjectKey("run-1", "big")] = []byte("payload")
+
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{
+				Items: []map[string]types.AttributeValue{
+					{
+						AttrPK:        &types.AttributeValueMemberS{Value: workflowRunPK("run-1")},
+						AttrSK:        &types.AttributeValueMemberS{Value: stepOutputSK("big")},
+						AttrObjectRef: &types.AttributeValueMemberS{Value: "s3://bucket/" + stepOutputObjectKey("run-1", "big")},
+					},
+				},
+			}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table", WithLargeObjectStore(s3Client, "bucket", 1024))
+
+	if err := store.DeleteRun(context.Background(), "run-1"); err != nil {
+		t.Fatalf("DeleteRun() failed: %v", err)
+	}
+
+	if len(s3Client.objects) != 0 {
+		t.Errorf("S3 objects = %d, want 0", len(s3Client.objects))
+	}
+}
+
+func TestDynamoDBStore_CreateStepExecution(t *testing.T) {
+	var capturedInput *dynamodb.PutItemInput
+
+	client := &mockDynamoDBClient{
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			capturedInput = params
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	exec := &gorkflow.StepExecution{
+		RunID:     "test-run-1",
+		StepID:    "step-1",
+		Status:    gorkflow.StepStatusPending,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err := store.CreateStepExecution(ctx, exec)
+	if err != nil {
+		t.Fatalf("CreateStepExecution() failed: %v", err)
+	}
+
+	if capturedInput == nil {
+		t.Fatal("PutItem was not called")
+	}
+
+	// Verify keys
+	pk := capturedInput.Item[AttrPK].(*types.AttributeValueMemberS).Value
+	expectedPK := stepExecutionPK(exec.RunID)
+	if pk != expectedPK {
+		t.Errorf("PK = %s, want %s", pk, expectedPK)
+	}
+
+	sk := capturedInput.Item[AttrSK].(*types.AttributeValueMemberS).Value
+	expectedSK := stepExecutionSK(exec.StepID)
+	if sk != expectedSK {
+		t.Errorf("SK = %s, want %s", sk, expectedSK)
+	}
+
+	entityType := capturedInput.Item[AttrEntityType].(*types.AttributeValueMemberS).Value
+	if entityType != EntityTypeStepExecution {
+		t.Errorf("EntityType = %s, want %s", entityType, EntityTypeStepExecution)
+	}
+}
+
+func TestDynamoDBStore_GetStepExecution(t *testing.T) {
+	runID := "test-run-1"
+	stepID := "step-1"
+
+	client := &mockDynamoDBClient{
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			now := time.Now()
+			return &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					"run_id":          &types.AttributeValueMemberS{Value: runID},
+					"step_id":         &types.AttributeValueMemberS{Value: stepID},
+					"execution_index": &types.AttributeValueMemberN{Value: "0"},
+					"status":          &types.AttributeValueMemberS{Value: string(gorkflow.StepStatusPending)},
+					"duration_ms":     &types.AttributeValueMemberN{Value: "0"},
+					"created_at":      &types.AttributeValueMemberS{Value: now.Format(time.RFC3339Nano)},
+					"updated_at":      &types.AttributeValueMemberS{Value: now.Format(time.RFC3339Nano)},
+				},
+			}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	exec, err := store.GetStepExecution(ctx, runID, stepID)
+	if err != nil {
+		t.Fatalf("GetStepExecution() failed: %v", err)
+	}
+
+	if exec.StepID != stepID {
+		t.Errorf("StepID = %s, want %s", exec.StepID, stepID)
+	}
+}
+
+func TestDynamoDBStore_GetStepExecution_NotFound(t *testing.T) {
+	client := &mockDynamoDBClient{
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: nil}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	_, err := store.GetStepExecution(ctx, "non-existent-run", "non-existent-step")
+	if err == nil {
+		t.Error("GetStepExecution() should have failed for non-existent execution")
+	}
+}
+
+func TestDynamoDBStore_UpdateStepExecution(t *testing.T) {
+	var capturedInput *dynamodb.PutItemInput
+
+	client := &mockDynamoDBClient{
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			capturedInput = params
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	now := time.Now()
+	exec := &gorkflow.StepExecution{
+		RunID:     "test-run-1",
+		StepID:    "step-1",
+		Status:    gorkflow.StepStatusCompleted,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := store.UpdateStepExecution(ctx, exec)
+	if err != nil {
+		t.Fatalf("UpdateStepExecution() failed: %v", err)
+	}
+
+	if capturedInput == nil {
+		t.Fatal("PutItem was not called")
+	}
+
+	// Verify UpdatedAt was updated
+	if exec.UpdatedAt.Before(now) {
+		t.Error("UpdatedAt should have been updated")
+	}
+}
+
+func TestDynamoDBStore_ListStepExecutions(t *testing.T) {
+	runID := "test-run-1"
+
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{
+				Items: []map[string]types.AttributeValue{
+					{
+						"RunID":     &types.AttributeValueMemberS{Value: runID},
+						"StepID":    &types.AttributeValueMemberS{Value: "step-1"},
+						"Status":    &types.AttributeValueMemberS{Value: string(gorkflow.StepStatusPending)},
+						"CreatedAt": &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339Nano)},
+						"UpdatedAt": &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339Nano)},
+					},
+					{
+						"RunID":     &types.AttributeValueMemberS{Value: runID},
+						"StepID":    &types.AttributeValueMemberS{Value: "step-2"},
+						"Status":    &types.AttributeValueMemberS{Value: string(gorkflow.StepStatusCompleted)},
+						"CreatedAt": &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339Nano)},
+						"UpdatedAt": &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339Nano)},
+					},
+				},
+			}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	executions, err := store.ListStepExecutions(ctx, runID)
+	if err != nil {
+		t.Fatalf("ListStepExecutions() failed: %v", err)
+	}
+
+	if len(executions) != 2 {
+		t.Errorf("ListStepExecutions() returned %d executions, want 2", len(executions))
+	}
+}
+
+func TestDynamoDBStore_ListStepExecutions_Error(t *testing.T) {
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			return nil, errors.New("dynamodb error")
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	_, err := store.ListStepExecutions(ctx, "test-run")
 	if err == nil {
 		t.Error("ListStepExecutions() should have failed with DynamoDB error")
 	}
@@ -602,27 +1456,102 @@ func TestDynamoDBStore_SaveStepOutput(t *testing.T) {
 		t.Fatal("PutItem was not called")
 	}
 
-	// Verify keys
-	pk := capturedInput.Item[AttrPK].(*types.AttributeValueMemberS).Value
-	expectedPK := stepOutputPK("test-run-1")
-	if pk != expectedPK {
-		t.Errorf("PK = %s, want %s", pk, expectedPK)
+	// Verify keys
+	pk := capturedInput.Item[AttrPK].(*types.AttributeValueMemberS).Value
+	expectedPK := stepOutputPK("test-run-1")
+	if pk != expectedPK {
+		t.Errorf("PK = %s, want %s", pk, expectedPK)
+	}
+
+	sk := capturedInput.Item[AttrSK].(*types.AttributeValueMemberS).Value
+	expectedSK := stepOutputSK("step-1")
+	if sk != expectedSK {
+		t.Errorf("SK = %s, want %s", sk, expectedSK)
+	}
+
+	entityType := capturedInput.Item[AttrEntityType].(*types.AttributeValueMemberS).Value
+	if entityType != EntityTypeStepOutput {
+		t.Errorf("EntityType = %s, want %s", entityType, EntityTypeStepOutput)
+	}
+
+	// Verify output is stored
+	if _, ok := capturedInput.Item["output"]; !ok {
+		t.Error("output field not set")
+	}
+}
+
+func TestDynamoDBStore_CommitStepResult(t *testing.T) {
+	var capturedInput *dynamodb.TransactWriteItemsInput
+	var puts int
+
+	client := &mockDynamoDBClient{
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			puts++
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		transactWriteItemsFunc: func(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+			capturedInput = params
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+	exec := &gorkflow.StepExecution{
+		RunID:  "test-run-1",
+		StepID: "step-1",
+		Status: gorkflow.StepStatusCompleted,
+	}
+
+	if err := store.CommitStepResult(context.Background(), exec, []byte(`{"result":"ok"}`)); err != nil {
+		t.Fatalf("CommitStepResult() failed: %v", err)
+	}
+
+	if puts != 0 {
+		t.Errorf("CommitStepResult() issued %d separate puts, want 0", puts)
+	}
+	if capturedInput == nil || len(capturedInput.TransactItems) != 2 {
+		t.Fatalf("TransactWriteItems should contain 2 items, got %v", capturedInput)
+	}
+
+	execItem := capturedInput.TransactItems[0].Put.Item
+	if sk := execItem[AttrSK].(*types.AttributeValueMemberS).Value; sk != stepExecutionSK("step-1") {
+		t.Errorf("first item SK = %q, want %q", sk, stepExecutionSK("step-1"))
+	}
+	if status := execItem["status"].(*types.AttributeValueMemberS).Value; status != string(gorkflow.StepStatusCompleted) {
+		t.Errorf("Status = %q, want %q", status, gorkflow.StepStatusCompleted)
+	}
+
+	outputItem := capturedInput.TransactItems[1].Put.Item
+	if sk := outputItem[AttrSK].(*types.AttributeValueMemberS).Value; sk != stepOutputSK("step-1") {
+		t.Errorf("second item SK = %q, want %q", sk, stepOutputSK("step-1"))
 	}
-
-	sk := capturedInput.Item[AttrSK].(*types.AttributeValueMemberS).Value
-	expectedSK := stepOutputSK("step-1")
-	if sk != expectedSK {
-		t.Errorf("SK = %s, want %s", sk, expectedSK)
+	if output := outputItem["output"].(*types.AttributeValueMemberB).Value; string(output) != `{"result":"ok"}` {
+		t.Errorf("output = %s, want %s", output, `{"result":"ok"}`)
 	}
+}
 
-	entityType := capturedInput.Item[AttrEntityType].(*types.AttributeValueMemberS).Value
-	if entityType != EntityTypeStepOutput {
-		t.Errorf("EntityType = %s, want %s", entityType, EntityTypeStepOutput)
+func TestDynamoDBStore_CommitStepResult_Error(t *testing.T) {
+	var puts int
+	client := &mockDynamoDBClient{
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			puts++
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		transactWriteItemsFunc: func(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+			return nil, errors.New("transaction cancelled")
+		},
 	}
 
-	// Verify output is stored
-	if _, ok := capturedInput.Item["output"]; !ok {
-		t.Error("output field not set")
+	store := NewDynamoDBStore(client, "test-table")
+	exec := &gorkflow.StepExecution{RunID: "test-run-1", StepID: "step-1"}
+
+	err := store.CommitStepResult(context.Background(), exec, []byte(`{}`))
+	if err == nil {
+		t.Fatal("CommitStepResult() should fail when the transaction is cancelled")
+	}
+	// Nothing is written outside the cancelled transaction
+	if puts != 0 {
+		t.Errorf("CommitStepResult() issued %d separate puts, want 0", puts)
 	}
 }
 
@@ -652,6 +1581,97 @@ func TestDynamoDBStore_LoadStepOutput(t *testing.T) {
 	}
 }
 
+func TestDynamoDBStore_SaveStepOutputs(t *testing.T) {
+	saved := make(map[string]map[string]types.AttributeValue)
+	var calls int
+	client := &mockDynamoDBClient{
+		batchWriteItemFunc: func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			calls++
+			requests := params.RequestItems["test-table"]
+			if len(requests) > maxBatchWriteItems {
+				t.Errorf("batch of %d requests exceeds the limit of %d", len(requests), maxBatchWriteItems)
+			}
+			for _, request := range requests {
+				item := request.PutRequest.Item
+				saved[item[AttrSK].(*types.AttributeValueMemberS).Value] = item
+			}
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: saved[params.Key[AttrSK].(*types.AttributeValueMemberS).Value]}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	// More outputs than fit in one BatchWriteItem call
+	outputs := make(map[string][]byte)
+	for i := range 30 {
+		outputs[fmt.Sprintf("branch-%d", i)] = []byte(fmt.Sprintf(`{"branch":%d}`, i))
+	}
+
+	if err := store.SaveStepOutputs(ctx, "test-run-1", outputs); err != nil {
+		t.Fatalf("SaveStepOutputs() failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("BatchWriteItem calls = %d, want 2", calls)
+	}
+
+	for stepID, want := range outputs {
+		got, err := store.LoadStepOutput(ctx, "test-run-1", stepID)
+		if err != nil {
+			t.Fatalf("LoadStepOutput(%s) failed: %v", stepID, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("LoadStepOutput(%s) = %s, want %s", stepID, got, want)
+		}
+	}
+}
+
+func TestDynamoDBStore_BatchLoadStepOutputs(t *testing.T) {
+	var calls int
+	client := &mockDynamoDBClient{
+		batchGetItemFunc: func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+			calls++
+			var items []map[string]types.AttributeValue
+			for _, key := range params.RequestItems["test-table"].Keys {
+				if key[AttrPK].(*types.AttributeValueMemberS).Value != stepOutputPK("test-run-1") {
+					t.Errorf("unexpected partition key %v", key[AttrPK])
+				}
+				sk := key[AttrSK].(*types.AttributeValueMemberS).Value
+				if sk == stepOutputSK("skipped") {
+					continue
+				}
+				items = append(items, map[string]types.AttributeValue{
+					AttrSK:   &types.AttributeValueMemberS{Value: sk},
+					"output": &types.AttributeValueMemberB{Value: []byte(`"` + sk + `"`)},
+				})
+			}
+			return &dynamodb.BatchGetItemOutput{
+				Responses: map[string][]map[string]types.AttributeValue{"test-table": items},
+			}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table").(gorkflow.StepOutputBatchLoader)
+
+	// Duplicates are fetched once and missing outputs are left out
+	outputs, err := store.BatchLoadStepOutputs(context.Background(), "test-run-1", []string{"step-1", "step-2", "skipped", "step-1"})
+	if err != nil {
+		t.Fatalf("BatchLoadStepOutputs() failed: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("BatchGetItem calls = %d, want 1", calls)
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("len(outputs) = %d, want 2", len(outputs))
+	}
+	if got := string(outputs["step-2"]); got != `"OUTPUT#step-2"` {
+		t.Errorf("outputs[step-2] = %s, want %q", got, `"OUTPUT#step-2"`)
+	}
+}
+
 func TestDynamoDBStore_LoadStepOutput_NotFound(t *testing.T) {
 	client := &mockDynamoDBClient{
 		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
@@ -918,3 +1938,398 @@ func TestDynamoDBStore_CountRunsByStatus_Error(t *testing.T) {
 		t.Error("CountRunsByStatus() should have failed with DynamoDB error")
 	}
 }
+
+func TestDynamoDBStore_CreateRun_ScheduleIndex(t *testing.T) {
+	var item map[string]types.AttributeValue
+	client := &mockDynamoDBClient{
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			item = params.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+
+	scheduledAt := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	run := &gorkflow.WorkflowRun{
+		RunID:       "run-1",
+		WorkflowID:  "workflow-1",
+		Status:      gorkflow.RunStatusPending,
+		ScheduledAt: &scheduledAt,
+	}
+	if err := store.CreateRun(context.Background(), run); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+
+	if got := item[AttrGSI3PK].(*types.AttributeValueMemberS).Value; got != "SCHED#2025-01-10" {
+		t.Errorf("GSI3PK = %q, want SCHED#2025-01-10", got)
+	}
+	if got := item[AttrGSI3SK].(*types.AttributeValueMemberS).Value; got != "2025-01-10T12:00:00.000000000Z" {
+		t.Errorf("GSI3SK = %q, want 2025-01-10T12:00:00.000000000Z", got)
+	}
+}
+
+func TestDynamoDBStore_ListDueRuns(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	var queries []*dynamodb.QueryInput
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			queries = append(queries, params)
+
+			pk := params.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{{
+				"run_id":      &types.AttributeValueMemberS{Value: "run-" + pk},
+				"workflow_id": &types.AttributeValueMemberS{Value: "workflow-1"},
+				"status":      &types.AttributeValueMemberS{Value: string(gorkflow.RunStatusPending)},
+			}}}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table", WithScheduleLookback(24*time.Hour))
+
+	runs, err := store.ListDueRuns(context.Background(), now, 0)
+	if err != nil {
+		t.Fatalf("ListDueRuns() failed: %v", err)
+	}
+
+	// Yesterday's bucket first, then today's
+	if len(queries) != 2 {
+		t.Fatalf("ListDueRuns() made %d queries, want 2", len(queries))
+	}
+	for i, bucket := range []string{"SCHED#2025-01-09", "SCHED#2025-01-10"} {
+		if got := *queries[i].IndexName; got != IndexScheduleIndex {
+			t.Errorf("IndexName = %q, want %q", got, IndexScheduleIndex)
+		}
+		if got := queries[i].ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value; got != bucket {
+			t.Errorf("query %d :pk = %q, want %q", i, got, bucket)
+		}
+		if got := queries[i].ExpressionAttributeValues[":due"].(*types.AttributeValueMemberS).Value; got != "2025-01-10T12:00:00.000000000Z" {
+			t.Errorf("query %d :due = %q", i, got)
+		}
+	}
+	if len(runs) != 2 || runs[0].RunID != "run-SCHED#2025-01-09" {
+		t.Errorf("ListDueRuns() = %v, want runs from both buckets in order", runs)
+	}
+
+	// Limit stops querying early
+	queries = nil
+	runs, err = store.ListDueRuns(context.Background(), now, 1)
+	if err != nil {
+		t.Fatalf("ListDueRuns() failed: %v", err)
+	}
+	if len(runs) != 1 || len(queries) != 1 {
+		t.Errorf("ListDueRuns(limit 1) returned %d runs in %d queries, want 1 in 1", len(runs), len(queries))
+	}
+}
+
+func TestDynamoDBStore_ClaimScheduledRun(t *testing.T) {
+	var update *dynamodb.UpdateItemInput
+	client := &mockDynamoDBClient{
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			update = params
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+		transactWriteItemsFunc: func(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+			t.Error("ClaimScheduledRun() should not use a transaction for a single update")
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+
+	claimed, err := store.ClaimScheduledRun(context.Background(), "run-1")
+	if err != nil || !claimed {
+		t.Fatalf("ClaimScheduledRun() = %v, %v, want true", claimed, err)
+	}
+	if update == nil {
+		t.Fatal("ClaimScheduledRun() did not issue an update")
+	}
+	if got := *update.UpdateExpression; got != "REMOVE GSI3PK, GSI3SK" {
+		t.Errorf("UpdateExpression = %q", got)
+	}
+	if got := *update.ConditionExpression; got != "attribute_exists(GSI3PK) AND #status = :pending" {
+		t.Errorf("ConditionExpression = %q", got)
+	}
+}
+
+func TestDynamoDBStore_ClaimScheduledRun_AlreadyClaimed(t *testing.T) {
+	client := &mockDynamoDBClient{
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			return nil, &types.ConditionalCheckFailedException{}
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+
+	claimed, err := store.ClaimScheduledRun(context.Background(), "run-1")
+	if err != nil || claimed {
+		t.Errorf("ClaimScheduledRun() = %v, %v, want false without error", claimed, err)
+	}
+}
+
+func TestDynamoDBStore_ClaimScheduledRun_Error(t *testing.T) {
+	client := &mockDynamoDBClient{
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			return nil, errors.New("throttled")
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+
+	if _, err := store.ClaimScheduledRun(context.Background(), "run-1"); err == nil {
+		t.Error("ClaimScheduledRun() should have failed")
+	}
+}
+
+func TestDynamoDBStore_IncrementState(t *testing.T) {
+	var captured *dynamodb.UpdateItemInput
+	client := &mockDynamoDBClient{
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			captured = params
+			return &dynamodb.UpdateItemOutput{
+				Attributes: map[string]types.AttributeValue{
+					"value": &types.AttributeValueMemberN{Value: "7"},
+				},
+			}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+
+	total, err := store.IncrementState(context.Background(), "run-1", "processed", 3)
+	if err != nil {
+		t.Fatalf("IncrementState() failed: %v", err)
+	}
+	if total != 7 {
+		t.Errorf("IncrementState() = %d, want 7", total)
+	}
+
+	if got := *captured.UpdateExpression; got != "ADD #value :delta SET #type = :type, updated_at = :now" {
+		t.Errorf("UpdateExpression = %q", got)
+	}
+	if got := captured.ExpressionAttributeValues[":delta"].(*types.AttributeValueMemberN).Value; got != "3" {
+		t.Errorf(":delta = %q, want 3", got)
+	}
+	if got := captured.Key[AttrSK].(*types.AttributeValueMemberS).Value; got != stateSK("processed") {
+		t.Errorf("SK = %q, want %q", got, stateSK("processed"))
+	}
+	if captured.ReturnValues != types.ReturnValueUpdatedNew {
+		t.Errorf("ReturnValues = %v, want UPDATED_NEW", captured.ReturnValues)
+	}
+}
+
+func TestDynamoDBStore_IncrementState_ConvertsBinaryValue(t *testing.T) {
+	var updates []*dynamodb.UpdateItemInput
+	client := &mockDynamoDBClient{
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			// Run TTL lookup
+			if params.ProjectionExpression != nil {
+				return &dynamodb.GetItemOutput{}, nil
+			}
+			return &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					"value": &types.AttributeValueMemberB{Value: []byte(`5`)},
+				},
+			}, nil
+		},
+		updateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			updates = append(updates, params)
+			if len(updates) == 1 {
+				// Value written by SaveState is binary
+				return nil, &types.ConditionalCheckFailedException{}
+			}
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+
+	total, err := store.IncrementState(context.Background(), "run-1", "count", 3)
+	if err != nil {
+		t.Fatalf("IncrementState() failed: %v", err)
+	}
+	if total != 8 {
+		t.Errorf("IncrementState() = %d, want 8", total)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("UpdateItem called %d times, want 2", len(updates))
+	}
+	if got := *updates[1].ConditionExpression; got != "#value = :old" {
+		t.Errorf("ConditionExpression = %q, want #value = :old", got)
+	}
+	if got := updates[1].ExpressionAttributeValues[":total"].(*types.AttributeValueMemberN).Value; got != "8" {
+		t.Errorf(":total = %q, want 8", got)
+	}
+}
+
+func TestDynamoDBStore_LoadState_Counter(t *testing.T) {
+	client := &mockDynamoDBClient{
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					"value": &types.AttributeValueMemberN{Value: "42"},
+				},
+			}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+
+	loaded, err := store.LoadState(context.Background(), "run-1", "processed")
+	if err != nil {
+		t.Fatalf("LoadState() failed: %v", err)
+	}
+	if string(loaded) != "42" {
+		t.Errorf("LoadState() = %s, want 42", loaded)
+	}
+}
+
+func TestDynamoDBStore_ListState(t *testing.T) {
+	items := []map[string]types.AttributeValue{
+		{
+			AttrSK:  &types.AttributeValueMemberS{Value: "STATE#item:1"},
+			"value": &types.AttributeValueMemberB{Value: []byte(`1`)},
+		},
+		{
+			AttrSK:  &types.AttributeValueMemberS{Value: "STATE#item:2"},
+			"value": &types.AttributeValueMemberN{Value: "2"},
+		},
+		{
+			AttrSK:  &types.AttributeValueMemberS{Value: "STATE#other:1"},
+			"value": &types.AttributeValueMemberB{Value: []byte(`3`)},
+		},
+	}
+
+	client := &mockDynamoDBClient{
+		queryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			if got := *params.KeyConditionExpression; got != "PK = :pk AND begins_with(SK, :sk)" {
+				t.Errorf("KeyConditionExpression = %q", got)
+			}
+
+			// Apply begins_with the way DynamoDB would
+			prefix := params.ExpressionAttributeValues[":sk"].(*types.AttributeValueMemberS).Value
+			var matched []map[string]types.AttributeValue
+			for _, item := range items {
+				if strings.HasPrefix(item[AttrSK].(*types.AttributeValueMemberS).Value, prefix) {
+					matched = append(matched, item)
+				}
+			}
+			return &dynamodb.QueryOutput{Items: matched}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+
+	state, err := store.ListState(context.Background(), "run-1", "item:")
+	if err != nil {
+		t.Fatalf("ListState() failed: %v", err)
+	}
+	if len(state) != 2 {
+		t.Fatalf("ListState() returned %d items, want 2", len(state))
+	}
+	if string(state["item:1"]) != "1" || string(state["item:2"]) != "2" {
+		t.Errorf("ListState() = %v", state)
+	}
+}
+
+func TestDynamoDBStore_StepExecution_AttemptsRoundTrip(t *testing.T) {
+	var stored map[string]types.AttributeValue
+
+	client := &mockDynamoDBClient{
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			stored = params.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	started := time.Now().UTC().Truncate(time.Millisecond)
+	exec := &gorkflow.StepExecution{
+		RunID:   "test-run-1",
+		StepID:  "step-1",
+		Status:  gorkflow.StepStatusCompleted,
+		Attempt: 1,
+		Attempts: []gorkflow.AttemptRecord{
+			{Attempt: 0, StartedAt: started, CompletedAt: started.Add(time.Second), DurationMs: 1000, Error: "timeout"},
+			{Attempt: 1, StartedAt: started.Add(2 * time.Second), CompletedAt: started.Add(3 * time.Second), DurationMs: 1000},
+		},
+	}
+
+	if err := store.UpdateStepExecution(ctx, exec); err != nil {
+		t.Fatalf("UpdateStepExecution() failed: %v", err)
+	}
+
+	got, err := store.GetStepExecution(ctx, exec.RunID, exec.StepID)
+	if err != nil {
+		t.Fatalf("GetStepExecution() failed: %v", err)
+	}
+
+	if len(got.Attempts) != 2 {
+		t.Fatalf("len(Attempts) = %d, want 2", len(got.Attempts))
+	}
+	if got.Attempts[0].Error != "timeout" || got.Attempts[1].Error != "" {
+		t.Errorf("Attempts errors = %q, %q, want %q, %q", got.Attempts[0].Error, got.Attempts[1].Error, "timeout", "")
+	}
+	if !got.Attempts[1].StartedAt.Equal(exec.Attempts[1].StartedAt) {
+		t.Errorf("Attempts[1].StartedAt = %v, want %v", got.Attempts[1].StartedAt, exec.Attempts[1].StartedAt)
+	}
+}
+
+func TestDynamoDBStore_LeaseRun(t *testing.T) {
+	var puts []*dynamodb.PutItemInput
+	client := &mockDynamoDBClient{
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			puts = append(puts, params)
+			if len(puts) > 1 {
+				// The first lease is still held
+				return nil, &types.ConditionalCheckFailedException{}
+			}
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table")
+
+	leased, err := store.LeaseRun(context.Background(), "run-1", "engine-a", time.Minute)
+	if err != nil || !leased {
+		t.Fatalf("LeaseRun() = %v, %v, want true", leased, err)
+	}
+
+	leased, err = store.LeaseRun(context.Background(), "run-1", "engine-b", time.Minute)
+	if err != nil || leased {
+		t.Errorf("second LeaseRun() = %v, %v, want false without error", leased, err)
+	}
+
+	put := puts[0]
+	if got := put.Item[AttrSK].(*types.AttributeValueMemberS).Value; got != "LEASE" {
+		t.Errorf("SK = %q, want LEASE", got)
+	}
+	if got := put.Item[AttrLeaseOwner].(*types.AttributeValueMemberS).Value; got != "engine-a" {
+		t.Errorf("lease owner = %q, want engine-a", got)
+	}
+	if got := *put.ConditionExpression; got != "attribute_not_exists(PK) OR #owner = :owner OR #expires < :now" {
+		t.Errorf("ConditionExpression = %q", got)
+	}
+}
+
+func TestDynamoDBStore_Ping(t *testing.T) {
+	var gets []*dynamodb.GetItemInput
+	client := &mockDynamoDBClient{
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			gets = append(gets, params)
+			if len(gets) > 1 {
+				return nil, errors.New("table not found")
+			}
+			return &dynamodb.GetItemOutput{}, nil
+		},
+	}
+	store := NewDynamoDBStore(client, "test-table").(gorkflow.Pinger)
+
+	if err := store.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if got := gets[0].Key[AttrPK].(*types.AttributeValueMemberS).Value; got != "HEALTH" {
+		t.Errorf("PK = %q, want HEALTH", got)
+	}
+
+	if err := store.Ping(context.Background()); err == nil {
+		t.Error("Ping() error = nil, want the client error")
+	}
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3URIScheme prefixes pointers to offloaded payloads
+const s3URIScheme = "s3://"
+
+// largeObjectStore offloads payloads that would exceed the DynamoDB item size limit to S3
+type largeObjectStore struct {
+	client    S3Client
+	bucket    string
+	threshold int
+}
+
+// WithLargeObjectStore offloads step outputs and state values larger than
+// thresholdBytes to the given S3 bucket. The DynamoDB item keeps an s3://
+// pointer which is resolved transparently on load.
+func WithLargeObjectStore(client S3Client, bucket string, thresholdBytes int) DynamoDBStoreOption {
+	return func(s *DynamoDBStore) {
+		s.largeObjects = &largeObjectStore{
+			client:    client,
+			bucket:    bucket,
+			threshold: thresholdBytes,
+		}
+	}
+}
+
+// shouldOffload reports whether data is too large to be stored inline
+func (l *largeObjectStore) shouldOffload(data []byte) bool {
+	return l != nil && len(data) > l.threshold
+}
+
+// put uploads data under key and returns its s3:// pointer
+func (l *largeObjectStore) put(ctx context.Context, key string, data []byte) (string, error) {
+	_, err := l.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(l.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+
+	return s3URIScheme + l.bucket + "/" + key, nil
+}
+
+// get downloads the object referenced by an s3:// pointer
+func (l *largeObjectStore) get(ctx context.Context, uri string) ([]byte, error) {
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := l.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download object %s: %w", uri, err)
+	}
+	defer result.Body.Close()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", uri, err)
+	}
+
+	return data, nil
+}
+
+// delete removes the object referenced by an s3:// pointer
+func (l *largeObjectStore) delete(ctx context.Context, uri string) error {
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", uri, err)
+	}
+
+	return nil
+}
+
+// parseS3URI splits an s3://bucket/key pointer into bucket and key
+func parseS3URI(uri string) (string, string, error) {
+	if !strings.HasPrefix(uri, s3URIScheme) {
+		return "", "", fmt.Errorf("invalid object pointer %q", uri)
+	}
+
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(uri, s3URIScheme), "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid object pointer %q", uri)
+	}
+
+	return bucket, key, nil
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// mockS3Client implements S3Client with an in-memory object map
+type mockS3Client struct {
+	objects map[string][]byte // bucket/key -> body
+}
+
+func newMockS3Client() *mockS3Client {
+	return &mockS3Client{objects: make(map[string][]byte)}
+}
+
+func (m *mockS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)] = body
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	body, ok := m.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)]
+	if !ok {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func (m *mockS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	delete(m.objects, aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+// newItemStoringClient returns a mock DynamoDB client that keeps put items keyed by PK/SK
+func newItemStoringClient() (*mockDynamoDBClient, map[string]map[string]types.AttributeValue) {
+	items := make(map[string]map[string]types.AttributeValue)
+	itemKey := func(item map[string]types.AttributeValue) string {
+		return item[AttrPK].(*types.AttributeValueMemberS).Value + "|" + item[AttrSK].(*types.AttributeValueMemberS).Value
+	}
+
+	client := &mockDynamoDBClient{
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			items[itemKey(params.Item)] = params.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: items[itemKey(params.Key)]}, nil
+		},
+	}
+
+	return client, items
+}
+
+func TestDynamoDBStore_LargeStepOutput_OffloadedToS3(t *testing.T) {
+	client, items := newItemStoringClient()
+	s3Client := newMockS3Client()
+
+	store := NewDynamoDBStore(client, "test-table", WithLargeObjectStore(s3Client, "test-bucket", 350*1024))
+	ctx := context.Background()
+
+	large := []byte(`{"data":"` + strings.Repeat("x", 500*1024) + `"}`)
+	if err := store.SaveStepOutput(ctx, "run-1", "big", large); err != nil {
+		t.Fatalf("SaveStepOutput() failed: %v", err)
+	}
+
+	item := items[stepOutputPK("run-1")+"|"+stepOutputSK("big")]
+	if _, ok := item["output"]; ok {
+		t.Error("large output should not be stored inline")
+	}
+	ref, ok := item[AttrObjectRef].(*types.AttributeValueMemberS)
+	if !ok {
+		t.Fatal("object_ref not set for large output")
+	}
+	if want := "s3://test-bucket/" + stepOutputObjectKey("run-1", "big"); ref.Value != want {
+		t.Errorf("object_ref = %s, want %s", ref.Value, want)
+	}
+	if len(s3Client.objects) != 1 {
+		t.Errorf("S3 objects = %d, want 1", len(s3Client.objects))
+	}
+
+	loaded, err := store.LoadStepOutput(ctx, "run-1", "big")
+	if err != nil {
+		t.Fatalf("LoadStepOutput() failed: %v", err)
+	}
+	if !bytes.Equal(loaded, large) {
+		t.Error("LoadStepOutput() did not return the offloaded payload")
+	}
+}
+
+func TestDynamoDBStore_SmallStepOutput_StaysInline(t *testing.T) {
+	client, items := newItemStoringClient()
+	s3Client := newMockS3Client()
+
+	store := NewDynamoDBStore(client, "test-table", WithLargeObjectStore(s3Client, "test-bucket", 350*1024))
+	ctx := context.Background()
+
+	small := []byte(`{"result":"ok"}`)
+	if err := store.SaveStepOutput(ctx, "run-1", "small", small); err != nil {
+		t.Fatalf("SaveStepOutput() failed: %v", err)
+	}
+
+	item := items[stepOutputPK("run-1")+"|"+stepOutputSK("small")]
+	if _, ok := item[AttrObjectRef]; ok {
+		t.Error("small output should not be offloaded")
+	}
+	if _, ok := item["output"]; !ok {
+		t.Error("small output should be stored inline")
+	}
+	if len(s3Client.objects) != 0 {
+		t.Errorf("S3 objects = %d, want 0", len(s3Client.objects))
+	}
+
+	loaded, err := store.LoadStepOutput(ctx, "run-1", "small")
+	if err != nil {
+		t.Fatalf("LoadStepOutput() failed: %v", err)
+	}
+	if !bytes.Equal(loaded, small) {
+		t.Errorf("LoadStepOutput() = %s, want %s", loaded, small)
+	}
+}
+
+func TestDynamoDBStore_LargeState_OffloadedToS3(t *testing.T) {
+	client, _ := newItemStoringClient()
+	s3Client := newMockS3Client()
+
+	store := NewDynamoDBStore(client, "test-table", WithLargeObjectStore(s3Client, "test-bucket", 1024))
+	ctx := context.Background()
+
+	large := []byte(`"` + strings.Repeat("y", 4096) + `"`)
+	if err := store.SaveState(ctx, "run-1", "blob", large); err != nil {
+		t.Fatalf("SaveState() failed: %v", err)
+	}
+
+	if _, ok := s3Client.objects["test-bucket/"+stateObjectKey("run-1", "blob")]; !ok {
+		t.Fatal("large state value was not uploaded to S3")
+	}
+
+	loaded, err := store.LoadState(ctx, "run-1", "blob")
+	if err != nil {
+		t.Fatalf("LoadState() failed: %v", err)
+	}
+	if !bytes.Equal(loaded, large) {
+		t.Error("LoadState() did not return the offloaded payload")
+	}
+}
+
+func TestDynamoDBStore_ObjectRef_WithoutLargeObjectStore(t *testing.T) {
+	client := &mockDynamoDBClient{
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					AttrObjectRef: &types.AttributeValueMemberS{Value: "s3://bucket/key"},
+				},
+			}, nil
+		},
+	}
+
+	store := NewDynamoDBStore(client, "test-table")
+
+	if _, err := store.LoadStepOutput(context.Background(), "run-1", "step-1"); err == nil {
+		t.Error("LoadStepOutput() should fail when no large object store is configured")
+	}
+}
+
+func TestParseS3URI(t *testing.T) {
+	bucket, key, err := parseS3URI("s3://bucket/runs/r1/outputs/s1")
+	if err != nil {
+		t.Fatalf("parseS3URI() failed: %v", err)
+	}
+	if bucket != "bucket" || key != "runs/r1/outputs/s1" {
+		t.Errorf("parseS3URI() = %s, %s", bucket, key)
+	}
+
+	for _, uri := range []string{"", "bucket/key", "s3://bucket", "s3:///key"} {
+		if _, _, err := parseS3URI(uri); err == nil {
+			t.Errorf("parseS3URI(%q) should have failed", uri)
+		}
+	}
+}
@@ -3,7 +3,13 @@ package store
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/sicko7947/gorkflow"
 )
@@ -14,17 +20,38 @@ type MemoryStore struct {
 	stepExecutions map[string]map[string]*gorkflow.StepExecution // runID -> stepID -> execution
 	stepOutputs    map[string]map[string][]byte                  // runID -> stepID -> output
 	state          map[string]map[string][]byte                  // runID -> key -> value
+	claimed        map[string]bool                               // scheduled runs already picked up
+	leases         map[string]runLease                           // runID -> current owner
+	compression    Compression
 	mu             sync.RWMutex
 }
 
+// MemoryStoreOption configures a MemoryStore
+type MemoryStoreOption func(*MemoryStore)
+
+// WithMemoryCompression compresses step outputs and state values held in memory
+func WithMemoryCompression(algo Compression) MemoryStoreOption {
+	return func(s *MemoryStore) {
+		s.compression = algo
+	}
+}
+
 // NewMemoryStore creates a new in-memory workflow store
-func NewMemoryStore() gorkflow.WorkflowStore {
-	return &MemoryStore{
+func NewMemoryStore(opts ...MemoryStoreOption) gorkflow.WorkflowStore {
+	s := &MemoryStore{
 		runs:           make(map[string]*gorkflow.WorkflowRun),
 		stepExecutions: make(map[string]map[string]*gorkflow.StepExecution),
 		stepOutputs:    make(map[string]map[string][]byte),
 		state:          make(map[string]map[string][]byte),
+		claimed:        make(map[string]bool),
+		leases:         make(map[string]runLease),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 // Workflow run operations
@@ -39,6 +66,7 @@ func (s *MemoryStore) CreateRun(ctx context.Context, run *gorkflow.WorkflowRun)
 
 	// Deep copy
 	runCopy := *run
+	runCopy.StatusHistory = slices.Clone(run.StatusHistory)
 	s.runs[run.RunID] = &runCopy
 
 	// Initialize maps for this run
@@ -60,9 +88,26 @@ func (s *MemoryStore) GetRun(ctx context.Context, runID string) (*gorkflow.Workf
 
 	// Deep copy
 	runCopy := *run
+	runCopy.StatusHistory = slices.Clone(run.StatusHistory)
 	return &runCopy, nil
 }
 
+func (s *MemoryStore) BatchGetRuns(ctx context.Context, runIDs []string) (map[string]*gorkflow.WorkflowRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runs := make(map[string]*gorkflow.WorkflowRun, len(runIDs))
+	for _, runID := range runIDs {
+		if run, exists := s.runs[runID]; exists {
+			runCopy := *run
+			runCopy.StatusHistory = slices.Clone(run.StatusHistory)
+			runs[runID] = &runCopy
+		}
+	}
+
+	return runs, nil
+}
+
 func (s *MemoryStore) UpdateRun(ctx context.Context, run *gorkflow.WorkflowRun) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -73,11 +118,29 @@ func (s *MemoryStore) UpdateRun(ctx context.Context, run *gorkflow.WorkflowRun)
 
 	// Deep copy
 	runCopy := *run
+	runCopy.StatusHistory = slices.Clone(run.StatusHistory)
 	s.runs[run.RunID] = &runCopy
 
 	return nil
 }
 
+func (s *MemoryStore) UpdateRunProgress(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.runs[run.RunID]
+	if !exists {
+		return fmt.Errorf("workflow run %s not found", run.RunID)
+	}
+
+	stored.Progress = run.Progress
+	stored.CurrentStep = run.CurrentStep
+	stored.TotalAttempts = run.TotalAttempts
+	stored.UpdatedAt = run.UpdatedAt
+
+	return nil
+}
+
 func (s *MemoryStore) UpdateRunStatus(ctx context.Context, runID string, status gorkflow.RunStatus, err *gorkflow.WorkflowError) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -86,8 +149,12 @@ func (s *MemoryStore) UpdateRunStatus(ctx context.Context, runID string, status
 	if !exists {
 		return fmt.Errorf("workflow run %s not found", runID)
 	}
+	if run.Status.IsTerminal() {
+		return fmt.Errorf("workflow run %s is %s: %w", runID, run.Status, gorkflow.ErrRunFinished)
+	}
 
-	run.Status = status
+	run.UpdatedAt = time.Now().UTC()
+	run.SetStatus(status, run.UpdatedAt)
 	run.Error = err
 
 	return nil
@@ -100,30 +167,69 @@ func (s *MemoryStore) ListRuns(ctx context.Context, filter gorkflow.RunFilter) (
 	var runs []*gorkflow.WorkflowRun
 
 	for _, run := range s.runs {
-		// Apply filters
-		if filter.WorkflowID != "" && run.WorkflowID != filter.WorkflowID {
-			continue
-		}
-		if filter.Status != nil && run.Status != *filter.Status {
-			continue
-		}
-		if filter.ResourceID != "" && run.ResourceID != filter.ResourceID {
+		if !matchesRunFilter(run, filter) {
 			continue
 		}
 
 		// Deep copy
 		runCopy := *run
+		runCopy.StatusHistory = slices.Clone(run.StatusHistory)
 		runs = append(runs, &runCopy)
+	}
 
-		// Apply limit
-		if filter.Limit > 0 && len(runs) >= filter.Limit {
-			break
-		}
+	sortRunsByCreatedAt(runs, filter.SortDescending)
+
+	// Apply limit
+	if filter.Limit > 0 && len(runs) > filter.Limit {
+		runs = runs[:filter.Limit]
 	}
 
 	return runs, nil
 }
 
+// matchesRunFilter reports whether a run passes the filter's workflow, resource, status, creation window and tags
+func matchesRunFilter(run *gorkflow.WorkflowRun, filter gorkflow.RunFilter) bool {
+	if filter.WorkflowID != "" && run.WorkflowID != filter.WorkflowID {
+		return false
+	}
+	if filter.Status != nil && run.Status != *filter.Status {
+		return false
+	}
+	if filter.ResourceID != "" && run.ResourceID != filter.ResourceID {
+		return false
+	}
+	if filter.CreatedAfter != nil && run.CreatedAt.Before(*filter.CreatedAfter) {
+		return false
+	}
+	if filter.CreatedBefore != nil && run.CreatedAt.After(*filter.CreatedBefore) {
+		return false
+	}
+	for key, value := range filter.Tags {
+		if tag, ok := run.Tags[key]; !ok || tag != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *MemoryStore) DeleteRun(ctx context.Context, runID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.runs[runID]; !exists {
+		return fmt.Errorf("workflow run %s not found", runID)
+	}
+
+	delete(s.runs, runID)
+	delete(s.stepExecutions, runID)
+	delete(s.stepOutputs, runID)
+	delete(s.state, runID)
+	delete(s.claimed, runID)
+	delete(s.leases, runID)
+
+	return nil
+}
+
 // Step execution operations
 
 func (s *MemoryStore) CreateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
@@ -134,9 +240,7 @@ func (s *MemoryStore) CreateStepExecution(ctx context.Context, exec *gorkflow.St
 		s.stepExecutions[exec.RunID] = make(map[string]*gorkflow.StepExecution)
 	}
 
-	// Deep copy
-	execCopy := *exec
-	s.stepExecutions[exec.RunID][exec.StepID] = &execCopy
+	s.stepExecutions[exec.RunID][exec.StepID] = copyStepExecution(exec)
 
 	return nil
 }
@@ -155,9 +259,7 @@ func (s *MemoryStore) GetStepExecution(ctx context.Context, runID, stepID string
 		return nil, fmt.Errorf("step execution %s/%s not found", runID, stepID)
 	}
 
-	// Deep copy
-	execCopy := *exec
-	return &execCopy, nil
+	return copyStepExecution(exec), nil
 }
 
 func (s *MemoryStore) UpdateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
@@ -168,9 +270,7 @@ func (s *MemoryStore) UpdateStepExecution(ctx context.Context, exec *gorkflow.St
 		return fmt.Errorf("no step executions for run %s", exec.RunID)
 	}
 
-	// Deep copy
-	execCopy := *exec
-	s.stepExecutions[exec.RunID][exec.StepID] = &execCopy
+	s.stepExecutions[exec.RunID][exec.StepID] = copyStepExecution(exec)
 
 	return nil
 }
@@ -186,20 +286,87 @@ func (s *MemoryStore) ListStepExecutions(ctx context.Context, runID string) ([]*
 
 	executions := make([]*gorkflow.StepExecution, 0, len(runExecs))
 	for _, exec := range runExecs {
-		// Deep copy
-		execCopy := *exec
-		executions = append(executions, &execCopy)
+		executions = append(executions, copyStepExecution(exec))
 	}
 
+	// Match DynamoDB, which returns executions ordered by step ID (sort key)
+	sort.Slice(executions, func(i, j int) bool {
+		return executions[i].StepID < executions[j].StepID
+	})
+
 	return executions, nil
 }
 
+// copyStepExecution deep copies exec, so callers and the store never share its attempts, error or tags
+func copyStepExecution(exec *gorkflow.StepExecution) *gorkflow.StepExecution {
+	execCopy := *exec
+	execCopy.Attempts = slices.Clone(exec.Attempts)
+	execCopy.Tags = maps.Clone(exec.Tags)
+	if exec.Error != nil {
+		errCopy := *exec.Error
+		errCopy.Details = maps.Clone(exec.Error.Details)
+		execCopy.Error = &errCopy
+	}
+	return &execCopy
+}
+
 // Step output operations
 
 func (s *MemoryStore) SaveStepOutput(ctx context.Context, runID, stepID string, output []byte) error {
+	output, err := compressPayload(s.compression, output)
+	if err != nil {
+		return fmt.Errorf("failed to save step output: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.putStepOutput(runID, stepID, output)
+	return nil
+}
+
+// SaveStepOutputs stores the outputs of several steps, keyed by step ID, under one lock
+func (s *MemoryStore) SaveStepOutputs(ctx context.Context, runID string, outputs map[string][]byte) error {
+	encoded := make(map[string][]byte, len(outputs))
+	for stepID, output := range outputs {
+		output, err := compressPayload(s.compression, output)
+		if err != nil {
+			return fmt.Errorf("failed to save step outputs: %w", err)
+		}
+		encoded[stepID] = output
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for stepID, output := range encoded {
+		s.putStepOutput(runID, stepID, output)
+	}
+	return nil
+}
+
+// CommitStepResult stores a finished step execution and its output under one lock
+func (s *MemoryStore) CommitStepResult(ctx context.Context, exec *gorkflow.StepExecution, output []byte) error {
+	output, err := compressPayload(s.compression, output)
+	if err != nil {
+		return fmt.Errorf("failed to commit step result: %w", err)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if _, exists := s.stepExecutions[exec.RunID]; !exists {
+		s.stepExecutions[exec.RunID] = make(map[string]*gorkflow.StepExecution)
+	}
+
+	s.stepExecutions[exec.RunID][exec.StepID] = copyStepExecution(exec)
+
+	s.putStepOutput(exec.RunID, exec.StepID, output)
+	return nil
+}
+
+// putStepOutput stores a copy of an encoded output; callers hold s.mu
+func (s *MemoryStore) putStepOutput(runID, stepID string, output []byte) {
 	if _, exists := s.stepOutputs[runID]; !exists {
 		s.stepOutputs[runID] = make(map[string][]byte)
 	}
@@ -208,8 +375,6 @@ func (s *MemoryStore) SaveStepOutput(ctx context.Context, runID, stepID string,
 	outputCopy := make([]byte, len(output))
 	copy(outputCopy, output)
 	s.stepOutputs[runID][stepID] = outputCopy
-
-	return nil
 }
 
 func (s *MemoryStore) LoadStepOutput(ctx context.Context, runID, stepID string) ([]byte, error) {
@@ -226,15 +391,49 @@ func (s *MemoryStore) LoadStepOutput(ctx context.Context, runID, stepID string)
 		return nil, fmt.Errorf("step output %s/%s not found", runID, stepID)
 	}
 
+	output, err := decompressPayload(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load step output: %w", err)
+	}
+
 	// Copy bytes
 	outputCopy := make([]byte, len(output))
 	copy(outputCopy, output)
 	return outputCopy, nil
 }
 
+func (s *MemoryStore) BatchLoadStepOutputs(ctx context.Context, runID string, stepIDs []string) (map[string][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	outputs := make(map[string][]byte, len(stepIDs))
+	for _, stepID := range stepIDs {
+		output, exists := s.stepOutputs[runID][stepID]
+		if !exists {
+			continue
+		}
+
+		output, err := decompressPayload(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load step output: %w", err)
+		}
+
+		// Copy bytes
+		outputCopy := make([]byte, len(output))
+		copy(outputCopy, output)
+		outputs[stepID] = outputCopy
+	}
+	return outputs, nil
+}
+
 // State operations
 
 func (s *MemoryStore) SaveState(ctx context.Context, runID, key string, value []byte) error {
+	value, err := compressPayload(s.compression, value)
+	if err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -264,6 +463,11 @@ func (s *MemoryStore) LoadState(ctx context.Context, runID, key string) ([]byte,
 		return nil, fmt.Errorf("state key %s not found", key)
 	}
 
+	value, err := decompressPayload(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
 	// Copy bytes
 	valueCopy := make([]byte, len(value))
 	copy(valueCopy, value)
@@ -284,6 +488,10 @@ func (s *MemoryStore) DeleteState(ctx context.Context, runID, key string) error
 }
 
 func (s *MemoryStore) GetAllState(ctx context.Context, runID string) (map[string][]byte, error) {
+	return s.ListState(ctx, runID, "")
+}
+
+func (s *MemoryStore) ListState(ctx context.Context, runID, prefix string) (map[string][]byte, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -295,6 +503,13 @@ func (s *MemoryStore) GetAllState(ctx context.Context, runID string) (map[string
 	// Deep copy
 	stateCopy := make(map[string][]byte)
 	for k, v := range runState {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		v, err := decompressPayload(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load state for key %s: %w", k, err)
+		}
 		valueCopy := make([]byte, len(v))
 		copy(valueCopy, v)
 		stateCopy[k] = valueCopy
@@ -303,6 +518,36 @@ func (s *MemoryStore) GetAllState(ctx context.Context, runID string) (map[string
 	return stateCopy, nil
 }
 
+func (s *MemoryStore) IncrementState(ctx context.Context, runID, key string, delta int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.state[runID]; !exists {
+		s.state[runID] = make(map[string][]byte)
+	}
+
+	total := delta
+	if current, exists := s.state[runID][key]; exists {
+		current, err := decompressPayload(current)
+		if err != nil {
+			return 0, fmt.Errorf("failed to increment state: %w", err)
+		}
+		counter, err := parseCounter(key, current)
+		if err != nil {
+			return 0, fmt.Errorf("failed to increment state: %w", err)
+		}
+		total += counter
+	}
+
+	value, err := compressPayload(s.compression, []byte(strconv.FormatInt(total, 10)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment state: %w", err)
+	}
+	s.state[runID][key] = value
+
+	return total, nil
+}
+
 // Query operations
 
 func (s *MemoryStore) CountRunsByStatus(ctx context.Context, resourceID string, status gorkflow.RunStatus) (int, error) {
@@ -318,3 +563,102 @@ func (s *MemoryStore) CountRunsByStatus(ctx context.Context, resourceID string,
 
 	return count, nil
 }
+
+func (s *MemoryStore) CountRuns(ctx context.Context, filter gorkflow.RunFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, run := range s.runs {
+		if matchesRunFilter(run, filter) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Scheduled run operations
+
+func (s *MemoryStore) ListDueRuns(ctx context.Context, dueBefore time.Time, limit int) ([]*gorkflow.WorkflowRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var runs []*gorkflow.WorkflowRun
+	for runID, run := range s.runs {
+		if !s.isScheduled(runID, run) || run.ScheduledAt.After(dueBefore) {
+			continue
+		}
+
+		// Deep copy
+		runCopy := *run
+		runCopy.StatusHistory = slices.Clone(run.StatusHistory)
+		runs = append(runs, &runCopy)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].ScheduledAt.Before(*runs[j].ScheduledAt)
+	})
+
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	return runs, nil
+}
+
+func (s *MemoryStore) ClaimScheduledRun(ctx context.Context, runID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run, exists := s.runs[runID]
+	if !exists {
+		return false, fmt.Errorf("workflow run %s not found", runID)
+	}
+
+	if !s.isScheduled(runID, run) {
+		return false, nil
+	}
+
+	s.claimed[runID] = true
+	return true, nil
+}
+
+// runLease is the engine holding a run and when its hold lapses
+type runLease struct {
+	owner     string
+	expiresAt time.Time
+}
+
+func (s *MemoryStore) LeaseRun(ctx context.Context, runID, owner string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if lease, held := s.leases[runID]; held && lease.owner != owner && now.Before(lease.expiresAt) {
+		return false, nil
+	}
+
+	s.leases[runID] = runLease{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Ping always succeeds; the memory store has no connection to lose
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// isScheduled reports whether a run is still waiting for its scheduled start
+func (s *MemoryStore) isScheduled(runID string, run *gorkflow.WorkflowRun) bool {
+	return run.ScheduledAt != nil && run.Status == gorkflow.RunStatusPending && !s.claimed[runID]
+}
+
+// sortRunsByCreatedAt orders runs by creation time, oldest first unless descending
+func sortRunsByCreatedAt(runs []*gorkflow.WorkflowRun, descending bool) {
+	sort.SliceStable(runs, func(i, j int) bool {
+		if descending {
+			return runs[i].CreatedAt.After(runs[j].CreatedAt)
+		}
+		return runs[i].CreatedAt.Before(runs[j].CreatedAt)
+	})
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// memorySnapshot is the serialized form of a MemoryStore. Payloads are stored
+// uncompressed so a snapshot can be loaded whatever compression the store uses.
+type memorySnapshot struct {
+	Runs           map[string]*gorkflow.WorkflowRun              `json:"runs"`
+	StepExecutions map[string]map[string]*gorkflow.StepExecution `json:"stepExecutions"`
+	StepOutputs    map[string]map[string][]byte                  `json:"stepOutputs"`
+	State          map[string]map[string][]byte                  `json:"state"`
+	Claimed        map[string]bool                               `json:"claimed,omitempty"`
+}
+
+// Snapshot serializes the whole store (runs, step executions, outputs and state) to JSON,
+// e.g. to save a run for offline debugging or to build test fixtures
+func (s *MemoryStore) Snapshot() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := memorySnapshot{
+		Runs:           s.runs,
+		StepExecutions: s.stepExecutions,
+		Claimed:        s.claimed,
+	}
+
+	var err error
+	if snapshot.StepOutputs, err = decompressPayloads(s.stepOutputs); err != nil {
+		return nil, fmt.Errorf("failed to snapshot step outputs: %w", err)
+	}
+	if snapshot.State, err = decompressPayloads(s.state); err != nil {
+		return nil, fmt.Errorf("failed to snapshot state: %w", err)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// LoadSnapshot replaces the contents of the store with a snapshot taken by Snapshot
+func (s *MemoryStore) LoadSnapshot(data []byte) error {
+	var snapshot memorySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to unmarshal snapshot: %w", err)
+	}
+
+	stepOutputs, err := compressPayloads(s.compression, snapshot.StepOutputs)
+	if err != nil {
+		return fmt.Errorf("failed to load step outputs: %w", err)
+	}
+	state, err := compressPayloads(s.compression, snapshot.State)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	// Sections missing from the snapshot leave the store empty rather than nil
+	if snapshot.Runs == nil {
+		snapshot.Runs = make(map[string]*gorkflow.WorkflowRun)
+	}
+	if snapshot.StepExecutions == nil {
+		snapshot.StepExecutions = make(map[string]map[string]*gorkflow.StepExecution)
+	}
+	if snapshot.Claimed == nil {
+		snapshot.Claimed = make(map[string]bool)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runs = snapshot.Runs
+	s.stepExecutions = snapshot.StepExecutions
+	s.stepOutputs = stepOutputs
+	s.state = state
+	s.claimed = snapshot.Claimed
+	s.leases = make(map[string]runLease)
+
+	return nil
+}
+
+// decompressPayloads copies a runID -> key -> payload map, decompressing each payload
+func decompressPayloads(payloads map[string]map[string][]byte) (map[string]map[string][]byte, error) {
+	out := make(map[string]map[string][]byte, len(payloads))
+	for runID, values := range payloads {
+		out[runID] = make(map[string][]byte, len(values))
+		for key, value := range values {
+			decoded, err := decompressPayload(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %w", runID, key, err)
+			}
+			out[runID][key] = decoded
+		}
+	}
+	return out, nil
+}
+
+// compressPayloads copies a runID -> key -> payload map, compressing each payload with algo
+func compressPayloads(algo Compression, payloads map[string]map[string][]byte) (map[string]map[string][]byte, error) {
+	out := make(map[string]map[string][]byte, len(payloads))
+	for runID, values := range payloads {
+		out[runID] = make(map[string][]byte, len(values))
+		for key, value := range values {
+			encoded, err := compressPayload(algo, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %w", runID, key, err)
+			}
+			out[runID][key] = encoded
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+func TestMemoryStore_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	source := NewMemoryStore(WithMemoryCompression(CompressionGzip)).(*MemoryStore)
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	run := &gorkflow.WorkflowRun{
+		RunID:      "run-1",
+		WorkflowID: "workflow-1",
+		Status:     gorkflow.RunStatusCompleted,
+		Input:      []byte(`{"query":"tech"}`),
+		Tags:       map[string]string{"env": "test"},
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+	}
+	if err := source.CreateRun(ctx, run); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+
+	exec := &gorkflow.StepExecution{
+		RunID:    "run-1",
+		StepID:   "discover",
+		Status:   gorkflow.StepStatusCompleted,
+		Attempts: []gorkflow.AttemptRecord{{Attempt: 0, StartedAt: createdAt, CompletedAt: createdAt}},
+	}
+	output := []byte(`{"companies":["acme","globex","initech","umbrella"],"count":4}`)
+	if err := source.CommitStepResult(ctx, exec, output); err != nil {
+		t.Fatalf("CommitStepResult() failed: %v", err)
+	}
+	if err := source.SaveState(ctx, "run-1", "cursor", []byte(`"page-2"`)); err != nil {
+		t.Fatalf("SaveState() failed: %v", err)
+	}
+	if _, err := source.IncrementState(ctx, "run-1", "processed", 3); err != nil {
+		t.Fatalf("IncrementState() failed: %v", err)
+	}
+
+	data, err := source.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() failed: %v", err)
+	}
+
+	// Loading into a store with different compression still round-trips the payloads
+	target := NewMemoryStore().(*MemoryStore)
+	if err := target.LoadSnapshot(data); err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+
+	want, _ := source.GetRun(ctx, "run-1")
+	got, err := target.GetRun(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("GetRun() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetRun() = %+v, want %+v", got, want)
+	}
+
+	gotExec, err := target.GetStepExecution(ctx, "run-1", "discover")
+	if err != nil {
+		t.Fatalf("GetStepExecution() failed: %v", err)
+	}
+	if gotExec.Status != gorkflow.StepStatusCompleted || len(gotExec.Attempts) != 1 {
+		t.Errorf("GetStepExecution() = %+v", gotExec)
+	}
+
+	gotOutput, err := target.LoadStepOutput(ctx, "run-1", "discover")
+	if err != nil {
+		t.Fatalf("LoadStepOutput() failed: %v", err)
+	}
+	if string(gotOutput) != string(output) {
+		t.Errorf("LoadStepOutput() = %s, want %s", gotOutput, output)
+	}
+
+	state, err := target.GetAllState(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("GetAllState() failed: %v", err)
+	}
+	if string(state["cursor"]) != `"page-2"` || string(state["processed"]) != "3" {
+		t.Errorf("GetAllState() = %v", state)
+	}
+}
+
+func TestMemoryStore_LoadSnapshot_Replaces(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore().(*MemoryStore)
+	if err := store.CreateRun(ctx, &gorkflow.WorkflowRun{RunID: "stale"}); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+
+	if err := store.LoadSnapshot([]byte(`{}`)); err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	if _, err := store.GetRun(ctx, "stale"); err == nil {
+		t.Error("run from before the snapshot was kept")
+	}
+
+	// The store stays usable after loading an empty snapshot
+	if err := store.CreateRun(ctx, &gorkflow.WorkflowRun{RunID: "fresh"}); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+
+	if err := store.LoadSnapshot([]byte(`not json`)); err == nil {
+		t.Error("LoadSnapshot() should fail on invalid data")
+	}
+}
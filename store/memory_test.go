@@ -2,6 +2,9 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -81,6 +84,41 @@ func TestMemoryStore_GetRun_NotFound(t *testing.T) {
 	}
 }
 
+func TestMemoryStore_BatchGetRuns(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	for _, runID := range []string{"run-1", "run-2"} {
+		if err := store.CreateRun(ctx, &gorkflow.WorkflowRun{RunID: runID, WorkflowID: "test-workflow", Status: gorkflow.RunStatusPending}); err != nil {
+			t.Fatalf("CreateRun() failed: %v", err)
+		}
+	}
+
+	runs, err := store.BatchGetRuns(ctx, []string{"run-1", "missing", "run-2"})
+	if err != nil {
+		t.Fatalf("BatchGetRuns() failed: %v", err)
+	}
+
+	if len(runs) != 2 {
+		t.Fatalf("len(runs) = %d, want 2", len(runs))
+	}
+	for _, runID := range []string{"run-1", "run-2"} {
+		if run, ok := runs[runID]; !ok || run.RunID != runID {
+			t.Errorf("runs[%s] = %v, want run %s", runID, run, runID)
+		}
+	}
+	if _, ok := runs["missing"]; ok {
+		t.Error("missing run should not be in the result")
+	}
+
+	// Returned runs are copies
+	runs["run-1"].Status = gorkflow.RunStatusFailed
+	run, _ := store.GetRun(ctx, "run-1")
+	if run.Status != gorkflow.RunStatusPending {
+		t.Errorf("Status = %s, want %s", run.Status, gorkflow.RunStatusPending)
+	}
+}
+
 func TestMemoryStore_UpdateRun(t *testing.T) {
 	store := NewMemoryStore()
 	ctx := context.Background()
@@ -129,6 +167,43 @@ func TestMemoryStore_UpdateRun_NotFound(t *testing.T) {
 	}
 }
 
+func TestMemoryStore_UpdateRunProgress(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	run := &gorkflow.WorkflowRun{RunID: "run-1", WorkflowID: "wf", Status: gorkflow.RunStatusRunning, CreatedAt: time.Now()}
+	if err := store.CreateRun(ctx, run); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+
+	// Another engine pauses the run; the executing engine's stale copy still says RUNNING
+	if err := store.UpdateRunStatus(ctx, run.RunID, gorkflow.RunStatusPaused, nil); err != nil {
+		t.Fatalf("UpdateRunStatus() failed: %v", err)
+	}
+
+	run.Progress = 0.5
+	run.CurrentStep = "enrich"
+	run.TotalAttempts = 3
+	if err := store.UpdateRunProgress(ctx, run); err != nil {
+		t.Fatalf("UpdateRunProgress() failed: %v", err)
+	}
+
+	stored, err := store.GetRun(ctx, run.RunID)
+	if err != nil {
+		t.Fatalf("GetRun() failed: %v", err)
+	}
+	if stored.Status != gorkflow.RunStatusPaused {
+		t.Errorf("Status = %s, want PAUSED", stored.Status)
+	}
+	if stored.Progress != 0.5 || stored.CurrentStep != "enrich" || stored.TotalAttempts != 3 {
+		t.Errorf("progress = %v, %q, %d, want 0.5, enrich, 3", stored.Progress, stored.CurrentStep, stored.TotalAttempts)
+	}
+
+	if err := store.UpdateRunProgress(ctx, &gorkflow.WorkflowRun{RunID: "missing"}); err == nil {
+		t.Error("UpdateRunProgress() of a missing run succeeded")
+	}
+}
+
 func TestMemoryStore_UpdateRunStatus(t *testing.T) {
 	store := NewMemoryStore()
 	ctx := context.Background()
@@ -170,6 +245,39 @@ func TestMemoryStore_UpdateRunStatus(t *testing.T) {
 	} else if retrieved.Error.Code != "TEST_ERROR" {
 		t.Errorf("Error code = %s, want TEST_ERROR", retrieved.Error.Code)
 	}
+
+	if n := len(retrieved.StatusHistory); n != 1 || retrieved.StatusHistory[n-1].Status != gorkflow.RunStatusFailed {
+		t.Errorf("StatusHistory = %v, want the transition to FAILED", retrieved.StatusHistory)
+	}
+}
+
+func TestMemoryStore_UpdateRunStatus_Finished(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	run := &gorkflow.WorkflowRun{
+		RunID:      "test-run-1",
+		WorkflowID: "test-workflow",
+		Status:     gorkflow.RunStatusCompleted,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := store.CreateRun(ctx, run); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+
+	err := store.UpdateRunStatus(ctx, run.RunID, gorkflow.RunStatusPaused, nil)
+	if !errors.Is(err, gorkflow.ErrRunFinished) {
+		t.Fatalf("UpdateRunStatus() error = %v, want ErrRunFinished", err)
+	}
+
+	retrieved, err := store.GetRun(ctx, run.RunID)
+	if err != nil {
+		t.Fatalf("GetRun() failed: %v", err)
+	}
+	if retrieved.Status != gorkflow.RunStatusCompleted {
+		t.Errorf("Status = %s, want %s", retrieved.Status, gorkflow.RunStatusCompleted)
+	}
 }
 
 func TestMemoryStore_ListRuns(t *testing.T) {
@@ -264,6 +372,233 @@ func TestMemoryStore_ListRuns(t *testing.T) {
 	}
 }
 
+func TestMemoryStore_ListRuns_CreatedWindow(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	base := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{-48 * time.Hour, -time.Hour, 0, time.Hour, 48 * time.Hour} {
+		run := &gorkflow.WorkflowRun{
+			RunID:      fmt.Sprintf("run-%d", i),
+			WorkflowID: "workflow-1",
+			Status:     gorkflow.RunStatusCompleted,
+			CreatedAt:  base.Add(offset),
+			UpdatedAt:  base.Add(offset),
+		}
+		if err := store.CreateRun(ctx, run); err != nil {
+			t.Fatalf("CreateRun() failed: %v", err)
+		}
+	}
+
+	after := base.Add(-time.Hour)
+	before := base.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		filter gorkflow.RunFilter
+		want   int
+	}{
+		{
+			name:   "created after (inclusive)",
+			filter: gorkflow.RunFilter{CreatedAfter: &after},
+			want:   4,
+		},
+		{
+			name:   "created before (inclusive)",
+			filter: gorkflow.RunFilter{CreatedBefore: &before},
+			want:   4,
+		},
+		{
+			name:   "created between",
+			filter: gorkflow.RunFilter{CreatedAfter: &after, CreatedBefore: &before},
+			want:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := store.ListRuns(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("ListRuns() failed: %v", err)
+			}
+
+			if len(results) != tt.want {
+				t.Errorf("ListRuns() returned %d runs, want %d", len(results), tt.want)
+			}
+			for _, run := range results {
+				if (tt.filter.CreatedAfter != nil && run.CreatedAt.Before(after)) ||
+					(tt.filter.CreatedBefore != nil && run.CreatedAt.After(before)) {
+					t.Errorf("ListRuns() returned run %s created at %v outside the window", run.RunID, run.CreatedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStore_CountRuns(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	base := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	runs := []struct {
+		workflowID string
+		status     gorkflow.RunStatus
+		offset     time.Duration
+	}{
+		{"workflow-1", gorkflow.RunStatusCompleted, -48 * time.Hour},
+		{"workflow-1", gorkflow.RunStatusFailed, -time.Hour},
+		{"workflow-1", gorkflow.RunStatusRunning, 0},
+		{"workflow-2", gorkflow.RunStatusCompleted, time.Hour},
+		{"workflow-2", gorkflow.RunStatusCompleted, 48 * time.Hour},
+	}
+	for i, r := range runs {
+		run := &gorkflow.WorkflowRun{
+			RunID:      fmt.Sprintf("run-%d", i),
+			WorkflowID: r.workflowID,
+			Status:     r.status,
+			CreatedAt:  base.Add(r.offset),
+			UpdatedAt:  base.Add(r.offset),
+		}
+		if err := store.CreateRun(ctx, run); err != nil {
+			t.Fatalf("CreateRun() failed: %v", err)
+		}
+	}
+
+	after := base.Add(-time.Hour)
+	before := base.Add(time.Hour)
+	completed := gorkflow.RunStatusCompleted
+
+	tests := []struct {
+		name   string
+		filter gorkflow.RunFilter
+		want   int
+	}{
+		{name: "all runs", filter: gorkflow.RunFilter{}, want: 5},
+		{name: "by workflow", filter: gorkflow.RunFilter{WorkflowID: "workflow-1"}, want: 3},
+		{name: "by workflow and status", filter: gorkflow.RunFilter{WorkflowID: "workflow-2", Status: &completed}, want: 2},
+		{name: "created between", filter: gorkflow.RunFilter{CreatedAfter: &after, CreatedBefore: &before}, want: 3},
+		{name: "by workflow created after", filter: gorkflow.RunFilter{WorkflowID: "workflow-1", CreatedAfter: &after}, want: 2},
+		{name: "limit is ignored", filter: gorkflow.RunFilter{WorkflowID: "workflow-1", Limit: 1}, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := store.CountRuns(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("CountRuns() failed: %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("CountRuns() = %d, want %d", count, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStore_ListRuns_Tags(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	tags := []map[string]string{
+		{"env": "prod", "team": "growth"},
+		{"env": "prod", "team": "billing"},
+		{"env": "staging", "team": "growth"},
+		nil,
+	}
+	for i, runTags := range tags {
+		run := &gorkflow.WorkflowRun{
+			RunID:      fmt.Sprintf("run-%d", i),
+			WorkflowID: "workflow-1",
+			Status:     gorkflow.RunStatusCompleted,
+			Tags:       runTags,
+			CreatedAt:  time.Now().Add(time.Duration(i) * time.Second),
+		}
+		if err := store.CreateRun(ctx, run); err != nil {
+			t.Fatalf("CreateRun() failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		tags map[string]string
+		want []string
+	}{
+		{name: "single tag", tags: map[string]string{"env": "prod"}, want: []string{"run-0", "run-1"}},
+		{name: "multiple tags", tags: map[string]string{"env": "prod", "team": "growth"}, want: []string{"run-0"}},
+		{name: "no match", tags: map[string]string{"env": "dev"}, want: nil},
+		{name: "no tags", tags: nil, want: []string{"run-0", "run-1", "run-2", "run-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := gorkflow.RunFilter{WorkflowID: "workflow-1", Tags: tt.tags}
+			results, err := store.ListRuns(ctx, filter)
+			if err != nil {
+				t.Fatalf("ListRuns() failed: %v", err)
+			}
+
+			var got []string
+			for _, run := range results {
+				got = append(got, run.RunID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ListRuns() = %v, want %v", got, tt.want)
+			}
+
+			count, err := store.CountRuns(ctx, filter)
+			if err != nil {
+				t.Fatalf("CountRuns() failed: %v", err)
+			}
+			if count != len(tt.want) {
+				t.Errorf("CountRuns() = %d, want %d", count, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMemoryStore_ListRuns_SortDescending(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	base := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{time.Hour, -time.Hour, 3 * time.Hour, 0} {
+		run := &gorkflow.WorkflowRun{
+			RunID:      fmt.Sprintf("run-%d", i),
+			WorkflowID: "workflow-1",
+			Status:     gorkflow.RunStatusCompleted,
+			CreatedAt:  base.Add(offset),
+			UpdatedAt:  base.Add(offset),
+		}
+		if err := store.CreateRun(ctx, run); err != nil {
+			t.Fatalf("CreateRun() failed: %v", err)
+		}
+	}
+
+	runs, err := store.ListRuns(ctx, gorkflow.RunFilter{SortDescending: true})
+	if err != nil {
+		t.Fatalf("ListRuns() failed: %v", err)
+	}
+	if len(runs) != 4 {
+		t.Fatalf("ListRuns() returned %d runs, want 4", len(runs))
+	}
+	if runs[0].RunID != "run-2" {
+		t.Errorf("runs[0].RunID = %q, want most recent run-2", runs[0].RunID)
+	}
+	for i := 1; i < len(runs); i++ {
+		if runs[i].CreatedAt.After(runs[i-1].CreatedAt) {
+			t.Errorf("runs not in descending order at index %d", i)
+		}
+	}
+
+	// Limit applies after ordering
+	runs, err = store.ListRuns(ctx, gorkflow.RunFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("ListRuns() failed: %v", err)
+	}
+	if len(runs) != 1 || runs[0].RunID != "run-1" {
+		t.Errorf("ListRuns() with limit returned %v, want oldest run-1", runs)
+	}
+}
+
 func TestMemoryStore_CreateStepExecution(t *testing.T) {
 	store := NewMemoryStore()
 	ctx := context.Background()
@@ -398,6 +733,48 @@ func TestMemoryStore_ListStepExecutions(t *testing.T) {
 	}
 }
 
+func TestMemoryStore_StepExecutionCopies(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	exec := &gorkflow.StepExecution{
+		RunID:    "test-run-1",
+		StepID:   "step-1",
+		Status:   gorkflow.StepStatusFailed,
+		Error:    &gorkflow.StepError{Message: "boom", Code: "TEST_ERROR"},
+		Attempts: []gorkflow.AttemptRecord{{Attempt: 1, Error: "boom"}},
+	}
+	if err := store.CreateStepExecution(ctx, exec); err != nil {
+		t.Fatalf("CreateStepExecution() failed: %v", err)
+	}
+
+	got, err := store.GetStepExecution(ctx, "test-run-1", "step-1")
+	if err != nil {
+		t.Fatalf("GetStepExecution() failed: %v", err)
+	}
+	got.Attempts[0].Error = "changed"
+	got.Error.Message = "changed"
+
+	listed, err := store.ListStepExecutions(ctx, "test-run-1")
+	if err != nil {
+		t.Fatalf("ListStepExecutions() failed: %v", err)
+	}
+	listed[0].Attempts[0].Error = "changed"
+	listed[0].Error.Message = "changed"
+
+	// Changes to returned executions do not reach the stored one
+	stored, err := store.GetStepExecution(ctx, "test-run-1", "step-1")
+	if err != nil {
+		t.Fatalf("GetStepExecution() failed: %v", err)
+	}
+	if stored.Attempts[0].Error != "boom" {
+		t.Errorf("Attempts[0].Error = %q, want boom", stored.Attempts[0].Error)
+	}
+	if stored.Error.Message != "boom" {
+		t.Errorf("Error.Message = %q, want boom", stored.Error.Message)
+	}
+}
+
 func TestMemoryStore_ListStepExecutions_EmptyRun(t *testing.T) {
 	store := NewMemoryStore()
 	ctx := context.Background()
@@ -445,6 +822,114 @@ func TestMemoryStore_SaveAndLoadStepOutput(t *testing.T) {
 	}
 }
 
+func TestMemoryStore_CommitStepResult(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	exec := &gorkflow.StepExecution{
+		RunID:  "run-1",
+		StepID: "step-1",
+		Status: gorkflow.StepStatusCompleted,
+	}
+	if err := store.CommitStepResult(ctx, exec, []byte(`{"result":"ok"}`)); err != nil {
+		t.Fatalf("CommitStepResult() failed: %v", err)
+	}
+
+	stored, err := store.GetStepExecution(ctx, "run-1", "step-1")
+	if err != nil {
+		t.Fatalf("GetStepExecution() failed: %v", err)
+	}
+	if stored.Status != gorkflow.StepStatusCompleted {
+		t.Errorf("Status = %v, want %v", stored.Status, gorkflow.StepStatusCompleted)
+	}
+
+	output, err := store.LoadStepOutput(ctx, "run-1", "step-1")
+	if err != nil {
+		t.Fatalf("LoadStepOutput() failed: %v", err)
+	}
+	if string(output) != `{"result":"ok"}` {
+		t.Errorf("output = %s, want %s", output, `{"result":"ok"}`)
+	}
+}
+
+func TestMemoryStore_CommitStepResult_Failure(t *testing.T) {
+	// An unsupported codec fails the commit part way through
+	store := NewMemoryStore(WithMemoryCompression("bogus"))
+	ctx := context.Background()
+
+	exec := &gorkflow.StepExecution{RunID: "run-1", StepID: "step-1", Status: gorkflow.StepStatusCompleted}
+	if err := store.CommitStepResult(ctx, exec, []byte(`{}`)); err == nil {
+		t.Fatal("CommitStepResult() should fail")
+	}
+
+	if _, err := store.GetStepExecution(ctx, "run-1", "step-1"); err == nil {
+		t.Error("step execution was written by a failed commit")
+	}
+	if _, err := store.LoadStepOutput(ctx, "run-1", "step-1"); err == nil {
+		t.Error("step output was written by a failed commit")
+	}
+}
+
+func TestMemoryStore_SaveStepOutputs(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	outputs := map[string][]byte{
+		"branch-a": []byte(`{"a":1}`),
+		"branch-b": []byte(`{"b":2}`),
+		"branch-c": []byte(`{"c":3}`),
+	}
+	if err := store.SaveStepOutputs(ctx, "test-run-1", outputs); err != nil {
+		t.Fatalf("SaveStepOutputs() failed: %v", err)
+	}
+
+	for stepID, want := range outputs {
+		got, err := store.LoadStepOutput(ctx, "test-run-1", stepID)
+		if err != nil {
+			t.Fatalf("LoadStepOutput(%s) failed: %v", stepID, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("LoadStepOutput(%s) = %s, want %s", stepID, got, want)
+		}
+	}
+
+	// The store keeps its own copy
+	outputs["branch-a"][2] = 'x'
+	got, _ := store.LoadStepOutput(ctx, "test-run-1", "branch-a")
+	if string(got) != `{"a":1}` {
+		t.Errorf("stored output changed with the caller's slice: %s", got)
+	}
+}
+
+func TestMemoryStore_BatchLoadStepOutputs(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	for _, stepID := range []string{"step-1", "step-2"} {
+		if err := store.SaveStepOutput(ctx, "test-run-1", stepID, []byte(`"`+stepID+`"`)); err != nil {
+			t.Fatalf("SaveStepOutput() failed: %v", err)
+		}
+	}
+
+	loader := store.(gorkflow.StepOutputBatchLoader)
+	outputs, err := loader.BatchLoadStepOutputs(ctx, "test-run-1", []string{"step-1", "step-2", "skipped"})
+	if err != nil {
+		t.Fatalf("BatchLoadStepOutputs() failed: %v", err)
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("len(outputs) = %d, want 2", len(outputs))
+	}
+	if got := string(outputs["step-2"]); got != `"step-2"` {
+		t.Errorf("outputs[step-2] = %s, want %q", got, `"step-2"`)
+	}
+
+	// Unknown runs have no outputs
+	outputs, err = loader.BatchLoadStepOutputs(ctx, "non-existent-run", []string{"step-1"})
+	if err != nil || len(outputs) != 0 {
+		t.Errorf("BatchLoadStepOutputs() = %v, %v, want no outputs", outputs, err)
+	}
+}
+
 func TestMemoryStore_LoadStepOutput_NotFound(t *testing.T) {
 	store := NewMemoryStore()
 	ctx := context.Background()
@@ -725,3 +1210,241 @@ func TestMemoryStore_ThreadSafety(t *testing.T) {
 		<-done
 	}
 }
+
+func TestMemoryStore_DeleteRun(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	for _, runID := range []string{"run-1", "run-2"} {
+		run := &gorkflow.WorkflowRun{
+			RunID:      runID,
+			WorkflowID: "test-workflow",
+			Status:     gorkflow.RunStatusCompleted,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		if err := store.CreateRun(ctx, run); err != nil {
+			t.Fatalf("CreateRun() failed: %v", err)
+		}
+		if err := store.CreateStepExecution(ctx, &gorkflow.StepExecution{RunID: runID, StepID: "step-1"}); err != nil {
+			t.Fatalf("CreateStepExecution() failed: %v", err)
+		}
+		if err := store.SaveStepOutput(ctx, runID, "step-1", []byte(`{}`)); err != nil {
+			t.Fatalf("SaveStepOutput() failed: %v", err)
+		}
+		if err := store.SaveState(ctx, runID, "key", []byte(`1`)); err != nil {
+			t.Fatalf("SaveState() failed: %v", err)
+		}
+	}
+
+	if err := store.DeleteRun(ctx, "run-1"); err != nil {
+		t.Fatalf("DeleteRun() failed: %v", err)
+	}
+
+	// Everything for run-1 is gone
+	if _, err := store.GetRun(ctx, "run-1"); err == nil {
+		t.Error("GetRun() should fail for deleted run")
+	}
+	execs, err := store.ListStepExecutions(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("ListStepExecutions() failed: %v", err)
+	}
+	if len(execs) != 0 {
+		t.Errorf("ListStepExecutions() returned %d executions, want 0", len(execs))
+	}
+	if _, err := store.LoadStepOutput(ctx, "run-1", "step-1"); err == nil {
+		t.Error("LoadStepOutput() should fail for deleted run")
+	}
+	if _, err := store.LoadState(ctx, "run-1", "key"); err == nil {
+		t.Error("LoadState() should fail for deleted run")
+	}
+
+	// run-2 is untouched
+	if _, err := store.GetRun(ctx, "run-2"); err != nil {
+		t.Errorf("GetRun() failed for untouched run: %v", err)
+	}
+	if _, err := store.GetStepExecution(ctx, "run-2", "step-1"); err != nil {
+		t.Errorf("GetStepExecution() failed for untouched run: %v", err)
+	}
+	if _, err := store.LoadStepOutput(ctx, "run-2", "step-1"); err != nil {
+		t.Errorf("LoadStepOutput() failed for untouched run: %v", err)
+	}
+	if _, err := store.LoadState(ctx, "run-2", "key"); err != nil {
+		t.Errorf("LoadState() failed for untouched run: %v", err)
+	}
+}
+
+func TestMemoryStore_DeleteRun_NotFound(t *testing.T) {
+	store := NewMemoryStore()
+
+	if err := store.DeleteRun(context.Background(), "non-existent"); err == nil {
+		t.Error("DeleteRun() should have failed for non-existent run")
+	}
+}
+
+func TestMemoryStore_ScheduledRuns(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	schedule := map[string]time.Duration{
+		"due-late":  -time.Minute,
+		"due-early": -time.Hour,
+		"future":    time.Hour,
+	}
+	for runID, offset := range schedule {
+		scheduledAt := now.Add(offset)
+		run := &gorkflow.WorkflowRun{
+			RunID:       runID,
+			WorkflowID:  "test-workflow",
+			Status:      gorkflow.RunStatusPending,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			ScheduledAt: &scheduledAt,
+		}
+		if err := store.CreateRun(ctx, run); err != nil {
+			t.Fatalf("CreateRun() failed: %v", err)
+		}
+	}
+
+	due, err := store.ListDueRuns(ctx, now, 0)
+	if err != nil {
+		t.Fatalf("ListDueRuns() failed: %v", err)
+	}
+	if len(due) != 2 || due[0].RunID != "due-early" || due[1].RunID != "due-late" {
+		t.Fatalf("ListDueRuns() = %v, want [due-early due-late]", due)
+	}
+
+	claimed, err := store.ClaimScheduledRun(ctx, "due-early")
+	if err != nil || !claimed {
+		t.Fatalf("ClaimScheduledRun() = %v, %v, want true", claimed, err)
+	}
+
+	// A run can only be claimed once
+	claimed, err = store.ClaimScheduledRun(ctx, "due-early")
+	if err != nil || claimed {
+		t.Errorf("second ClaimScheduledRun() = %v, %v, want false", claimed, err)
+	}
+
+	due, err = store.ListDueRuns(ctx, now, 0)
+	if err != nil {
+		t.Fatalf("ListDueRuns() failed: %v", err)
+	}
+	if len(due) != 1 || due[0].RunID != "due-late" {
+		t.Errorf("ListDueRuns() after claim = %v, want [due-late]", due)
+	}
+
+	if _, err := store.ClaimScheduledRun(ctx, "non-existent"); err == nil {
+		t.Error("ClaimScheduledRun() should have failed for non-existent run")
+	}
+}
+
+func TestMemoryStore_IncrementState_Concurrent(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if err := store.CreateRun(ctx, &gorkflow.WorkflowRun{RunID: "run-1"}); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+
+	// Each parallel step has its own accessor over the shared store
+	var wg sync.WaitGroup
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		go func(delta int64) {
+			defer wg.Done()
+			if _, err := gorkflow.NewStateAccessor("run-1", store).Increment("processed", delta); err != nil {
+				t.Errorf("Increment() failed: %v", err)
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	total, err := gorkflow.GetTyped[int64](gorkflow.NewStateAccessor("run-1", store), "processed")
+	if err != nil {
+		t.Fatalf("GetTyped() failed: %v", err)
+	}
+	if total != 55 {
+		t.Errorf("processed = %d, want 55", total)
+	}
+}
+
+func TestMemoryStore_IncrementState_ExistingValue(t *testing.T) {
+	store := NewMemoryStore(WithMemoryCompression(CompressionGzip))
+	ctx := context.Background()
+
+	if err := store.SaveState(ctx, "run-1", "count", []byte(`5`)); err != nil {
+		t.Fatalf("SaveState() failed: %v", err)
+	}
+	total, err := store.IncrementState(ctx, "run-1", "count", -2)
+	if err != nil {
+		t.Fatalf("IncrementState() failed: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("IncrementState() = %d, want 3", total)
+	}
+
+	if err := store.SaveState(ctx, "run-1", "name", []byte(`"acme"`)); err != nil {
+		t.Fatalf("SaveState() failed: %v", err)
+	}
+	if _, err := store.IncrementState(ctx, "run-1", "name", 1); err == nil {
+		t.Error("IncrementState() should fail for a non-integer value")
+	}
+}
+
+func TestMemoryStore_ListState(t *testing.T) {
+	store := NewMemoryStore()
+
+	state := gorkflow.NewStateAccessor("run-1", store)
+	for key, value := range map[string]int{"item:1": 1, "item:2": 2, "other:1": 3} {
+		if err := state.Set(key, value); err != nil {
+			t.Fatalf("Set() failed: %v", err)
+		}
+	}
+
+	items, err := state.List("item:")
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("List() returned %d items, want 2", len(items))
+	}
+	if string(items["item:1"]) != "1" || string(items["item:2"]) != "2" {
+		t.Errorf("List() = %v", items)
+	}
+
+	// An empty prefix matches everything
+	all, err := state.List("")
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("List(\"\") returned %d items, want 3", len(all))
+	}
+}
+
+func TestMemoryStore_LeaseRun(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	leased, err := store.LeaseRun(ctx, "run-1", "engine-a", time.Minute)
+	if err != nil || !leased {
+		t.Fatalf("LeaseRun() = %v, %v, want true", leased, err)
+	}
+
+	// Only the holder can renew while the lease is live
+	leased, err = store.LeaseRun(ctx, "run-1", "engine-b", time.Minute)
+	if err != nil || leased {
+		t.Errorf("LeaseRun() by second owner = %v, %v, want false", leased, err)
+	}
+	leased, err = store.LeaseRun(ctx, "run-1", "engine-a", time.Millisecond)
+	if err != nil || !leased {
+		t.Errorf("LeaseRun() renewal = %v, %v, want true", leased, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	leased, err = store.LeaseRun(ctx, "run-1", "engine-b", time.Minute)
+	if err != nil || !leased {
+		t.Errorf("LeaseRun() after expiry = %v, %v, want true", leased, err)
+	}
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/sicko7947/gorkflow"
+)
+
+// MetricsRecorder receives the name of a store method (e.g. "CreateRun"), how long the call took and the error it returned
+type MetricsRecorder func(op string, d time.Duration, err error)
+
+// metricsStore times the calls of the store it wraps
+type metricsStore struct {
+	inner    gorkflow.WorkflowStore
+	recorder MetricsRecorder
+}
+
+// WithMetrics wraps any WorkflowStore so that every call is timed and reported to recorder,
+// e.g. to export per-operation latency histograms. Wrap a WithRetry store to time calls
+// including their retries, or wrap the inner store with it to time each attempt.
+func WithMetrics(inner gorkflow.WorkflowStore, recorder MetricsRecorder) gorkflow.WorkflowStore {
+	return &metricsStore{inner: inner, recorder: recorder}
+}
+
+// measureCall times call and reports it as op
+func measureCall[T any](s *metricsStore, op string, call func() (T, error)) (T, error) {
+	start := time.Now()
+	result, err := call()
+	s.recorder(op, time.Since(start), err)
+	return result, err
+}
+
+// measure is measureCall for calls that only return an error
+func (s *metricsStore) measure(op string, call func() error) error {
+	_, err := measureCall(s, op, func() (struct{}, error) {
+		return struct{}{}, call()
+	})
+	return err
+}
+
+// Workflow runs
+
+func (s *metricsStore) CreateRun(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	return s.measure("CreateRun", func() error { return s.inner.CreateRun(ctx, run) })
+}
+
+func (s *metricsStore) GetRun(ctx context.Context, runID string) (*gorkflow.WorkflowRun, error) {
+	return measureCall(s, "GetRun", func() (*gorkflow.WorkflowRun, error) { return s.inner.GetRun(ctx, runID) })
+}
+
+func (s *metricsStore) BatchGetRuns(ctx context.Context, runIDs []string) (map[string]*gorkflow.WorkflowRun, error) {
+	return measureCall(s, "BatchGetRuns", func() (map[string]*gorkflow.WorkflowRun, error) { return s.inner.BatchGetRuns(ctx, runIDs) })
+}
+
+func (s *metricsStore) UpdateRun(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	return s.measure("UpdateRun", func() error { return s.inner.UpdateRun(ctx, run) })
+}
+
+func (s *metricsStore) UpdateRunProgress(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	return s.measure("UpdateRunProgress", func() error { return s.inner.UpdateRunProgress(ctx, run) })
+}
+
+func (s *metricsStore) UpdateRunStatus(ctx context.Context, runID string, status gorkflow.RunStatus, err *gorkflow.WorkflowError) error {
+	return s.measure("UpdateRunStatus", func() error { return s.inner.UpdateRunStatus(ctx, runID, status, err) })
+}
+
+func (s *metricsStore) ListRuns(ctx context.Context, filter gorkflow.RunFilter) ([]*gorkflow.WorkflowRun, error) {
+	return measureCall(s, "ListRuns", func() ([]*gorkflow.WorkflowRun, error) { return s.inner.ListRuns(ctx, filter) })
+}
+
+func (s *metricsStore) DeleteRun(ctx context.Context, runID string) error {
+	return s.measure("DeleteRun", func() error { return s.inner.DeleteRun(ctx, runID) })
+}
+
+// Step executions
+
+func (s *metricsStore) CreateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
+	return s.measure("CreateStepExecution", func() error { return s.inner.CreateStepExecution(ctx, exec) })
+}
+
+func (s *metricsStore) GetStepExecution(ctx context.Context, runID, stepID string) (*gorkflow.StepExecution, error) {
+	return measureCall(s, "GetStepExecution", func() (*gorkflow.StepExecution, error) { return s.inner.GetStepExecution(ctx, runID, stepID) })
+}
+
+func (s *metricsStore) UpdateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
+	return s.measure("UpdateStepExecution", func() error { return s.inner.UpdateStepExecution(ctx, exec) })
+}
+
+func (s *metricsStore) ListStepExecutions(ctx context.Context, runID string) ([]*gorkflow.StepExecution, error) {
+	return measureCall(s, "ListStepExecutions", func() ([]*gorkflow.StepExecution, error) { return s.inner.ListStepExecutions(ctx, runID) })
+}
+
+// Step outputs
+
+func (s *metricsStore) SaveStepOutput(ctx context.Context, runID, stepID string, output []byte) error {
+	return s.measure("SaveStepOutput", func() error { return s.inner.SaveStepOutput(ctx, runID, stepID, output) })
+}
+
+func (s *metricsStore) LoadStepOutput(ctx context.Context, runID, stepID string) ([]byte, error) {
+	return measureCall(s, "LoadStepOutput", func() ([]byte, error) { return s.inner.LoadStepOutput(ctx, runID, stepID) })
+}
+
+func (s *metricsStore) SaveStepOutputs(ctx context.Context, runID string, outputs map[string][]byte) error {
+	return s.measure("SaveStepOutputs", func() error { return s.inner.SaveStepOutputs(ctx, runID, outputs) })
+}
+
+// BatchLoadStepOutputs is timed like any other call. Inner stores that are not a
+// gorkflow.StepOutputBatchLoader are read one step at a time, each load timed as LoadStepOutput.
+func (s *metricsStore) BatchLoadStepOutputs(ctx context.Context, runID string, stepIDs []string) (map[string][]byte, error) {
+	loader, ok := s.inner.(gorkflow.StepOutputBatchLoader)
+	if !ok {
+		return loadEachStepOutput(ctx, s, runID, stepIDs)
+	}
+	return measureCall(s, "BatchLoadStepOutputs", func() (map[string][]byte, error) { return loader.BatchLoadStepOutputs(ctx, runID, stepIDs) })
+}
+
+func (s *metricsStore) CommitStepResult(ctx context.Context, exec *gorkflow.StepExecution, output []byte) error {
+	return s.measure("CommitStepResult", func() error { return s.inner.CommitStepResult(ctx, exec, output) })
+}
+
+// Workflow state
+
+func (s *metricsStore) SaveState(ctx context.Context, runID, key string, value []byte) error {
+	return s.measure("SaveState", func() error { return s.inner.SaveState(ctx, runID, key, value) })
+}
+
+func (s *metricsStore) LoadState(ctx context.Context, runID, key string) ([]byte, error) {
+	return measureCall(s, "LoadState", func() ([]byte, error) { return s.inner.LoadState(ctx, runID, key) })
+}
+
+func (s *metricsStore) DeleteState(ctx context.Context, runID, key string) error {
+	return s.measure("DeleteState", func() error { return s.inner.DeleteState(ctx, runID, key) })
+}
+
+func (s *metricsStore) GetAllState(ctx context.Context, runID string) (map[string][]byte, error) {
+	return measureCall(s, "GetAllState", func() (map[string][]byte, error) { return s.inner.GetAllState(ctx, runID) })
+}
+
+func (s *metricsStore) ListState(ctx context.Context, runID, prefix string) (map[string][]byte, error) {
+	return measureCall(s, "ListState", func() (map[string][]byte, error) { return s.inner.ListState(ctx, runID, prefix) })
+}
+
+func (s *metricsStore) IncrementState(ctx context.Context, runID, key string, delta int64) (int64, error) {
+	return measureCall(s, "IncrementState", func() (int64, error) { return s.inner.IncrementState(ctx, runID, key, delta) })
+}
+
+// Queries
+
+func (s *metricsStore) CountRunsByStatus(ctx context.Context, resourceID string, status gorkflow.RunStatus) (int, error) {
+	return measureCall(s, "CountRunsByStatus", func() (int, error) { return s.inner.CountRunsByStatus(ctx, resourceID, status) })
+}
+
+func (s *metricsStore) CountRuns(ctx context.Context, filter gorkflow.RunFilter) (int, error) {
+	return measureCall(s, "CountRuns", func() (int, error) { return s.inner.CountRuns(ctx, filter) })
+}
+
+// Scheduled runs
+
+func (s *metricsStore) ListDueRuns(ctx context.Context, dueBefore time.Time, limit int) ([]*gorkflow.WorkflowRun, error) {
+	return measureCall(s, "ListDueRuns", func() ([]*gorkflow.WorkflowRun, error) { return s.inner.ListDueRuns(ctx, dueBefore, limit) })
+}
+
+func (s *metricsStore) ClaimScheduledRun(ctx context.Context, runID string) (bool, error) {
+	return measureCall(s, "ClaimScheduledRun", func() (bool, error) { return s.inner.ClaimScheduledRun(ctx, runID) })
+}
+
+// Run ownership
+
+func (s *metricsStore) LeaseRun(ctx context.Context, runID, owner string, ttl time.Duration) (bool, error) {
+	return measureCall(s, "LeaseRun", func() (bool, error) { return s.inner.LeaseRun(ctx, runID, owner, ttl) })
+}
+
+// Health
+
+// Ping is timed like any other call. Inner stores that are not a gorkflow.Pinger are assumed reachable.
+func (s *metricsStore) Ping(ctx context.Context) error {
+	pinger, ok := s.inner.(gorkflow.Pinger)
+	if !ok {
+		return nil
+	}
+	return s.measure("Ping", func() error { return pinger.Ping(ctx) })
+}
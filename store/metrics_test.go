@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/sicko7947/gorkflow"
+)
+
+// recordedCall is one report made to a MetricsRecorder
+type recordedCall struct {
+	op  string
+	d   time.Duration
+	err error
+}
+
+func TestWithMetrics(t *testing.T) {
+	var calls []recordedCall
+	wfStore := WithMetrics(NewMemoryStore(), func(op string, d time.Duration, err error) {
+		calls = append(calls, recordedCall{op: op, d: d, err: err})
+	})
+	ctx := context.Background()
+
+	if err := wfStore.CreateRun(ctx, &gorkflow.WorkflowRun{RunID: "run-1"}); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+	if _, err := wfStore.LoadStepOutput(ctx, "run-1", "missing"); err == nil {
+		t.Fatal("LoadStepOutput() should have failed for a missing output")
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("recorder called %d times, want 2", len(calls))
+	}
+	if calls[0].op != "CreateRun" || calls[0].err != nil || calls[0].d < 0 {
+		t.Errorf("first call = %+v, want CreateRun without error", calls[0])
+	}
+	if calls[1].op != "LoadStepOutput" || calls[1].err == nil {
+		t.Errorf("second call = %+v, want LoadStepOutput with its error", calls[1])
+	}
+}
+
+func TestWithMetrics_AroundRetries(t *testing.T) {
+	var ops []string
+	flaky := newFlakyStore(t, &types.ProvisionedThroughputExceededException{})
+	wfStore := WithMetrics(WithRetry(flaky, fastRetries), func(op string, d time.Duration, err error) {
+		ops = append(ops, op)
+		if err != nil {
+			t.Errorf("%s reported %v, want the retried call's success", op, err)
+		}
+	})
+
+	if _, err := wfStore.GetRun(context.Background(), "run-1"); err != nil {
+		t.Fatalf("GetRun() failed: %v", err)
+	}
+	if len(ops) != 1 || ops[0] != "GetRun" || flaky.calls != 2 {
+		t.Errorf("ops = %v after %d attempts, want one GetRun after 2", ops, flaky.calls)
+	}
+}
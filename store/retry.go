@@ -0,0 +1,286 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	awsretry "github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/sicko7947/gorkflow"
+)
+
+// RetryPolicy controls how a store wrapped with WithRetry retries failed calls
+type RetryPolicy struct {
+	MaxAttempts int              // Tries per call, including the first (default 3)
+	BaseDelay   time.Duration    // Wait before the first retry, doubled for each retry after it (default 50ms)
+	MaxDelay    time.Duration    // Cap on a single wait (default 2s)
+	Retryable   func(error) bool // Errors worth retrying (default IsTransientError)
+}
+
+// DefaultRetryPolicy is applied to the fields WithRetry is given as zero
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   50 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	Retryable:   IsTransientError,
+}
+
+// transientCancellationReasons are TransactWriteItems cancellation codes that may clear on retry
+var transientCancellationReasons = map[string]bool{
+	"ThrottlingError":               true,
+	"ProvisionedThroughputExceeded": true,
+	"TransactionConflict":           true,
+}
+
+// IsTransientError reports whether a store error is likely to clear on retry: AWS throttling
+// and timeout errors, DynamoDB internal server errors and transactions cancelled by
+// throttling or a conflicting transaction. Context cancellation is never transient.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var cancelled *types.TransactionCanceledException
+	if errors.As(err, &cancelled) {
+		for _, reason := range cancelled.CancellationReasons {
+			if code := reason.Code; code != nil && transientCancellationReasons[*code] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var internal *types.InternalServerError
+	if errors.As(err, &internal) {
+		return true
+	}
+
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		_, retryable := awsretry.DefaultRetryableErrorCodes[apiErr.ErrorCode()]
+		return retryable || isThrottleError(err)
+	}
+	return false
+}
+
+// isThrottleError reports whether the request was rejected by throttling, and so never applied
+func isThrottleError(err error) bool {
+	var apiErr interface{ ErrorCode() string }
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	_, throttled := awsretry.DefaultThrottleErrorCodes[apiErr.ErrorCode()]
+	return throttled
+}
+
+// retryingStore retries the calls of the store it wraps
+type retryingStore struct {
+	inner  gorkflow.WorkflowStore
+	policy RetryPolicy
+}
+
+// WithRetry wraps any WorkflowStore so that calls failing with a retryable error are retried with
+// exponential backoff. Zero fields of policy take their DefaultRetryPolicy values. Waits end
+// early when the call's context is done, returning the last error.
+func WithRetry(inner gorkflow.WorkflowStore, policy RetryPolicy) gorkflow.WorkflowStore {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = DefaultRetryPolicy.Retryable
+	}
+	return &retryingStore{inner: inner, policy: policy}
+}
+
+// retryCall runs call until it succeeds, fails with an error the policy does not retry,
+// or runs out of attempts
+func retryCall[T any](ctx context.Context, policy RetryPolicy, call func() (T, error)) (T, error) {
+	return retryCallIf(ctx, policy, policy.Retryable, call)
+}
+
+// retryCallIf is retryCall with the errors to retry given by retryable
+func retryCallIf[T any](ctx context.Context, policy RetryPolicy, retryable func(error) bool, call func() (T, error)) (T, error) {
+	delay := policy.BaseDelay
+	for attempt := 1; ; attempt++ {
+		result, err := call()
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return result, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+		delay = min(delay*2, policy.MaxDelay)
+	}
+}
+
+// retry is retryCall for calls that only return an error
+func (s *retryingStore) retry(ctx context.Context, call func() error) error {
+	_, err := retryCall(ctx, s.policy, func() (struct{}, error) {
+		return struct{}{}, call()
+	})
+	return err
+}
+
+// Workflow runs
+
+func (s *retryingStore) CreateRun(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	return s.retry(ctx, func() error { return s.inner.CreateRun(ctx, run) })
+}
+
+func (s *retryingStore) GetRun(ctx context.Context, runID string) (*gorkflow.WorkflowRun, error) {
+	return retryCall(ctx, s.policy, func() (*gorkflow.WorkflowRun, error) { return s.inner.GetRun(ctx, runID) })
+}
+
+func (s *retryingStore) BatchGetRuns(ctx context.Context, runIDs []string) (map[string]*gorkflow.WorkflowRun, error) {
+	return retryCall(ctx, s.policy, func() (map[string]*gorkflow.WorkflowRun, error) { return s.inner.BatchGetRuns(ctx, runIDs) })
+}
+
+func (s *retryingStore) UpdateRun(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	return s.retry(ctx, func() error { return s.inner.UpdateRun(ctx, run) })
+}
+
+func (s *retryingStore) UpdateRunProgress(ctx context.Context, run *gorkflow.WorkflowRun) error {
+	return s.retry(ctx, func() error { return s.inner.UpdateRunProgress(ctx, run) })
+}
+
+func (s *retryingStore) UpdateRunStatus(ctx context.Context, runID string, status gorkflow.RunStatus, err *gorkflow.WorkflowError) error {
+	return s.retry(ctx, func() error { return s.inner.UpdateRunStatus(ctx, runID, status, err) })
+}
+
+func (s *retryingStore) ListRuns(ctx context.Context, filter gorkflow.RunFilter) ([]*gorkflow.WorkflowRun, error) {
+	return retryCall(ctx, s.policy, func() ([]*gorkflow.WorkflowRun, error) { return s.inner.ListRuns(ctx, filter) })
+}
+
+func (s *retryingStore) DeleteRun(ctx context.Context, runID string) error {
+	return s.retry(ctx, func() error { return s.inner.DeleteRun(ctx, runID) })
+}
+
+// Step executions
+
+func (s *retryingStore) CreateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
+	return s.retry(ctx, func() error { return s.inner.CreateStepExecution(ctx, exec) })
+}
+
+func (s *retryingStore) GetStepExecution(ctx context.Context, runID, stepID string) (*gorkflow.StepExecution, error) {
+	return retryCall(ctx, s.policy, func() (*gorkflow.StepExecution, error) { return s.inner.GetStepExecution(ctx, runID, stepID) })
+}
+
+func (s *retryingStore) UpdateStepExecution(ctx context.Context, exec *gorkflow.StepExecution) error {
+	return s.retry(ctx, func() error { return s.inner.UpdateStepExecution(ctx, exec) })
+}
+
+func (s *retryingStore) ListStepExecutions(ctx context.Context, runID string) ([]*gorkflow.StepExecution, error) {
+	return retryCall(ctx, s.policy, func() ([]*gorkflow.StepExecution, error) { return s.inner.ListStepExecutions(ctx, runID) })
+}
+
+// Step outputs
+
+func (s *retryingStore) SaveStepOutput(ctx context.Context, runID, stepID string, output []byte) error {
+	return s.retry(ctx, func() error { return s.inner.SaveStepOutput(ctx, runID, stepID, output) })
+}
+
+func (s *retryingStore) LoadStepOutput(ctx context.Context, runID, stepID string) ([]byte, error) {
+	return retryCall(ctx, s.policy, func() ([]byte, error) { return s.inner.LoadStepOutput(ctx, runID, stepID) })
+}
+
+func (s *retryingStore) SaveStepOutputs(ctx context.Context, runID string, outputs map[string][]byte) error {
+	return s.retry(ctx, func() error { return s.inner.SaveStepOutputs(ctx, runID, outputs) })
+}
+
+// BatchLoadStepOutputs is retried like any other call. Inner stores that are not a
+// gorkflow.StepOutputBatchLoader are read one step at a time, each load retried.
+func (s *retryingStore) BatchLoadStepOutputs(ctx context.Context, runID string, stepIDs []string) (map[string][]byte, error) {
+	loader, ok := s.inner.(gorkflow.StepOutputBatchLoader)
+	if !ok {
+		return loadEachStepOutput(ctx, s, runID, stepIDs)
+	}
+	return retryCall(ctx, s.policy, func() (map[string][]byte, error) { return loader.BatchLoadStepOutputs(ctx, runID, stepIDs) })
+}
+
+// loadEachStepOutput is gorkflow.LoadStepOutputs with one LoadStepOutput per step. The wrapper
+// hides the decorator's own BatchLoadStepOutputs, which would otherwise be called again.
+func loadEachStepOutput(ctx context.Context, store gorkflow.WorkflowStore, runID string, stepIDs []string) (map[string][]byte, error) {
+	return gorkflow.LoadStepOutputs(ctx, struct{ gorkflow.WorkflowStore }{store}, runID, stepIDs)
+}
+
+func (s *retryingStore) CommitStepResult(ctx context.Context, exec *gorkflow.StepExecution, output []byte) error {
+	return s.retry(ctx, func() error { return s.inner.CommitStepResult(ctx, exec, output) })
+}
+
+// Workflow state
+
+func (s *retryingStore) SaveState(ctx context.Context, runID, key string, value []byte) error {
+	return s.retry(ctx, func() error { return s.inner.SaveState(ctx, runID, key, value) })
+}
+
+func (s *retryingStore) LoadState(ctx context.Context, runID, key string) ([]byte, error) {
+	return retryCall(ctx, s.policy, func() ([]byte, error) { return s.inner.LoadState(ctx, runID, key) })
+}
+
+func (s *retryingStore) DeleteState(ctx context.Context, runID, key string) error {
+	return s.retry(ctx, func() error { return s.inner.DeleteState(ctx, runID, key) })
+}
+
+func (s *retryingStore) GetAllState(ctx context.Context, runID string) (map[string][]byte, error) {
+	return retryCall(ctx, s.policy, func() (map[string][]byte, error) { return s.inner.GetAllState(ctx, runID) })
+}
+
+func (s *retryingStore) ListState(ctx context.Context, runID, prefix string) (map[string][]byte, error) {
+	return retryCall(ctx, s.policy, func() (map[string][]byte, error) { return s.inner.ListState(ctx, runID, prefix) })
+}
+
+// IncrementState is only retried after throttling: other failures may have been applied,
+// and a retry would count them twice
+func (s *retryingStore) IncrementState(ctx context.Context, runID, key string, delta int64) (int64, error) {
+	retryable := func(err error) bool { return s.policy.Retryable(err) && isThrottleError(err) }
+	return retryCallIf(ctx, s.policy, retryable, func() (int64, error) { return s.inner.IncrementState(ctx, runID, key, delta) })
+}
+
+// Queries
+
+func (s *retryingStore) CountRunsByStatus(ctx context.Context, resourceID string, status gorkflow.RunStatus) (int, error) {
+	return retryCall(ctx, s.policy, func() (int, error) { return s.inner.CountRunsByStatus(ctx, resourceID, status) })
+}
+
+func (s *retryingStore) CountRuns(ctx context.Context, filter gorkflow.RunFilter) (int, error) {
+	return retryCall(ctx, s.policy, func() (int, error) { return s.inner.CountRuns(ctx, filter) })
+}
+
+// Scheduled runs
+
+func (s *retryingStore) ListDueRuns(ctx context.Context, dueBefore time.Time, limit int) ([]*gorkflow.WorkflowRun, error) {
+	return retryCall(ctx, s.policy, func() ([]*gorkflow.WorkflowRun, error) { return s.inner.ListDueRuns(ctx, dueBefore, limit) })
+}
+
+func (s *retryingStore) ClaimScheduledRun(ctx context.Context, runID string) (bool, error) {
+	return retryCall(ctx, s.policy, func() (bool, error) { return s.inner.ClaimScheduledRun(ctx, runID) })
+}
+
+// Run ownership
+
+func (s *retryingStore) LeaseRun(ctx context.Context, runID, owner string, ttl time.Duration) (bool, error) {
+	return retryCall(ctx, s.policy, func() (bool, error) { return s.inner.LeaseRun(ctx, runID, owner, ttl) })
+}
+
+// Health
+
+// Ping pings the inner store without retries, so a failing store is reported promptly. Inner
+// stores that are not a gorkflow.Pinger are assumed reachable.
+func (s *retryingStore) Ping(ctx context.Context) error {
+	if pinger, ok := s.inner.(gorkflow.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/sicko7947/gorkflow"
+)
+
+// flakyStore fails each call with the queued errors before passing it to the memory store
+type flakyStore struct {
+	gorkflow.WorkflowStore
+	errs  []error
+	calls int
+}
+
+func (s *flakyStore) fail() error {
+	s.calls++
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+func (s *flakyStore) GetRun(ctx context.Context, runID string) (*gorkflow.WorkflowRun, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	return s.WorkflowStore.GetRun(ctx, runID)
+}
+
+func (s *flakyStore) IncrementState(ctx context.Context, runID, key string, delta int64) (int64, error) {
+	if err := s.fail(); err != nil {
+		return 0, err
+	}
+	return s.WorkflowStore.IncrementState(ctx, runID, key, delta)
+}
+
+func (s *flakyStore) LoadStepOutput(ctx context.Context, runID, stepID string) ([]byte, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	return s.WorkflowStore.LoadStepOutput(ctx, runID, stepID)
+}
+
+func newFlakyStore(t *testing.T, errs ...error) *flakyStore {
+	t.Helper()
+	inner := NewMemoryStore()
+	if err := inner.CreateRun(context.Background(), &gorkflow.WorkflowRun{RunID: "run-1"}); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+	return &flakyStore{WorkflowStore: inner, errs: errs}
+}
+
+var fastRetries = RetryPolicy{BaseDelay: time.Millisecond}
+
+func TestWithRetry_TransientErrors(t *testing.T) {
+	throttled := fmt.Errorf("failed to get workflow run: %w", &types.ProvisionedThroughputExceededException{})
+	flaky := newFlakyStore(t, throttled, throttled)
+	wfStore := WithRetry(flaky, fastRetries)
+
+	run, err := wfStore.GetRun(context.Background(), "run-1")
+	if err != nil {
+		t.Fatalf("GetRun() failed: %v", err)
+	}
+	if run.RunID != "run-1" {
+		t.Errorf("GetRun() = %s, want run-1", run.RunID)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("calls = %d, want 3", flaky.calls)
+	}
+}
+
+func TestWithRetry_GivesUp(t *testing.T) {
+	throttled := &types.RequestLimitExceeded{}
+	flaky := newFlakyStore(t, throttled, throttled, throttled, throttled)
+	wfStore := WithRetry(flaky, fastRetries)
+
+	if _, err := wfStore.GetRun(context.Background(), "run-1"); !errors.As(err, &throttled) {
+		t.Errorf("GetRun() error = %v, want the last throttling error", err)
+	}
+	if flaky.calls != DefaultRetryPolicy.MaxAttempts {
+		t.Errorf("calls = %d, want %d", flaky.calls, DefaultRetryPolicy.MaxAttempts)
+	}
+}
+
+func TestWithRetry_PermanentErrors(t *testing.T) {
+	flaky := newFlakyStore(t, errors.New("workflow run run-1 not found"))
+	wfStore := WithRetry(flaky, fastRetries)
+
+	if _, err := wfStore.GetRun(context.Background(), "run-1"); err == nil {
+		t.Error("GetRun() should have failed")
+	}
+	if flaky.calls != 1 {
+		t.Errorf("calls = %d, want 1", flaky.calls)
+	}
+}
+
+func TestWithRetry_BatchLoadWithoutBatchLoader(t *testing.T) {
+	flaky := newFlakyStore(t, &types.RequestLimitExceeded{})
+	if err := flaky.SaveStepOutput(context.Background(), "run-1", "step-1", []byte(`1`)); err != nil {
+		t.Fatalf("SaveStepOutput() failed: %v", err)
+	}
+	wfStore := WithRetry(flaky, fastRetries).(gorkflow.StepOutputBatchLoader)
+
+	// flakyStore only loads step by step, and each load is retried
+	outputs, err := wfStore.BatchLoadStepOutputs(context.Background(), "run-1", []string{"step-1", "missing"})
+	if err != nil {
+		t.Fatalf("BatchLoadStepOutputs() failed: %v", err)
+	}
+	if len(outputs) != 1 || string(outputs["step-1"]) != "1" {
+		t.Errorf("BatchLoadStepOutputs() = %v, want only step-1", outputs)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("calls = %d, want 3", flaky.calls)
+	}
+}
+
+func TestWithRetry_IncrementOnlyRetriesThrottling(t *testing.T) {
+	// An internal error may hide an applied increment
+	flaky := newFlakyStore(t, &types.InternalServerError{})
+	wfStore := WithRetry(flaky, fastRetries)
+	if _, err := wfStore.IncrementState(context.Background(), "run-1", "count", 1); err == nil {
+		t.Error("IncrementState() should not retry an internal error")
+	}
+
+	flaky = newFlakyStore(t, &types.ProvisionedThroughputExceededException{})
+	wfStore = WithRetry(flaky, fastRetries)
+	count, err := wfStore.IncrementState(context.Background(), "run-1", "count", 1)
+	if err != nil || count != 1 {
+		t.Errorf("IncrementState() = %d, %v, want 1", count, err)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"throughput exceeded", &types.ProvisionedThroughputExceededException{}, true},
+		{"request limit", fmt.Errorf("failed to update run: %w", &types.RequestLimitExceeded{}), true},
+		{"internal server error", &types.InternalServerError{}, true},
+		{"throttled transaction", &types.TransactionCanceledException{
+			CancellationReasons: []types.CancellationReason{{Code: aws.String("None")}, {Code: aws.String("ThrottlingError")}},
+		}, true},
+		{"failed condition", &types.TransactionCanceledException{
+			CancellationReasons: []types.CancellationReason{{Code: aws.String("ConditionalCheckFailed")}},
+		}, false},
+		{"condition check", &types.ConditionalCheckFailedException{}, false},
+		{"cancelled", context.Canceled, false},
+		{"plain error", errors.New("not found"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransientError(tt.err); got != tt.want {
+				t.Errorf("IsTransientError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
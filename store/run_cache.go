@@ -0,0 +1,83 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultRunCacheSize is how many runs a DynamoDBStore keeps TTLs and index keys for
+const DefaultRunCacheSize = 10000
+
+// runCache holds per-run values, dropping the least recently used run once it is full.
+// Runs finished by another engine are never evicted explicitly, so the bound is what
+// keeps a long-lived store from growing with every run it has seen.
+type runCache[V any] struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // most recently used first
+	entries map[string]*list.Element
+}
+
+type runCacheEntry[V any] struct {
+	runID string
+	value V
+}
+
+func newRunCache[V any](size int) *runCache[V] {
+	return &runCache[V]{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *runCache[V]) get(runID string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[runID]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*runCacheEntry[V]).value, true
+}
+
+func (c *runCache[V]) put(runID string, value V) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[runID]; ok {
+		elem.Value.(*runCacheEntry[V]).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[runID] = c.order.PushFront(&runCacheEntry[V]{runID: runID, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*runCacheEntry[V]).runID)
+	}
+}
+
+func (c *runCache[V]) delete(runID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[runID]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, runID)
+	}
+}
+
+func (c *runCache[V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
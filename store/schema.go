@@ -1,6 +1,9 @@
 package store
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // DynamoDB schema constants for single-table design
 const (
@@ -11,9 +14,16 @@ const (
 	AttrGSI1SK     = "GSI1SK"
 	AttrGSI2PK     = "GSI2PK"
 	AttrGSI2SK     = "GSI2SK"
+	AttrGSI3PK     = "GSI3PK"
+	AttrGSI3SK     = "GSI3SK"
 	AttrEntityType = "entity_type"
 	AttrData       = "data"
 	AttrTTL        = "ttl"
+	AttrObjectRef  = "object_ref"
+
+	// Run lease attributes
+	AttrLeaseOwner   = "lease_owner"
+	AttrLeaseExpires = "lease_expires"
 
 	// Entity types
 	EntityTypeWorkflowRun   = "WorkflowRun"
@@ -21,9 +31,20 @@ const (
 	EntityTypeStepOutput    = "StepOutput"
 	EntityTypeState         = "State"
 
+	// BatchWriteItem accepts at most 25 requests per call
+	maxBatchWriteItems = 25
+
+	// BatchGetItem accepts at most 100 keys per call
+	maxBatchGetItems = 100
+
 	// Index names
 	IndexStatusIndex   = "GSI1"
 	IndexResourceIndex = "GSI2"
+	IndexScheduleIndex = "GSI3"
+
+	// Scheduled runs are bucketed by UTC due date; sort keys are fixed-width so they order lexically
+	scheduleBucketFormat = "2006-01-02"
+	scheduleTimeFormat   = "2006-01-02T15:04:05.000000000Z"
 )
 
 // Key builders for single-table design
@@ -37,6 +58,21 @@ func workflowRunSK() string {
 	return "META"
 }
 
+// Run lease keys: PK=RUN#{runID}, SK=LEASE. The lease is its own item so that
+// UpdateRun, which rewrites META whole, never clears it.
+func runLeaseSK() string {
+	return "LEASE"
+}
+
+// Health check key: PK=HEALTH, SK=PING. No item is stored under it; Ping only reads it.
+func healthCheckPK() string {
+	return "HEALTH"
+}
+
+func healthCheckSK() string {
+	return "PING"
+}
+
 func workflowRunGSI1PK(workflowID, status string) string {
 	return fmt.Sprintf("WF#%s#STATUS#%s", workflowID, status)
 }
@@ -53,6 +89,15 @@ func workflowRunGSI2SK(createdAt string) string {
 	return createdAt
 }
 
+// Scheduled run keys (sparse, only while the run waits to start): GSI3PK=SCHED#{dueDate}, GSI3SK={dueAt}
+func scheduledRunGSI3PK(dueAt time.Time) string {
+	return fmt.Sprintf("SCHED#%s", dueAt.UTC().Format(scheduleBucketFormat))
+}
+
+func scheduledRunGSI3SK(dueAt time.Time) string {
+	return dueAt.UTC().Format(scheduleTimeFormat)
+}
+
 // StepExecution keys: PK=RUN#{runID}, SK=STEP#{stepID}
 func stepExecutionPK(runID string) string {
 	return fmt.Sprintf("RUN#%s", runID)
@@ -88,3 +133,12 @@ func statePrefix() string {
 func stepPrefix() string {
 	return "STEP#"
 }
+
+// S3 object keys for offloaded payloads
+func stepOutputObjectKey(runID, stepID string) string {
+	return fmt.Sprintf("runs/%s/outputs/%s", runID, stepID)
+}
+
+func stateObjectKey(runID, key string) string {
+	return fmt.Sprintf("runs/%s/state/%s", runID, key)
+}
@@ -448,6 +448,7 @@ func TestIndexNameConstants(t *testing.T) {
 	indexes := []string{
 		IndexStatusIndex,
 		IndexResourceIndex,
+		IndexScheduleIndex,
 	}
 
 	seen := make(map[string]bool)
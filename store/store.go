@@ -9,4 +9,7 @@ package store
 //   - DynamoDBStore: Production-ready AWS DynamoDB backend
 //   - MemoryStore: In-memory backend for testing
 //
+// WithRetry and WithMetrics wrap any implementation to retry transient errors
+// and to time every call.
+//
 // Schema design follows single-table patterns defined in schema.go.
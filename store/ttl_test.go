@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/sicko7947/gorkflow"
+)
+
+func TestDynamoDBStore_ChildItemsInheritRunTTL(t *testing.T) {
+	client, items := newItemStoringClient()
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	expiry := time.Now().Add(24 * time.Hour).Unix()
+	run := &gorkflow.WorkflowRun{
+		RunID:      "run-1",
+		WorkflowID: "test-workflow",
+		Status:     gorkflow.RunStatusRunning,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		TTL:        expiry,
+	}
+	if err := store.CreateRun(ctx, run); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+
+	if err := store.CreateStepExecution(ctx, &gorkflow.StepExecution{RunID: "run-1", StepID: "step-1"}); err != nil {
+		t.Fatalf("CreateStepExecution() failed: %v", err)
+	}
+	if err := store.SaveStepOutput(ctx, "run-1", "step-1", []byte(`{}`)); err != nil {
+		t.Fatalf("SaveStepOutput() failed: %v", err)
+	}
+	if err := store.SaveState(ctx, "run-1", "key", []byte(`1`)); err != nil {
+		t.Fatalf("SaveState() failed: %v", err)
+	}
+
+	for _, sk := range []string{stepExecutionSK("step-1"), stepOutputSK("step-1"), stateSK("key")} {
+		item := items[workflowRunPK("run-1")+"|"+sk]
+		ttl, ok := item[AttrTTL].(*types.AttributeValueMemberN)
+		if !ok {
+			t.Errorf("%s: ttl attribute not set", sk)
+			continue
+		}
+		if ttl.Value != strconv.FormatInt(expiry, 10) {
+			t.Errorf("%s: ttl = %s, want %d", sk, ttl.Value, expiry)
+		}
+	}
+}
+
+func TestDynamoDBStore_RunTTL_LoadedOnceOnCacheMiss(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Unix()
+	getCalls := 0
+	var captured []map[string]types.AttributeValue
+
+	client := &mockDynamoDBClient{
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			getCalls++
+			return &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					AttrTTL: &types.AttributeValueMemberN{Value: strconv.FormatInt(expiry, 10)},
+				},
+			}, nil
+		},
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			captured = append(captured, params.Item)
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}
+
+	// A fresh store (e.g. another engine instance) that never saw CreateRun
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	if err := store.CreateStepExecution(ctx, &gorkflow.StepExecution{RunID: "run-1", StepID: "step-1"}); err != nil {
+		t.Fatalf("CreateStepExecution() failed: %v", err)
+	}
+	if err := store.SaveStepOutput(ctx, "run-1", "step-1", []byte(`{}`)); err != nil {
+		t.Fatalf("SaveStepOutput() failed: %v", err)
+	}
+
+	if getCalls != 1 {
+		t.Errorf("GetItem called %d times, want 1", getCalls)
+	}
+
+	for i, item := range captured {
+		ttl, ok := item[AttrTTL].(*types.AttributeValueMemberN)
+		if !ok || ttl.Value != strconv.FormatInt(expiry, 10) {
+			t.Errorf("item %d: ttl = %v, want %d", i, item[AttrTTL], expiry)
+		}
+	}
+}
+
+func TestDynamoDBStore_NoRunTTL(t *testing.T) {
+	client, items := newItemStoringClient()
+	store := NewDynamoDBStore(client, "test-table")
+	ctx := context.Background()
+
+	run := &gorkflow.WorkflowRun{RunID: "run-1", Status: gorkflow.RunStatusRunning}
+	if err := store.CreateRun(ctx, run); err != nil {
+		t.Fatalf("CreateRun() failed: %v", err)
+	}
+	if err := store.SaveStepOutput(ctx, "run-1", "step-1", []byte(`{}`)); err != nil {
+		t.Fatalf("SaveStepOutput() failed: %v", err)
+	}
+
+	if _, ok := items[workflowRunPK("run-1")+"|"+stepOutputSK("step-1")][AttrTTL]; ok {
+		t.Error("ttl should not be set when the run has no TTL")
+	}
+}
@@ -1,15 +1,27 @@
 package gorkflow
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrRunFinished is returned by UpdateRunStatus when the run already reached a terminal status
+var ErrRunFinished = errors.New("workflow run already finished")
 
 // WorkflowStore defines the persistence interface for workflows
 type WorkflowStore interface {
 	// Workflow runs
 	CreateRun(ctx context.Context, run *WorkflowRun) error
 	GetRun(ctx context.Context, runID string) (*WorkflowRun, error)
+	BatchGetRuns(ctx context.Context, runIDs []string) (map[string]*WorkflowRun, error) // Missing runs are left out
 	UpdateRun(ctx context.Context, run *WorkflowRun) error
+	// UpdateRunStatus writes only the status and error, failing with ErrRunFinished once the run is COMPLETED, FAILED or CANCELLED
 	UpdateRunStatus(ctx context.Context, runID string, status RunStatus, err *WorkflowError) error
+	// UpdateRunProgress writes only Progress, CurrentStep, TotalAttempts and UpdatedAt, leaving the stored status as it is
+	UpdateRunProgress(ctx context.Context, run *WorkflowRun) error
 	ListRuns(ctx context.Context, filter RunFilter) ([]*WorkflowRun, error)
+	DeleteRun(ctx context.Context, runID string) error
 
 	// Step executions
 	CreateStepExecution(ctx context.Context, exec *StepExecution) error
@@ -19,16 +31,61 @@ type WorkflowStore interface {
 
 	// Step outputs (for inter-step communication)
 	SaveStepOutput(ctx context.Context, runID, stepID string, output []byte) error
+	SaveStepOutputs(ctx context.Context, runID string, outputs map[string][]byte) error // Keyed by step ID
 	LoadStepOutput(ctx context.Context, runID, stepID string) ([]byte, error)
+	CommitStepResult(ctx context.Context, exec *StepExecution, output []byte) error // Writes the execution and its output atomically
 
 	// Workflow state
 	SaveState(ctx context.Context, runID, key string, value []byte) error
 	LoadState(ctx context.Context, runID, key string) ([]byte, error)
 	DeleteState(ctx context.Context, runID, key string) error
 	GetAllState(ctx context.Context, runID string) (map[string][]byte, error)
+	ListState(ctx context.Context, runID, prefix string) (map[string][]byte, error)
+	IncrementState(ctx context.Context, runID, key string, delta int64) (int64, error)
 
 	// Queries
 	CountRunsByStatus(ctx context.Context, resourceID string, status RunStatus) (int, error)
+	CountRuns(ctx context.Context, filter RunFilter) (int, error) // Same filters as ListRuns; Limit and LastKey are ignored
+
+	// Scheduled runs
+	ListDueRuns(ctx context.Context, dueBefore time.Time, limit int) ([]*WorkflowRun, error)
+	ClaimScheduledRun(ctx context.Context, runID string) (bool, error)
+
+	// Run ownership
+	LeaseRun(ctx context.Context, runID, owner string, ttl time.Duration) (bool, error) // False while another owner holds an unexpired lease
+}
+
+// Pinger is implemented by stores that can check their connection to the backing database.
+// Engine.Health pings the store when it implements Pinger.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// StepOutputBatchLoader is implemented by stores that can load several step outputs in one call.
+// LoadStepOutputs uses it when the store implements it.
+type StepOutputBatchLoader interface {
+	// BatchLoadStepOutputs leaves out steps without an output
+	BatchLoadStepOutputs(ctx context.Context, runID string, stepIDs []string) (map[string][]byte, error)
+}
+
+// LoadStepOutputs loads the outputs of several steps, leaving out steps without an output. Stores
+// that are not a StepOutputBatchLoader are read with one LoadStepOutput per step; as those report
+// a missing output as an error, steps whose read fails are left out too.
+func LoadStepOutputs(ctx context.Context, store WorkflowStore, runID string, stepIDs []string) (map[string][]byte, error) {
+	if loader, ok := store.(StepOutputBatchLoader); ok {
+		return loader.BatchLoadStepOutputs(ctx, runID, stepIDs)
+	}
+
+	outputs := make(map[string][]byte, len(stepIDs))
+	for _, stepID := range stepIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if output, err := store.LoadStepOutput(ctx, runID, stepID); err == nil {
+			outputs[stepID] = output
+		}
+	}
+	return outputs, nil
 }
 
 // RunFilter defines filtering criteria for workflow runs
@@ -36,6 +93,17 @@ type RunFilter struct {
 	WorkflowID string
 	Status     *RunStatus
 	ResourceID string
-	Limit      int
-	LastKey    map[string]interface{}
+
+	// Runs must carry every one of these tags with the given value
+	Tags map[string]string
+
+	// Creation time window (inclusive)
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+
+	// Order by CreatedAt, newest first when set
+	SortDescending bool
+
+	Limit   int
+	LastKey map[string]interface{}
 }
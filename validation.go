@@ -1,8 +1,8 @@
 package gorkflow
 
 import (
-	"encoding/json"
 	"fmt"
+	"reflect"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -30,6 +30,18 @@ func (vc *validationConfig) validateStruct(v interface{}) error {
 		return nil
 	}
 
+	// Only structs carry validation tags; scalars, maps and slices pass through
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
 	if err := vc.validator.Struct(v); err != nil {
 		if validationErrors, ok := err.(validator.ValidationErrors); ok {
 			return newValidationError(validationErrors)
@@ -40,11 +52,11 @@ func (vc *validationConfig) validateStruct(v interface{}) error {
 }
 
 // validateInputData unmarshals and validates input data
-func validateInputData[T any](data []byte, config *validationConfig) (T, error) {
+func validateInputData[T any](codec Codec, data []byte, config *validationConfig) (T, error) {
 	var input T
 
 	// Unmarshal
-	if err := json.Unmarshal(data, &input); err != nil {
+	if err := codec.Unmarshal(data, &input); err != nil {
 		return input, fmt.Errorf("failed to unmarshal input: %w", err)
 	}
 
@@ -59,7 +71,7 @@ func validateInputData[T any](data []byte, config *validationConfig) (T, error)
 }
 
 // validateOutputData validates and marshals output data
-func validateOutputData[T any](output T, config *validationConfig) ([]byte, error) {
+func validateOutputData[T any](codec Codec, output T, config *validationConfig) ([]byte, error) {
 	// Validate if enabled
 	if config != nil && config.validateOutput {
 		if err := config.validateStruct(output); err != nil {
@@ -68,7 +80,7 @@ func validateOutputData[T any](output T, config *validationConfig) ([]byte, erro
 	}
 
 	// Marshal
-	outputBytes, err := json.Marshal(output)
+	outputBytes, err := codec.Marshal(output)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal output: %w", err)
 	}
@@ -0,0 +1,51 @@
+package gorkflow
+
+import (
+	"context"
+	"fmt"
+)
+
+// OutputMigration upgrades a step output stored at one version to the shape of the next version
+type OutputMigration func(data []byte) ([]byte, error)
+
+// UpgradeStepOutput brings an output loaded from the store up to the step's current
+// WithOutputVersion, applying its migrations one version at a time. Outputs of steps without
+// migrations are returned as they are; outputs stored before versioning count as version 0.
+func UpgradeStepOutput(ctx context.Context, wfStore WorkflowStore, wf *Workflow, runID, stepID string, data []byte) ([]byte, error) {
+	step, err := wf.GetStep(stepID)
+	if err != nil {
+		return data, nil
+	}
+	version, migrations := stepOutputVersion(step)
+	if version == 0 || len(migrations) == 0 {
+		return data, nil
+	}
+
+	exec, err := wfStore.GetStepExecution(ctx, runID, stepID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output version of step %s: %w", stepID, err)
+	}
+
+	for from := exec.OutputVersion; from < version; from++ {
+		migrate, ok := migrations[from]
+		if !ok {
+			return nil, fmt.Errorf("no migration for output of step %s from version %d", stepID, from)
+		}
+		if data, err = migrate(data); err != nil {
+			return nil, fmt.Errorf("failed to migrate output of step %s from version %d: %w", stepID, from, err)
+		}
+	}
+	return data, nil
+}
+
+// stepOutputVersion returns the step's current output version and its migrations, keyed by the version they upgrade from
+func stepOutputVersion(step StepExecutor) (int, map[int]OutputMigration) {
+	provider, ok := step.(interface {
+		GetOutputVersion() int
+		GetOutputMigrations() map[int]OutputMigration
+	})
+	if !ok {
+		return 0, nil
+	}
+	return provider.GetOutputVersion(), provider.GetOutputMigrations()
+}
@@ -0,0 +1,84 @@
+package gorkflow_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sicko7947/gorkflow"
+	"github.com/sicko7947/gorkflow/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fetchOutputV2 struct {
+	Count int `json:"count"`
+}
+
+// renameTotal upgrades {"total": n} (v1) to {"count": n} (v2)
+func renameTotal(data []byte) ([]byte, error) {
+	var v1 struct {
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(data, &v1); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fetchOutputV2{Count: v1.Total})
+}
+
+func newVersionedWorkflow() *gorkflow.Workflow {
+	wf := gorkflow.NewWorkflowInstance("versioned", "Versioned")
+	wf.AddStep(gorkflow.NewStep("fetch", "Fetch",
+		func(ctx *gorkflow.StepContext, input struct{}) (fetchOutputV2, error) {
+			return fetchOutputV2{Count: 1}, nil
+		},
+		gorkflow.WithOutputVersion(2),
+		gorkflow.WithOutputMigration(1, renameTotal),
+	))
+	return wf
+}
+
+// seedOutput stores an output the fetch step wrote at version
+func seedOutput(t *testing.T, wfStore gorkflow.WorkflowStore, version int, output string) {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, wfStore.CreateStepExecution(ctx, &gorkflow.StepExecution{
+		RunID:         "run-1",
+		StepID:        "fetch",
+		Status:        gorkflow.StepStatusCompleted,
+		OutputVersion: version,
+	}))
+	require.NoError(t, wfStore.SaveStepOutput(ctx, "run-1", "fetch", []byte(output)))
+}
+
+func TestStepOutputAccessor_MigratesOldOutputs(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	seedOutput(t, wfStore, 1, `{"total":3}`)
+
+	outputs := gorkflow.NewStepOutputAccessor("run-1", wfStore, gorkflow.WithOutputMigrations(newVersionedWorkflow()))
+
+	output, err := gorkflow.GetTypedOutput[fetchOutputV2](outputs, "fetch")
+	require.NoError(t, err)
+	assert.Equal(t, 3, output.Count)
+}
+
+func TestStepOutputAccessor_CurrentOutputsUnchanged(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	seedOutput(t, wfStore, 2, `{"count":5}`)
+
+	outputs := gorkflow.NewStepOutputAccessor("run-1", wfStore, gorkflow.WithOutputMigrations(newVersionedWorkflow()))
+
+	output, err := gorkflow.GetTypedOutput[fetchOutputV2](outputs, "fetch")
+	require.NoError(t, err)
+	assert.Equal(t, 5, output.Count)
+}
+
+func TestStepOutputAccessor_MissingMigration(t *testing.T) {
+	wfStore := store.NewMemoryStore()
+	seedOutput(t, wfStore, 0, `{"sum":3}`)
+
+	outputs := gorkflow.NewStepOutputAccessor("run-1", wfStore, gorkflow.WithOutputMigrations(newVersionedWorkflow()))
+
+	_, err := gorkflow.GetTypedOutput[fetchOutputV2](outputs, "fetch")
+	assert.ErrorContains(t, err, "no migration for output of step fetch from version 0")
+}
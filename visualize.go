@@ -0,0 +1,225 @@
+package gorkflow
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// dotNodeStyles holds the Graphviz attributes for each node type
+var dotNodeStyles = map[NodeType]string{
+	NodeTypeSequential:  `shape=box, style=rounded`,
+	NodeTypeParallel:    `shape=box, style="rounded,filled", fillcolor=lightblue`,
+	NodeTypeConditional: `shape=diamond, style=filled, fillcolor=lightyellow`,
+}
+
+// mermaidNodeShapes holds the Mermaid open and close brackets for each node type
+var mermaidNodeShapes = map[NodeType][2]string{
+	NodeTypeSequential:  {"[", "]"},
+	NodeTypeParallel:    {"[/", "/]"},
+	NodeTypeConditional: {"{", "}"},
+}
+
+// dotStatusColors holds the Graphviz fill color for each step status
+var dotStatusColors = map[StepStatus]string{
+	StepStatusPending:   "white",
+	StepStatusRunning:   "lightblue",
+	StepStatusRetrying:  "orange",
+	StepStatusWaiting:   "lightyellow",
+	StepStatusCompleted: "palegreen",
+	StepStatusFailed:    "lightcoral",
+	StepStatusSkipped:   "lightgray",
+}
+
+// ToDOT renders the graph in Graphviz DOT format with step IDs as labels
+func (g *ExecutionGraph) ToDOT() string {
+	return g.toDOT("workflow", func(stepID string) string { return stepID })
+}
+
+// ToDOT renders the workflow graph in Graphviz DOT format with step names as labels
+func (w *Workflow) ToDOT() string {
+	return w.graph.toDOT(w.id, w.stepLabel)
+}
+
+func (g *ExecutionGraph) toDOT(name string, label func(stepID string) string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(name))
+	b.WriteString("  rankdir=TB;\n")
+
+	for _, stepID := range g.sortedNodeIDs() {
+		node := g.Nodes[stepID]
+
+		style, ok := dotNodeStyles[node.Type]
+		if !ok {
+			style = dotNodeStyles[NodeTypeSequential]
+		}
+		if stepID == g.EntryPoint {
+			style += ", peripheries=2"
+		}
+
+		fmt.Fprintf(&b, "  %s [label=%s, %s];\n", dotQuote(stepID), dotQuote(label(stepID)), style)
+	}
+
+	for _, stepID := range g.sortedNodeIDs() {
+		for _, nextID := range g.Nodes[stepID].Next {
+			if g.EdgeCondition(stepID, nextID) != nil {
+				fmt.Fprintf(&b, "  %s -> %s [style=dashed];\n", dotQuote(stepID), dotQuote(nextID))
+				continue
+			}
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(stepID), dotQuote(nextID))
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ToStatusDOT renders the definition in Graphviz DOT format with each step filled by the
+// color of its status. Steps missing from statuses are drawn as pending.
+func (d WorkflowDefinition) ToStatusDOT(statuses map[string]StepStatus) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(d.ID))
+	b.WriteString("  rankdir=TB;\n")
+
+	steps := slices.SortedFunc(slices.Values(d.Steps), func(a, b StepDefinition) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	for _, step := range steps {
+		status, ok := statuses[step.ID]
+		if !ok {
+			status = StepStatusPending
+		}
+		color, ok := dotStatusColors[status]
+		if !ok {
+			color = dotStatusColors[StepStatusPending]
+		}
+
+		label := step.Name
+		if label == "" {
+			label = step.ID
+		}
+
+		shape := "box"
+		if step.Type == NodeTypeConditional {
+			shape = "diamond"
+		}
+
+		attrs := fmt.Sprintf(`shape=%s, style="rounded,filled", fillcolor=%s, tooltip=%s`, shape, color, dotQuote(string(status)))
+		if step.ID == d.EntryPoint {
+			attrs += ", peripheries=2"
+		}
+
+		fmt.Fprintf(&b, "  %s [label=%s, %s];\n", dotQuote(step.ID), dotQuote(label), attrs)
+	}
+
+	for _, edge := range d.Edges {
+		if edge.Conditional {
+			fmt.Fprintf(&b, "  %s -> %s [style=dashed];\n", dotQuote(edge.From), dotQuote(edge.To))
+			continue
+		}
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ToMermaid renders the workflow graph as a Mermaid flowchart with step names as labels.
+// The entry point is styled with the "entry" class.
+func (w *Workflow) ToMermaid() string {
+	return w.graph.toMermaid(w.stepLabel)
+}
+
+func (g *ExecutionGraph) toMermaid(label func(stepID string) string) string {
+	var b strings.Builder
+	b.WriteString("graph TD\n")
+
+	stepIDs := g.sortedNodeIDs()
+	ids := mermaidIDs(stepIDs)
+
+	for _, stepID := range stepIDs {
+		shape, ok := mermaidNodeShapes[g.Nodes[stepID].Type]
+		if !ok {
+			shape = mermaidNodeShapes[NodeTypeSequential]
+		}
+		fmt.Fprintf(&b, "    %s%s%s%s\n", ids[stepID], shape[0], mermaidQuote(label(stepID)), shape[1])
+	}
+
+	for _, stepID := range stepIDs {
+		for _, nextID := range g.Nodes[stepID].Next {
+			arrow := "-->"
+			if g.EdgeCondition(stepID, nextID) != nil {
+				arrow = "-.->"
+			}
+			fmt.Fprintf(&b, "    %s %s %s\n", ids[stepID], arrow, ids[nextID])
+		}
+	}
+
+	if entry, ok := ids[g.EntryPoint]; ok {
+		b.WriteString("    classDef entry stroke-width:3px\n")
+		fmt.Fprintf(&b, "    class %s entry\n", entry)
+	}
+
+	return b.String()
+}
+
+// mermaidIDs maps step IDs to unique identifiers that are safe in Mermaid syntax
+func mermaidIDs(stepIDs []string) map[string]string {
+	ids := make(map[string]string, len(stepIDs))
+	used := make(map[string]bool, len(stepIDs))
+
+	for _, stepID := range stepIDs {
+		id := strings.Map(func(r rune) rune {
+			if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		}, stepID)
+		// "end" is a flowchart keyword
+		if id == "" || strings.EqualFold(id, "end") {
+			id += "_"
+		}
+
+		unique := id
+		for i := 2; used[unique]; i++ {
+			unique = fmt.Sprintf("%s_%d", id, i)
+		}
+		used[unique] = true
+		ids[stepID] = unique
+	}
+
+	return ids
+}
+
+// mermaidQuote returns s as a quoted Mermaid label
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", "<br>").Replace(s) + `"`
+}
+
+// sortedNodeIDs returns the node IDs in a stable order for rendering
+func (g *ExecutionGraph) sortedNodeIDs() []string {
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// stepLabel returns the step's name, falling back to its ID
+func (w *Workflow) stepLabel(stepID string) string {
+	if step, err := w.GetStep(stepID); err == nil && step.GetName() != "" {
+		return step.GetName()
+	}
+	return stepID
+}
+
+// dotQuote returns s as a quoted DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
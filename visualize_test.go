@@ -0,0 +1,124 @@
+package gorkflow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDiamondWorkflow builds start -> (left, right) -> check, with parallel branches
+// and a conditional join
+func newDiamondWorkflow() *Workflow {
+	wf := NewWorkflowInstance("diamond", "Diamond")
+	wf.AddStep(NewStep("start", "Start", testHandler))
+	wf.AddStep(NewStep("left", "Left Branch", testHandler))
+	wf.AddStep(NewStep("right", "Right Branch", testHandler))
+	wf.AddStep(NewStep("check", `Check "Result"`, testHandler))
+
+	wf.Graph().AddNode("start", NodeTypeSequential)
+	wf.Graph().AddNode("left", NodeTypeParallel)
+	wf.Graph().AddNode("right", NodeTypeParallel)
+	wf.Graph().AddNode("check", NodeTypeConditional)
+
+	wf.Graph().AddEdge("start", "left")
+	wf.Graph().AddEdge("start", "right")
+	wf.Graph().AddEdge("left", "check")
+	wf.Graph().AddEdge("right", "check")
+	wf.Graph().SetEntryPoint("start")
+
+	return wf
+}
+
+func TestExecutionGraph_ToDOT(t *testing.T) {
+	dot := newDiamondWorkflow().Graph().ToDOT()
+
+	assert.Contains(t, dot, "digraph \"workflow\" {\n")
+	assert.Contains(t, dot, "}\n")
+
+	// Entry point is marked
+	assert.Contains(t, dot, `"start" [label="start", shape=box, style=rounded, peripheries=2];`)
+
+	// Node types are styled distinctly
+	assert.Contains(t, dot, `"left" [label="left", shape=box, style="rounded,filled", fillcolor=lightblue];`)
+	assert.Contains(t, dot, `"right" [label="right", shape=box, style="rounded,filled", fillcolor=lightblue];`)
+	assert.Contains(t, dot, `"check" [label="check", shape=diamond, style=filled, fillcolor=lightyellow];`)
+
+	for _, edge := range []string{
+		`"start" -> "left";`,
+		`"start" -> "right";`,
+		`"left" -> "check";`,
+		`"right" -> "check";`,
+	} {
+		assert.Contains(t, dot, edge)
+	}
+
+	// Output is deterministic
+	assert.Equal(t, dot, newDiamondWorkflow().Graph().ToDOT())
+}
+
+func TestWorkflow_ToDOT(t *testing.T) {
+	dot := newDiamondWorkflow().ToDOT()
+
+	assert.Contains(t, dot, "digraph \"diamond\" {\n")
+	assert.Contains(t, dot, `"start" [label="Start", shape=box, style=rounded, peripheries=2];`)
+	assert.Contains(t, dot, `"left" [label="Left Branch",`)
+	assert.Contains(t, dot, `"check" [label="Check \"Result\"",`)
+	assert.Contains(t, dot, `"left" -> "check";`)
+}
+
+func TestWorkflow_ToMermaid(t *testing.T) {
+	mermaid := newDiamondWorkflow().ToMermaid()
+	lines := strings.Split(strings.TrimSpace(mermaid), "\n")
+
+	require.NotEmpty(t, lines)
+	assert.Equal(t, "graph TD", lines[0])
+
+	var nodes, edges []string
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.Contains(line, " --> "):
+			edges = append(edges, line)
+		case strings.HasPrefix(line, "class"):
+		default:
+			nodes = append(nodes, line)
+		}
+	}
+
+	// Parallel and conditional steps get distinct shapes
+	assert.ElementsMatch(t, []string{
+		`start["Start"]`,
+		`left[/"Left Branch"/]`,
+		`right[/"Right Branch"/]`,
+		`check{"Check #quot;Result#quot;"}`,
+	}, nodes)
+
+	assert.Len(t, edges, 4)
+	assert.Contains(t, edges, "start --> left")
+	assert.Contains(t, edges, "right --> check")
+
+	// Entry node is marked
+	assert.Contains(t, lines, "    class start entry")
+}
+
+func TestWorkflow_ToMermaid_SanitizesIDs(t *testing.T) {
+	wf := NewWorkflowInstance("ids", "IDs")
+	wf.AddStep(NewStep("fetch-data", "Fetch", testHandler))
+	wf.AddStep(NewStep("fetch.data", "Fetch Again", testHandler))
+	wf.AddStep(NewStep("end", "End", testHandler))
+
+	wf.Graph().AddNode("fetch-data", NodeTypeSequential)
+	wf.Graph().AddNode("fetch.data", NodeTypeSequential)
+	wf.Graph().AddNode("end", NodeTypeSequential)
+	wf.Graph().AddEdge("fetch-data", "fetch.data")
+	wf.Graph().AddEdge("fetch.data", "end")
+	wf.Graph().SetEntryPoint("fetch-data")
+
+	mermaid := wf.ToMermaid()
+
+	assert.Contains(t, mermaid, "fetch_data --> fetch_data_2")
+	assert.Contains(t, mermaid, "fetch_data_2 --> end_")
+	assert.Contains(t, mermaid, "class fetch_data entry")
+}
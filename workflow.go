@@ -2,6 +2,9 @@ package gorkflow
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -27,6 +30,11 @@ type Workflow struct {
 
 	// Custom context
 	customContext any
+
+	// Topological order of the graph, computed once since the graph is fixed after Build
+	order     []string
+	orderErr  error
+	orderOnce sync.Once
 }
 
 // ID returns the workflow ID
@@ -49,11 +57,28 @@ func (w *Workflow) Version() string {
 	return w.version
 }
 
+// Tags returns the workflow tags
+func (w *Workflow) Tags() map[string]string {
+	return w.tags
+}
+
 // Graph returns the execution graph
 func (w *Workflow) Graph() *ExecutionGraph {
 	return w.graph
 }
 
+// ExecutionOrder returns the step IDs in topological order. The order is computed on first
+// use and cached, so the graph must not change once the workflow is built.
+func (w *Workflow) ExecutionOrder() ([]string, error) {
+	w.orderOnce.Do(func() {
+		w.order, w.orderErr = w.graph.TopologicalSort()
+	})
+	if w.orderErr != nil {
+		return nil, w.orderErr
+	}
+	return slices.Clone(w.order), nil
+}
+
 // GetStep retrieves a step by ID
 func (w *Workflow) GetStep(stepID string) (StepExecutor, error) {
 	step, exists := w.steps[stepID]
@@ -78,6 +103,23 @@ func (w *Workflow) GetContext() any {
 	return w.customContext
 }
 
+// Clone returns a deep copy of the workflow that can be given a different version, tags or
+// config without affecting the original. Steps, conditions and the custom context are shared.
+func (w *Workflow) Clone() *Workflow {
+	return &Workflow{
+		id:            w.id,
+		name:          w.name,
+		description:   w.description,
+		version:       w.version,
+		steps:         maps.Clone(w.steps),
+		graph:         w.graph.Clone(),
+		config:        w.config,
+		tags:          maps.Clone(w.tags),
+		createdAt:     w.createdAt,
+		customContext: w.customContext,
+	}
+}
+
 // WorkflowOption configures a workflow
 type WorkflowOption func(*Workflow)
 
@@ -106,3 +106,79 @@ func TestWorkflow_Accessors(t *testing.T) {
 	assert.NotNil(t, wf.Graph())
 	assert.NotNil(t, wf.GetConfig())
 }
+
+func TestWorkflow_Clone(t *testing.T) {
+	condition := func(ctx *StepContext) (bool, error) { return true, nil }
+
+	wf := NewWorkflowInstance("test-workflow", "Test Workflow")
+	wf.SetTags(map[string]string{"team": "core"})
+	for _, id := range []string{"step1", "step2"} {
+		wf.AddStep(NewStep(id, id, testHandler))
+		wf.Graph().AddNode(id, NodeTypeSequential)
+	}
+	require.NoError(t, wf.Graph().AddConditionalEdge("step1", "step2", condition))
+	wf.Graph().SetEntryPoint("step1")
+
+	clone := wf.Clone()
+	clone.SetVersion("2.0.0")
+	clone.Tags()["team"] = "payments"
+	clone.Tags()["env"] = "staging"
+
+	// The original keeps its metadata
+	assert.Equal(t, "1.0", wf.Version())
+	assert.Equal(t, map[string]string{"team": "core"}, wf.Tags())
+	assert.Equal(t, map[string]string{"team": "payments", "env": "staging"}, clone.Tags())
+
+	// Steps and edges carry over, conditions included
+	assert.Len(t, clone.GetAllSteps(), 2)
+	assert.Equal(t, "step1", clone.Graph().EntryPoint)
+	assert.Len(t, clone.Graph().Nodes["step1"].Conditions, 1)
+
+	order, err := clone.ExecutionOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"step1", "step2"}, order)
+
+	// Graph changes on the clone stay on the clone
+	clone.Graph().AddNode("step3", NodeTypeSequential)
+	assert.NotContains(t, wf.Graph().Nodes, "step3")
+}
+
+func TestWorkflow_ExecutionOrder(t *testing.T) {
+	wf := NewWorkflowInstance("test-workflow", "Test Workflow")
+	for _, id := range []string{"step1", "step2", "step3"} {
+		wf.AddStep(NewStep(id, id, testHandler))
+		wf.Graph().AddNode(id, NodeTypeSequential)
+	}
+	require.NoError(t, wf.Graph().AddEdge("step1", "step2"))
+	require.NoError(t, wf.Graph().AddEdge("step2", "step3"))
+
+	order, err := wf.ExecutionOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"step1", "step2", "step3"}, order)
+
+	// Callers get their own copy of the cached order
+	order[0] = "changed"
+
+	// The order is computed once: later graph changes are not picked up
+	wf.Graph().AddNode("step4", NodeTypeSequential)
+	require.NoError(t, wf.Graph().AddEdge("step3", "step4"))
+
+	order, err = wf.ExecutionOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"step1", "step2", "step3"}, order)
+}
+
+func TestWorkflow_ExecutionOrder_Cycle(t *testing.T) {
+	wf := NewWorkflowInstance("test-workflow", "Test Workflow")
+	wf.Graph().AddNode("step1", NodeTypeSequential)
+	wf.Graph().AddNode("step2", NodeTypeSequential)
+	require.NoError(t, wf.Graph().AddEdge("step1", "step2"))
+	require.NoError(t, wf.Graph().AddEdge("step2", "step1"))
+
+	_, err := wf.ExecutionOrder()
+	assert.Error(t, err)
+
+	// The error is cached along with the order
+	_, err = wf.ExecutionOrder()
+	assert.Error(t, err)
+}